@@ -2,6 +2,7 @@ package PVM
 
 import (
 	"github.com/New-JAMneration/JAM-Protocol/internal/service_account"
+	"github.com/New-JAMneration/JAM-Protocol/internal/stateaccess"
 	"github.com/New-JAMneration/JAM-Protocol/internal/types"
 	"github.com/New-JAMneration/JAM-Protocol/internal/utilities/hash"
 )
@@ -14,7 +15,7 @@ func Psi_A(
 	gas types.Gas, // g
 	operandOrDeferTransfers []types.OperandOrDeferredTransfer, // i
 	eta types.Entropy,
-	storageKeyVal types.StateKeyVals,
+	access *stateaccess.Overlay, // unmatched state entries, changes are recorded in a child
 ) (
 	psi_result Psi_A_ReturnType,
 ) {
@@ -26,7 +27,7 @@ func Psi_A(
 			Result:            nil,
 			Gas:               0,
 			ServiceBlobs:      []types.ServiceBlob{},
-			StorageKeyVal:     storageKeyVal,
+			StateAccess:       access.Child(),
 		}
 	}
 
@@ -51,7 +52,7 @@ func Psi_A(
 			Result:            nil,
 			Gas:               0,
 			ServiceBlobs:      []types.ServiceBlob{},
-			StorageKeyVal:     storageKeyVal,
+			StateAccess:       access.Child(),
 		}
 	}
 
@@ -63,7 +64,7 @@ func Psi_A(
 			Result:            nil,
 			Gas:               0,
 			ServiceBlobs:      []types.ServiceBlob{},
-			StorageKeyVal:     storageKeyVal,
+			StateAccess:       access.Child(),
 		}
 	}
 
@@ -92,8 +93,8 @@ func Psi_A(
 	}
 	serialized = append(serialized, encoded...)
 
-	newPartialState := partialState.DeepCopy()
-	newStorageKeyVal := storageKeyVal.DeepCopy()
+	newPartialState := partialState.CopyOnWrite()
+	contextAccess := access.Child()
 	serviceAccount := newPartialState.ServiceAccounts[serviceID]
 	addition := HostCallArgs{
 		GeneralArgs: GeneralArgs{
@@ -101,12 +102,12 @@ func Psi_A(
 			ServiceID:           &serviceID,
 			ServiceAccountState: &newPartialState.ServiceAccounts,
 			CoreID:              nil,
-			StorageKeyVal:       &newStorageKeyVal,
+			StateAccess:         contextAccess,
 		},
-		// storageKeyVal can be seen as service storage state, what partialState do, the storageKeyVal will do the same
+		// the state access overlay can be seen as service storage state, what partialState do, the overlay will do the same
 		AccumulateArgs: AccumulateArgs{
-			ResultContextX:             I(newPartialState, serviceID, timeslot, eta, &newStorageKeyVal),
-			ResultContextY:             I(partialState, serviceID, timeslot, eta, &storageKeyVal),
+			ResultContextX:             I(newPartialState, serviceID, timeslot, eta, contextAccess),
+			ResultContextY:             I(partialState, serviceID, timeslot, eta, access.Child()),
 			Eta:                        eta,
 			OperandOrDeferredTransfers: operandOrDeferTransfers,
 			Timeslot:                   timeslot,
@@ -114,7 +115,7 @@ func Psi_A(
 	}
 
 	resultM := Psi_M(StandardCodeFormat(code), 5, types.Gas(gas), Argument(serialized), AccumulateOmegas, addition)
-	partialState, deferredTransfer, result, gas, serviceBlobs, storageKeyVal := C(types.Gas(resultM.Gas), resultM.ReasonOrBytes, AccumulateArgs{
+	partialState, deferredTransfer, result, gas, serviceBlobs, resultAccess := C(types.Gas(resultM.Gas), resultM.ReasonOrBytes, AccumulateArgs{
 		ResultContextX: resultM.Addition.AccumulateArgs.ResultContextX,
 		ResultContextY: resultM.Addition.AccumulateArgs.ResultContextY,
 	})
@@ -125,7 +126,7 @@ func Psi_A(
 		Result:            result,
 		Gas:               gas,
 		ServiceBlobs:      serviceBlobs,
-		StorageKeyVal:     storageKeyVal,
+		StateAccess:       resultAccess,
 	}
 }
 
@@ -144,41 +145,41 @@ func wrapWithG(original Omega) Omega {
 }
 
 // (B.13) C
-func C(gas types.Gas, reasonOrBytes any, resultContext AccumulateArgs) (types.PartialStateSet, []types.DeferredTransfer, *types.OpaqueHash, types.Gas, types.ServiceBlobs, types.StateKeyVals) {
+func C(gas types.Gas, reasonOrBytes any, resultContext AccumulateArgs) (types.PartialStateSet, []types.DeferredTransfer, *types.OpaqueHash, types.Gas, types.ServiceBlobs, *stateaccess.Overlay) {
 	serviceBlobs := make(types.ServiceBlobs, 0)
 	switch reasonOrBytes := reasonOrBytes.(type) {
 	case error: // system error
 		for _, v := range resultContext.ResultContextY.ServiceBlobs {
 			serviceBlobs = append(serviceBlobs, v)
 		}
-		return resultContext.ResultContextY.PartialState, resultContext.ResultContextY.DeferredTransfers, resultContext.ResultContextY.Exception, gas, serviceBlobs, *resultContext.ResultContextY.StorageKeyVal
+		return resultContext.ResultContextY.PartialState, resultContext.ResultContextY.DeferredTransfers, resultContext.ResultContextY.Exception, gas, serviceBlobs, resultContext.ResultContextY.StateAccess
 	case []byte:
 		var h types.OpaqueHash
 		if len(reasonOrBytes) != len(h) {
-			return resultContext.ResultContextX.PartialState, resultContext.ResultContextX.DeferredTransfers, resultContext.ResultContextX.Exception, gas, serviceBlobs, *resultContext.ResultContextX.StorageKeyVal
+			return resultContext.ResultContextX.PartialState, resultContext.ResultContextX.DeferredTransfers, resultContext.ResultContextX.Exception, gas, serviceBlobs, resultContext.ResultContextX.StateAccess
 		}
 		copy(h[:], reasonOrBytes[:len(h)])
 		opaqueHash := &h
 		for _, v := range resultContext.ResultContextX.ServiceBlobs {
 			serviceBlobs = append(serviceBlobs, v)
 		}
-		return resultContext.ResultContextX.PartialState, resultContext.ResultContextX.DeferredTransfers, opaqueHash, gas, serviceBlobs, *resultContext.ResultContextX.StorageKeyVal
+		return resultContext.ResultContextX.PartialState, resultContext.ResultContextX.DeferredTransfers, opaqueHash, gas, serviceBlobs, resultContext.ResultContextX.StateAccess
 	default:
 		if reasonOrBytes == OUT_OF_GAS || reasonOrBytes == PANIC {
 			for _, v := range resultContext.ResultContextY.ServiceBlobs {
 				serviceBlobs = append(serviceBlobs, v)
 			}
-			return resultContext.ResultContextY.PartialState, resultContext.ResultContextY.DeferredTransfers, resultContext.ResultContextY.Exception, gas, serviceBlobs, *resultContext.ResultContextY.StorageKeyVal
+			return resultContext.ResultContextY.PartialState, resultContext.ResultContextY.DeferredTransfers, resultContext.ResultContextY.Exception, gas, serviceBlobs, resultContext.ResultContextY.StateAccess
 		}
 		for _, v := range resultContext.ResultContextX.ServiceBlobs {
 			serviceBlobs = append(serviceBlobs, v)
 		}
-		return resultContext.ResultContextX.PartialState, resultContext.ResultContextX.DeferredTransfers, resultContext.ResultContextX.Exception, gas, serviceBlobs, *resultContext.ResultContextX.StorageKeyVal
+		return resultContext.ResultContextX.PartialState, resultContext.ResultContextX.DeferredTransfers, resultContext.ResultContextX.Exception, gas, serviceBlobs, resultContext.ResultContextX.StateAccess
 	}
 }
 
 // (B.10)
-func I(partialState types.PartialStateSet, serviceID types.ServiceID, ht types.TimeSlot, eta types.Entropy, storageKeyVal *types.StateKeyVals) ResultContext {
+func I(partialState types.PartialStateSet, serviceID types.ServiceID, ht types.TimeSlot, eta types.Entropy, access *stateaccess.Overlay) ResultContext {
 	serialized := []byte{}
 	encoder := types.NewEncoder()
 
@@ -218,7 +219,7 @@ func I(partialState types.PartialStateSet, serviceID types.ServiceID, ht types.T
 		DeferredTransfers: []types.DeferredTransfer{},
 		Exception:         nil,
 		ServiceBlobs:      make(map[types.OpaqueHash]types.ServiceBlob),
-		StorageKeyVal:     storageKeyVal,
+		StateAccess:       access,
 	}
 }
 
@@ -228,7 +229,7 @@ type Psi_A_ReturnType struct {
 	Result            *types.OpaqueHash
 	Gas               types.Gas
 	ServiceBlobs      []types.ServiceBlob
-	StorageKeyVal     types.StateKeyVals
+	StateAccess       *stateaccess.Overlay // changes to commit into the overlay passed to Psi_A
 }
 
 // (B.7)
@@ -239,16 +240,23 @@ type ResultContext struct {
 	DeferredTransfers []types.DeferredTransfer               // t
 	Exception         *types.OpaqueHash                      // y
 	ServiceBlobs      map[types.OpaqueHash]types.ServiceBlob // p   v0.6.5
-	StorageKeyVal     *types.StateKeyVals                    // add this for fuzzer
+	StateAccess       *stateaccess.Overlay                   // add this for fuzzer
 }
 
 func (origin *ResultContext) DeepCopy() ResultContext {
+	return origin.copyWith(origin.PartialState.DeepCopy())
+}
+
+// CopyOnWrite is DeepCopy with the partial state copied on write: service
+// accounts share their maps with origin until a host call changes them.
+func (origin *ResultContext) CopyOnWrite() ResultContext {
+	return origin.copyWith(origin.PartialState.CopyOnWrite())
+}
+
+func (origin *ResultContext) copyWith(copiedPartialState types.PartialStateSet) ResultContext {
 	// ServiceID
 	copiedServiceID := origin.ServiceID
 
-	// PartialState
-	copiedPartialState := origin.PartialState.DeepCopy()
-
 	// ImportServiceID
 	copiedImportServiceID := origin.ImportServiceID
 
@@ -283,8 +291,8 @@ func (origin *ResultContext) DeepCopy() ResultContext {
 		copiedServiceBlobs[k] = copiedServiceBlob
 	}
 
-	// StorageKeyVal
-	copiedStorageKeyVal := origin.StorageKeyVal.DeepCopy()
+	// StateAccess
+	copiedStateAccess := origin.StateAccess.Clone()
 
 	return ResultContext{
 		ServiceID:         copiedServiceID,
@@ -293,6 +301,6 @@ func (origin *ResultContext) DeepCopy() ResultContext {
 		DeferredTransfers: copiedDeferredTransfers,
 		Exception:         copiedException,
 		ServiceBlobs:      copiedServiceBlobs,
-		StorageKeyVal:     &copiedStorageKeyVal,
+		StateAccess:       copiedStateAccess,
 	}
 }
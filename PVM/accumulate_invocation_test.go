@@ -0,0 +1,150 @@
+package PVM
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/New-JAMneration/JAM-Protocol/internal/stateaccess"
+	"github.com/New-JAMneration/JAM-Protocol/internal/types"
+	"github.com/New-JAMneration/JAM-Protocol/internal/utilities/hash"
+)
+
+// checkpointService formats the code of a service whose accumulate writes
+// the value at 0x10004 to the key at 0x10000 and checkpoints, then writes the
+// value at 0x10008 to the key a given number of times, checkpointing between
+// the writes. It halts, or panics after the last write if the flag is 1.
+const checkpointService = `
+	trap                    ; refine
+	trap
+	trap
+	trap
+	trap
+accumulate:
+	load_imm s0, %[1]d
+	load_imm a0, 0x10000
+	load_imm a1, 4
+	load_imm a2, 0x10004
+	load_imm a3, 4
+	ecalli 4                ; write
+	ecalli 17               ; checkpoint
+	fallthrough
+loop:
+	load_imm a0, 0x10000
+	load_imm a1, 4
+	load_imm a2, 0x10008
+	load_imm a3, 4
+	ecalli 4                ; write
+	add_imm_64 s0, s0, -1
+	branch_eq_imm s0, 0, done
+	ecalli 17               ; checkpoint
+	jump loop
+done:	load_imm s1, %[2]d
+	branch_eq_imm s1, 1, panic
+	load_imm a0, 0
+	load_imm a1, 0
+	load_imm_64 ra, 0xffff0000
+	jump_ind ra, 0          ; halt
+panic:	trap
+.ro_data 0x6b657930 0x01020304 0x05060708
+.stack_size 0x1000
+`
+
+// checkpointState returns a partial state of accounts services with items
+// storage items each, all running checkpointService.
+func checkpointState(tb testing.TB, accounts, items, writes int, panics bool) types.PartialStateSet {
+	panicFlag := 0
+	if panics {
+		panicFlag = 1
+	}
+	asm, err := Assemble(fmt.Sprintf(checkpointService, writes, panicFlag))
+	if err != nil {
+		tb.Fatalf("Assemble: %v", err)
+	}
+	metaCode, err := types.NewEncoder().Encode(&types.MetaCode{Metadata: types.ByteSequence("checkpoint"), Code: types.ByteSequence(asm.StandardProgram())})
+	if err != nil {
+		tb.Fatalf("encode code: %v", err)
+	}
+	codeHash := hash.Blake2bHash(metaCode)
+
+	state := types.PartialStateSet{ServiceAccounts: make(types.ServiceAccountState, accounts)}
+	for s := range accounts {
+		account := types.ServiceAccount{
+			ServiceInfo:    types.ServiceInfo{CodeHash: codeHash, Balance: 1 << 40},
+			PreimageLookup: types.PreimagesMapEntry{codeHash: metaCode},
+			LookupDict:     types.LookupMetaMapEntry{},
+			StorageDict:    make(types.Storage, items),
+		}
+		for i := range items {
+			account.StorageDict[fmt.Sprintf("item%d", i)] = bytes.Repeat([]byte{byte(i)}, 32)
+		}
+		state.ServiceAccounts[types.ServiceID(s)] = account
+	}
+	return state
+}
+
+func TestCheckpointCopyOnWrite(t *testing.T) {
+	tests := []struct {
+		name   string
+		writes int
+		panics bool
+		want   []byte
+	}{
+		{name: "halt", writes: 3, want: []byte{5, 6, 7, 8}},
+		{name: "panic after checkpoint", writes: 1, panics: true, want: []byte{1, 2, 3, 4}},
+		{name: "panic after checkpoints", writes: 3, panics: true, want: []byte{5, 6, 7, 8}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state := checkpointState(t, 2, 1, tt.writes, tt.panics)
+			result := Psi_A(state, 0, 0, 1_000_000, nil, types.Entropy{}, nil)
+
+			if got := result.PartialStateSet.ServiceAccounts[0].StorageDict["key0"]; !bytes.Equal(got, tt.want) {
+				t.Errorf("key0 = %x, want %x", got, tt.want)
+			}
+			for s, account := range state.ServiceAccounts {
+				if _, ok := account.StorageDict["key0"]; ok || len(account.StorageDict) != 1 {
+					t.Errorf("service %d storage of the input state changed: %v", s, account.StorageDict)
+				}
+			}
+			if storage := result.PartialStateSet.ServiceAccounts[1].StorageDict; len(storage) != 1 {
+				t.Errorf("service 1 storage = %v, want one item", storage)
+			}
+		})
+	}
+}
+
+type failingSource struct{}
+
+func (failingSource) Get(types.StateKey) ([]byte, bool, error) {
+	return nil, false, errors.New("state not readable")
+}
+
+// A storage key missing from the state access overlay cannot be told apart
+// from one that failed to read, so the accumulation must not go on.
+func TestStateAccessReadErrorPanics(t *testing.T) {
+	state := checkpointState(t, 1, 0, 1, false)
+	defer func() {
+		if recover() == nil {
+			t.Error("write of a key that cannot be read did not panic")
+		}
+	}()
+	Psi_A(state, 0, 0, 1_000_000, nil, types.Entropy{}, stateaccess.NewOverlay(failingSource{}))
+}
+
+// BenchmarkAccumulateCheckpoint runs an accumulation checkpointing after
+// every write, in states of growing size. The service and states are
+// synthetic: it measures how the cost of a checkpoint grows with the state,
+// not the time of a real accumulation.
+func BenchmarkAccumulateCheckpoint(b *testing.B) {
+	for _, size := range []struct{ accounts, items int }{{1, 100}, {1, 10000}, {100, 100}, {1000, 100}} {
+		b.Run(fmt.Sprintf("accounts=%d/items=%d", size.accounts, size.items), func(b *testing.B) {
+			state := checkpointState(b, size.accounts, size.items, 100, false)
+			b.ResetTimer()
+			for range b.N {
+				Psi_A(state, 0, 0, 1_000_000, nil, types.Entropy{}, nil)
+			}
+		})
+	}
+}
@@ -27,7 +27,7 @@ func Psi_M(
 		}
 	}
 
-	program, exitReason := DeBlobProgramCode(programCode)
+	program, exitReason := LoadProgram(programCode)
 	if exitReason != ExitContinue {
 		return Psi_M_ReturnType{
 			Gas:           0,
@@ -36,9 +36,10 @@ func Psi_M(
 		}
 	}
 
-	addition.Program = &program
+	addition.Program = program
 
-	host := NewHost(&program, registers, &memory, Gas(gas), addition, omegas)
+	host := NewHost(program, registers, &memory, Gas(gas), addition, omegas)
+	host.Interpreter.Tracer = newInvocationTracer(counter, gas, &addition)
 	psiHResult := host.HostCall(counter, 0)
 
 	g, v, a := R(gas, psiHResult)
@@ -131,8 +132,8 @@ func readRAM(start, length uint64, m Memory) ([]byte, bool) {
 	out := make([]byte, 0, length)
 
 	for p := startPage; p <= endPage; p++ {
-		page, ok := m.Pages[p]
-		if !ok || page.Value == nil || len(page.Value) == 0 {
+		page := m.Page(p)
+		if page == nil || page.Value == nil || len(page.Value) == 0 {
 			return nil, false
 		}
 
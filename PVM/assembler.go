@@ -0,0 +1,468 @@
+package PVM
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/New-JAMneration/JAM-Protocol/internal/types"
+	utils "github.com/New-JAMneration/JAM-Protocol/internal/utilities"
+)
+
+// Assembly is an assembled program: code, bitmask and jump table as they
+// are laid out in a program blob (A.2) and the data, heap and stack sizes of
+// a standard program (A.37).
+type Assembly struct {
+	Code      []byte
+	Bitmask   []byte // one bit per code byte, set at instruction starts
+	JumpTable []ProgramCounter
+	Labels    map[string]ProgramCounter
+
+	ReadOnlyData  []byte
+	ReadWriteData []byte
+	HeapPages     uint16
+	StackSize     uint32
+}
+
+// Assemble assembles PVM source text. Each line holds a label, an
+// instruction, a directive or any of them after a label; ";" starts a
+// comment:
+//
+//	loop:   add_imm_64 a0, a0, -1     ; mnemonics as in opcodeInfoTable
+//	        branch_ne_imm a0, 0, loop ; targets are labels or @pc
+//	        jump_ind ra, 0
+//	.jump_table loop, @0             ; entries of the jump table
+//	.ro_data 0x0102                  ; also .rw_data
+//	.heap_pages 1                    ; also .stack_size
+//
+// Operands are written in the order FormatOperands prints them, so the
+// output of Disassemble assembles back into the same program. Registers are
+// named as in RegName or r0..r12; immediates are decimal or 0x-prefixed hex,
+// optionally negative.
+func Assemble(source string) (*Assembly, error) {
+	var (
+		asm       = &Assembly{Labels: map[string]ProgramCounter{}}
+		instrs    []*asmInstr
+		labelAt   = map[string]int{} // label -> index of the instruction it names
+		jumpTable []asmTarget
+	)
+
+	for i, line := range strings.Split(source, "\n") {
+		lineNo := i + 1
+		errorf := func(format string, args ...any) error {
+			return fmt.Errorf("line %d: %s", lineNo, fmt.Sprintf(format, args...))
+		}
+
+		if comment := strings.IndexByte(line, ';'); comment >= 0 {
+			line = line[:comment]
+		}
+		line = strings.TrimSpace(line)
+		if colon := strings.IndexByte(line, ':'); colon >= 0 && isAsmLabel(line[:colon]) {
+			label := line[:colon]
+			if _, ok := labelAt[label]; ok {
+				return nil, errorf("label %q defined twice", label)
+			}
+			labelAt[label] = len(instrs)
+			line = strings.TrimSpace(line[colon+1:])
+		}
+		if line == "" {
+			continue
+		}
+
+		name, rest := line, ""
+		if space := strings.IndexAny(line, " \t"); space >= 0 {
+			name, rest = line[:space], strings.TrimSpace(line[space:])
+		}
+		var operands []string
+		if rest != "" {
+			operands = strings.Split(rest, ",")
+			for k := range operands {
+				operands[k] = strings.TrimSpace(operands[k])
+			}
+		}
+
+		if strings.HasPrefix(name, ".") {
+			if err := asm.directive(name, operands, &jumpTable); err != nil {
+				return nil, errorf("%v", err)
+			}
+			continue
+		}
+		instr, err := parseAsmInstr(name, operands)
+		if err != nil {
+			return nil, errorf("%v", err)
+		}
+		instr.line = lineNo
+		instrs = append(instrs, instr)
+	}
+
+	// Lay the instructions out until no target needs a longer offset.
+	// Instructions only grow, so this terminates; an offset encoded longer
+	// than needed is still valid.
+	resolve := func(target asmTarget) (ProgramCounter, error) {
+		if target.label == "" {
+			return target.pc, nil
+		}
+		index, ok := labelAt[target.label]
+		if !ok {
+			return 0, fmt.Errorf("undefined label %q", target.label)
+		}
+		if index == len(instrs) {
+			return ProgramCounter(len(asm.Code)), nil
+		}
+		return instrs[index].pc, nil
+	}
+	for {
+		asm.Code = asm.Code[:0]
+		for _, instr := range instrs {
+			instr.pc = ProgramCounter(len(asm.Code))
+			asm.Code = append(asm.Code, make([]byte, instr.size)...)
+		}
+		grown := false
+		for _, instr := range instrs {
+			encoded, err := instr.encode(resolve)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %v", instr.line, err)
+			}
+			if len(encoded) > instr.size {
+				instr.size, grown = len(encoded), true
+			} else {
+				copy(asm.Code[instr.pc:], encoded)
+			}
+		}
+		if !grown {
+			break
+		}
+	}
+
+	asm.Bitmask = make([]byte, (len(asm.Code)+7)/8)
+	for _, instr := range instrs {
+		asm.Bitmask[instr.pc/8] |= 1 << (instr.pc % 8)
+	}
+	for label := range labelAt {
+		asm.Labels[label], _ = resolve(asmTarget{label: label})
+	}
+	for _, entry := range jumpTable {
+		dest, err := resolve(entry)
+		if err != nil {
+			return nil, fmt.Errorf("jump table: %v", err)
+		}
+		asm.JumpTable = append(asm.JumpTable, dest)
+	}
+
+	return asm, nil
+}
+
+// MustAssemble is like Assemble but panics on error. It is meant for
+// programs written into tests.
+func MustAssemble(source string) *Assembly {
+	asm, err := Assemble(source)
+	if err != nil {
+		panic(err)
+	}
+	return asm
+}
+
+// ProgramBlob encodes the program blob of asm (A.2), as read by
+// DeBlobProgramCode.
+func (asm *Assembly) ProgramBlob() []byte {
+	// z is the fewest octets holding every jump table entry
+	z := min(1, len(asm.JumpTable))
+	for _, dest := range asm.JumpTable {
+		for z < 4 && uint64(dest) >= 1<<(8*z) {
+			z++
+		}
+	}
+
+	blob := utils.SerializeU64(types.U64(len(asm.JumpTable)))
+	blob = append(blob, byte(z))
+	blob = append(blob, utils.SerializeU64(types.U64(len(asm.Code)))...)
+	for _, dest := range asm.JumpTable {
+		blob = append(blob, utils.SerializeFixedLength(types.U64(dest), types.U64(z))...)
+	}
+	blob = append(blob, asm.Code...)
+	return append(blob, asm.Bitmask...)
+}
+
+// Program deblobs asm, ready for NewInterpreter.
+func (asm *Assembly) Program() (Program, ExitReason) {
+	return DeBlobProgramCode(asm.ProgramBlob())
+}
+
+// StandardProgram encodes asm as a standard program (A.37), as read by
+// SingleInitializer.
+func (asm *Assembly) StandardProgram() StandardCodeFormat {
+	code := asm.ProgramBlob()
+
+	var p []byte
+	p = append(p, utils.SerializeFixedLength(types.U64(len(asm.ReadOnlyData)), 3)...)
+	p = append(p, utils.SerializeFixedLength(types.U64(len(asm.ReadWriteData)), 3)...)
+	p = append(p, utils.SerializeFixedLength(types.U64(asm.HeapPages), 2)...)
+	p = append(p, utils.SerializeFixedLength(types.U64(asm.StackSize), 3)...)
+	p = append(p, asm.ReadOnlyData...)
+	p = append(p, asm.ReadWriteData...)
+	p = append(p, utils.SerializeFixedLength(types.U64(len(code)), 4)...)
+	return append(p, code...)
+}
+
+func (asm *Assembly) directive(name string, operands []string, jumpTable *[]asmTarget) error {
+	switch name {
+	case ".jump_table":
+		for _, operand := range operands {
+			target, err := parseAsmTarget(operand)
+			if err != nil {
+				return err
+			}
+			*jumpTable = append(*jumpTable, target)
+		}
+	case ".ro_data", ".rw_data":
+		var data []byte
+		for _, operand := range operands {
+			for _, field := range strings.Fields(operand) {
+				hexData, ok := strings.CutPrefix(field, "0x")
+				decoded, err := hex.DecodeString(hexData)
+				if !ok || err != nil {
+					return fmt.Errorf("%s: want 0x-prefixed hex bytes, got %q", name, field)
+				}
+				data = append(data, decoded...)
+			}
+		}
+		if name == ".ro_data" {
+			asm.ReadOnlyData = append(asm.ReadOnlyData, data...)
+		} else {
+			asm.ReadWriteData = append(asm.ReadWriteData, data...)
+		}
+	case ".heap_pages", ".stack_size":
+		if len(operands) != 1 {
+			return fmt.Errorf("%s takes one operand", name)
+		}
+		bits := 24
+		if name == ".heap_pages" {
+			bits = 16
+		}
+		v, err := strconv.ParseUint(operands[0], 0, bits)
+		if err != nil {
+			return fmt.Errorf("%s: %v", name, err)
+		}
+		if name == ".heap_pages" {
+			asm.HeapPages = uint16(v)
+		} else {
+			asm.StackSize = uint32(v)
+		}
+	default:
+		return fmt.Errorf("unknown directive %s", name)
+	}
+	return nil
+}
+
+// asmTarget is a jump target: a label or, when label is empty, a pc.
+type asmTarget struct {
+	label string
+	pc    ProgramCounter
+}
+
+// asmInstr is a parsed instruction. size is the number of octets it takes,
+// grown as its target moves away.
+type asmInstr struct {
+	line   int
+	opcode byte
+	regs   []uint8
+	imms   []uint64
+	target *asmTarget
+	pc     ProgramCounter
+	size   int
+}
+
+// asmOperandKinds lists the operands of each category in source order: r for
+// a register, i for an immediate and t for a jump target.
+var asmOperandKinds = [...]string{
+	InstrCatNoArg:        "",
+	InstrCatOneImm:       "i",
+	InstrCatOneRegExtImm: "ri",
+	InstrCatTwoImm:       "ii",
+	InstrCatOneOffset:    "t",
+	InstrCatOneRegOneImm: "ri",
+	InstrCatOneRegTwoImm: "rii",
+	InstrCatOneRegImmOff: "rit",
+	InstrCatTwoReg:       "rr",
+	InstrCatTwoRegOneImm: "rri",
+	InstrCatTwoRegOneOff: "rrt",
+	InstrCatTwoRegTwoImm: "rrii",
+	InstrCatThreeReg:     "rrr",
+}
+
+func parseAsmInstr(name string, operands []string) (*asmInstr, error) {
+	op, ok := OpcodeByName(name)
+	if !ok {
+		return nil, fmt.Errorf("unknown instruction %q", name)
+	}
+	kinds := asmOperandKinds[opcodeInfoTable[op].Category]
+	if len(operands) != len(kinds) {
+		return nil, fmt.Errorf("%s takes %d operands, got %d", name, len(kinds), len(operands))
+	}
+
+	instr := &asmInstr{opcode: op, size: 1}
+	for k, operand := range operands {
+		switch kinds[k] {
+		case 'r':
+			r, err := parseAsmRegister(operand)
+			if err != nil {
+				return nil, err
+			}
+			instr.regs = append(instr.regs, r)
+		case 'i':
+			v, err := parseAsmImmediate(operand)
+			if err != nil {
+				return nil, err
+			}
+			instr.imms = append(instr.imms, v)
+		case 't':
+			target, err := parseAsmTarget(operand)
+			if err != nil {
+				return nil, err
+			}
+			instr.target = &target
+		}
+	}
+	return instr, nil
+}
+
+func parseAsmRegister(s string) (uint8, error) {
+	for r, name := range RegName {
+		if s == name {
+			return uint8(r), nil
+		}
+	}
+	if n, ok := strings.CutPrefix(s, "r"); ok {
+		if r, err := strconv.ParseUint(n, 10, 8); err == nil && r < uint64(len(RegName)) {
+			return uint8(r), nil
+		}
+	}
+	return 0, fmt.Errorf("invalid register %q", s)
+}
+
+func parseAsmImmediate(s string) (uint64, error) {
+	if v, err := strconv.ParseInt(s, 0, 64); err == nil {
+		return uint64(v), nil
+	}
+	v, err := strconv.ParseUint(s, 0, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid immediate %q", s)
+	}
+	return v, nil
+}
+
+func parseAsmTarget(s string) (asmTarget, error) {
+	if pc, ok := strings.CutPrefix(s, "@"); ok {
+		v, err := strconv.ParseUint(pc, 0, 32)
+		if err != nil {
+			return asmTarget{}, fmt.Errorf("invalid target %q", s)
+		}
+		return asmTarget{pc: ProgramCounter(v)}, nil
+	}
+	if !isAsmLabel(s) {
+		return asmTarget{}, fmt.Errorf("invalid target %q", s)
+	}
+	return asmTarget{label: s}, nil
+}
+
+func isAsmLabel(s string) bool {
+	if s == "" || (s[0] >= '0' && s[0] <= '9') {
+		return false
+	}
+	for _, c := range s {
+		if !(c == '_' || c == '.' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// encode encodes instr at its pc (A.5). The last operand, whose length
+// follows from the skip length, is widened to fill instr.size.
+func (instr *asmInstr) encode(resolve func(asmTarget) (ProgramCounter, error)) ([]byte, error) {
+	var fields [][]byte // the last one is widened
+	immediate := func(v uint64) ([]byte, error) {
+		for l := 0; l <= 4; l++ {
+			if signExtendOctets(v, l) == v {
+				return utils.SerializeFixedLength(types.U64(v), types.U64(l)), nil
+			}
+		}
+		return nil, fmt.Errorf("immediate %#x does not fit in 4 octets", v)
+	}
+	regs := func(lo, hi uint8) []byte {
+		return []byte{lo | hi<<4}
+	}
+
+	category := opcodeInfoTable[instr.opcode].Category
+	var imms [][]byte
+	for _, v := range instr.imms {
+		if category == InstrCatOneRegExtImm {
+			break // the only 8-octet immediate, encoded below
+		}
+		encoded, err := immediate(v)
+		if err != nil {
+			return nil, err
+		}
+		imms = append(imms, encoded)
+	}
+	if instr.target != nil {
+		target, err := resolve(*instr.target)
+		if err != nil {
+			return nil, err
+		}
+		// offsets wrap around like the pc arithmetic of the decoder
+		offset, _ := immediate(uint64(int64(int32(uint32(target) - uint32(instr.pc)))))
+		imms = append(imms, offset)
+	}
+
+	r := instr.regs
+	switch category {
+	case InstrCatNoArg:
+	case InstrCatOneImm, InstrCatOneOffset:
+		fields = [][]byte{imms[0]}
+	case InstrCatOneRegExtImm:
+		fields = [][]byte{{r[0]}, binary.LittleEndian.AppendUint64(nil, instr.imms[0])}
+	case InstrCatTwoImm:
+		fields = [][]byte{{byte(len(imms[0]))}, imms[0], imms[1]}
+	case InstrCatOneRegOneImm:
+		fields = [][]byte{{r[0]}, imms[0]}
+	case InstrCatOneRegTwoImm, InstrCatOneRegImmOff:
+		fields = [][]byte{regs(r[0], uint8(len(imms[0]))), imms[0], imms[1]}
+	case InstrCatTwoReg:
+		fields = [][]byte{regs(r[0], r[1])}
+	case InstrCatTwoRegOneImm, InstrCatTwoRegOneOff:
+		fields = [][]byte{regs(r[0], r[1]), imms[0]}
+	case InstrCatTwoRegTwoImm:
+		fields = [][]byte{regs(r[0], r[1]), {byte(len(imms[0]))}, imms[0], imms[1]}
+	case InstrCatThreeReg:
+		// written rD, rA, rB; encoded rA, rB then rD
+		fields = [][]byte{regs(r[1], r[2]), {r[0]}}
+	}
+
+	encoded := []byte{instr.opcode}
+	for _, field := range fields {
+		encoded = append(encoded, field...)
+	}
+	if n := len(fields); n > 0 && len(encoded) < instr.size {
+		last := fields[n-1]
+		fill := byte(0)
+		if len(last) > 0 && last[len(last)-1]&0x80 != 0 {
+			fill = 0xff
+		}
+		for len(encoded) < instr.size {
+			encoded = append(encoded, fill)
+		}
+	}
+	return encoded, nil
+}
+
+// signExtendOctets is v cut to its low l octets and sign-extended back.
+func signExtendOctets(v uint64, l int) uint64 {
+	if l == 0 {
+		return 0
+	}
+	shift := 64 - 8*l
+	return uint64(int64(v<<shift) >> shift)
+}
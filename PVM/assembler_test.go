@@ -0,0 +1,118 @@
+package PVM
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestAssembleRoundTrip(t *testing.T) {
+	// one instruction of every category
+	asm := MustAssemble(`
+	start:	fallthrough
+		ecalli 3
+		load_imm_64 a0, 0x1122334455667788
+		store_imm_u8 0x20000, -2
+		load_imm a1, -1
+		store_imm_ind_u8 sp, 8, 0x1234
+		move_reg s0, a1
+		store_ind_u8 a2, sp, -0x160
+		add_32 t0, t1, t2
+		load_imm_jump ra, 7, end
+	mid:	branch_eq a0, a1, start
+		load_imm_jump_ind ra, a3, 2, 0x10000
+	end:	jump mid
+	`)
+
+	d, err := Disassemble(asm.ProgramBlob())
+	if err != nil {
+		t.Fatalf("Disassemble: %v", err)
+	}
+	if len(d.Issues) != 0 {
+		t.Errorf("issues: %+v", d.Issues)
+	}
+
+	var source strings.Builder
+	for i := range d.Instrs {
+		source.WriteString(OpcodeName(d.Instrs[i].Opcode) + " " + FormatOperands(&d.Instrs[i]) + "\n")
+	}
+	again, err := Assemble(source.String())
+	if err != nil {
+		t.Fatalf("assembling the disassembly: %v\n%s", err, source.String())
+	}
+	if !bytes.Equal(again.Code, asm.Code) || !bytes.Equal(again.Bitmask, asm.Bitmask) {
+		t.Errorf("disassembly assembles to\n%x\nwant\n%x\nsource:\n%s", again.Code, asm.Code, source.String())
+	}
+}
+
+func TestAssembleRun(t *testing.T) {
+	asm := MustAssemble(`
+		load_imm a0, 5
+		load_imm a1, 0
+		fallthrough
+	loop:	add_imm_64 a1, a1, 3
+		add_imm_64 a0, a0, -1
+		branch_ne_imm a0, 0, loop
+		load_imm t0, 4          ; jump table entry 1
+		jump_ind t0, 0
+		trap
+	done:	load_imm_64 a2, 0x1122334455667788
+		load_imm_64 ra, 0xffff0000
+		jump_ind ra, 0          ; halt
+	.jump_table loop, done
+	`)
+	if asm.Labels["done"] != asm.JumpTable[1] {
+		t.Errorf("jump table = %v, labels = %v", asm.JumpTable, asm.Labels)
+	}
+
+	program, exitReason := asm.Program()
+	if exitReason != ExitContinue {
+		t.Fatalf("Program: %v", exitReason)
+	}
+	interp := NewInterpreter(&program, Registers{}, &Memory{}, 1000)
+	exitReason, _ = interp.SingleStepInvoke(0)
+	if exitReason != ExitHalt {
+		t.Fatalf("exit = %v, want halt", exitReason)
+	}
+	if interp.Registers[7] != 0 || interp.Registers[8] != 15 || interp.Registers[9] != 0x1122334455667788 {
+		t.Errorf("registers = %v", interp.Registers)
+	}
+}
+
+func TestAssembleStandardProgram(t *testing.T) {
+	asm := MustAssemble(`
+		trap
+	.ro_data 0x0102 0x03
+	.rw_data 0xff
+	.heap_pages 2
+	.stack_size 0x1000
+	`)
+	p, err := DecodeStandardProgram(asm.StandardProgram())
+	if err != nil {
+		t.Fatalf("DecodeStandardProgram: %v", err)
+	}
+	if !bytes.Equal(p.ReadOnlyData, []byte{1, 2, 3}) || !bytes.Equal(p.ReadWriteData, []byte{0xff}) ||
+		p.HeapPages != 2 || p.StackSize != 0x1000 || !bytes.Equal(p.Code, asm.ProgramBlob()) {
+		t.Errorf("standard program = %+v", p)
+	}
+}
+
+func TestAssembleErrors(t *testing.T) {
+	tests := []struct {
+		source string
+		want   string
+	}{
+		{source: "nop", want: `line 1: unknown instruction "nop"`},
+		{source: "trap\nmove_reg a0", want: "line 2: move_reg takes 2 operands, got 1"},
+		{source: "load_imm x9, 1", want: `invalid register "x9"`},
+		{source: "load_imm a0, 0x100000000", want: "does not fit in 4 octets"},
+		{source: "jump nowhere", want: `undefined label "nowhere"`},
+		{source: "a: trap\na: trap", want: `label "a" defined twice`},
+		{source: ".ro_data 0x123", want: "want 0x-prefixed hex bytes"},
+	}
+	for _, tt := range tests {
+		if _, err := Assemble(tt.source); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Assemble(%q) error = %v, want %q", tt.source, err, tt.want)
+		}
+	}
+}
@@ -21,7 +21,7 @@ type BlockMeta struct {
 	EndPC      ProgramCounter // PC of the terminating instruction (inclusive)
 	InstrStart int            // index into Program.Instrs[]
 	InstrEnd   int            // exclusive upper bound into Program.Instrs[]
-	GasCost    Gas            // v0.7.2: = InstrCount; TODO(gas-model): = simulatePipeline()
+	GasCost    Gas            // v0.7: = InstrCount; v0.8: = simulatePipeline()
 }
 
 // InstrCount returns the number of instructions in this block.
@@ -38,12 +38,7 @@ func decodeOperands(instr *InstrMeta, idata ProgramCode, bitmask Bitmask) {
 	instr.Dst = 0xFF
 	instr.Src = [2]uint8{0xFF, 0xFF}
 
-	instrCategory := opcodeInfoTable[instr.Opcode].Category
-	if instrCategory != InstrCatNoArg && int(pc)+1 >= len(idata) {
-		return
-	}
-
-	switch instrCategory {
+	switch opcodeInfoTable[instr.Opcode].Category {
 	case InstrCatNoArg:
 		// 0, 1: no operands
 
@@ -58,10 +53,8 @@ func decodeOperands(instr *InstrMeta, idata ProgramCode, bitmask Bitmask) {
 
 	case InstrCatOneRegExtImm:
 		// 20 (load_imm_64): Dst = rA, Imm[0] = imm64
-		instr.Dst = min(12, idata[pc+1]%16)
-		if int(pc+10) <= len(idata) {
-			instr.Imm[0] = binary.LittleEndian.Uint64(idata[pc+2 : pc+10])
-		}
+		instr.Dst = min(12, zetaByte(idata, pc+1)%16)
+		instr.Imm[0] = binary.LittleEndian.Uint64(zetaBytes(idata, pc+2, pc+10))
 
 	case InstrCatTwoImm:
 		// 30-33: Imm[0] = addr (vX), Imm[1] = val (vY)
@@ -197,7 +190,7 @@ func (p *Program) preDecodeBlocks() ExitReason {
 			if IsBlockTerminator(op) {
 				block.EndPC = pc
 				block.InstrEnd = len(p.Instrs)
-				block.GasCost = Gas(block.InstrEnd - block.InstrStart)
+				block.GasCost = blockGasCost(p.GasModel, p.Instrs[block.InstrStart:block.InstrEnd])
 				p.BlockAt[block.StartPC] = block
 				pc += ProgramCounter(skipLen) + 1
 				break
@@ -4,7 +4,7 @@ func branch(pc ProgramCounter, b ProgramCounter, C bool, bitmask Bitmask, instru
 	switch {
 	case !C:
 		return ExitContinue, pc
-	case !bitmask.IsStartOfBasicBlock(b) && instruction.isOpcodeValid(b):
+	case !bitmask.IsStartOfBasicBlock(b) || !instruction.isOpcodeValid(b): // (A.17) b must start a basic block
 		return ExitPanic, pc
 	default:
 		return ExitContinue, b
@@ -0,0 +1,391 @@
+package PVM
+
+import (
+	"sync"
+
+	"github.com/New-JAMneration/JAM-Protocol/internal/types"
+	"github.com/New-JAMneration/JAM-Protocol/internal/utilities/hash"
+)
+
+// compiledFn runs one step of a compiled block. Like instrMetaFn it returns
+// the PC of the step's last instruction to fall through, or the branch
+// target.
+type compiledFn func(interp *Interpreter) (ExitReason, ProgramCounter)
+
+// compiledStep is one instruction, or a fused pair of instructions, of a
+// compiled basic block.
+type compiledStep struct {
+	run    compiledFn
+	pc     ProgramCounter // PC of the last instruction in the step
+	next   ProgramCounter // PC following the step
+	gas    Gas            // charged when execution enters the block at this step
+	refund Gas            // returned when the block exits at this step
+	last   bool           // the step ends its basic block
+}
+
+// CompiledProgram is the threaded-code form of a Program: every basic block
+// is a chain of closures with the register operands and immediates resolved
+// at compile time, and gas is charged once per block instead of once per
+// instruction: under GasModelV07 the instructions from the entry step to the
+// end of the block, refunding the ones not run when the block exits early,
+// and under GasModelV08 the block cost on entry at its start.
+type CompiledProgram struct {
+	steps  []compiledStep
+	stepAt []int32 // PC-indexed: stepAt[pc] = index into steps, -1 if no step starts at pc
+}
+
+// Compile builds the threaded-code form of p. A load_imm directly followed
+// by a branch in the same block is fused into one step.
+func (p *Program) Compile() *CompiledProgram {
+	c := &CompiledProgram{
+		steps:  make([]compiledStep, 0, len(p.Instrs)),
+		stepAt: make([]int32, len(p.InstructionData)),
+	}
+	for i := range c.stepAt {
+		c.stepAt[i] = -1
+	}
+
+	for pc := range p.BlockAt {
+		block := p.BlockAt[pc]
+		if block == nil {
+			continue
+		}
+
+		first := len(c.steps)
+		var counts []Gas // instructions in each step of the block
+		for i := block.InstrStart; i < block.InstrEnd; i++ {
+			instr := &p.Instrs[i]
+			var step compiledStep
+			count := Gas(1)
+			if next := i + 1; next < block.InstrEnd {
+				if run := fuseLoadImmBranch(p, instr, &p.Instrs[next]); run != nil {
+					step.run = run
+					count = 2
+					i = next
+				}
+			}
+			if step.run == nil {
+				step.run = compileInstr(p, instr)
+			}
+
+			last := &p.Instrs[i]
+			step.pc = last.PC
+			step.next = last.PC + ProgramCounter(last.SkipLen) + 1
+			c.stepAt[instr.PC] = int32(len(c.steps))
+			c.steps = append(c.steps, step)
+			counts = append(counts, count)
+		}
+
+		c.steps[len(c.steps)-1].last = true
+		if p.GasModel == GasModelV08 {
+			c.steps[first].gas = block.GasCost
+			continue
+		}
+		gas := Gas(0)
+		for i := len(counts) - 1; i >= 0; i-- {
+			step := &c.steps[first+i]
+			step.refund = gas
+			gas += counts[i]
+			step.gas = gas
+		}
+	}
+
+	return c
+}
+
+// CompiledInvoke runs the program from pc with its compiled form until it
+// stops, returning the same exit reason, PC and gas as
+// SingleStepInvokeDecodedBlocks. A block is charged up front when the gas
+// covers it. Blocks the gas does not cover, and entries at a PC no step
+// starts at, run on the pre-decoded instructions. A traced interpreter runs
+// SingleStepInvokeDecodedBlocks, which reports every instruction.
+func (interp *Interpreter) CompiledInvoke(pc ProgramCounter) (ExitReason, ProgramCounter) {
+	if interp.Tracer != nil {
+		return interp.SingleStepInvokeDecodedBlocks(pc)
+	}
+	compiled := interp.Program.Compiled
+	steps := compiled.steps
+
+	for {
+		idx := int32(-1)
+		if int(pc) < len(compiled.stepAt) {
+			idx = compiled.stepAt[pc]
+		}
+		if idx < 0 || interp.Gas < steps[idx].gas {
+			exitReason, pcPrime := interp.invokeDecodedBlock(pc)
+			if exitReason != ExitContinue {
+				return exitReason, pcPrime
+			}
+			pc = pcPrime
+			continue
+		}
+
+		interp.Gas -= steps[idx].gas
+		for i := idx; ; i++ {
+			step := &steps[i]
+			exitReason, newPC := step.run(interp)
+
+			if exitReason != ExitContinue {
+				interp.Gas += step.refund
+				switch exitReason.GetReasonType() {
+				case HALT, PANIC:
+					return exitReason, 0
+				case HOST_CALL:
+					return exitReason, step.next
+				default:
+					return exitReason, step.pc
+				}
+			}
+
+			if newPC != step.pc {
+				pc = newPC
+				break
+			}
+			if step.last {
+				pc = step.next
+				break
+			}
+		}
+	}
+}
+
+// compileInstr returns a closure for instr with its operands bound. Opcodes
+// without a specialized closure run their pre-decoded handler.
+func compileInstr(p *Program, instr *InstrMeta) compiledFn {
+	d, a, b, imm := instr.Dst, instr.Src[0], instr.Src[1], instr.Imm[0]
+	pc := instr.PC
+
+	switch info := opcodeInfoTable[instr.Opcode]; {
+	case info.Category == InstrCatOneRegImmOff && isRegister(a):
+		return compileImmBranch(p, instr)
+	case info.Category == InstrCatTwoRegOneOff && isRegister(a) && isRegister(b):
+		return compileRegBranch(p, instr)
+	}
+
+	switch instr.Opcode {
+	case 40: // jump
+		target, ok := branchTarget(p, ProgramCounter(imm))
+		return func(interp *Interpreter) (ExitReason, ProgramCounter) {
+			if !ok {
+				return ExitPanic, pc
+			}
+			return ExitContinue, target
+		}
+	case 51: // load_imm
+		if isRegister(d) {
+			return func(interp *Interpreter) (ExitReason, ProgramCounter) {
+				interp.Registers[d] = imm
+				return ExitContinue, pc
+			}
+		}
+	case 100: // move_reg
+		if isRegister(d) && isRegister(a) {
+			return func(interp *Interpreter) (ExitReason, ProgramCounter) {
+				interp.Registers[d] = interp.Registers[a]
+				return ExitContinue, pc
+			}
+		}
+	case 132, 133, 134, 149: // and_imm, xor_imm, or_imm, add_imm_64
+		if isRegister(d) && isRegister(a) {
+			op := aluOp(instr.Opcode)
+			return func(interp *Interpreter) (ExitReason, ProgramCounter) {
+				interp.Registers[d] = op(interp.Registers[a], imm)
+				return ExitContinue, pc
+			}
+		}
+	case 200, 201, 210, 211, 212: // add_64, sub_64, and, xor, or
+		if isRegister(d) && isRegister(a) && isRegister(b) {
+			op := aluOp(instr.Opcode)
+			return func(interp *Interpreter) (ExitReason, ProgramCounter) {
+				interp.Registers[d] = op(interp.Registers[a], interp.Registers[b])
+				return ExitContinue, pc
+			}
+		}
+	}
+
+	exec := instr.Exec
+	return func(interp *Interpreter) (ExitReason, ProgramCounter) {
+		return exec(interp, instr)
+	}
+}
+
+// fuseLoadImmBranch returns the superinstruction for a load_imm followed by
+// a branch, or nil if the pair does not fuse.
+func fuseLoadImmBranch(p *Program, loadImm, br *InstrMeta) compiledFn {
+	if loadImm.Opcode != 51 || !isRegister(loadImm.Dst) {
+		return nil
+	}
+	if category := opcodeInfoTable[br.Opcode].Category; category != InstrCatOneRegImmOff && category != InstrCatTwoRegOneOff {
+		return nil
+	}
+	if !isRegister(br.Src[0]) || (opcodeInfoTable[br.Opcode].Category == InstrCatTwoRegOneOff && !isRegister(br.Src[1])) {
+		return nil
+	}
+
+	d, imm := loadImm.Dst, loadImm.Imm[0]
+	branch := compileInstr(p, br)
+	return func(interp *Interpreter) (ExitReason, ProgramCounter) {
+		interp.Registers[d] = imm
+		return branch(interp)
+	}
+}
+
+// compileImmBranch compiles load_imm_jump and the branch_*_imm opcodes
+// (80-90).
+func compileImmBranch(p *Program, instr *InstrMeta) compiledFn {
+	a, vX, pc := instr.Src[0], instr.Imm[0], instr.PC
+	target, ok := branchTarget(p, ProgramCounter(instr.Imm[1]))
+
+	if instr.Opcode == 80 { // load_imm_jump
+		return func(interp *Interpreter) (ExitReason, ProgramCounter) {
+			interp.Registers[a] = vX
+			if !ok {
+				return ExitPanic, pc
+			}
+			return ExitContinue, target
+		}
+	}
+
+	cond := branchCondition(instr.Opcode)
+	if cond == nil {
+		exec := instr.Exec
+		return func(interp *Interpreter) (ExitReason, ProgramCounter) {
+			return exec(interp, instr)
+		}
+	}
+	return func(interp *Interpreter) (ExitReason, ProgramCounter) {
+		if !cond(interp.Registers[a], vX) {
+			return ExitContinue, pc
+		}
+		if !ok {
+			return ExitPanic, pc
+		}
+		return ExitContinue, target
+	}
+}
+
+// compileRegBranch compiles the two-register branches (170-175).
+func compileRegBranch(p *Program, instr *InstrMeta) compiledFn {
+	a, b, pc := instr.Src[0], instr.Src[1], instr.PC
+	target, ok := branchTarget(p, ProgramCounter(instr.Imm[0]))
+
+	cond := branchCondition(instr.Opcode)
+	if cond == nil {
+		exec := instr.Exec
+		return func(interp *Interpreter) (ExitReason, ProgramCounter) {
+			return exec(interp, instr)
+		}
+	}
+	return func(interp *Interpreter) (ExitReason, ProgramCounter) {
+		if !cond(interp.Registers[a], interp.Registers[b]) {
+			return ExitContinue, pc
+		}
+		if !ok {
+			return ExitPanic, pc
+		}
+		return ExitContinue, target
+	}
+}
+
+// branchTarget resolves the static part of branch (A.17): whether b starts a
+// basic block.
+func branchTarget(p *Program, b ProgramCounter) (ProgramCounter, bool) {
+	return b, p.Bitmasks.IsStartOfBasicBlock(b) && p.InstructionData.isOpcodeValid(b)
+}
+
+func branchCondition(opcode byte) func(a, b uint64) bool {
+	switch opcode {
+	case 81, 170: // branch_eq
+		return func(a, b uint64) bool { return a == b }
+	case 82, 171: // branch_ne
+		return func(a, b uint64) bool { return a != b }
+	case 83, 172: // branch_lt_u
+		return func(a, b uint64) bool { return a < b }
+	case 84: // branch_le_u_imm
+		return func(a, b uint64) bool { return a <= b }
+	case 85, 174: // branch_ge_u
+		return func(a, b uint64) bool { return a >= b }
+	case 86: // branch_gt_u_imm
+		return func(a, b uint64) bool { return a > b }
+	case 87, 173: // branch_lt_s
+		return func(a, b uint64) bool { return int64(a) < int64(b) }
+	case 88: // branch_le_s_imm
+		return func(a, b uint64) bool { return int64(a) <= int64(b) }
+	case 89, 175: // branch_ge_s
+		return func(a, b uint64) bool { return int64(a) >= int64(b) }
+	case 90: // branch_gt_s_imm
+		return func(a, b uint64) bool { return int64(a) > int64(b) }
+	}
+	return nil
+}
+
+func aluOp(opcode byte) func(a, b uint64) uint64 {
+	switch opcode {
+	case 132, 210: // and
+		return func(a, b uint64) uint64 { return a & b }
+	case 133, 211: // xor
+		return func(a, b uint64) uint64 { return a ^ b }
+	case 134, 212: // or
+		return func(a, b uint64) uint64 { return a | b }
+	case 149, 200: // add_64
+		return func(a, b uint64) uint64 { return a + b }
+	case 201: // sub_64
+		return func(a, b uint64) uint64 { return a - b }
+	}
+	return nil
+}
+
+func isRegister(index uint8) bool {
+	return int(index) < len(Registers{})
+}
+
+// compiledCacheSize bounds the number of programs kept by LoadProgram.
+const compiledCacheSize = 256
+
+// compiledCacheKey identifies a compiled program: the block costs depend on
+// the gas model as well as the code.
+type compiledCacheKey struct {
+	model    GasModel
+	codeHash types.OpaqueHash
+}
+
+var compiledCache = struct {
+	sync.Mutex
+	programs map[compiledCacheKey]*Program
+}{programs: map[compiledCacheKey]*Program{}}
+
+// LoadProgram deblobs and compiles a program blob, reusing the program
+// compiled for an earlier invocation of the same code. The returned program
+// is shared and must not be modified.
+func LoadProgram(blob []byte) (*Program, ExitReason) {
+	model := CurrentGasModel()
+	key := compiledCacheKey{model: model, codeHash: hash.Blake2bHash(blob)}
+
+	compiledCache.Lock()
+	program, ok := compiledCache.programs[key]
+	compiledCache.Unlock()
+	if ok {
+		return program, ExitContinue
+	}
+
+	decoded, exitReason := deBlobProgramCode(blob, model)
+	if exitReason != ExitContinue {
+		return nil, exitReason
+	}
+	program = &decoded
+	program.Compiled = program.Compile()
+
+	compiledCache.Lock()
+	defer compiledCache.Unlock()
+	if cached, ok := compiledCache.programs[key]; ok {
+		return cached, ExitContinue
+	}
+	if len(compiledCache.programs) >= compiledCacheSize {
+		for evicted := range compiledCache.programs {
+			delete(compiledCache.programs, evicted)
+			break
+		}
+	}
+	compiledCache.programs[key] = program
+	return program, ExitContinue
+}
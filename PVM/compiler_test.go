@@ -0,0 +1,74 @@
+package PVM
+
+import "testing"
+
+// fusedBranchBlob is
+//
+//	0: load_imm r0, 5
+//	3: branch_eq_imm r0, 5, 8
+//	7: trap
+//	8: load_imm r1, 7
+//	11: trap
+var fusedBranchBlob = []byte{
+	0, 0, 12,
+	51, 0x00, 5, 81, 0x10, 5, 5, 0, 51, 0x01, 7, 0,
+	0x89, 0x09,
+}
+
+func TestCompiledInvoke(t *testing.T) {
+	program, exitReason := DeBlobProgramCode(fusedBranchBlob)
+	if exitReason != ExitContinue {
+		t.Fatalf("DeBlobProgramCode: %v", exitReason)
+	}
+	program.Compiled = program.Compile()
+
+	if got := program.Compiled.stepAt[3]; got != -1 {
+		t.Fatalf("branch at pc 3 is step %d, want it fused into the load_imm at pc 0", got)
+	}
+
+	tests := []struct {
+		name    string
+		pc      ProgramCounter
+		gas     Gas
+		wantR1  uint64
+		wantGas Gas
+		want    ExitReason
+	}{
+		{name: "fused branch taken", pc: 0, gas: 10, wantR1: 7, wantGas: 6, want: ExitPanic},
+		{name: "entry between fused instructions", pc: 3, gas: 10, wantGas: 8, want: ExitPanic},
+		{name: "block not covered by gas", pc: 0, gas: 1, wantGas: 0, want: ExitOOG},
+		{name: "not an instruction", pc: 4, gas: 10, wantGas: 9, want: ExitPanic},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decoded := NewInterpreter(&program, Registers{}, &Memory{}, tt.gas)
+			wantReason, wantPC := decoded.SingleStepInvokeDecodedBlocks(tt.pc)
+
+			interp := NewInterpreter(&program, Registers{}, &Memory{}, tt.gas)
+			exitReason, pc := interp.CompiledInvoke(tt.pc)
+			if exitReason != tt.want || exitReason != wantReason || pc != wantPC {
+				t.Fatalf("CompiledInvoke = %v, %d; want %v, decoded engine %v, %d", exitReason, pc, tt.want, wantReason, wantPC)
+			}
+			if interp.Gas != tt.wantGas || interp.Gas != decoded.Gas {
+				t.Errorf("gas = %d, want %d (decoded engine %d)", interp.Gas, tt.wantGas, decoded.Gas)
+			}
+			if interp.Registers[1] != tt.wantR1 || interp.Registers != decoded.Registers {
+				t.Errorf("registers = %x, decoded engine %x", interp.Registers, decoded.Registers)
+			}
+		})
+	}
+}
+
+func TestLoadProgramCache(t *testing.T) {
+	first, exitReason := LoadProgram(fusedBranchBlob)
+	if exitReason != ExitContinue {
+		t.Fatalf("LoadProgram: %v", exitReason)
+	}
+	if first.Compiled == nil {
+		t.Fatal("LoadProgram returned a program without its compiled form")
+	}
+	second, _ := LoadProgram(append([]byte(nil), fusedBranchBlob...))
+	if first != second {
+		t.Error("LoadProgram compiled the same code twice")
+	}
+}
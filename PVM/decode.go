@@ -2,25 +2,53 @@ package PVM
 
 import (
 	"encoding/binary"
-	"errors"
 	"fmt"
 
 	"github.com/New-JAMneration/JAM-Protocol/internal/types"
 	utils "github.com/New-JAMneration/JAM-Protocol/internal/utilities"
 )
 
+// operandLength is min(4, max(0, skipLength-used)) from A.5, without the
+// wrap-around of the unsigned subtraction when an operand is cut short.
+func operandLength(skipLength, used ProgramCounter) ProgramCounter {
+	if skipLength <= used {
+		return 0
+	}
+	return min(4, skipLength-used)
+}
+
+// zetaByte and zetaBytes read ζ, the instruction data extended with zeros
+// (A.3), so the operands of the last instruction never read past the code.
+func zetaByte(code []byte, i ProgramCounter) byte {
+	if int(i) >= len(code) {
+		return 0
+	}
+	return code[i]
+}
+
+func zetaBytes(code []byte, from, to ProgramCounter) []byte {
+	if int(to) <= len(code) {
+		return code[from:to]
+	}
+	out := make([]byte, to-from)
+	if int(from) < len(code) {
+		copy(out, code[from:])
+	}
+	return out
+}
+
 func getRegModIndex(instructionCode []byte, pc ProgramCounter) uint8 {
-	return min(12, (instructionCode[pc+1])%16)
+	return min(12, (zetaByte(instructionCode, pc+1))%16)
 }
 
 func getRegFloorIndex(instructionCode []byte, pc ProgramCounter) uint8 {
-	return min(12, (instructionCode[pc+1])>>4)
+	return min(12, (zetaByte(instructionCode, pc+1))>>4)
 }
 
 // A.5.2
 func decodeOneImmediate(instructionCode []byte, pc ProgramCounter, skipLength ProgramCounter) (int, error) {
 	lX := min(4, skipLength)
-	immediateData := instructionCode[pc+1 : pc+lX+1]
+	immediateData := zetaBytes(instructionCode, pc+1, pc+lX+1)
 	immediate, _, err := ReadUintSignExtended(immediateData, len(immediateData))
 	if err != nil {
 		return 0, err
@@ -35,9 +63,9 @@ func decodeOneRegisterAndOneExtendedWidthImmediate(instructionCode []byte, pc Pr
 
 // A.5.4
 func decodeTwoImmediates(instructionCode []byte, pc ProgramCounter, skipLength ProgramCounter) (uint64, uint64, error) {
-	lX := ProgramCounter(min(4, uint8(instructionCode[pc+1])))
+	lX := ProgramCounter(min(4, uint8(zetaByte(instructionCode, pc+1))))
 
-	decodedVX, err := utils.DeserializeFixedLength(instructionCode[pc+2:pc+2+lX], types.U64(lX))
+	decodedVX, err := utils.DeserializeFixedLength(zetaBytes(instructionCode, pc+2, pc+2+lX), types.U64(lX))
 	if err != nil {
 		return 0, 0, fmt.Errorf("opcode %s(%d) at pc=%d deserialize vx raise error : %w", zeta[opcode(instructionCode[pc])], opcode(instructionCode[pc]), pc, err)
 	}
@@ -47,8 +75,8 @@ func decodeTwoImmediates(instructionCode []byte, pc ProgramCounter, skipLength P
 		return 0, 0, fmt.Errorf("opcosde %s(%d) at pc=%d signExtend lx raise error : %w", zeta[opcode(instructionCode[pc])], opcode(instructionCode[pc]), pc, err)
 	}
 
-	lY := min(4, max(0, skipLength-lX-1))
-	decodedVy, err := utils.DeserializeFixedLength(instructionCode[pc+2+lX:pc+2+lX+lY], types.U64(lY))
+	lY := operandLength(skipLength, lX+1)
+	decodedVy, err := utils.DeserializeFixedLength(zetaBytes(instructionCode, pc+2+lX, pc+2+lX+lY), types.U64(lY))
 	if err != nil {
 		return 0, 0, fmt.Errorf("opcosde %s(%d) at pc=%d deserialization vy raise error : %w", zeta[opcode(instructionCode[pc])], opcode(instructionCode[pc]), pc, err)
 	}
@@ -64,7 +92,7 @@ func decodeTwoImmediates(instructionCode []byte, pc ProgramCounter, skipLength P
 // returns vX
 func decodeOneOffset(instructionCode []byte, pc ProgramCounter, skipLength ProgramCounter) (ProgramCounter, error) {
 	lX := min(4, skipLength)
-	offsetData := instructionCode[pc+1 : pc+1+lX]
+	offsetData := zetaBytes(instructionCode, pc+1, pc+1+lX)
 	offset, _, err := ReadIntFixed(offsetData, len(offsetData))
 	if err != nil {
 		return 0, err
@@ -76,10 +104,10 @@ func decodeOneOffset(instructionCode []byte, pc ProgramCounter, skipLength Progr
 // A.5.6
 // returns rA, vX
 func decodeOneRegisterAndOneImmediate(instructionCode []byte, pc ProgramCounter, skipLength ProgramCounter) (uint8, uint64, error) {
-	rA := min(12, instructionCode[pc+1]%16)
-	lX := min(4, max(0, skipLength-1))
+	rA := min(12, zetaByte(instructionCode, pc+1)%16)
+	lX := operandLength(skipLength, 1)
 
-	immediateData := instructionCode[pc+2 : pc+2+lX]
+	immediateData := zetaBytes(instructionCode, pc+2, pc+2+lX)
 	immediate, _, err := ReadUintSignExtended(immediateData, len(immediateData))
 	if err != nil {
 		pvmLogger.Errorf("opcode %s at instruction %d deserialize vy raise error : %s", zeta[opcode(instructionCode[pc])], pc, err)
@@ -91,10 +119,10 @@ func decodeOneRegisterAndOneImmediate(instructionCode []byte, pc ProgramCounter,
 
 // A.5.7
 func decodeOneRegisterAndTwoImmediates(instructionCode []byte, pc ProgramCounter, skipLength ProgramCounter) (int8, uint64, uint64, error) {
-	rA := int8(min(12, instructionCode[pc+1]%16))
-	lX := min(4, ProgramCounter(uint8((instructionCode[pc+1] >> 4))))
+	rA := int8(min(12, zetaByte(instructionCode, pc+1)%16))
+	lX := min(4, ProgramCounter(uint8((zetaByte(instructionCode, pc+1) >> 4))))
 	pcMargin := pc + 2 + lX
-	decodedVX, err := utils.DeserializeFixedLength(instructionCode[pc+2:pcMargin], types.U64(lX))
+	decodedVX, err := utils.DeserializeFixedLength(zetaBytes(instructionCode, pc+2, pcMargin), types.U64(lX))
 	if err != nil {
 		return 0, 0, 0, fmt.Errorf("opcode %s(%d) at pc=%d deserialize vx raise error : %w", zeta[opcode(instructionCode[pc])], opcode(instructionCode[pc]), pc, err)
 	}
@@ -103,8 +131,8 @@ func decodeOneRegisterAndTwoImmediates(instructionCode []byte, pc ProgramCounter
 		return 0, 0, 0, fmt.Errorf("opcode %s(%d) at pc=%d signExtend vx raise error : %w", zeta[opcode(instructionCode[pc])], opcode(instructionCode[pc]), pc, err)
 	}
 
-	lY := min(4, max(0, skipLength-lX-1))
-	decodedVY, err := utils.DeserializeFixedLength(instructionCode[pcMargin:pcMargin+lY], types.U64(lY))
+	lY := operandLength(skipLength, lX+1)
+	decodedVY, err := utils.DeserializeFixedLength(zetaBytes(instructionCode, pcMargin, pcMargin+lY), types.U64(lY))
 	if err != nil {
 		return 0, 0, 0, fmt.Errorf("opcode %s(%d) at pc=%d deserialize vy raise error : %w", zeta[opcode(instructionCode[pc])], opcode(instructionCode[pc]), pc, err)
 	}
@@ -119,17 +147,17 @@ func decodeOneRegisterAndTwoImmediates(instructionCode []byte, pc ProgramCounter
 // A.5.8
 // returns rA, vX, vY
 func decodeOneRegisterOneImmediateAndOneOffset(instructionCode []byte, pc ProgramCounter, skipLength ProgramCounter) (uint8, uint64, ProgramCounter, error) {
-	rA := min(12, instructionCode[pc+1]%16)
-	lX := ProgramCounter(min(4, (instructionCode[pc+1]>>4)%8))
-	lY := min(4, max(0, skipLength-lX-1))
+	rA := min(12, zetaByte(instructionCode, pc+1)%16)
+	lX := ProgramCounter(min(4, (zetaByte(instructionCode, pc+1)>>4)%8))
+	lY := operandLength(skipLength, lX+1)
 
-	immediateData := instructionCode[pc+2 : pc+2+lX]
+	immediateData := zetaBytes(instructionCode, pc+2, pc+2+lX)
 	immediate, _, err := ReadUintSignExtended(immediateData, len(immediateData))
 	if err != nil {
 		return 0, 0, 0, err
 	}
 
-	offsetData := instructionCode[pc+2+lX : pc+2+lX+lY]
+	offsetData := zetaBytes(instructionCode, pc+2+lX, pc+2+lX+lY)
 	offset, _, err := ReadIntFixed(offsetData, len(offsetData))
 	if err != nil {
 		return 0, 0, 0, err
@@ -140,19 +168,16 @@ func decodeOneRegisterOneImmediateAndOneOffset(instructionCode []byte, pc Progra
 
 // A.5.9
 func decodeTwoRegisters(instructionCode []byte, pc ProgramCounter) (rD uint8, rA uint8, err error) {
-	if int(pc+1) >= len(instructionCode) {
-		return 0, 0, errors.New("pc out of bound")
-	}
 	rD = getRegModIndex(instructionCode, pc)
 	rA = getRegFloorIndex(instructionCode, pc)
 	return rD, rA, nil
 }
 
 func decodeTwoRegistersAndOneImmediate(instructionCode []byte, pc ProgramCounter, skipLength ProgramCounter) (uint8, uint8, uint64, error) {
-	rA := min(12, instructionCode[pc+1]&15)
-	rB := min(12, instructionCode[pc+1]>>4)
-	lX := min(4, max(0, skipLength-1))
-	decodedVX, err := utils.DeserializeFixedLength(instructionCode[pc+2:pc+2+lX], types.U64(lX))
+	rA := min(12, zetaByte(instructionCode, pc+1)&15)
+	rB := min(12, zetaByte(instructionCode, pc+1)>>4)
+	lX := operandLength(skipLength, 1)
+	decodedVX, err := utils.DeserializeFixedLength(zetaBytes(instructionCode, pc+2, pc+2+lX), types.U64(lX))
 	if err != nil {
 		return 0, 0, 0, fmt.Errorf("opcode %s(%d) at pc=%d deserialization error : %w", zeta[opcode(instructionCode[pc])], opcode(instructionCode[pc]), pc, err)
 	}
@@ -167,11 +192,11 @@ func decodeTwoRegistersAndOneImmediate(instructionCode []byte, pc ProgramCounter
 // A.5.11
 // returns rA, rB, vX
 func decodeTwoRegistersAndOneOffset(instructionCode []byte, pc ProgramCounter, skipLength ProgramCounter) (uint8, uint8, ProgramCounter, error) {
-	rA := min(12, instructionCode[pc+1]%16)
-	rB := min(12, instructionCode[pc+1]>>4)
-	lX := min(4, max(0, skipLength-1))
+	rA := min(12, zetaByte(instructionCode, pc+1)%16)
+	rB := min(12, zetaByte(instructionCode, pc+1)>>4)
+	lX := operandLength(skipLength, 1)
 
-	offsetData := instructionCode[pc+2 : pc+2+lX]
+	offsetData := zetaBytes(instructionCode, pc+2, pc+2+lX)
 	offset, _, err := ReadIntFixed(offsetData, len(offsetData))
 	if err != nil {
 		return 0, 0, 0, err
@@ -183,18 +208,18 @@ func decodeTwoRegistersAndOneOffset(instructionCode []byte, pc ProgramCounter, s
 // A.5.12
 // returns rA, rB, vX, vY
 func decodeTwoRegistersAndTwoImmediates(instructionCode []byte, pc ProgramCounter, skipLength ProgramCounter) (uint8, uint8, uint64, uint64, error) {
-	rA := min(12, instructionCode[pc+1]%16)
-	rB := min(12, instructionCode[pc+1]>>4)
-	lX := ProgramCounter(min(4, instructionCode[pc+2]%8))
-	lY := min(4, max(0, skipLength-lX-2))
+	rA := min(12, zetaByte(instructionCode, pc+1)%16)
+	rB := min(12, zetaByte(instructionCode, pc+1)>>4)
+	lX := ProgramCounter(min(4, zetaByte(instructionCode, pc+2)%8))
+	lY := operandLength(skipLength, lX+2)
 
-	vXData := instructionCode[pc+3 : pc+3+lX]
+	vXData := zetaBytes(instructionCode, pc+3, pc+3+lX)
 	vX, _, err := ReadUintFixed(vXData, len(vXData))
 	if err != nil {
 		return 0, 0, 0, 0, err
 	}
 
-	vYData := instructionCode[pc+3+lX : pc+3+lX+lY]
+	vYData := zetaBytes(instructionCode, pc+3+lX, pc+3+lX+lY)
 	vY, _, err := ReadUintFixed(vYData, len(vYData))
 	if err != nil {
 		return 0, 0, 0, 0, err
@@ -205,12 +230,9 @@ func decodeTwoRegistersAndTwoImmediates(instructionCode []byte, pc ProgramCounte
 
 // A.5.13
 func decodeThreeRegisters(instructionCode []byte, pc ProgramCounter) (rA uint8, rB uint8, rD uint8, err error) {
-	if int(pc+2) >= len(instructionCode) {
-		return 0, 0, 0, errors.New("pc out of bound")
-	}
 	rA = getRegModIndex(instructionCode, pc)
 	rB = getRegFloorIndex(instructionCode, pc)
-	rD = min(12, instructionCode[pc+2])
+	rD = min(12, zetaByte(instructionCode, pc+2))
 	return rA, rB, rD, nil
 }
 
@@ -223,8 +245,8 @@ func storeIntoMemory(interp *Interpreter, offset int, memIndex uint32, immediate
 	pageNum := memIndex / ZP
 	pageIndex := memIndex % ZP
 
-	page, ok := mem.Pages[pageNum]
-	if !ok {
+	page := mem.Page(pageNum)
+	if page == nil {
 		return ExitPageFault | ExitReason(memIndex)
 	}
 	if page.Access != MemoryReadWrite {
@@ -245,24 +267,30 @@ func storeIntoMemory(interp *Interpreter, offset int, memIndex uint32, immediate
 	}
 	src := buf[:offset]
 
+	page = mem.writablePage(pageNum, page)
+
 	// Fast path: entirely within current page.
 	if pageIndex+uint32(offset) <= ZP {
 		copy(page.Value[pageIndex:], src)
+		interp.traceWrite(memIndex, src)
 		return ExitContinue
 	}
 
 	// Cross-page slow path.
-	nextPage, ok := mem.Pages[pageNum+1]
-	if !ok {
+	nextPage := mem.Page(pageNum + 1)
+	if nextPage == nil {
 		return ExitPageFault | ExitReason(memIndex)
 	}
 	if nextPage.Access != MemoryReadWrite {
 		return ExitPageFault | ExitReason(memIndex)
 	}
 
+	nextPage = mem.writablePage(pageNum+1, nextPage)
+
 	firstLen := ZP - pageIndex
 	copy(page.Value[pageIndex:], src[:firstLen])
 	copy(nextPage.Value, src[firstLen:])
+	interp.traceWrite(memIndex, src)
 	return ExitContinue
 }
 
@@ -275,8 +303,8 @@ func loadFromMemory(interp *Interpreter, offset uint32, vx uint32) (uint64, Exit
 	pageNum := vx / ZP
 	pageIndex := vx % ZP
 
-	page, ok := mem.Pages[pageNum]
-	if !ok {
+	page := mem.Page(pageNum)
+	if page == nil {
 		return 0, ExitPageFault | ExitReason(vx)
 	}
 
@@ -296,8 +324,8 @@ func loadFromMemory(interp *Interpreter, offset uint32, vx uint32) (uint64, Exit
 	}
 
 	// Cross-page slow path: assemble bytes into a stack buffer.
-	nextPage, ok := mem.Pages[pageNum+1]
-	if !ok {
+	nextPage := mem.Page(pageNum + 1)
+	if nextPage == nil {
 		return 0, ExitPageFault | ExitReason(vx)
 	}
 
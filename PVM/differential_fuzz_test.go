@@ -0,0 +1,272 @@
+package PVM
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"runtime/debug"
+	"sort"
+	"testing"
+)
+
+// validOpcodes lists every opcode of opcodeInfoTable in ascending order.
+var validOpcodes = func() []byte {
+	var ops []byte
+	for op := 0; op < 256; op++ {
+		if IsValidOpcode(byte(op)) {
+			ops = append(ops, byte(op))
+		}
+	}
+	return ops
+}()
+
+// fuzzInput hands out the bytes of a fuzz input, returning zeros once it is
+// exhausted so every input maps to a program.
+type fuzzInput struct {
+	data []byte
+}
+
+func (in *fuzzInput) byte() byte {
+	if len(in.data) == 0 {
+		return 0
+	}
+	b := in.data[0]
+	in.data = in.data[1:]
+	return b
+}
+
+func (in *fuzzInput) bytes(n int) []byte {
+	out := make([]byte, n)
+	for i := range out {
+		out[i] = in.byte()
+	}
+	return out
+}
+
+func (in *fuzzInput) uint64() uint64 {
+	return binary.LittleEndian.Uint64(in.bytes(8))
+}
+
+// interestingValues are register values that hit the edges of the
+// arithmetic, comparison and memory instructions.
+var interestingValues = []uint64{
+	0, 1, 2, 7, 0x7f, 0x80, 0xff, 0x1_0000, 0x2_0000, 0x2_0ffc, 0x2_1000,
+	0x7fff_ffff, 0x8000_0000, 0xffff_ffff, 0xffff_0000, 1 << 63, ^uint64(0),
+}
+
+// differentialProgram is a program blob with the initial state every engine
+// starts from.
+type differentialProgram struct {
+	blob      []byte
+	registers Registers
+	gas       Gas
+	memory    Memory
+}
+
+// Memory of the generated programs: one read-only page below two writable
+// pages, so accesses can hit every access level and cross page boundaries.
+const (
+	fuzzReadOnlyPage  = 0x1_0000 / ZP
+	fuzzReadWritePage = 0x2_0000 / ZP
+)
+
+// buildDifferentialProgram derives a program and its initial state from a
+// fuzz input. Instructions are drawn from the valid opcodes with arbitrary
+// operand bytes; the low bits of the first byte select mutations that make
+// the program invalid: clearing or setting bitmask bits, replacing an
+// opcode with an invalid one and filling the jump table with arbitrary
+// targets.
+func buildDifferentialProgram(data []byte) *differentialProgram {
+	in := &fuzzInput{data: data}
+	flags := in.byte()
+
+	p := &differentialProgram{gas: Gas(in.byte())<<4 | Gas(in.byte()&0x0f)}
+	for i := range p.registers {
+		if selector := in.byte(); selector&0x80 == 0 {
+			p.registers[i] = interestingValues[int(selector)%len(interestingValues)]
+		} else {
+			p.registers[i] = in.uint64()
+		}
+	}
+
+	p.memory.SetPage(fuzzReadOnlyPage, &Page{Value: make([]byte, ZP), Access: MemoryReadOnly})
+	p.memory.SetPage(fuzzReadWritePage, &Page{Value: make([]byte, ZP), Access: MemoryReadWrite})
+	p.memory.SetPage(fuzzReadWritePage+1, &Page{Value: make([]byte, ZP), Access: MemoryReadWrite})
+	copy(p.memory.Page(fuzzReadOnlyPage).Value, in.bytes(16))
+	copy(p.memory.Page(fuzzReadWritePage).Value[ZP-16:], in.bytes(16))
+
+	var code []byte
+	var starts []int
+	count := int(in.byte()%32) + 1
+	for i := 0; i < count && len(in.data) > 0; i++ {
+		starts = append(starts, len(code))
+		code = append(code, validOpcodes[int(in.byte())%len(validOpcodes)])
+		code = append(code, in.bytes(int(in.byte()%16))...)
+	}
+	if flags&0x01 == 0 {
+		starts = append(starts, len(code))
+		code = append(code, 0) // trap, so most programs end in a terminator
+	}
+
+	bitmask := make([]byte, (len(code)+7)/8)
+	for _, start := range starts {
+		bitmask[start/8] |= 1 << (start % 8)
+	}
+	if flags&0x02 != 0 && len(code) > 0 {
+		at := int(in.byte()) % len(code)
+		bitmask[at/8] ^= 1 << (at % 8)
+	}
+	if flags&0x04 != 0 && len(starts) > 0 {
+		code[starts[int(in.byte())%len(starts)]] = 2 // not an opcode
+	}
+
+	jumpTable := make([]uint32, in.byte()%4)
+	for i := range jumpTable {
+		if flags&0x08 != 0 {
+			jumpTable[i] = uint32(in.byte())
+		} else {
+			jumpTable[i] = uint32(starts[int(in.byte())%len(starts)])
+		}
+	}
+
+	// A.2: E(|j|) ++ E_1(z) ++ E(|c|) ++ E_z(j) ++ c ++ k, with z = 4.
+	blob := []byte{byte(len(jumpTable)), 4, byte(len(code) & 0x7f)}
+	if len(code) >= 0x80 {
+		blob[2] = 0x80 | byte(len(code)>>8)
+		blob = append(blob, byte(len(code)))
+	}
+	for _, target := range jumpTable {
+		blob = binary.LittleEndian.AppendUint32(blob, target)
+	}
+	blob = append(blob, code...)
+	p.blob = append(blob, bitmask...)
+	return p
+}
+
+func (p *differentialProgram) newMemory() *Memory {
+	// every engine writes to pages of its own; p.memory stays as generated
+	memory := p.memory.Clone()
+	return &memory
+}
+
+// engineResult is the state an engine stopped in.
+type engineResult struct {
+	ExitReason ExitReason
+	PC         ProgramCounter
+	Gas        Gas
+	Registers  Registers
+	Pages      map[uint32][]byte
+}
+
+// engine selects the interpreter loop runEngine runs a program with.
+type engine int
+
+const (
+	singleStepEngine engine = iota
+	decodedEngine
+	compiledEngine
+)
+
+func (e engine) String() string {
+	return [...]string{"single-step", "decoded", "compiled"}[e]
+}
+
+func runEngine(t testing.TB, p *differentialProgram, program *Program, e engine) (result engineResult) {
+	defer func() {
+		if r := recover(); r != nil {
+			t.Fatalf("%v engine panicked on blob %x: %v\n%s", e, p.blob, r, debug.Stack())
+		}
+	}()
+
+	interp := NewInterpreter(program, p.registers, p.newMemory(), p.gas)
+	switch e {
+	case compiledEngine:
+		result.ExitReason, result.PC = interp.CompiledInvoke(0)
+	case decodedEngine:
+		result.ExitReason, result.PC = interp.SingleStepInvokeDecodedBlocks(0)
+	default:
+		result.ExitReason, result.PC = interp.SingleStepInvoke(0)
+		// The single-step engine stops at the ecalli instruction itself; the
+		// decoded engine, which host calls resume from, reports the next one.
+		if result.ExitReason.GetReasonType() == HOST_CALL {
+			result.PC += ProgramCounter(skip(int(result.PC), program.Bitmasks)) + 1
+		}
+	}
+
+	result.Gas = interp.Gas
+	result.Registers = interp.Registers
+	result.Pages = map[uint32][]byte{}
+	for index, page := range interp.Memory.Pages() {
+		if original := p.memory.Page(index); original == nil || !bytes.Equal(original.Value, page.Value) {
+			result.Pages[index] = page.Value
+		}
+	}
+	return result
+}
+
+func (r engineResult) String() string {
+	pages := make([]uint32, 0, len(r.Pages))
+	for index := range r.Pages {
+		pages = append(pages, index)
+	}
+	sort.Slice(pages, func(i, j int) bool { return pages[i] < pages[j] })
+	return fmt.Sprintf("exit=%v pc=%d gas=%d regs=%x touched pages=%v", r.ExitReason, r.PC, r.Gas, r.Registers, pages)
+}
+
+func checkDifferential(t *testing.T, data []byte) {
+	p := buildDifferentialProgram(data)
+
+	program, exitReason := func() (program Program, exitReason ExitReason) {
+		defer func() {
+			if r := recover(); r != nil {
+				t.Fatalf("DeBlobProgramCode panicked on blob %x: %v\n%s", p.blob, r, debug.Stack())
+			}
+		}()
+		return DeBlobProgramCode(p.blob)
+	}()
+	if exitReason != ExitContinue {
+		// The engines share the decoded program, so a blob that does not
+		// decode has nothing to compare.
+		return
+	}
+
+	program.Compiled = program.Compile()
+	singleStep := runEngine(t, p, &program, singleStepEngine)
+	for _, e := range []engine{decodedEngine, compiledEngine} {
+		checkSameResult(t, p, singleStepEngine, singleStep, e, runEngine(t, p, &program, e))
+	}
+
+	program, _ = deBlobProgramCode(p.blob, GasModelV08)
+	program.Compiled = program.Compile()
+	singleStep = runEngine(t, p, &program, singleStepEngine)
+	for _, e := range []engine{decodedEngine, compiledEngine} {
+		checkSameResult(t, p, singleStepEngine, singleStep, e, runEngine(t, p, &program, e))
+	}
+}
+
+func checkSameResult(t *testing.T, p *differentialProgram, e1 engine, r1 engineResult, e2 engine, r2 engineResult) {
+	if r1.ExitReason != r2.ExitReason || r1.PC != r2.PC || r1.Gas != r2.Gas || r1.Registers != r2.Registers {
+		t.Fatalf("engines diverge on blob %x\n%-12s %v\n%-12s %v", p.blob, e1.String()+":", r1, e2.String()+":", r2)
+	}
+	if len(r1.Pages) != len(r2.Pages) {
+		t.Fatalf("engines touched different pages on blob %x\n%-12s %v\n%-12s %v", p.blob, e1.String()+":", r1, e2.String()+":", r2)
+	}
+	for index, value := range r1.Pages {
+		if !bytes.Equal(value, r2.Pages[index]) {
+			t.Fatalf("%v and %v engines wrote different contents to page %d on blob %x", e1, e2, index, p.blob)
+		}
+	}
+}
+
+// FuzzDifferentialEngines runs generated programs on the single-step, the
+// pre-decoded and the compiled engine and requires all of them to stop in the
+// same state, under GasModelV07 and GasModelV08. Minimized
+// failures are kept in testdata/fuzz/FuzzDifferentialEngines and run as
+// regression tests by go test.
+//
+//	go test ./PVM -run '^$' -fuzz FuzzDifferentialEngines
+func FuzzDifferentialEngines(f *testing.F) {
+	f.Add([]byte{})
+	f.Add([]byte{0x00, 0x10, 0x00, 0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12})
+	f.Fuzz(checkDifferential)
+}
@@ -0,0 +1,185 @@
+package PVM
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Disassembly is a program blob decoded for inspection. Unlike
+// DeBlobProgramCode it does not stop at the first malformed instruction:
+// every instruction start the bitmask marks is decoded and what would make
+// the program panic or misbehave is collected in Issues.
+type Disassembly struct {
+	CodeSize  int
+	JumpTable []ProgramCounter // destinations of dynamic jumps, by index
+	Instrs    []InstrMeta      // every instruction start, in pc order
+	Blocks    []BlockMeta      // basic blocks, GasCost under CurrentGasModel
+	Issues    []DisasmIssue
+}
+
+// DisasmIssue is a problem found at PC.
+type DisasmIssue struct {
+	PC      ProgramCounter
+	Message string
+}
+
+// Disassemble decodes a program blob (A.2). An error is returned only when
+// the blob cannot be split into jump table, code and bitmask.
+func Disassemble(blob []byte) (*Disassembly, error) {
+	prog, rawBitmask, err := splitProgramBlob(blob)
+	if err != nil {
+		return nil, err
+	}
+
+	code, bitmask := prog.InstructionData, prog.Bitmasks
+	d := &Disassembly{CodeSize: len(code)}
+	issue := func(pc ProgramCounter, format string, args ...any) {
+		d.Issues = append(d.Issues, DisasmIssue{PC: pc, Message: fmt.Sprintf(format, args...)})
+	}
+	isBlockStart := func(pc ProgramCounter) bool {
+		return bitmask.IsStartOfBasicBlock(pc) && code.isOpcodeValid(pc)
+	}
+
+	if len(code) > 0 && !bitmask.IsStartOfInstruction(0) {
+		issue(0, "bitmask does not mark an instruction at pc 0")
+	}
+	for i := len(code); i < 8*len(rawBitmask); i++ {
+		if rawBitmask[i/8]&(1<<(i%8)) != 0 {
+			issue(ProgramCounter(len(code)), "bitmask marks instruction starts past the end of the code")
+			break
+		}
+	}
+
+	for i := uint32(0); i < prog.JumpTable.Size; i++ {
+		dest, _, err := ReadUintFixed(prog.JumpTable.Data[i*prog.JumpTable.Length:], int(prog.JumpTable.Length))
+		if err != nil {
+			return nil, err
+		}
+		d.JumpTable = append(d.JumpTable, ProgramCounter(dest))
+		if !isBlockStart(ProgramCounter(dest)) {
+			issue(ProgramCounter(dest), "jump table entry %d is not the start of a basic block", i)
+		}
+	}
+
+	var block *BlockMeta
+	for pc := 0; pc < len(code); pc++ {
+		if !bitmask.IsStartOfInstruction(pc) {
+			continue
+		}
+
+		instr := InstrMeta{
+			PC:      ProgramCounter(pc),
+			Opcode:  code[pc],
+			SkipLen: uint8(skip(pc, bitmask)),
+		}
+		decodeOperands(&instr, code, bitmask)
+		d.Instrs = append(d.Instrs, instr)
+
+		if !IsValidOpcode(instr.Opcode) {
+			issue(instr.PC, "invalid opcode %d executes as trap", instr.Opcode)
+		}
+		if gap := nextInstructionStart(pc, bitmask) - pc - 1; gap > 24 {
+			issue(instr.PC, "%d operand bytes, only 24 are decoded", gap)
+		}
+		if target, ok := staticJumpTarget(&instr); ok && !isBlockStart(target) {
+			issue(instr.PC, "jump target @%d is not the start of a basic block", target)
+		}
+
+		if bitmask.IsStartOfBasicBlock(instr.PC) {
+			d.Blocks = append(d.Blocks, BlockMeta{StartPC: instr.PC, InstrStart: len(d.Instrs) - 1})
+			block = &d.Blocks[len(d.Blocks)-1]
+		}
+		if block != nil {
+			block.EndPC = instr.PC
+			block.InstrEnd = len(d.Instrs)
+		}
+	}
+
+	model := CurrentGasModel()
+	for i := range d.Blocks {
+		block := &d.Blocks[i]
+		block.GasCost = blockGasCost(model, d.Instrs[block.InstrStart:block.InstrEnd])
+	}
+	if n := len(d.Blocks); n > 0 && !IsBlockTerminator(d.Instrs[d.Blocks[n-1].InstrEnd-1].Opcode) {
+		issue(d.Blocks[n-1].EndPC, "last basic block does not end with a terminator")
+	}
+
+	return d, nil
+}
+
+// nextInstructionStart returns the pc of the instruction after the one at
+// pc, or the code size.
+func nextInstructionStart(pc int, bitmask Bitmask) int {
+	next := pc + 1
+	for next < len(bitmask) && !bitmask.IsStartOfInstruction(next) {
+		next++
+	}
+	return next
+}
+
+// staticJumpTarget returns the target of a jump or branch instruction.
+func staticJumpTarget(instr *InstrMeta) (ProgramCounter, bool) {
+	switch opcodeInfoTable[instr.Opcode].Category {
+	case InstrCatOneOffset, InstrCatTwoRegOneOff:
+		return ProgramCounter(instr.Imm[0]), true
+	case InstrCatOneRegImmOff:
+		return ProgramCounter(instr.Imm[1]), true
+	default:
+		return 0, false
+	}
+}
+
+// FormatOperands renders the decoded operands of instr, registers by their
+// ABI names and jump targets as @pc.
+func FormatOperands(instr *InstrMeta) string {
+	reg := func(r uint8) string {
+		if isRegister(r) {
+			return RegName[r]
+		}
+		return "?"
+	}
+	target := func(v uint64) string {
+		return fmt.Sprintf("@%d", v)
+	}
+
+	var operands []string
+	switch opcodeInfoTable[instr.Opcode].Category {
+	case InstrCatOneImm:
+		operands = []string{fmt.Sprint(instr.Imm[0])}
+	case InstrCatOneRegExtImm, InstrCatOneRegOneImm:
+		operands = []string{reg(instr.Dst), formatImmediate(instr.Imm[0])}
+	case InstrCatTwoImm:
+		operands = []string{formatImmediate(instr.Imm[0]), formatImmediate(instr.Imm[1])}
+	case InstrCatOneOffset:
+		operands = []string{target(instr.Imm[0])}
+	case InstrCatOneRegTwoImm:
+		operands = []string{reg(instr.Src[0]), formatImmediate(instr.Imm[0]), formatImmediate(instr.Imm[1])}
+	case InstrCatOneRegImmOff:
+		operands = []string{reg(instr.Src[0]), formatImmediate(instr.Imm[0]), target(instr.Imm[1])}
+	case InstrCatTwoReg:
+		operands = []string{reg(instr.Dst), reg(instr.Src[0])}
+	case InstrCatTwoRegOneImm:
+		operands = []string{reg(instr.Dst), reg(instr.Src[0]), formatImmediate(instr.Imm[0])}
+	case InstrCatTwoRegOneOff:
+		operands = []string{reg(instr.Src[0]), reg(instr.Src[1]), target(instr.Imm[0])}
+	case InstrCatTwoRegTwoImm:
+		operands = []string{reg(instr.Dst), reg(instr.Src[0]), formatImmediate(instr.Imm[0]), formatImmediate(instr.Imm[1])}
+	case InstrCatThreeReg:
+		operands = []string{reg(instr.Dst), reg(instr.Src[0]), reg(instr.Src[1])}
+	}
+	return strings.Join(operands, ", ")
+}
+
+// formatImmediate prints a sign-extended immediate in decimal when small
+// and in hex otherwise.
+func formatImmediate(v uint64) string {
+	signed := int64(v)
+	switch {
+	case signed > -256 && signed < 256:
+		return fmt.Sprint(signed)
+	case signed < 0:
+		return fmt.Sprintf("-0x%x", uint64(-signed))
+	default:
+		return fmt.Sprintf("0x%x", v)
+	}
+}
@@ -0,0 +1,43 @@
+package PVM
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDisassemble(t *testing.T) {
+	// 0: jump @5, 2: load_imm r0, 1, 5: move_reg; the bitmask also marks
+	// pc 7, past the end of the code.
+	blob := []byte{0, 0, 7, 40, 5, 51, 0x00, 0x01, 100, 0x01, 0xa5}
+
+	d, err := Disassemble(blob)
+	if err != nil {
+		t.Fatalf("Disassemble: %v", err)
+	}
+
+	var pcs []ProgramCounter
+	for _, instr := range d.Instrs {
+		pcs = append(pcs, instr.PC)
+	}
+	if want := []ProgramCounter{0, 2, 5}; !reflect.DeepEqual(pcs, want) {
+		t.Errorf("instructions at %v, want %v", pcs, want)
+	}
+	if len(d.Blocks) != 2 || d.Blocks[1].StartPC != 2 || d.Blocks[1].EndPC != 5 || d.Blocks[1].InstrCount() != 2 {
+		t.Errorf("blocks = %+v, want @0 and @2..5", d.Blocks)
+	}
+	if got := FormatOperands(&d.Instrs[0]); got != "@5" {
+		t.Errorf("jump operands = %q, want @5", got)
+	}
+	if got := FormatOperands(&d.Instrs[1]); got != "ra, 1" {
+		t.Errorf("load_imm operands = %q, want ra, 1", got)
+	}
+
+	want := []DisasmIssue{
+		{PC: 7, Message: "bitmask marks instruction starts past the end of the code"},
+		{PC: 0, Message: "jump target @5 is not the start of a basic block"},
+		{PC: 5, Message: "last basic block does not end with a terminator"},
+	}
+	if !reflect.DeepEqual(d.Issues, want) {
+		t.Errorf("issues = %+v, want %+v", d.Issues, want)
+	}
+}
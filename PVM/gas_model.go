@@ -0,0 +1,246 @@
+package PVM
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// GasModel selects how the instructions of a basic block are charged gas.
+type GasModel uint32
+
+const (
+	// GasModelV07 charges one gas per instruction before it runs (GP v0.7.x).
+	GasModelV07 GasModel = iota
+	// GasModelV08 charges the simulated pipeline cost of a basic block once,
+	// when execution enters the block (GP v0.8.0).
+	GasModelV08
+)
+
+func (m GasModel) String() string {
+	switch m {
+	case GasModelV07:
+		return "v0.7"
+	case GasModelV08:
+		return "v0.8"
+	default:
+		return fmt.Sprintf("GasModel(%d)", uint32(m))
+	}
+}
+
+var gasModel atomic.Uint32
+
+// CurrentGasModel returns the gas model programs are deblobbed with.
+func CurrentGasModel() GasModel {
+	return GasModel(gasModel.Load())
+}
+
+// SetGasModel sets the gas model used by programs deblobbed from now on.
+// Programs already deblobbed keep the model they were built with.
+func SetGasModel(m GasModel) {
+	gasModel.Store(uint32(m))
+}
+
+// GasModelForVersion returns the gas model of a Gray Paper version such as
+// "0.7.2": v0.7 up to 0.8.0 and the pipeline model from then on.
+func GasModelForVersion(version string) (GasModel, error) {
+	parts := strings.Split(strings.TrimPrefix(version, "v"), ".")
+	if len(parts) != 3 {
+		return 0, fmt.Errorf("invalid protocol version %q", version)
+	}
+	var numbers [3]uint64
+	for i, part := range parts {
+		n, err := strconv.ParseUint(part, 10, 32)
+		if err != nil {
+			return 0, fmt.Errorf("invalid protocol version %q: %w", version, err)
+		}
+		numbers[i] = n
+	}
+	if numbers[0] == 0 && numbers[1] < 8 {
+		return GasModelV07, nil
+	}
+	return GasModelV08, nil
+}
+
+// ExecUnit is the execution unit of the v0.8.0 pipeline an opcode runs on.
+type ExecUnit uint8
+
+const (
+	UnitALU ExecUnit = iota
+	UnitLoad
+	UnitStore
+	UnitMul
+	UnitDiv
+	numExecUnits
+)
+
+// OpcodeResource is the cost of an opcode in the v0.8.0 pipeline model
+// (A.10): the cycles until its result is available, the decoder slots it
+// takes and the execution unit it occupies.
+type OpcodeResource struct {
+	Cycles      uint8
+	DecodeSlots uint8
+	Unit        ExecUnit
+}
+
+// Parameters of the pipeline the v0.8.0 gas cost model (A.10) simulates: the
+// decoder takes up to decodeSlotsPerCycle slots a cycle, and an instruction
+// is decoded only once the one reorderBufferSize places before it retired.
+const (
+	decodeSlotsPerCycle = 4
+	reorderBufferSize   = 32
+)
+
+// execUnitCount is the number of instances of each execution unit in the
+// v0.8.0 pipeline (A.10). Every unit but the divider is pipelined and
+// accepts an instruction per cycle; a division holds its divider until its
+// result is available.
+var execUnitCount = [numExecUnits]int{
+	UnitALU:   4,
+	UnitLoad:  2,
+	UnitStore: 1,
+	UnitMul:   1,
+	UnitDiv:   1,
+}
+
+// Resources of the opcode groups of the v0.8.0 cost table (A.10), referenced
+// by opcodeInfoTable: ALU and control flow instructions, load_imm_64, which
+// takes two decoder slots, loads, stores, multiplications, the upper-half
+// multiplications and the 32- and 64-bit divisions and remainders.
+var (
+	resALU       = OpcodeResource{Cycles: 1, DecodeSlots: 1, Unit: UnitALU}
+	resLoadImm64 = OpcodeResource{Cycles: 1, DecodeSlots: 2, Unit: UnitALU}
+	resLoad      = OpcodeResource{Cycles: 4, DecodeSlots: 1, Unit: UnitLoad}
+	resStore     = OpcodeResource{Cycles: 1, DecodeSlots: 1, Unit: UnitStore}
+	resMul       = OpcodeResource{Cycles: 3, DecodeSlots: 1, Unit: UnitMul}
+	resMulUpper  = OpcodeResource{Cycles: 4, DecodeSlots: 1, Unit: UnitMul}
+	resDiv32     = OpcodeResource{Cycles: 20, DecodeSlots: 1, Unit: UnitDiv}
+	resDiv64     = OpcodeResource{Cycles: 40, DecodeSlots: 1, Unit: UnitDiv}
+)
+
+// blockGasCost returns the gas charged for the instructions of a basic
+// block under model.
+func blockGasCost(model GasModel, instrs []InstrMeta) Gas {
+	if model == GasModelV08 {
+		return simulatePipeline(instrs)
+	}
+	return Gas(len(instrs))
+}
+
+// simulatePipeline returns the cycles an in-order decoder feeding
+// out-of-order execution units takes to retire a basic block, at least 1.
+// Instructions are decoded decodeSlotsPerCycle slots a cycle while the
+// reorder buffer has room, start once their operands are ready and their
+// unit is free, and retire in order.
+func simulatePipeline(instrs []InstrMeta) Gas {
+	var (
+		decodeCycle, slotsUsed uint64
+		lastRetire             uint64
+		regReady               [len(Registers{})]uint64
+		unitFree               [numExecUnits][]uint64
+	)
+	for unit, count := range execUnitCount {
+		unitFree[unit] = make([]uint64, count)
+	}
+	retire := make([]uint64, len(instrs))
+
+	for k := range instrs {
+		instr := &instrs[k]
+		resource := opcodeInfoTable[instr.Opcode].Resource
+
+		if slotsUsed+uint64(resource.DecodeSlots) > decodeSlotsPerCycle {
+			decodeCycle++
+			slotsUsed = 0
+		}
+		if k >= reorderBufferSize && decodeCycle < retire[k-reorderBufferSize] {
+			decodeCycle = retire[k-reorderBufferSize]
+			slotsUsed = 0
+		}
+		slotsUsed += uint64(resource.DecodeSlots)
+
+		start := decodeCycle
+		reads, write := instrRegisters(instr)
+		for _, r := range reads {
+			if isRegister(r) {
+				start = max(start, regReady[r])
+			}
+		}
+
+		free := unitFree[resource.Unit]
+		instance := 0
+		for i := range free {
+			if free[i] < free[instance] {
+				instance = i
+			}
+		}
+		start = max(start, free[instance])
+		if resource.Unit == UnitDiv {
+			free[instance] = start + uint64(resource.Cycles)
+		} else {
+			free[instance] = start + 1
+		}
+
+		done := start + uint64(resource.Cycles)
+		if isRegister(write) {
+			regReady[write] = done
+		}
+		lastRetire = max(lastRetire, done)
+		retire[k] = lastRetire
+	}
+
+	return Gas(max(lastRetire, 1))
+}
+
+// instrRegisters returns the registers instr reads and the register it
+// writes, 0xFF for none.
+func instrRegisters(instr *InstrMeta) (reads [3]uint8, write uint8) {
+	reads = [3]uint8{0xFF, 0xFF, 0xFF}
+	write = 0xFF
+	op := instr.Opcode
+
+	switch opcodeInfoTable[op].Category {
+	case InstrCatOneRegExtImm:
+		write = instr.Dst
+	case InstrCatOneRegOneImm:
+		switch {
+		case op == 50: // jump_ind
+			reads[0] = instr.Src[0]
+		case op >= 59: // store_*
+			reads[0] = instr.Src[0]
+		default: // load_imm, load_*
+			write = instr.Dst
+		}
+	case InstrCatOneRegTwoImm:
+		reads[0] = instr.Src[0]
+	case InstrCatOneRegImmOff:
+		if op == 80 { // load_imm_jump
+			write = instr.Dst
+		} else {
+			reads[0] = instr.Src[0]
+		}
+	case InstrCatTwoReg, InstrCatTwoRegTwoImm:
+		reads[0] = instr.Src[0]
+		write = instr.Dst
+	case InstrCatTwoRegOneImm:
+		reads[0] = instr.Src[0]
+		switch {
+		case op <= 123: // store_ind_*
+			reads[1] = instr.Dst
+		case op == 147 || op == 148: // cmov_*_imm keeps rA when the condition fails
+			reads[1] = instr.Dst
+			write = instr.Dst
+		default:
+			write = instr.Dst
+		}
+	case InstrCatTwoRegOneOff:
+		reads[0], reads[1] = instr.Src[0], instr.Src[1]
+	case InstrCatThreeReg:
+		reads[0], reads[1] = instr.Src[0], instr.Src[1]
+		if op == 218 || op == 219 { // cmov_iz, cmov_nz
+			reads[2] = instr.Dst
+		}
+		write = instr.Dst
+	}
+	return reads, write
+}
@@ -0,0 +1,221 @@
+package PVM
+
+import "testing"
+
+func TestGasModelForVersion(t *testing.T) {
+	tests := []struct {
+		version string
+		want    GasModel
+		wantErr bool
+	}{
+		{version: "0.7.1", want: GasModelV07},
+		{version: "0.7.2", want: GasModelV07},
+		{version: "0.8.0", want: GasModelV08},
+		{version: "v0.8.1", want: GasModelV08},
+		{version: "1.0.0", want: GasModelV08},
+		{version: "0.8", wantErr: true},
+		{version: "0.x.0", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := GasModelForVersion(tt.version)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("GasModelForVersion(%q) error = %v, wantErr %v", tt.version, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && got != tt.want {
+			t.Errorf("GasModelForVersion(%q) = %v, want %v", tt.version, got, tt.want)
+		}
+	}
+}
+
+// TestOpcodeResources checks that every valid opcode has a resource in the
+// v0.8.0 cost table (A.10) the decoder can fit in a cycle.
+func TestOpcodeResources(t *testing.T) {
+	for op, info := range opcodeInfoTable {
+		if info.Category == InstrCatInvalid {
+			continue
+		}
+		r := info.Resource
+		if r.Cycles == 0 || r.DecodeSlots == 0 || r.DecodeSlots > decodeSlotsPerCycle || r.Unit >= numExecUnits {
+			t.Errorf("opcode %d (%s): resource %+v", op, info.Name, r)
+		}
+	}
+}
+
+// TestSimulatePipeline checks one rule of the v0.8.0 pipeline (A.10) per
+// case. The wanted costs are worked out by hand from the rule.
+func TestSimulatePipeline(t *testing.T) {
+	trap := InstrMeta{Opcode: 0, Dst: 0xFF, Src: [2]uint8{0xFF, 0xFF}}
+	loadImm := func(r uint8) InstrMeta {
+		return InstrMeta{Opcode: 51, Dst: r, Src: [2]uint8{r, 0xFF}}
+	}
+	loadImm64 := func(r uint8) InstrMeta {
+		return InstrMeta{Opcode: 20, Dst: r, Src: [2]uint8{0xFF, 0xFF}}
+	}
+	addImm := func(d, a uint8) InstrMeta {
+		return InstrMeta{Opcode: 149, Dst: d, Src: [2]uint8{a, 0xFF}}
+	}
+	threeReg := func(op, d, a, b uint8) InstrMeta {
+		return InstrMeta{Opcode: op, Dst: d, Src: [2]uint8{a, b}}
+	}
+	storeImm := InstrMeta{Opcode: 30, Dst: 0xFF, Src: [2]uint8{0xFF, 0xFF}}
+	loadInd := InstrMeta{Opcode: 130, Dst: 1, Src: [2]uint8{0, 0xFF}}
+
+	robFull := []InstrMeta{threeReg(203, 2, 0, 1)}
+	for i := range reorderBufferSize {
+		robFull = append(robFull, loadImm(uint8(3+i%9)))
+	}
+
+	tests := []struct {
+		name   string
+		instrs []InstrMeta
+		want   Gas
+	}{
+		// A block costs at least one gas.
+		{name: "trap", instrs: []InstrMeta{trap}, want: 1},
+		// Four one-slot instructions decode in cycle 0 and finish in cycle
+		// 1; the trap decodes in cycle 1.
+		{
+			name:   "four slots a cycle",
+			instrs: []InstrMeta{loadImm(0), loadImm(1), loadImm(2), loadImm(3), trap},
+			want:   2,
+		},
+		// load_imm_64 takes two slots: two decode in cycle 0, the third
+		// with the trap in cycle 1.
+		{
+			name:   "load_imm_64 takes two slots",
+			instrs: []InstrMeta{loadImm64(0), loadImm64(1), loadImm64(2), trap},
+			want:   2,
+		},
+		// Each add starts when the previous result is available.
+		{
+			name:   "dependent instructions wait for their operands",
+			instrs: []InstrMeta{loadImm(0), addImm(0, 0), addImm(0, 0), trap},
+			want:   3,
+		},
+		// A load takes 4 cycles before the add can use its result.
+		{
+			name:   "load latency",
+			instrs: []InstrMeta{loadInd, addImm(2, 1), trap},
+			want:   5,
+		},
+		// The single store unit takes one store a cycle.
+		{
+			name:   "one store unit",
+			instrs: []InstrMeta{storeImm, storeImm, trap},
+			want:   2,
+		},
+		// The multiplier is pipelined: the second starts a cycle after the
+		// first and both take 3 cycles.
+		{
+			name:   "pipelined multiplier",
+			instrs: []InstrMeta{threeReg(192, 2, 0, 1), threeReg(192, 3, 0, 1), trap},
+			want:   4,
+		},
+		{
+			name:   "upper multiplication",
+			instrs: []InstrMeta{threeReg(213, 2, 0, 1), trap},
+			want:   4,
+		},
+		{
+			name:   "64-bit division",
+			instrs: []InstrMeta{threeReg(203, 2, 0, 1), trap},
+			want:   40,
+		},
+		// The divider is not pipelined: the second division starts when
+		// the first one is done after 20 cycles.
+		{
+			name:   "divider is not pipelined",
+			instrs: []InstrMeta{threeReg(193, 2, 0, 1), threeReg(193, 3, 0, 1), trap},
+			want:   40,
+		},
+		// The division retires at 40 and the load_imm after it in order.
+		// The load_imm reorderBufferSize places after the division decodes
+		// when the division retires, at 40, instead of in cycle 8.
+		{name: "reorder buffer full", instrs: robFull, want: 41},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := simulatePipeline(tt.instrs); got != tt.want {
+				t.Errorf("simulatePipeline = %d, want %d", got, tt.want)
+			}
+			if got := blockGasCost(GasModelV07, tt.instrs); got != Gas(len(tt.instrs)) {
+				t.Errorf("blockGasCost(GasModelV07) = %d, want %d", got, len(tt.instrs))
+			}
+		})
+	}
+}
+
+func TestBlockGasCharging(t *testing.T) {
+	program, exitReason := deBlobProgramCode(fusedBranchBlob, GasModelV08)
+	if exitReason != ExitContinue {
+		t.Fatalf("deBlobProgramCode: %v", exitReason)
+	}
+	program.Compiled = program.Compile()
+
+	tests := []struct {
+		name    string
+		gas     Gas
+		wantGas Gas
+		want    ExitReason
+	}{
+		// load_imm + branch_eq_imm costs 2, load_imm r1 + trap costs 1.
+		{name: "charged per block", gas: 10, wantGas: 7, want: ExitPanic},
+		{name: "out of gas at block entry", gas: 1, wantGas: 1, want: ExitOOG},
+	}
+	for _, tt := range tests {
+		for _, e := range []engine{singleStepEngine, decodedEngine, compiledEngine} {
+			interp := NewInterpreter(&program, Registers{}, &Memory{}, tt.gas)
+			var got ExitReason
+			switch e {
+			case compiledEngine:
+				got, _ = interp.CompiledInvoke(0)
+			case decodedEngine:
+				got, _ = interp.SingleStepInvokeDecodedBlocks(0)
+			default:
+				got, _ = interp.SingleStepInvoke(0)
+			}
+			if got != tt.want || interp.Gas != tt.wantGas {
+				t.Errorf("%s (%v): exit %v with gas %d, want %v with gas %d", tt.name, e, got, interp.Gas, tt.want, tt.wantGas)
+			}
+		}
+	}
+}
+
+// TestInvokeGasModel runs fusedBranchBlob as an inner machine of the invoke
+// host call, which has to charge it gas under the current model too.
+func TestInvokeGasModel(t *testing.T) {
+	defer SetGasModel(CurrentGasModel())
+
+	for _, tt := range []struct {
+		model   GasModel
+		wantGas uint64
+	}{
+		{model: GasModelV07, wantGas: 6}, // four instructions
+		{model: GasModelV08, wantGas: 7}, // two blocks
+	} {
+		SetGasModel(tt.model)
+
+		const o = 0x10000
+		var memory Memory
+		memory.SetPage(o/ZP, &Page{Value: make([]byte, ZP), Access: MemoryReadWrite})
+		memory.Write(o, []byte{10}) // g, followed by the zero registers
+		registers := Registers{7: 0, 8: o}
+		gas := Gas(100)
+		input := OmegaInput{
+			Operation: InvokeOp,
+			VM:        &VMState{Registers: &registers, Memory: &memory, Gas: &gas},
+			Addition: HostCallArgs{RefineArgs: RefineArgs{IntegratedPVMMap: IntegratedPVMMap{
+				0: {ProgramCode: fusedBranchBlob},
+			}}},
+		}
+		invoke(input)
+
+		if registers[7] != INNERPANIC {
+			t.Errorf("%v: r7 = %d, want INNERPANIC", tt.model, registers[7])
+		}
+		if got := memory.Read(o, 8); got[0] != byte(tt.wantGas) {
+			t.Errorf("%v: inner gas left %d, want %d", tt.model, got[0], tt.wantGas)
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 
 	"github.com/New-JAMneration/JAM-Protocol/internal/service_account"
+	"github.com/New-JAMneration/JAM-Protocol/internal/stateaccess"
 	"github.com/New-JAMneration/JAM-Protocol/internal/types"
 	utils "github.com/New-JAMneration/JAM-Protocol/internal/utilities"
 	"github.com/New-JAMneration/JAM-Protocol/internal/utilities/hash"
@@ -236,7 +237,7 @@ func checkpoint(input OmegaInput) (output OmegaOutput) {
 		return *result
 	}
 
-	input.Addition.ResultContextY = input.Addition.ResultContextX.DeepCopy()
+	input.Addition.ResultContextY = input.Addition.ResultContextX.CopyOnWrite()
 
 	input.VM.Registers[7] = uint64(*input.VM.Gas)
 
@@ -602,7 +603,7 @@ func query(input OmegaInput) (output OmegaOutput) {
 	}
 	lookupKey := types.LookupMetaMapkey{Hash: types.OpaqueHash(h), Length: types.U32(z)} // x_bold{s}_l
 	var timeSlotSet types.TimeSlotSet
-	lookupTimeSlotSet := getLookupItemFromKeyVal(input.Addition.ResultContextX.StorageKeyVal, serviceID, lookupKey)
+	lookupTimeSlotSet := getLookupItemFromKeyVal(input.Addition.ResultContextX.StateAccess, account, serviceID, lookupKey)
 	if lookupTimeSlotSet != nil {
 		decoder := types.NewDecoder()
 		err := decoder.Decode(lookupTimeSlotSet, &timeSlotSet)
@@ -612,6 +613,7 @@ func query(input OmegaInput) (output OmegaOutput) {
 				Addition:   input.Addition,
 			}
 		}
+		input.Addition.ownServiceAccount(serviceID, &account)
 		account.LookupDict[lookupKey] = timeSlotSet
 	}
 
@@ -648,8 +650,8 @@ func query(input OmegaInput) (output OmegaOutput) {
 	}
 }
 
-func loadLookupTimeSlotSet(account *types.ServiceAccount, storageKeyVal *types.StateKeyVals, serviceID types.ServiceID, lookupKey types.LookupMetaMapkey) *OmegaOutput {
-	lookupTimeSlotSet := getLookupItemFromKeyVal(storageKeyVal, serviceID, lookupKey)
+func loadLookupTimeSlotSet(account *types.ServiceAccount, access *stateaccess.Overlay, serviceID types.ServiceID, lookupKey types.LookupMetaMapkey) *OmegaOutput {
+	lookupTimeSlotSet := getLookupItemFromKeyVal(access, *account, serviceID, lookupKey)
 	if lookupTimeSlotSet == nil {
 		return nil
 	}
@@ -728,9 +730,10 @@ func solicit(input OmegaInput) (output OmegaOutput) {
 			Addition:   input.Addition,
 		}
 	}
+	input.Addition.ownServiceAccount(serviceID, &a)
 
 	lookupKey := types.LookupMetaMapkey{Hash: types.OpaqueHash(h), Length: types.U32(z)}
-	if result := loadLookupTimeSlotSet(&a, input.Addition.ResultContextX.StorageKeyVal, serviceID, lookupKey); result != nil {
+	if result := loadLookupTimeSlotSet(&a, input.Addition.ResultContextX.StateAccess, serviceID, lookupKey); result != nil {
 		result.Addition = input.Addition
 		return *result
 	}
@@ -774,10 +777,11 @@ func forget(input OmegaInput) (output OmegaOutput) {
 	timeslot := input.Addition.Timeslot
 	// x_bold{s} = (x_u)_d[x_s] check service exists
 	if a, accountExists := input.Addition.ResultContextX.PartialState.ServiceAccounts[serviceID]; accountExists {
+		input.Addition.ownServiceAccount(serviceID, &a)
 		lookupKey := types.LookupMetaMapkey{Hash: types.OpaqueHash(h), Length: types.U32(z)} // x_bold{s}_l
 		// check lookupItem from key-val
 		var timeSlotSet types.TimeSlotSet
-		lookupTimeSlotSet := getLookupItemFromKeyVal(input.Addition.ResultContextX.StorageKeyVal, serviceID, lookupKey)
+		lookupTimeSlotSet := getLookupItemFromKeyVal(input.Addition.ResultContextX.StateAccess, a, serviceID, lookupKey)
 		if lookupTimeSlotSet != nil {
 			decoder := types.NewDecoder()
 			err := decoder.Decode(lookupTimeSlotSet, &timeSlotSet)
@@ -926,7 +930,7 @@ func provide(input OmegaInput) (output OmegaOutput) {
 
 	// check lookupItem from key-val
 	var timeSlotSet types.TimeSlotSet
-	lookupTimeSlotSet := getLookupItemFromKeyVal(input.Addition.ResultContextX.StorageKeyVal, s, lookupKey)
+	lookupTimeSlotSet := getLookupItemFromKeyVal(input.Addition.ResultContextX.StateAccess, account, s, lookupKey)
 	if lookupTimeSlotSet != nil {
 		decoder := types.NewDecoder()
 		err := decoder.Decode(lookupTimeSlotSet, &timeSlotSet)
@@ -936,6 +940,7 @@ func provide(input OmegaInput) (output OmegaOutput) {
 				Addition:   input.Addition,
 			}
 		}
+		input.Addition.ownServiceAccount(s, &account)
 		account.LookupDict[lookupKey] = timeSlotSet
 	}
 
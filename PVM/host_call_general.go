@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/New-JAMneration/JAM-Protocol/internal/service_account"
+	"github.com/New-JAMneration/JAM-Protocol/internal/stateaccess"
 	"github.com/New-JAMneration/JAM-Protocol/internal/types"
 	"github.com/New-JAMneration/JAM-Protocol/internal/utilities/merklization"
 )
@@ -56,7 +57,7 @@ type GeneralArgs struct {
 	ServiceID           *types.ServiceID
 	ServiceAccountState *types.ServiceAccountState
 	CoreID              *types.CoreIndex
-	StorageKeyVal       *types.StateKeyVals
+	StateAccess         *stateaccess.Overlay
 }
 
 type AccumulateArgs struct {
@@ -88,6 +89,23 @@ type HostCallArgs struct {
 	*Program
 }
 
+// ownServiceAccount is called before a host call changes the storage,
+// lookup or preimage maps of account, service serviceID of the accumulation
+// context. Since the last checkpoint those maps are shared with
+// ResultContextY; they are copied here on the first change.
+func (args *HostCallArgs) ownServiceAccount(serviceID types.ServiceID, account *types.ServiceAccount) {
+	state := &args.AccumulateArgs.ResultContextX.PartialState
+	if !state.Own(serviceID) {
+		return
+	}
+	owned := state.ServiceAccounts[serviceID]
+	account.StorageDict, account.LookupDict, account.PreimageLookup = owned.StorageDict, owned.LookupDict, owned.PreimageLookup
+	if args.GeneralArgs.ServiceID != nil && *args.GeneralArgs.ServiceID == serviceID && args.GeneralArgs.ServiceAccount != nil {
+		general := args.GeneralArgs.ServiceAccount
+		general.StorageDict, general.LookupDict, general.PreimageLookup = owned.StorageDict, owned.LookupDict, owned.PreimageLookup
+	}
+}
+
 func getPtr[T any](v T) *T { return &v }
 
 var hostCallName = []string{
@@ -646,9 +664,9 @@ func read(input OmegaInput) (output OmegaOutput) {
 	// first compute k , mu_ko...+kz
 	storageRawKey := input.VM.Memory.Read(ko, kz)
 	v, exists := a.StorageDict[string(storageRawKey)]
-	storageValueFromKeyVal := getStorageFromKeyVal(input.Addition.GeneralArgs.StorageKeyVal, serviceID, storageRawKey)
 	// v = nil
 	if !exists {
+		storageValueFromKeyVal := getStorageFromKeyVal(input.Addition.GeneralArgs.StateAccess, serviceID, storageRawKey)
 		if storageValueFromKeyVal == nil { // check storage state key-val
 			input.VM.Registers[7] = NONE
 			return OmegaOutput{
@@ -664,9 +682,10 @@ func read(input OmegaInput) (output OmegaOutput) {
 			// entry silently disappears from the global unmatched pool and is lost
 			// if the target service isn't part of the accumulating set this block.
 			if callerServiceID == serviceID {
+				input.Addition.ownServiceAccount(serviceID, &a)
 				a.StorageDict[string(storageRawKey)] = v
 				input.Addition.AccumulateArgs.ResultContextX.PartialState.ServiceAccounts[serviceID] = a
-				removeStorageFromKeyVal(input.Addition.GeneralArgs.StorageKeyVal, serviceID, storageRawKey)
+				removeStorageFromKeyVal(input.Addition.GeneralArgs.StateAccess, serviceID, storageRawKey)
 			}
 		}
 	}
@@ -719,16 +738,16 @@ func write(input OmegaInput) (output OmegaOutput) {
 
 	serviceID := *input.Addition.GeneralArgs.ServiceID
 	a := *input.Addition.GeneralArgs.ServiceAccount
+	input.Addition.ownServiceAccount(serviceID, &a)
 
 	value, storageRawKeyExists := a.StorageDict[string(storageRawKey)]
-	storageRawData := getStorageFromKeyVal(input.Addition.GeneralArgs.StorageKeyVal, serviceID, storageRawKey)
 	var l uint64
 	var footprintItems types.U32
 	var footprintOctets types.U64
 	if storageRawKeyExists {
 		footprintItems, footprintOctets = service_account.CalcStorageItemfootprint(string(storageRawKey), value)
 		l = uint64(len(value))
-	} else if !storageRawKeyExists && storageRawData != nil {
+	} else if storageRawData := getStorageFromKeyVal(input.Addition.GeneralArgs.StateAccess, serviceID, storageRawKey); storageRawData != nil {
 		footprintItems, footprintOctets = service_account.CalcStorageItemfootprint(string(storageRawKey), *storageRawData)
 		l = uint64(len(*storageRawData))
 	} else {
@@ -739,7 +758,7 @@ func write(input OmegaInput) (output OmegaOutput) {
 
 	if vz == 0 { // remove storage
 		delete(a.StorageDict, string(storageRawKey))
-		removeStorageFromKeyVal(input.Addition.GeneralArgs.StorageKeyVal, serviceID, storageRawKey)
+		removeStorageFromKeyVal(input.Addition.GeneralArgs.StateAccess, serviceID, storageRawKey)
 
 		// direct update items, octets
 		a.ServiceInfo.Items -= footprintItems
@@ -765,7 +784,7 @@ func write(input OmegaInput) (output OmegaOutput) {
 
 		// balance check passed, now apply the storage mutation
 		a.StorageDict[string(storageRawKey)] = storageRawData
-		removeStorageFromKeyVal(input.Addition.GeneralArgs.StorageKeyVal, serviceID, storageRawKey)
+		removeStorageFromKeyVal(input.Addition.GeneralArgs.StateAccess, serviceID, storageRawKey)
 		pvmLogger.Debugf("write storage key: 0x%x, val: 0x%x", encodedKey.Key, storageRawData)
 		// update items, octets
 		a.ServiceInfo.Items = newItems
@@ -951,49 +970,44 @@ func check(serviceID types.ServiceID, serviceAccountState types.ServiceAccountSt
 }
 
 // 0.7.0 later, fuzzer (forks) needs to recover state, storage, part of lookupData cannot be recover,
-// Thus, needs to check storage, part of lookupData from KeyVal
-// return storage val and add storage state into ResultContextX
-func getStorageFromKeyVal(keyVal *types.StateKeyVals, serviceID types.ServiceID, storageKey types.ByteSequence) *types.ByteSequence {
+// Thus, needs to check storage, part of lookupData from the state access overlay.
+// The overlay reads the prior state from the store, so a read error means the
+// state cannot be computed: it panics rather than treat the key as absent.
+func getStorageFromKeyVal(access *stateaccess.Overlay, serviceID types.ServiceID, storageKey types.ByteSequence) *types.ByteSequence {
 	requestedStorageStateKey := merklization.WrapEncodeDelta2KeyVal(serviceID, storageKey, nil)
-	for _, v := range *keyVal {
-		if v.Key == requestedStorageStateKey.Key {
-			return &v.Value
-		}
+	value, found, err := access.Get(requestedStorageStateKey.Key)
+	if err != nil {
+		panic(fmt.Errorf("read storage key 0x%x: %w", requestedStorageStateKey.Key, err))
+	}
+	if !found {
+		return nil
 	}
 
-	return nil
+	storageValue := types.ByteSequence(value)
+	return &storageValue
 }
 
-func removeStorageFromKeyVal(keyVal *types.StateKeyVals, serviceID types.ServiceID, storageKey types.ByteSequence) {
+func removeStorageFromKeyVal(access *stateaccess.Overlay, serviceID types.ServiceID, storageKey types.ByteSequence) {
 	requestedStorageStateKey := merklization.WrapEncodeDelta2KeyVal(serviceID, storageKey, nil)
-	for k, v := range *keyVal {
-		if v.Key == requestedStorageStateKey.Key {
-			pvmLogger.Debugf("remove storage key: 0x%x\n", requestedStorageStateKey.Key)
-			if k < len(*keyVal)-1 { // not the last index
-				*keyVal = append((*keyVal)[:k], (*keyVal)[k+1:]...)
-			} else {
-				*keyVal = (*keyVal)[:k]
-			}
-			return
-		}
-	}
+	pvmLogger.Debugf("remove storage key: 0x%x\n", requestedStorageStateKey.Key)
+	access.Remove(requestedStorageStateKey.Key)
 }
 
-func getLookupItemFromKeyVal(keyVal *types.StateKeyVals, serviceID types.ServiceID, lookupKey types.LookupMetaMapkey) []byte {
+// getLookupItemFromKeyVal returns the lookup item of a key not in
+// account's lookup dict and removes it from the overlay. A key in the dict is
+// removed without being read.
+func getLookupItemFromKeyVal(access *stateaccess.Overlay, account types.ServiceAccount, serviceID types.ServiceID, lookupKey types.LookupMetaMapkey) []byte {
 	lookupStateKey := merklization.EncodeDelta4Key(serviceID, lookupKey)
-	for k, v := range *keyVal {
-		if v.Key == lookupStateKey {
-			// remove from key-val
-			if k < len(*keyVal)-1 { // not the last index
-				*keyVal = append((*keyVal)[:k], (*keyVal)[k+1:]...)
-			} else {
-				*keyVal = (*keyVal)[:k]
-			}
-			return v.Value
-		}
+	if _, exists := account.LookupDict[lookupKey]; exists {
+		access.Remove(lookupStateKey)
+		return nil
+	}
+	value, _, err := access.Take(lookupStateKey)
+	if err != nil {
+		panic(fmt.Errorf("read lookup key 0x%x: %w", lookupStateKey, err))
 	}
 
-	return nil
+	return value
 }
 
 func derefernceOrNil[T any](p *T) any {
@@ -91,7 +91,11 @@ func (h *Host) HostCall(pc ProgramCounter, instrCount uint64) (psi_result Psi_H_
 		var exitReason ExitReason
 		var pcPrime ProgramCounter
 
-		exitReason, pcPrime = h.Interpreter.SingleStepInvokeDecodedBlocks(pc)
+		if h.Interpreter.Program.Compiled != nil {
+			exitReason, pcPrime = h.Interpreter.CompiledInvoke(pc)
+		} else {
+			exitReason, pcPrime = h.Interpreter.SingleStepInvokeDecodedBlocks(pc)
+		}
 
 		switch exitReason.GetReasonType() {
 		case HALT, PANIC, OUT_OF_GAS, PAGE_FAULT:
@@ -103,6 +107,7 @@ func (h *Host) HostCall(pc ProgramCounter, instrCount uint64) (psi_result Psi_H_
 				Gas:       &h.Interpreter.Gas,
 			}
 			psi_result.Addition = h.Addition
+			h.traceEnd(exitReason, pcPrime)
 			return
 		}
 
@@ -125,14 +130,28 @@ func (h *Host) HostCall(pc ProgramCounter, instrCount uint64) (psi_result Psi_H_
 				omega = hostCallException
 			}
 		}
+		tracer := h.Interpreter.Tracer
+		gasBefore, registersBefore := h.Interpreter.Gas, h.Interpreter.Registers
 		omegaResult := omega(input)
+		if tracer != nil {
+			call := &TraceHostCall{
+				ID:        exitReason.GetHostCallID(),
+				Name:      HostCallName(input.Operation),
+				GasBefore: gasBefore,
+				GasAfter:  h.Interpreter.Gas,
+				Registers: changedRegisters(&registersBefore, &h.Interpreter.Registers),
+				Exit:      traceExit(omegaResult.ExitReason),
+			}
+			copy(call.Args[:], registersBefore[7:])
+			tracer.HostCall(call)
+		}
 		pvmLogger.Debugf("%s host-call return: %d, gas : %d\nRegisters: %v\n",
 			hostCallName[input.Operation], omegaResult.ExitReason.GetReasonType(), h.Interpreter.Gas, h.Interpreter.Registers)
 
 		switch omegaResult.ExitReason {
 		case ExitContinue:
 			h.Addition = omegaResult.Addition
-			// SingleStepInvokeDecodedBlocks already returns the next instruction PC
+			// both engines already return the next instruction PC
 			// (ecalli.PC + skipLen + 1 = fallthrough PC), so no skip needed.
 			pc = pcPrime
 			continue
@@ -145,6 +164,7 @@ func (h *Host) HostCall(pc ProgramCounter, instrCount uint64) (psi_result Psi_H_
 				Gas:       &h.Interpreter.Gas,
 			}
 			psi_result.Addition = omegaResult.Addition
+			h.traceEnd(omegaResult.ExitReason, pcPrime)
 			return
 		}
 	}
@@ -3,6 +3,7 @@ package PVM
 import (
 	"github.com/New-JAMneration/JAM-Protocol/internal/service_account"
 	"github.com/New-JAMneration/JAM-Protocol/internal/types"
+	utils "github.com/New-JAMneration/JAM-Protocol/internal/utilities"
 )
 
 // historical_lookup = 6
@@ -303,34 +304,36 @@ func pages(input OmegaInput) (output OmegaOutput) {
 	}
 
 	// otherwise : ok
+	integratedPVMType := input.Addition.IntegratedPVMMap[n]
 	// u_v
 	if r >= 3 {
 		for i := uint32(p); i < uint32(p+c); i++ {
-			input.Addition.IntegratedPVMMap[n].Memory.Pages[i] = &Page{
+			integratedPVMType.Memory.SetPage(i, &Page{
 				Value:  make([]byte, ZP),
 				Access: MemoryInaccessible,
-			}
+			})
 		}
 	}
 
 	// u_a
 	if r == 1 || r == 3 {
 		for i := uint32(p); i < uint32(p+c); i++ {
-			input.Addition.IntegratedPVMMap[n].Memory.Pages[i] = &Page{
+			integratedPVMType.Memory.SetPage(i, &Page{
 				Value:  make([]byte, ZP),
 				Access: MemoryReadOnly,
-			}
+			})
 		}
 	}
 
 	if r == 2 || r == 4 {
 		for i := uint32(p); i < uint32(p+c); i++ {
-			input.Addition.IntegratedPVMMap[n].Memory.Pages[i] = &Page{
+			integratedPVMType.Memory.SetPage(i, &Page{
 				Value:  make([]byte, ZP),
 				Access: MemoryReadWrite,
-			}
+			})
 		}
 	}
+	input.Addition.IntegratedPVMMap[n] = integratedPVMType
 
 	input.VM.Registers[7] = OK
 
@@ -387,27 +390,28 @@ func invoke(input OmegaInput) (output OmegaOutput) {
 			pvmLogger.Errorf("host-call function \"invoke\" decode register:%d error : %v", i-1, err)
 		}
 	}
-	// psi preprocess
-	tmpProgram := Program{
-		InstructionData: input.Addition.IntegratedPVMMap[n].ProgramCode,
-	}
+	// psi preprocess: m[n]_p is a program blob, deblobbed as Ψ does (A.1),
+	// and charged gas under the current gas model like the outer machine
+	tmpProgram, exitReason := LoadProgram(input.Addition.IntegratedPVMMap[n].ProgramCode)
 	tempMemory := input.Addition.IntegratedPVMMap[n].Memory
 	// wrap m[n]_p (program),  w (registers),  m[n]_u (memory),   g (gas) into NewHost
-	tempHost := NewHost(&tmpProgram, w, &tempMemory, Gas(g), HostCallArgs{}, nil)
+	tempHost := NewHost(tmpProgram, w, &tempMemory, Gas(g), HostCallArgs{}, nil)
 
 	var c ExitReason
 	var pcPrime ProgramCounter
 
-	c, pcPrime = tempHost.Interpreter.SingleStepInvoke(input.Addition.IntegratedPVMMap[n].PC)
+	if exitReason != ExitContinue {
+		// machine only accepts code that deblobs
+		c = ExitPanic
+	} else {
+		c, pcPrime = tempHost.Interpreter.CompiledInvoke(input.Addition.IntegratedPVMMap[n].PC)
+	}
 
-	// mu* = mu
-	encoder := types.NewEncoder()
-	data = types.ByteSequence(make([]byte, offset))
-	encoded, _ := encoder.Encode(&tempHost.Interpreter.Gas) // encode g'
-	copy(data, encoded)
-	for i := uint64(1); i < offset/8; i++ {
-		encoded, _ := encoder.Encode(&tempHost.Interpreter.Registers[i-1])
-		copy(data[8*i:8*(i+1)], encoded)
+	// mu* = mu, with E_8(g') ⌢ E_8(w') at o; the encoder has no plain uint64
+	data = types.ByteSequence(make([]byte, 0, offset))
+	data = append(data, utils.SerializeFixedLength(types.U64(tempHost.Interpreter.Gas), 8)...)
+	for _, register := range tempHost.Interpreter.Registers {
+		data = append(data, utils.SerializeFixedLength(types.U64(register), 8)...)
 	}
 	// write data into memory (mu)
 	input.VM.Memory.Write(o, data)
@@ -415,11 +419,8 @@ func invoke(input OmegaInput) (output OmegaOutput) {
 	// m* = m
 	tmp := input.Addition.IntegratedPVMMap[n]
 	tmp.Memory = *tempHost.Interpreter.Memory
-	if c.GetReasonType() == HOST_CALL {
-		tmp.PC = pcPrime + 1 + ProgramCounter(skip(int(pcPrime), input.Addition.Program.Bitmasks))
-	} else {
-		tmp.PC = pcPrime
-	}
+	// for a host call CompiledInvoke already returns ι + 1 + skip(ι)
+	tmp.PC = pcPrime
 	input.Addition.IntegratedPVMMap[n] = tmp
 
 	switch c.GetReasonType() {
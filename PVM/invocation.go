@@ -4,8 +4,8 @@ import (
 	"fmt"
 )
 
-// Currently keep for refine host-call -> invoke host-call transition
-// per-instruction based of (A.1) ψ_1,
+// per-instruction based of (A.1) ψ_1, charging gas under the program's gas
+// model as SingleStepInvokeDecodedBlocks does
 func (interp *Interpreter) SingleStepInvoke(pc ProgramCounter) (ExitReason, ProgramCounter) {
 	for {
 		exitReason, pcPrime := interp.SingleStepStateTransition(pc)
@@ -23,6 +23,16 @@ func (interp *Interpreter) SingleStepInvoke(pc ProgramCounter) (ExitReason, Prog
 
 // (v.0.7.1 A.6, A.7) SingleStepStateTransition
 func (interp *Interpreter) SingleStepStateTransition(pc ProgramCounter) (ExitReason, ProgramCounter) {
+	if interp.Tracer == nil {
+		return interp.singleStepStateTransition(pc)
+	}
+	gas, registers := interp.Gas, interp.Registers
+	exitReason, pcPrime := interp.singleStepStateTransition(pc)
+	interp.traceInstruction(pc, gas, &registers, exitReason)
+	return exitReason, pcPrime
+}
+
+func (interp *Interpreter) singleStepStateTransition(pc ProgramCounter) (ExitReason, ProgramCounter) {
 	// check program-counter exceed blob length
 	if int(pc) >= len(interp.Program.InstructionData) {
 		return ExitPanic, pc
@@ -30,13 +40,28 @@ func (interp *Interpreter) SingleStepStateTransition(pc ProgramCounter) (ExitRea
 
 	var exitReason ExitReason
 
-	// (v0.7.1  A.19) check opcode validity
-	opcodeData := interp.Program.InstructionData.isOpcode(pc)
+	// (v0.7.1  A.19) check opcode validity; a PC that does not start an
+	// instruction executes as trap
+	opcodeData := opcode(0)
+	startOfInstruction := interp.Program.Bitmasks.IsStartOfInstruction(int(pc))
+	if startOfInstruction {
+		opcodeData = interp.Program.InstructionData.isOpcode(pc)
+	}
+	cost := Gas(1)
+	if interp.Program.GasModel == GasModelV08 && startOfInstruction {
+		// the block cost is charged on entry at its start, as
+		// invokeDecodedBlock does; a trap at a non-instruction start still
+		// costs one gas
+		cost = 0
+		if int(pc) < len(interp.Program.BlockAt) && interp.Program.BlockAt[pc] != nil {
+			cost = interp.Program.BlockAt[pc].GasCost
+		}
+	}
 	// (GP A.6) OOG when ρ < 1 (gas insufficient for next instruction)
-	if interp.Gas < 1 {
+	if interp.Gas < cost {
 		return ExitOOG, pc
 	}
-	interp.Gas -= 1
+	interp.Gas -= cost
 
 	target := execInstructions[opcodeData]
 	if target == nil {
@@ -55,6 +80,8 @@ func (interp *Interpreter) SingleStepStateTransition(pc ProgramCounter) (ExitRea
 		return exitReason, 0
 	case HOST_CALL: // host-call: newPC = pc
 		return exitReason, newPC
+	case PAGE_FAULT: // the faulting instruction is not skipped
+		return exitReason, pc
 	}
 
 	if pc != newPC {
@@ -64,78 +91,129 @@ func (interp *Interpreter) SingleStepStateTransition(pc ProgramCounter) (ExitRea
 
 	// iota' = iota + 1 +skip(iota)
 	newPC += skipLength + 1
-	// detailed instruction print
-	// logger.Debugf("instr:%s(%d) pc=%d operand=%v gas=%d registers=%x", zeta[opcode(opcodeData)], opcodeData, programCounter, instructionCode[programCounter:programCounter+skipLength+1], interp.Gas, interp.Registers)
-	// logger.Debugf("       gas : %d -> %d", interp.Gas+gasDelta, interp.Gas)
 
 	return exitReason, newPC
 }
 
 func (interp *Interpreter) SingleStepInvokeDecodedBlocks(pc ProgramCounter) (ExitReason, ProgramCounter) {
+	for {
+		exitReason, pcPrime := interp.invokeDecodedBlock(pc)
+		if exitReason != ExitContinue {
+			return exitReason, pcPrime
+		}
+		pc = pcPrime
+	}
+}
+
+// invokeDecodedBlock runs the pre-decoded instructions from pc to the end of
+// their basic block. Under GasModelV07 gas is charged per instruction; under
+// GasModelV08 the block cost is charged when pc starts the block, and
+// resuming inside a block (after a host call) costs nothing. It returns
+// ExitContinue with the PC execution continues at, or the exit reason and
+// the PC to stop with.
+func (interp *Interpreter) invokeDecodedBlock(pc ProgramCounter) (ExitReason, ProgramCounter) {
 	prog := interp.Program
 	instrSlice := prog.Instrs
-	n := len(prog.BlockAt)
+	tracer := interp.Tracer
+	entryGas := interp.Gas
 
-	for {
-		if int(pc) >= n {
-			return ExitPanic, 0
+	if int(pc) >= len(prog.BlockAt) {
+		if tracer != nil {
+			interp.traceInstruction(pc, entryGas, &interp.Registers, ExitPanic)
 		}
+		return ExitPanic, 0
+	}
 
-		var startIdx, endIdx int
+	var startIdx, endIdx int
+	var exitReason ExitReason
 
-		if block := prog.BlockAt[pc]; block != nil {
-			startIdx = block.InstrStart
-			endIdx = block.InstrEnd
-		} else if idx := prog.InstrIdxAt[pc]; idx >= 0 {
-			startIdx = int(idx)
-			foundTerminator := false
-			for endIdx = startIdx; endIdx < len(instrSlice); endIdx++ {
-				if IsBlockTerminator(instrSlice[endIdx].Opcode) {
-					endIdx++
-					foundTerminator = true
-					break
+	if block := prog.BlockAt[pc]; block != nil {
+		startIdx = block.InstrStart
+		endIdx = block.InstrEnd
+		if prog.GasModel == GasModelV08 {
+			if interp.Gas < block.GasCost {
+				if tracer != nil {
+					interp.traceInstruction(pc, entryGas, &interp.Registers, ExitOOG)
 				}
+				return ExitOOG, pc
 			}
-			if !foundTerminator {
-				return ExitPanic, 0
+			interp.Gas -= block.GasCost
+		}
+	} else if idx := prog.InstrIdxAt[pc]; idx >= 0 {
+		startIdx = int(idx)
+		foundTerminator := false
+		for endIdx = startIdx; endIdx < len(instrSlice); endIdx++ {
+			if IsBlockTerminator(instrSlice[endIdx].Opcode) {
+				endIdx++
+				foundTerminator = true
+				break
 			}
-		} else {
+		}
+		if !foundTerminator {
 			return ExitPanic, 0
 		}
+	} else {
+		// (A.19) not an instruction start: execute as trap, which still
+		// costs gas
+		if interp.Gas < 1 {
+			exitReason = ExitOOG
+		} else {
+			interp.Gas -= 1
+			exitReason = ExitPanic
+		}
+		if tracer != nil {
+			interp.traceInstruction(pc, entryGas, &interp.Registers, exitReason)
+		}
+		if exitReason == ExitOOG {
+			return ExitOOG, pc
+		}
+		return ExitPanic, 0
+	}
 
-		instrs := instrSlice[startIdx:endIdx]
-		branchTaken := false
-		for i := range instrs {
-			instr := &instrs[i]
+	instrs := instrSlice[startIdx:endIdx]
+	for i := range instrs {
+		instr := &instrs[i]
 
+		gasBefore := interp.Gas
+		if i == 0 {
+			gasBefore = entryGas
+		}
+		var registersBefore Registers
+		if tracer != nil {
+			registersBefore = interp.Registers
+		}
+
+		if prog.GasModel == GasModelV07 {
 			// (GP A.6) OOG when ρ < 1 (gas insufficient for next instruction)
 			if interp.Gas < 1 {
+				if tracer != nil {
+					interp.traceInstruction(instr.PC, gasBefore, &registersBefore, ExitOOG)
+				}
 				return ExitOOG, instr.PC
 			}
 			interp.Gas -= 1
+		}
 
-			exitReason, newPC := instr.Exec(interp, instr)
-
-			switch exitReason.GetReasonType() {
-			case HALT, PANIC:
-				return exitReason, 0
-			case PAGE_FAULT, OUT_OF_GAS:
-				return exitReason, instr.PC
-			case HOST_CALL:
-				return exitReason, instr.PC + ProgramCounter(instr.SkipLen) + 1
-			}
+		exitReason, newPC := instr.Exec(interp, instr)
+		if tracer != nil {
+			interp.traceInstruction(instr.PC, gasBefore, &registersBefore, exitReason)
+		}
 
-			if instr.PC != newPC {
-				pc = newPC
-				branchTaken = true
-				break
-			}
+		switch exitReason.GetReasonType() {
+		case HALT, PANIC:
+			return exitReason, 0
+		case PAGE_FAULT, OUT_OF_GAS:
+			return exitReason, instr.PC
+		case HOST_CALL:
+			return exitReason, instr.PC + ProgramCounter(instr.SkipLen) + 1
 		}
-		if !branchTaken {
-			last := &instrs[len(instrs)-1]
-			pc = last.PC + ProgramCounter(last.SkipLen) + 1
+
+		if instr.PC != newPC {
+			return ExitContinue, newPC
 		}
 	}
+	last := &instrs[len(instrs)-1]
+	return ExitContinue, last.PC + ProgramCounter(last.SkipLen) + 1
 }
 
 // block based version of (A.1) ψ_1
@@ -0,0 +1,133 @@
+package PVM
+
+import (
+	"testing"
+)
+
+// invocationBlob encodes code as a program blob (A.2) without a jump table,
+// with instructions starting at starts.
+func invocationBlob(code []byte, starts ...int) []byte {
+	blob := []byte{0, 4, byte(len(code))}
+	blob = append(blob, code...)
+	bitmask := make([]byte, (len(code)+7)/8)
+	for _, start := range starts {
+		bitmask[start/8] |= 1 << (start % 8)
+	}
+	return append(blob, bitmask...)
+}
+
+type invocationResult struct {
+	exit ExitReason
+	pc   ProgramCounter
+	gas  Gas
+}
+
+// invokeBothEngines runs blob on the single-step and the pre-decoded engine
+// from pc.
+func invokeBothEngines(t *testing.T, blob []byte, pc ProgramCounter, gas Gas) (singleStep, decoded invocationResult) {
+	t.Helper()
+	program, exitReason := DeBlobProgramCode(blob)
+	if exitReason != ExitContinue {
+		t.Fatalf("DeBlobProgramCode(%x) = %v", blob, exitReason)
+	}
+
+	interp := NewInterpreter(&program, Registers{}, &Memory{}, gas)
+	singleStep.exit, singleStep.pc = interp.SingleStepInvoke(pc)
+	singleStep.gas = interp.Gas
+
+	interp = NewInterpreter(&program, Registers{}, &Memory{}, gas)
+	decoded.exit, decoded.pc = interp.SingleStepInvokeDecodedBlocks(pc)
+	decoded.gas = interp.Gas
+	return singleStep, decoded
+}
+
+// (A.17) a branch to an offset that does not start a basic block panics at
+// the branch, even when the byte there is not an opcode.
+func TestBranchIntoInstruction(t *testing.T) {
+	// 0: jump 3      ; 3 is the register byte, 2, of load_imm
+	// 2: load_imm r2, 0
+	// 5: trap
+	blob := invocationBlob([]byte{40, 3, 51, 2, 0, 0}, 0, 2, 5)
+	singleStep, decoded := invokeBothEngines(t, blob, 0, 10)
+	for name, result := range map[string]invocationResult{"single-step": singleStep, "decoded": decoded} {
+		if result.exit != ExitPanic || result.gas != 9 {
+			t.Errorf("%s: exit %v with gas %d, want panic with gas 9", name, result.exit, result.gas)
+		}
+	}
+}
+
+// (A.6) a page fault leaves the program counter at the faulting
+// instruction, so the host can map the page and run it again.
+func TestPageFaultKeepsPC(t *testing.T) {
+	// 0: fallthrough
+	// 1: load_u8 r0, 0x10000 ; not mapped
+	// 6: trap
+	blob := invocationBlob([]byte{1, 52, 0, 0, 0, 1, 0}, 0, 1, 6)
+	singleStep, decoded := invokeBothEngines(t, blob, 0, 10)
+	for name, result := range map[string]invocationResult{"single-step": singleStep, "decoded": decoded} {
+		if result.exit.GetReasonType() != PAGE_FAULT || result.pc != 1 {
+			t.Errorf("%s: exit %v at pc %d, want page fault at pc 1", name, result.exit, result.pc)
+		}
+	}
+}
+
+// (A.19) an instruction is only decoded where the bitmask starts one; any
+// other program counter executes as trap, which costs gas like any other
+// instruction.
+func TestTrapOutsideInstructionStart(t *testing.T) {
+	// 0: trap
+	// 1: 0x01        ; fallthrough, but not an instruction start
+	// 2: trap
+	blob := invocationBlob([]byte{0, 1, 0}, 0, 2)
+	singleStep, decoded := invokeBothEngines(t, blob, 1, 10)
+	for name, result := range map[string]invocationResult{"single-step": singleStep, "decoded": decoded} {
+		if result.exit != ExitPanic || result.gas != 9 {
+			t.Errorf("%s: exit %v with gas %d, want panic with gas 9", name, result.exit, result.gas)
+		}
+	}
+}
+
+// (A.5.2) the immediate of ecalli is sign-extended to 64 bits; the host call
+// exit keeps its low 32 bits so the exit type is not overwritten.
+func TestEcalliSignExtendedImmediate(t *testing.T) {
+	// 0: ecalli 0x80  ; one byte immediate, νX = 2^64 - 0x80
+	// 2: trap
+	blob := invocationBlob([]byte{10, 0x80, 0}, 0, 2)
+	singleStep, decoded := invokeBothEngines(t, blob, 0, 10)
+	for name, result := range map[string]invocationResult{"single-step": singleStep, "decoded": decoded} {
+		if want := ExitHostCall | 0xffff_ff80; result.exit != want {
+			t.Errorf("%s: exit %#x, want %#x", name, uint64(result.exit), uint64(want))
+		}
+	}
+}
+
+// (A.3) operands are read from ζ, the code extended with zeros, so those of
+// an instruction cut short by the end of the code are zero.
+func TestOperandsPastEndOfCode(t *testing.T) {
+	// 0: branch_eq_imm r1, 0x22, 0 ; three immediate bytes past the end
+	blob := invocationBlob([]byte{81, 0x41, 0x22}, 0)
+	singleStep, decoded := invokeBothEngines(t, blob, 0, 5)
+	for name, result := range map[string]invocationResult{"single-step": singleStep, "decoded": decoded} {
+		// r1 = 0 is not 0x22, so the branch falls through past the end
+		if result.exit != ExitPanic || result.gas != 4 {
+			t.Errorf("%s: exit %v with gas %d, want panic with gas 4", name, result.exit, result.gas)
+		}
+	}
+}
+
+// (A.5.13) an immediate longer than the instruction leaves no room for the
+// one after it: lY = min(4, max(0, ℓ - lX - 2)) is zero, not four bytes of
+// the following instructions.
+func TestOperandLengthCutShort(t *testing.T) {
+	// 0: load_imm_jump_ind r0, r0, 0, 0 ; lX = 4 but ℓ = 2
+	// 3: trap x3
+	// 6: trap        ; its operand bytes are 0xffff0000 little-endian
+	blob := invocationBlob([]byte{180, 0x00, 0x04, 0, 0, 0, 0, 0, 0, 0xff, 0xff}, 0, 3, 4, 5, 6)
+	singleStep, decoded := invokeBothEngines(t, blob, 0, 10)
+	for name, result := range map[string]invocationResult{"single-step": singleStep, "decoded": decoded} {
+		// djump to 0 panics; to 0xffff0000 it would halt
+		if result.exit != ExitPanic || result.gas != 9 {
+			t.Errorf("%s: exit %v with gas %d, want panic with gas 9", name, result.exit, result.gas)
+		}
+	}
+}
@@ -1,6 +1,9 @@
 package PVM
 
 import (
+	"bytes"
+	"iter"
+
 	"github.com/New-JAMneration/JAM-Protocol/internal/types"
 )
 
@@ -9,10 +12,26 @@ type MemoryAccess int
 type Page struct {
 	Value  []byte // 4096 byte (ZP) page data
 	Access MemoryAccess
+	shared bool // Value is shared with a clone; copy it before writing
 }
 
+const (
+	pageCount         = 1 << 32 / ZP // pages in the 2^32 byte address space
+	pageDirectoryBits = 10           // low bits of a page number indexing a page directory
+	pageDirectorySize = 1 << pageDirectoryBits
+)
+
+// pageDirectory maps the pages of a 4 MiB (pageDirectorySize pages) range.
+type pageDirectory [pageDirectorySize]*Page
+
+// pageTable maps a page number's high bits to its page directory.
+type pageTable [pageCount / pageDirectorySize]*pageDirectory
+
+// Memory is the PVM RAM: a two-level page table over the 2^32 byte address
+// space, allocated as pages are mapped. Copies of a Memory value share their
+// pages, as invoke does with the inner machine's memory.
 type Memory struct {
-	Pages       map[uint32]*Page // Key: Page Number, Value: Page Data
+	table       *pageTable
 	heapPointer uint64
 	heapLimit   uint64 // max heap pointer (stackStart from Y); Jan's max_heap_pointer
 }
@@ -23,9 +42,59 @@ const (
 	MemoryReadWrite                        // W Read + Write
 )
 
+// Page returns page index, or nil if it is not mapped.
+func (m *Memory) Page(index uint32) *Page {
+	if m.table == nil || index >= pageCount {
+		return nil
+	}
+	directory := m.table[index>>pageDirectoryBits]
+	if directory == nil {
+		return nil
+	}
+	return directory[index&(pageDirectorySize-1)]
+}
+
+// SetPage maps page index to page, or unmaps it if page is nil.
+func (m *Memory) SetPage(index uint32, page *Page) {
+	if m.table == nil {
+		if page == nil {
+			return
+		}
+		m.table = &pageTable{}
+	}
+	directory := m.table[index>>pageDirectoryBits]
+	if directory == nil {
+		if page == nil {
+			return
+		}
+		directory = &pageDirectory{}
+		m.table[index>>pageDirectoryBits] = directory
+	}
+	directory[index&(pageDirectorySize-1)] = page
+}
+
+// Pages iterates over the mapped pages in address order.
+func (m *Memory) Pages() iter.Seq2[uint32, *Page] {
+	return func(yield func(uint32, *Page) bool) {
+		if m.table == nil {
+			return
+		}
+		for high, directory := range m.table {
+			if directory == nil {
+				continue
+			}
+			for low, page := range directory {
+				if page != nil && !yield(uint32(high)<<pageDirectoryBits|uint32(low), page) {
+					return
+				}
+			}
+		}
+	}
+}
+
 func (m *Memory) GetPageAccess(index uint32) MemoryAccess {
-	page, found := m.Pages[index]
-	if !found {
+	page := m.Page(index)
+	if page == nil {
 		return MemoryInaccessible
 	}
 
@@ -42,8 +111,14 @@ func (m *Memory) Read(start uint64, offset uint64) types.ByteSequence {
 	pageNumber := uint32(start / ZP)
 	pageIndex := start % ZP
 
+	// Fast path: entirely within one page.
+	if pageIndex+offset <= ZP {
+		copy(buffer, m.Page(pageNumber).Value[pageIndex:])
+		return buffer
+	}
+
 	for copied := uint64(0); copied < offset; {
-		copied += uint64(copy(buffer[copied:], m.Pages[pageNumber].Value[pageIndex:]))
+		copied += uint64(copy(buffer[copied:], m.Page(pageNumber).Value[pageIndex:]))
 		pageNumber++
 		pageIndex = 0
 	}
@@ -57,10 +132,39 @@ func (m *Memory) Write(start uint64, data types.ByteSequence) {
 	}
 	pageNumber := uint32(start / ZP)
 	pageIndex := start % ZP
+
+	// Fast path: entirely within one page.
+	if pageIndex+uint64(len(data)) <= ZP {
+		copy(m.writablePage(pageNumber, m.Page(pageNumber)).Value[pageIndex:], data)
+		return
+	}
+
 	for copied := 0; copied < len(data); {
-		n := copy(m.Pages[pageNumber].Value[pageIndex:], data[copied:])
+		n := copy(m.writablePage(pageNumber, m.Page(pageNumber)).Value[pageIndex:], data[copied:])
 		copied += n
 		pageNumber++
 		pageIndex = 0
 	}
 }
+
+// Clone returns a copy of m that shares its pages with m until either of
+// them writes to a page, so cloning costs O(pages) rather than O(bytes).
+func (m *Memory) Clone() Memory {
+	clone := Memory{heapPointer: m.heapPointer, heapLimit: m.heapLimit}
+	for index, page := range m.Pages() {
+		page.shared = true
+		clone.SetPage(index, page)
+	}
+	return clone
+}
+
+// writablePage returns page, page index of m, ready to be written: a page
+// shared with a clone is first replaced by a copy of its own.
+func (m *Memory) writablePage(index uint32, page *Page) *Page {
+	if !page.shared {
+		return page
+	}
+	page = &Page{Value: bytes.Clone(page.Value), Access: page.Access}
+	m.SetPage(index, page)
+	return page
+}
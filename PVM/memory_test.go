@@ -0,0 +1,86 @@
+package PVM
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+func TestMemoryPageTable(t *testing.T) {
+	var m Memory
+	if m.Page(0x20) != nil || m.GetPageAccess(0x20) != MemoryInaccessible {
+		t.Fatal("empty memory maps page 0x20")
+	}
+	// pages in two directories, the last one at the top of the address space
+	for _, index := range []uint32{0x20, 0x21, pageCount - 1} {
+		m.SetPage(index, &Page{Value: make([]byte, ZP), Access: MemoryReadWrite})
+	}
+	if m.Page(pageCount) != nil {
+		t.Error("page past the address space is mapped")
+	}
+
+	var indexes []uint32
+	for index := range m.Pages() {
+		indexes = append(indexes, index)
+	}
+	if want := []uint32{0x20, 0x21, pageCount - 1}; fmt.Sprint(indexes) != fmt.Sprint(want) {
+		t.Errorf("Pages() = %v, want %v", indexes, want)
+	}
+
+	// a write across the page boundary
+	m.Write(0x20ffe, []byte{1, 2, 3, 4})
+	if got := m.Read(0x20ffe, 4); !bytes.Equal(got, []byte{1, 2, 3, 4}) {
+		t.Errorf("Read = %v, want 1 2 3 4", got)
+	}
+
+	clone := m.Clone()
+	clone.Write(0x20fff, []byte{9, 9})
+	if got := m.Read(0x20ffe, 4); !bytes.Equal(got, []byte{1, 2, 3, 4}) {
+		t.Errorf("write to the clone changed the original: %v", got)
+	}
+	if got := clone.Read(0x20ffe, 4); !bytes.Equal(got, []byte{1, 9, 9, 4}) {
+		t.Errorf("clone Read = %v, want 1 9 9 4", got)
+	}
+
+	m.SetPage(0x21, nil)
+	if m.GetPageAccess(0x21) != MemoryInaccessible || clone.GetPageAccess(0x21) != MemoryReadWrite {
+		t.Error("unmapping page 0x21 of the original changed the clone")
+	}
+}
+
+// BenchmarkMemoryLoadStore runs a program incrementing every 64-bit word
+// of a range of read-write pages, stepping stride bytes at a time.
+func BenchmarkMemoryLoadStore(b *testing.B) {
+	const start, end = 0x20000, 0x120000 // 256 pages
+	for _, stride := range []int{8, ZP + 8} {
+		b.Run(fmt.Sprintf("stride=%d", stride), func(b *testing.B) {
+			program, exitReason := MustAssemble(fmt.Sprintf(`
+				load_imm a0, %#x
+				fallthrough
+			loop:	load_ind_u64 a2, a0, 0
+				add_imm_64 a2, a2, 1
+				store_ind_u64 a2, a0, 0
+				add_imm_64 a0, a0, %d
+				branch_lt_u_imm a0, %#x, loop
+				load_imm_64 ra, 0xffff0000
+				jump_ind ra, 0
+			`, start, stride, end)).Program()
+			if exitReason != ExitContinue {
+				b.Fatalf("Program: %v", exitReason)
+			}
+			program.Compiled = program.Compile()
+			var memory Memory
+			for index := uint32(start / ZP); index < end/ZP; index++ {
+				memory.SetPage(index, &Page{Value: make([]byte, ZP), Access: MemoryReadWrite})
+			}
+
+			b.ResetTimer()
+			for range b.N {
+				interp := NewInterpreter(&program, Registers{}, &memory, 1<<40)
+				if exitReason, _ := interp.CompiledInvoke(0); exitReason != ExitHalt {
+					b.Fatalf("exit = %v, want halt", exitReason)
+				}
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@ package PVM
 
 import (
 	"encoding/binary"
+	"fmt"
 	"math/bits"
 )
 
@@ -73,53 +74,75 @@ type Program struct {
 	Instrs     []InstrMeta  // pre-decoded instruction metadata (flat array)
 	BlockAt    []*BlockMeta // PC-indexed: BlockAt[pc] non-nil if pc starts a basic block
 	InstrIdxAt []int32      // PC-indexed: InstrIdxAt[pc] = index into Instrs[], -1 if not an instruction start
+
+	Compiled *CompiledProgram // threaded-code form, set by LoadProgram; nil runs the pre-decoded blocks
+	GasModel GasModel         // how BlockAt[pc].GasCost is computed and charged
 }
 
 // DeBlobProgramCode deblob code, jump table, bitmask | A.2
+// Blocks are charged gas according to CurrentGasModel.
 func DeBlobProgramCode(data []byte) (_ Program, _ ExitReason) {
+	return deBlobProgramCode(data, CurrentGasModel())
+}
+
+func deBlobProgramCode(data []byte, model GasModel) (_ Program, _ ExitReason) {
+	prog, _, err := splitProgramBlob(data)
+	if err != nil {
+		pvmLogger.Errorf("%v", err)
+		return Program{}, ExitPanic
+	}
+	prog.GasModel = model
+
+	if exitReason := prog.preDecodeBlocks(); exitReason != ExitContinue {
+		return Program{}, exitReason
+	}
+
+	return prog, ExitContinue
+}
+
+// splitProgramBlob splits a program blob into its jump table, code and
+// bitmask, returning the bitmask as encoded in the blob as well.
+func splitProgramBlob(data []byte) (Program, []byte, error) {
 	// E_(|j|) : size of jumpTable
 	jumpTableSize, dataUsed, exitReason := ReadUintVariable(data)
 	if exitReason != ExitContinue {
-		pvmLogger.Errorf("jumpTableSize ReadUintVariable error")
-		return Program{}, ExitPanic
+		return Program{}, nil, fmt.Errorf("jumpTableSize ReadUintVariable error")
 	}
 	data = data[dataUsed:]
 
 	// E_1(z) : length of jumpTableLength
 	jumpTableLength, exitReason := decodeUintFixedLength(data, 1)
 	if exitReason != ExitContinue {
-		pvmLogger.Errorf("jumpTableLength decodeUintFixedLength error")
-		return Program{}, ExitPanic
+		return Program{}, nil, fmt.Errorf("jumpTableLength decodeUintFixedLength error")
 	}
 	data = data[1:]
 
 	// E_(|c|) : size of instructions
 	instSize, dataUsed, exitReason := ReadUintVariable(data)
 	if exitReason != ExitContinue {
-		pvmLogger.Errorf("instSize ReadUintVariable error")
-		return Program{}, ExitPanic
+		return Program{}, nil, fmt.Errorf("instSize ReadUintVariable error")
 	}
 	data = data[dataUsed:]
 
 	if jumpTableLength*jumpTableSize >= 1<<32 {
-		pvmLogger.Errorf("jump table size %d bits exceed litmit of 32 bits", jumpTableLength*jumpTableSize)
-		return Program{}, ExitPanic
-		// panic("the jump table's size is supposed to be at most 32 bits")
+		return Program{}, nil, fmt.Errorf("jump table size %d bits exceed litmit of 32 bits", jumpTableLength*jumpTableSize)
 	}
 
 	// E_z(j) = jumpTableSize * jumpTableLength = E_(|j|) * E_1(z)
 	jumpTableData, data, err := ReadBytes(data, jumpTableLength*jumpTableSize)
 	if err != nil {
-		pvmLogger.Errorf("jumpTableData ReadBytes error: %v", err)
-		return Program{}, ExitPanic
+		return Program{}, nil, fmt.Errorf("jumpTableData ReadBytes error: %v", err)
 	}
 
+	if instSize > uint64(len(data)) {
+		return Program{}, nil, fmt.Errorf("code has %d bytes, blob has %d left", instSize, len(data))
+	}
 	instructions := data[:instSize]
 	bitmaskData := data[instSize:]
 	bitmask, exitReason := MakeBitMasks(instructions, bitmaskData)
 	if exitReason == ExitPanic {
 		// A.2 if bitmasks cannot fit instructions, return panic
-		return Program{}, ExitPanic
+		return Program{}, nil, fmt.Errorf("bitmask has %d bytes, want %d", len(bitmaskData), (len(instructions)+7)/8)
 	}
 
 	prog := Program{
@@ -131,12 +154,7 @@ func DeBlobProgramCode(data []byte) (_ Program, _ ExitReason) {
 		Bitmasks:        bitmask,      // k
 		InstructionData: instructions, // c
 	}
-
-	if exitReason := prog.preDecodeBlocks(); exitReason != ExitContinue {
-		return Program{}, exitReason
-	}
-
-	return prog, ExitContinue
+	return prog, bitmaskData, nil
 }
 
 // skip computes the distance to the next opcode  A.3
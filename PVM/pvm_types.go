@@ -79,6 +79,9 @@ type Interpreter struct {
 	Registers Registers
 	Memory    *Memory
 	Gas       Gas
+	Tracer    Tracer // nil disables tracing
+
+	traceWrites []TraceMemoryWrite // stores of the instruction being traced
 }
 
 type Host struct {
@@ -0,0 +1,144 @@
+package pvmtest_test
+
+import (
+	"fmt"
+
+	"github.com/New-JAMneration/JAM-Protocol/PVM"
+	"github.com/New-JAMneration/JAM-Protocol/PVM/pvmtest"
+	"github.com/New-JAMneration/JAM-Protocol/internal/types"
+)
+
+// The accumulate entry point is at pc 5, after five bytes of refine code.
+const refineTraps = "trap\ntrap\ntrap\ntrap\ntrap\n"
+
+func ExampleEnv_Accumulate() {
+	// copy the value of "greeting" to "reply"
+	code := PVM.MustAssemble(refineTraps + `
+	accumulate:
+		load_imm a0, -1         ; this service
+		load_imm a1, 0x10000    ; "greeting" in the read-only data
+		load_imm a2, 8
+		load_imm a3, 0x30000    ; the heap page, after the read-only data
+		load_imm a4, 0
+		load_imm a5, 64
+		ecalli 3                ; read
+		move_reg a3, a0
+		load_imm a0, 0x10008    ; "reply"
+		load_imm a1, 5
+		load_imm a2, 0x30000
+		ecalli 4                ; write
+		load_imm a0, 0
+		load_imm a1, 0
+		load_imm_64 ra, 0xffff0000
+		jump_ind ra, 0          ; halt
+	.ro_data 0x6772656574696e67 0x7265706c79
+	.heap_pages 1
+	`).StandardProgram()
+
+	env := &pvmtest.Env{
+		ServiceID: 7,
+		Info:      types.ServiceInfo{Balance: 1_000_000},
+		Storage:   map[string][]byte{"greeting": []byte("hello")},
+	}
+	result, err := env.Accumulate(code, 1_000_000)
+	if err != nil {
+		panic(err)
+	}
+
+	fmt.Println(result.Exit)
+	read := result.CallsNamed("read")[0]
+	fmt.Printf("read %q: %d bytes %q\n", read.ReadBefore(read.Args[1], read.Args[2]), read.Result(), read.ReadAfter(read.Args[3], read.Result()))
+	write := result.CallsNamed("write")[0]
+	fmt.Printf("write %q: none before %v\n", write.ReadBefore(write.Args[0], write.Args[1]), write.Result() == PVM.NONE)
+	fmt.Printf("reply = %q\n", result.Service.StorageDict["reply"])
+	// Output:
+	// halt
+	// read "greeting": 5 bytes "hello"
+	// write "reply": none before true
+	// reply = "hello"
+}
+
+func ExampleEnv_Accumulate_checkpoint() {
+	// transfer 100 and checkpoint, then transfer 200 and panic
+	code := PVM.MustAssemble(refineTraps + `
+	accumulate:
+		load_imm a0, 1          ; to service 1
+		load_imm a1, 100
+		load_imm a2, 0          ; gas limit
+		load_imm a3, 0x10000    ; memo
+		ecalli 20               ; transfer
+		ecalli 17               ; checkpoint
+		load_imm a0, 1
+		load_imm a1, 200
+		load_imm a2, 0
+		load_imm a3, 0x10000
+		ecalli 20               ; transfer
+		trap
+	.ro_data 0x00
+	`).StandardProgram()
+
+	env := &pvmtest.Env{
+		Info:     types.ServiceInfo{Balance: 1_000_000},
+		Services: types.ServiceAccountState{1: {}},
+	}
+	result, err := env.Accumulate(code, 1_000_000)
+	if err != nil {
+		panic(err)
+	}
+
+	fmt.Println(result.Exit)
+	for _, call := range result.Calls {
+		fmt.Println(call.Name)
+	}
+	for _, transfer := range result.Transfers {
+		fmt.Printf("%d -> %d: %d\n", transfer.SenderID, transfer.ReceiverID, transfer.Balance)
+	}
+	fmt.Println("balance", result.Service.ServiceInfo.Balance)
+	// Output:
+	// panic
+	// transfer
+	// checkpoint
+	// transfer
+	// 0 -> 1: 100
+	// balance 999900
+}
+
+func ExampleEnv_Refine() {
+	// export "hello" and return it, after asking for the gas left
+	code := PVM.MustAssemble(`
+		ecalli 0                ; gas
+		load_imm a0, 0x10000
+		load_imm a1, 5
+		ecalli 7                ; export
+		load_imm a0, 0x10000
+		load_imm a1, 5
+		load_imm_64 ra, 0xffff0000
+		jump_ind ra, 0          ; halt
+	.ro_data 0x68656c6c6f
+	`).StandardProgram()
+
+	env := &pvmtest.Env{
+		WorkPackage: &types.WorkPackage{Items: []types.WorkItem{{Service: 0}}},
+		Mocks: map[PVM.OperationType]func(vm *PVM.VMState) PVM.ExitReason{
+			PVM.GasOp: func(vm *PVM.VMState) PVM.ExitReason {
+				vm.Registers[7] = 42
+				return PVM.ExitContinue
+			},
+		},
+	}
+	result, err := env.Refine(code, 1_000_000)
+	if err != nil {
+		panic(err)
+	}
+
+	fmt.Printf("%s %q\n", result.Exit, result.Output)
+	for _, call := range result.Calls {
+		fmt.Println(call.Name, call.Result())
+	}
+	fmt.Printf("%d export %q\n", len(result.Exports), result.Exports[0][:5])
+	// Output:
+	// halt "hello"
+	// gas 42
+	// export 0
+	// 1 export "hello"
+}
@@ -0,0 +1,327 @@
+// Package pvmtest runs a service's accumulate or refine code through Ψ_M
+// against a declared environment, without a chain state, and records every
+// host call the code makes for tests to assert on.
+//
+// An Env declares the service's info, storage, preimages and preimage
+// requests, the other services it can see and the items fetch returns.
+// Env.Accumulate and Env.Refine run a standard program blob with the host
+// calls of that invocation, as Ψ_A and Ψ_R do, and return the exit, the
+// output, the calls made and the state the run left behind. Host calls can
+// be replaced with Env.Mocks.
+package pvmtest
+
+import (
+	"fmt"
+
+	"github.com/New-JAMneration/JAM-Protocol/PVM"
+	"github.com/New-JAMneration/JAM-Protocol/internal/types"
+	"github.com/New-JAMneration/JAM-Protocol/internal/utilities/hash"
+)
+
+// Env is the environment a program runs in. The zero value is service 0,
+// without balance or storage, at time slot 0.
+type Env struct {
+	ServiceID types.ServiceID
+	Info      types.ServiceInfo                            // as info returns it; writes need a balance above the storage deposit
+	Storage   map[string][]byte                            // by raw key, as read and write see it
+	Preimages map[types.OpaqueHash][]byte                  // by hash, as lookup sees them
+	Requests  map[types.LookupMetaMapkey]types.TimeSlotSet // preimage requests, as query, solicit and forget see them
+	Services  types.ServiceAccountState                    // the other services, for read, lookup, info, transfer...
+
+	// accumulate
+	Timeslot types.TimeSlot
+	Entropy  types.Entropy                     // η'0, fetch item 1
+	Items    []types.OperandOrDeferredTransfer // operands and transfers, fetch items 14 and 15
+
+	// refine
+	CoreIndex      types.CoreIndex
+	WorkPackage    *types.WorkPackage // required; the work item run is WorkPackage.Items[WorkItemIndex]
+	WorkItemIndex  uint
+	AuthOutput     types.ByteSequence
+	ImportSegments [][]types.ExportSegment
+	ExtrinsicData  PVM.ExtrinsicDataMap
+
+	// Mocks replaces host calls: the function is called with the machine
+	// state instead of the host call and returns how execution goes on,
+	// PVM.ExitContinue to resume after the ecalli. It charges no gas unless
+	// it does so itself.
+	Mocks map[PVM.OperationType]func(vm *PVM.VMState) PVM.ExitReason
+}
+
+// Result is what a run left behind.
+type Result struct {
+	Exit    string    // "halt", "panic" or "out-of-gas"
+	Output  []byte    // the bytes returned at a halt
+	GasUsed types.Gas // gas consumed, as Ψ_M reports it
+	Calls   []Call    // the host calls made, in order
+
+	// accumulate: the partial state after the run, as it is after the last
+	// checkpoint if the code panicked or ran out of gas
+	State     types.PartialStateSet
+	Service   types.ServiceAccount // the service's account in State
+	Transfers []types.DeferredTransfer
+	Yield     *types.OpaqueHash
+	Provided  types.ServiceBlobs
+
+	// refine
+	Exports []types.ExportSegment
+}
+
+// CallsNamed returns the host calls named name, e.g. "write".
+func (r *Result) CallsNamed(name string) []Call {
+	var calls []Call
+	for _, call := range r.Calls {
+		if call.Name == name {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+// Call is a host call the program made.
+type Call struct {
+	Op        PVM.OperationType
+	Name      string        // as the GP names it, e.g. "write"
+	Args      [6]uint64     // ω7..ω12 when called
+	Registers PVM.Registers // all registers on return; Registers[7] is the result
+	GasBefore PVM.Gas
+	GasAfter  PVM.Gas
+	Exit      string // set when the host call stops execution: "panic", "out-of-gas"...
+
+	before, after PVM.Memory
+}
+
+// Result returns ω7 on return: the value or error code the host call
+// returned, e.g. PVM.OK or PVM.NONE.
+func (c *Call) Result() uint64 {
+	return c.Registers[7]
+}
+
+// ReadBefore reads length bytes at address of memory as the host call was
+// made, e.g. the key a write was given. It returns nil if the range is not
+// readable.
+func (c *Call) ReadBefore(address, length uint64) []byte {
+	return readMemory(&c.before, address, length)
+}
+
+// ReadAfter reads length bytes at address of memory as the host call left
+// it, e.g. the value a read copied out. It returns nil if the range is not
+// readable.
+func (c *Call) ReadAfter(address, length uint64) []byte {
+	return readMemory(&c.after, address, length)
+}
+
+func readMemory(m *PVM.Memory, address, length uint64) []byte {
+	if length == 0 {
+		return []byte{}
+	}
+	if length > 1<<32 || address > 1<<32-length {
+		return nil
+	}
+	for page := address / PVM.ZP; page <= (address+length-1)/PVM.ZP; page++ {
+		if m.GetPageAccess(uint32(page)) == PVM.MemoryInaccessible {
+			return nil
+		}
+	}
+	return m.Read(address, length)
+}
+
+// Accumulate runs code, a standard program, from the accumulate entry point
+// with the accumulate host calls and gas, as Ψ_A does for env.Items.
+func (env *Env) Accumulate(code PVM.StandardCodeFormat, gas types.Gas) (*Result, error) {
+	// t, s and |o|, as Ψ_A encodes them
+	encoder := types.NewEncoder()
+	var argument []byte
+	for _, v := range []uint64{uint64(env.Timeslot), uint64(env.ServiceID), uint64(len(env.Items))} {
+		encoded, err := encoder.EncodeUint(v)
+		if err != nil {
+			return nil, fmt.Errorf("encode accumulate argument: %w", err)
+		}
+		argument = append(argument, encoded...)
+	}
+
+	serviceID := env.ServiceID
+	state := types.PartialStateSet{ServiceAccounts: env.serviceAccounts()}
+	newState := state.DeepCopy()
+	serviceAccount := newState.ServiceAccounts[env.ServiceID]
+	addition := PVM.HostCallArgs{
+		GeneralArgs: PVM.GeneralArgs{
+			ServiceAccount:      &serviceAccount,
+			ServiceID:           &serviceID,
+			ServiceAccountState: &newState.ServiceAccounts,
+		},
+		AccumulateArgs: PVM.AccumulateArgs{
+			ResultContextX:             PVM.I(newState, env.ServiceID, env.Timeslot, env.Entropy, nil),
+			ResultContextY:             PVM.I(state, env.ServiceID, env.Timeslot, env.Entropy, nil),
+			Eta:                        env.Entropy,
+			OperandOrDeferredTransfers: env.Items,
+			Timeslot:                   env.Timeslot,
+		},
+	}
+
+	result := &Result{}
+	m := PVM.Psi_M(code, 5, gas, PVM.Argument(argument), env.hostCalls(PVM.AccumulateOmegas, result), addition)
+	result.setExit(m)
+	result.State, result.Transfers, result.Yield, _, result.Provided, _ = PVM.C(m.Gas, m.ReasonOrBytes, PVM.AccumulateArgs{
+		ResultContextX: m.Addition.ResultContextX,
+		ResultContextY: m.Addition.ResultContextY,
+	})
+	result.Service = result.State.ServiceAccounts[env.ServiceID]
+	return result, nil
+}
+
+// Refine runs code, a standard program, from the refine entry point with
+// the refine host calls and gas, as Ψ_R does for work item
+// env.WorkItemIndex of env.WorkPackage.
+func (env *Env) Refine(code PVM.StandardCodeFormat, gas types.Gas) (*Result, error) {
+	if env.WorkPackage == nil || env.WorkItemIndex >= uint(len(env.WorkPackage.Items)) {
+		return nil, fmt.Errorf("refine needs a work package with work item %d", env.WorkItemIndex)
+	}
+	workItem := env.WorkPackage.Items[env.WorkItemIndex]
+
+	// c, i, w_c and ↕w_y, then H(p), as Ψ_R encodes them
+	encoder := types.NewEncoder()
+	var argument []byte
+	for _, v := range []any{uint64(env.CoreIndex), uint64(env.WorkItemIndex), &workItem.CodeHash, &workItem.Payload} {
+		var encoded []byte
+		var err error
+		if n, ok := v.(uint64); ok {
+			encoded, err = encoder.EncodeUint(n)
+		} else {
+			encoded, err = encoder.Encode(v)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("encode refine argument: %w", err)
+		}
+		argument = append(argument, encoded...)
+	}
+	encodedPackage, err := encoder.Encode(env.WorkPackage)
+	if err != nil {
+		return nil, fmt.Errorf("encode work package: %w", err)
+	}
+	packageHash := hash.Blake2bHash(encodedPackage)
+	argument = append(argument, packageHash[:]...)
+
+	extrinsics := make([][]types.ExtrinsicSpec, len(env.WorkPackage.Items))
+	for i, item := range env.WorkPackage.Items {
+		extrinsics[i] = item.Extrinsic
+	}
+	serviceID, coreIndex := env.ServiceID, env.CoreIndex
+	serviceAccounts := env.serviceAccounts()
+	addition := PVM.HostCallArgs{
+		GeneralArgs: PVM.GeneralArgs{
+			ServiceID:           &serviceID,
+			ServiceAccountState: &serviceAccounts,
+			CoreID:              &coreIndex,
+		},
+		RefineArgs: PVM.RefineArgs{
+			WorkItemIndex:    types.Some(env.WorkItemIndex),
+			WorkPackage:      env.WorkPackage,
+			AuthOutput:       types.Some(env.AuthOutput),
+			ImportSegments:   env.ImportSegments,
+			ExtrinsicDataMap: env.ExtrinsicData,
+			IntegratedPVMMap: PVM.IntegratedPVMMap{},
+			ExportSegment:    []types.ExportSegment{},
+			TimeSlot:         env.WorkPackage.Context.LookupAnchorSlot,
+			Extrinsics:       extrinsics,
+		},
+	}
+
+	result := &Result{}
+	m := PVM.Psi_M(code, 0, gas, PVM.Argument(argument), env.hostCalls(PVM.RefineOmegas, result), addition)
+	result.setExit(m)
+	result.Exports = m.Addition.ExportSegment
+	return result, nil
+}
+
+// serviceAccounts returns env.Services with the service's own account.
+func (env *Env) serviceAccounts() types.ServiceAccountState {
+	accounts := make(types.ServiceAccountState, len(env.Services)+1)
+	for id, account := range env.Services {
+		accounts[id] = account
+	}
+	account := types.ServiceAccount{
+		ServiceInfo:    env.Info,
+		PreimageLookup: make(types.PreimagesMapEntry, len(env.Preimages)),
+		LookupDict:     make(types.LookupMetaMapEntry, len(env.Requests)),
+		StorageDict:    make(types.Storage, len(env.Storage)),
+	}
+	for key, value := range env.Storage {
+		account.StorageDict[key] = value
+	}
+	for h, preimage := range env.Preimages {
+		account.PreimageLookup[h] = preimage
+	}
+	for key, timeslots := range env.Requests {
+		account.LookupDict[key] = append(types.TimeSlotSet(nil), timeslots...)
+	}
+	accounts[env.ServiceID] = account
+	return accounts
+}
+
+// hostCalls returns omegas with env.Mocks in place, recording every host
+// call into result.
+func (env *Env) hostCalls(omegas PVM.Omegas, result *Result) PVM.Omegas {
+	recorded := make(PVM.Omegas, len(omegas))
+	for op := range omegas {
+		omega := omegas[op]
+		if mock, ok := env.Mocks[PVM.OperationType(op)]; ok {
+			omega = func(input PVM.OmegaInput) PVM.OmegaOutput {
+				return PVM.OmegaOutput{ExitReason: mock(input.VM), Addition: input.Addition}
+			}
+		}
+		if omega == nil {
+			continue
+		}
+		recorded[op] = func(input PVM.OmegaInput) PVM.OmegaOutput {
+			call := Call{
+				Op:        input.Operation,
+				Name:      PVM.HostCallName(input.Operation),
+				GasBefore: *input.VM.Gas,
+				before:    input.VM.Memory.Clone(),
+			}
+			copy(call.Args[:], input.VM.Registers[7:])
+
+			output := omega(input)
+
+			call.Registers = *input.VM.Registers
+			call.GasAfter = *input.VM.Gas
+			call.after = input.VM.Memory.Clone()
+			if output.ExitReason != PVM.ExitContinue {
+				call.Exit = exitName(output.ExitReason)
+			}
+			result.Calls = append(result.Calls, call)
+			return output
+		}
+	}
+	return recorded
+}
+
+func (r *Result) setExit(m PVM.Psi_M_ReturnType) {
+	r.GasUsed = m.Gas
+	switch v := m.ReasonOrBytes.(type) {
+	case []byte:
+		r.Exit, r.Output = "halt", v
+	case nil:
+		r.Exit = "halt"
+	default:
+		if v == PVM.OUT_OF_GAS {
+			r.Exit = "out-of-gas"
+		} else {
+			r.Exit = "panic"
+		}
+	}
+}
+
+func exitName(exitReason PVM.ExitReason) string {
+	switch exitReason.GetReasonType() {
+	case PVM.HALT:
+		return "halt"
+	case PVM.OUT_OF_GAS:
+		return "out-of-gas"
+	case PVM.PAGE_FAULT:
+		return "page-fault"
+	default:
+		return "panic"
+	}
+}
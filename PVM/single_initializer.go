@@ -18,54 +18,121 @@ func Z(x int) uint32 {
 	return ZZ * ((uint32(x) + ZZ - 1) / ZZ)
 }
 
+// MemorySegment is a region of the standard memory layout: data is placed
+// in [Start, End) and the pages up to Padded are mapped as well.
+type MemorySegment struct {
+	Start, End, Padded uint32
+}
+
+// MemoryLayout is the memory layout of a standard program (A.36). The heap
+// starts after the read-write pages and grows through sbrk up to the stack.
+type MemoryLayout struct {
+	ReadOnly  MemorySegment
+	ReadWrite MemorySegment
+	HeapStart uint32
+	Stack     MemorySegment // End = Padded
+	Arguments MemorySegment
+}
+
+// standardLayout places read-only data of length oLen, read-write data of
+// length wLen followed by z zeroed pages, a stack of s bytes and an argument
+// of length aLen.
+func standardLayout(oLen, wLen int, z uint16, s uint32, aLen int) MemoryLayout {
+	var layout MemoryLayout
+
+	layout.ReadOnly.Start = uint32(ZZ)
+	layout.ReadOnly.End = layout.ReadOnly.Start + uint32(oLen)
+	layout.ReadOnly.Padded = layout.ReadOnly.Start + P(oLen)
+
+	layout.ReadWrite.Start = 2*ZZ + Z(oLen)
+	layout.ReadWrite.End = layout.ReadWrite.Start + uint32(wLen)
+	layout.ReadWrite.Padded = layout.ReadWrite.Start + P(wLen) + uint32(z)*ZP
+	layout.HeapStart = layout.ReadWrite.Padded
+	// heapEnd := readWritePadding + ZP // ZP is according to davxy, traces-on-sbrk
+
+	layout.Stack.End = uint32(1<<32 - 2*ZZ - ZI)
+	layout.Stack.Start = layout.Stack.End - P(int(s))
+	layout.Stack.Padded = layout.Stack.End
+
+	layout.Arguments.Start = uint32(1<<32 - ZZ - ZI)
+	layout.Arguments.End = layout.Arguments.Start + uint32(aLen)
+	layout.Arguments.Padded = layout.Arguments.End + P(aLen)
+
+	return layout
+}
+
+// fitsAddressSpace reports whether a standard program with the given data,
+// heap and stack sizes fits into the 32-bit address space (A.36).
+func fitsAddressSpace(oLen, wLen int, z uint16, s uint32) bool {
+	return 5*ZZ+uint64(Z(oLen))+uint64(Z(wLen+int(z)*int(ZP)+int(s)+ZI)) <= 1<<32
+}
+
+// StandardProgram is a decoded standard program p (A.37): its data, heap and
+// stack sizes, its code blob and where SingleInitializer maps them.
+type StandardProgram struct {
+	ReadOnlyData  []byte // o
+	ReadWriteData []byte // w
+	HeapPages     uint16 // z
+	StackSize     uint32 // s
+	Code          []byte // c
+	Layout        MemoryLayout
+}
+
+// DecodeStandardProgram decodes p as SingleInitializer does, laying out an
+// empty argument.
+func DecodeStandardProgram(p StandardCodeFormat) (*StandardProgram, error) {
+	c, o, w, z, s, err := DecodeSerializedValues(p)
+	if err != nil {
+		return nil, err
+	}
+	if !fitsAddressSpace(len(o), len(w), z, s) {
+		return nil, fmt.Errorf("program does not fit the address space")
+	}
+
+	return &StandardProgram{
+		ReadOnlyData:  o,
+		ReadWriteData: w,
+		HeapPages:     z,
+		StackSize:     s,
+		Code:          c,
+		Layout:        standardLayout(len(o), len(w), z, s, 0),
+	}, nil
+}
+
 // A.36 Y func
 func SingleInitializer(p StandardCodeFormat, a Argument) (Instructions, Registers, Memory, ExitReason) {
 	c, o, w, z, s, err := DecodeSerializedValues(p)
 	if err != nil {
 		return nil, Registers{}, Memory{}, ExitPanic
 	}
-	if 5*ZZ+uint64(Z(len(o)))+uint64(Z(len(w)+int(z)*int(ZP)+int(s)+ZI)) > 1<<32 {
+	if !fitsAddressSpace(len(o), len(w), z, s) {
 		pvmLogger.Errorf("memory layout calculations failed")
 		return nil, Registers{}, Memory{}, ExitPanic
 	}
 
 	// Memory layout calculations
-	readOnlyStart := uint32(ZZ)
-	readOnlyEnd := readOnlyStart + uint32(len(o))
-	readOnlyPadding := readOnlyStart + P(len(o))
-	readWriteStart := 2*ZZ + Z(len(o))
-	readWriteEnd := readWriteStart + uint32(len(w))
-	readWritePadding := readWriteStart + P(len(w)) + uint32(z)*ZP
-	heapStart := readWritePadding
-	// heapEnd := readWritePadding + ZP // ZP is according to davxy, traces-on-sbrk
-
-	stackEnd := uint32(1<<32 - 2*ZZ - ZI)
-	stackStart := stackEnd - P(int(s))
-	argumentStart := uint32(1<<32 - ZZ - ZI)
-	// argumentEnd := argumentStart + uint32(len(a))
-	argumentEnd := argumentStart + uint32(len(a))
-	argumentPadding := argumentEnd + P(len(a))
+	layout := standardLayout(len(o), len(w), z, s, len(a))
+	ro, rw, stack, args := layout.ReadOnly, layout.ReadWrite, layout.Stack, layout.Arguments
 
 	mem := Memory{
-		Pages:       make(map[uint32]*Page),
-		heapPointer: uint64(heapStart),
-		heapLimit:   uint64(stackStart),
+		heapPointer: uint64(layout.HeapStart),
+		heapLimit:   uint64(stack.Start),
 	}
 
-	allocateMemorySegment(&mem, readOnlyStart, readOnlyEnd, o, MemoryReadOnly)
-	allocateMemorySegment(&mem, readOnlyEnd, readOnlyPadding, nil, MemoryReadOnly) // Padding
-	pvmLogger.Debugf("Memory Map   RO data : 0x%08x  0x%08x  0x%08x", readOnlyStart, readOnlyEnd, readOnlyPadding)
+	allocateMemorySegment(&mem, ro.Start, ro.End, o, MemoryReadOnly)
+	allocateMemorySegment(&mem, ro.End, ro.Padded, nil, MemoryReadOnly) // Padding
+	pvmLogger.Debugf("Memory Map   RO data : 0x%08x  0x%08x  0x%08x", ro.Start, ro.End, ro.Padded)
 
-	allocateMemorySegment(&mem, readWriteStart, readWriteEnd, w, MemoryReadWrite)
-	allocateMemorySegment(&mem, readWriteEnd, readWritePadding, nil, MemoryReadWrite) // Padding
-	pvmLogger.Debugf("Memory Map   RW data : 0x%08x  0x%08x  0x%08x", readWriteStart, readWriteEnd, readWritePadding)
+	allocateMemorySegment(&mem, rw.Start, rw.End, w, MemoryReadWrite)
+	allocateMemorySegment(&mem, rw.End, rw.Padded, nil, MemoryReadWrite) // Padding
+	pvmLogger.Debugf("Memory Map   RW data : 0x%08x  0x%08x  0x%08x", rw.Start, rw.End, rw.Padded)
 
-	allocateStack(&mem, stackStart, stackEnd)
-	pvmLogger.Debugf("Memory Map     stack : 0x%08x  0x%08x", stackStart, stackEnd)
+	allocateStack(&mem, stack.Start, stack.End)
+	pvmLogger.Debugf("Memory Map     stack : 0x%08x  0x%08x", stack.Start, stack.End)
 
-	allocateMemorySegment(&mem, argumentStart, argumentEnd, a, MemoryReadOnly)
-	allocateMemorySegment(&mem, argumentEnd, argumentPadding, nil, MemoryReadOnly) // Padding
-	pvmLogger.Debugf("Memory Map arguments : 0x%08x  0x%08x  0x%08x", argumentStart, argumentEnd, argumentPadding)
+	allocateMemorySegment(&mem, args.Start, args.End, a, MemoryReadOnly)
+	allocateMemorySegment(&mem, args.End, args.Padded, nil, MemoryReadOnly) // Padding
+	pvmLogger.Debugf("Memory Map arguments : 0x%08x  0x%08x  0x%08x", args.Start, args.End, args.Padded)
 
 	// allocateMemorySegment(&mem, heapStart, heapEnd, nil, MemoryReadWrite)
 	// pvmLogger.Debugf("Heap pointer : 0x%08x", heapStart)
@@ -85,13 +152,13 @@ func allocateMemorySegment(mem *Memory, start, end uint32, content []byte, acces
 		pageNum := addr / ZP
 		// check page overlap for padding
 		if content == nil {
-			if _, exists := mem.Pages[pageNum]; exists {
+			if mem.Page(pageNum) != nil {
 				continue
 			} else {
-				mem.Pages[pageNum] = &Page{
+				mem.SetPage(pageNum, &Page{
 					Value:  make([]byte, ZP),
 					Access: access,
-				}
+				})
 				continue
 			}
 		}
@@ -99,10 +166,10 @@ func allocateMemorySegment(mem *Memory, start, end uint32, content []byte, acces
 
 		page := make([]byte, ZP)
 		copy(page, content[:pageSize])
-		mem.Pages[pageNum] = &Page{
+		mem.SetPage(pageNum, &Page{
 			Value:  page,
 			Access: access,
-		}
+		})
 		content = content[pageSize:]
 	}
 }
@@ -110,10 +177,10 @@ func allocateMemorySegment(mem *Memory, start, end uint32, content []byte, acces
 func allocateStack(mem *Memory, start, end uint32) {
 	for addr := start; addr < end; addr += ZP {
 		pageNum := addr / ZP
-		mem.Pages[pageNum] = &Page{
+		mem.SetPage(pageNum, &Page{
 			Value:  make([]byte, ZP), // fill with 0
 			Access: MemoryReadWrite,
-		}
+		})
 	}
 }
 
@@ -49,7 +49,7 @@ func TestSingleInitializer(t *testing.T) {
 
 	// valiadate o in read-only memory
 	readOnlyPageNum := uint32(ZZ / ZP)
-	if page, exists := mem.Pages[readOnlyPageNum]; !exists {
+	if page := mem.Page(readOnlyPageNum); page == nil {
 		t.Errorf("Expected read-only memory at page %d, but not found", readOnlyPageNum)
 	} else if page.Access != MemoryReadOnly {
 		t.Errorf("Expected read-only access for page %d, got %v", readOnlyPageNum, page.Access)
@@ -60,7 +60,7 @@ func TestSingleInitializer(t *testing.T) {
 
 	// validate w in read-write memory
 	readWritePageNum := uint32((2*ZZ + Z(len("obj10"))) / ZP)
-	if page, exists := mem.Pages[readWritePageNum]; !exists {
+	if page := mem.Page(readWritePageNum); page == nil {
 		t.Errorf("Expected read-write memory at page %d, but not found", readWritePageNum)
 	} else if page.Access != MemoryReadWrite {
 		t.Errorf("Expected read-write access for page %d, got %v", readWritePageNum, page.Access)
@@ -71,7 +71,7 @@ func TestSingleInitializer(t *testing.T) {
 	// validate a in argument memory
 	stackEnd := uint32(1<<32 - 2*ZZ - ZI)
 	argumentPageNum := uint32(stackEnd / ZP)
-	if page, exists := mem.Pages[argumentPageNum]; !exists {
+	if page := mem.Page(argumentPageNum); page == nil {
 		t.Errorf("Expected argument memory at page %d, but not found", argumentPageNum)
 	} else if page.Access != MemoryReadOnly {
 		t.Errorf("Expected read-only access for argument page %d, got %v", argumentPageNum, page.Access)
@@ -83,7 +83,7 @@ func TestSingleInitializer(t *testing.T) {
 	stackStart := stackEnd - P(int(32))
 	for addr := stackStart; addr < stackEnd; addr += ZP {
 		pageNum := addr / ZP
-		if page, exists := mem.Pages[pageNum]; !exists {
+		if page := mem.Page(pageNum); page == nil {
 			t.Errorf("Expected stack memory at page %d, but not found", pageNum)
 		} else if page.Access != MemoryReadWrite {
 			t.Errorf("Expected read-write access for stack page %d, got %v", pageNum, page.Access)
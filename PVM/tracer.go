@@ -0,0 +1,264 @@
+package PVM
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"sync"
+	"sync/atomic"
+
+	"github.com/New-JAMneration/JAM-Protocol/internal/types"
+)
+
+// Tracer receives the instructions an Interpreter executes and the host
+// calls made on its behalf. Tracing is opt-in: with a nil
+// Interpreter.Tracer nothing is recorded.
+type Tracer interface {
+	// Instruction is called after each instruction, including the one that
+	// stops execution.
+	Instruction(step *TraceStep)
+	// HostCall is called after each host call returns.
+	HostCall(call *TraceHostCall)
+	// End is called once when the invocation stops.
+	End(end *TraceEnd)
+}
+
+// TraceStart describes an invocation of Ψ_M as it starts.
+type TraceStart struct {
+	Service    *types.ServiceID `json:"service,omitempty"` // nil for is-authorized
+	EntryPoint ProgramCounter   `json:"entry_point"`
+	Gas        Gas              `json:"gas"`
+	Slot       types.TimeSlot   `json:"slot"` // of the accumulation, or the lookup anchor of a refinement
+}
+
+// TraceStep is one executed instruction.
+type TraceStep struct {
+	PC        ProgramCounter     `json:"pc"`
+	Opcode    byte               `json:"opcode"`
+	Name      string             `json:"name"`
+	Args      TraceBytes         `json:"args"` // operand bytes following the opcode
+	GasBefore Gas                `json:"gas_before"`
+	GasAfter  Gas                `json:"gas_after"`
+	Registers map[int]uint64     `json:"regs,omitempty"` // registers the instruction changed
+	Writes    []TraceMemoryWrite `json:"writes,omitempty"`
+	Exit      string             `json:"exit,omitempty"` // set when the instruction stops execution
+	Address   uint32             `json:"fault_address,omitempty"`
+}
+
+// TraceMemoryWrite is a store of an instruction.
+type TraceMemoryWrite struct {
+	Address uint32     `json:"addr"`
+	Data    TraceBytes `json:"data"`
+}
+
+// TraceHostCall is one host call: the argument registers ω7..ω12 it was
+// called with and the registers it changed.
+type TraceHostCall struct {
+	ID        uint8          `json:"id"`
+	Name      string         `json:"name"`
+	GasBefore Gas            `json:"gas_before"`
+	GasAfter  Gas            `json:"gas_after"`
+	Args      [6]uint64      `json:"args"`
+	Registers map[int]uint64 `json:"regs,omitempty"`
+	Exit      string         `json:"exit,omitempty"` // set when the host call stops execution
+}
+
+// TraceEnd is the state an invocation stopped in.
+type TraceEnd struct {
+	Exit    string         `json:"exit"`
+	Address uint32         `json:"fault_address,omitempty"`
+	PC      ProgramCounter `json:"pc"`
+	Gas     Gas            `json:"gas"`
+}
+
+// TraceBytes is a byte string written to traces as 0x-prefixed hex.
+type TraceBytes []byte
+
+func (b TraceBytes) MarshalJSON() ([]byte, error) {
+	return json.Marshal("0x" + hex.EncodeToString(b))
+}
+
+// traceExit names an exit reason as the w3f pvm test vectors do; the empty
+// string for ExitContinue.
+func traceExit(exitReason ExitReason) string {
+	switch exitReason.GetReasonType() {
+	case HALT:
+		return "halt"
+	case PANIC:
+		return "panic"
+	case OUT_OF_GAS:
+		return "out-of-gas"
+	case PAGE_FAULT:
+		return "page-fault"
+	case HOST_CALL:
+		return "host-call"
+	default:
+		return ""
+	}
+}
+
+func traceFaultAddress(exitReason ExitReason) uint32 {
+	if exitReason.GetReasonType() == PAGE_FAULT {
+		return exitReason.GetPageFaultAddress()
+	}
+	return 0
+}
+
+func changedRegisters(before, after *Registers) map[int]uint64 {
+	var changed map[int]uint64
+	for i := range after {
+		if after[i] != before[i] {
+			if changed == nil {
+				changed = map[int]uint64{}
+			}
+			changed[i] = after[i]
+		}
+	}
+	return changed
+}
+
+// traceInstruction reports the instruction at pc, run from gasBefore and
+// registersBefore, to the tracer.
+func (interp *Interpreter) traceInstruction(pc ProgramCounter, gasBefore Gas, registersBefore *Registers, exitReason ExitReason) {
+	code := interp.Program.InstructionData
+	step := &TraceStep{
+		PC:        pc,
+		GasBefore: gasBefore,
+		GasAfter:  interp.Gas,
+		Registers: changedRegisters(registersBefore, &interp.Registers),
+		Writes:    interp.traceWrites,
+		Exit:      traceExit(exitReason),
+		Address:   traceFaultAddress(exitReason),
+	}
+	// (A.19) anything but the start of a valid instruction executes as trap
+	if int(pc) < len(code) && interp.Program.Bitmasks.IsStartOfInstruction(int(pc)) && IsValidOpcode(code[pc]) {
+		step.Opcode = code[pc]
+		end := min(int(pc)+1+int(skip(int(pc), interp.Program.Bitmasks)), len(code))
+		step.Args = TraceBytes(code[pc+1 : end])
+	}
+	step.Name = OpcodeName(step.Opcode)
+	interp.traceWrites = nil
+
+	interp.Tracer.Instruction(step)
+}
+
+// traceEnd reports the state a host-call invocation stopped in.
+func (h *Host) traceEnd(exitReason ExitReason, pc ProgramCounter) {
+	if h.Interpreter.Tracer == nil {
+		return
+	}
+	h.Interpreter.Tracer.End(&TraceEnd{
+		Exit:    traceExit(exitReason),
+		Address: traceFaultAddress(exitReason),
+		PC:      pc,
+		Gas:     h.Interpreter.Gas,
+	})
+}
+
+// HostCallName names a host call as the GP does, e.g. "write"; the empty
+// string for an unknown one.
+func HostCallName(operation OperationType) string {
+	if operation >= 0 && int(operation) < len(hostCallName) {
+		return hostCallName[operation]
+	}
+	return ""
+}
+
+// traceWrite records a store for the instruction being traced.
+func (interp *Interpreter) traceWrite(address uint32, data []byte) {
+	if interp.Tracer != nil {
+		interp.traceWrites = append(interp.traceWrites, TraceMemoryWrite{Address: address, Data: TraceBytes(data).clone()})
+	}
+}
+
+func (b TraceBytes) clone() TraceBytes {
+	return append(TraceBytes(nil), b...)
+}
+
+// JSONTracer writes a trace as JSON Lines, one object per invocation start,
+// instruction, host call and invocation end with "kind" set to "start",
+// "instr", "host" or "end". Keys
+// are fixed and register maps are sorted, so traces of the same execution
+// are byte-for-byte identical and can be diffed line by line.
+type JSONTracer struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+	err error
+}
+
+func NewJSONTracer(w io.Writer) *JSONTracer {
+	return &JSONTracer{enc: json.NewEncoder(w)}
+}
+
+// Start writes the start record of an invocation. It is not part of Tracer:
+// the start is known when the tracer is created (see TraceInvocations).
+func (t *JSONTracer) Start(start *TraceStart) {
+	t.write(struct {
+		Kind string `json:"kind"`
+		*TraceStart
+	}{"start", start})
+}
+
+func (t *JSONTracer) Instruction(step *TraceStep) {
+	t.write(struct {
+		Kind string `json:"kind"`
+		*TraceStep
+	}{"instr", step})
+}
+
+func (t *JSONTracer) HostCall(call *TraceHostCall) {
+	t.write(struct {
+		Kind string `json:"kind"`
+		*TraceHostCall
+	}{"host", call})
+}
+
+func (t *JSONTracer) End(end *TraceEnd) {
+	t.write(struct {
+		Kind string `json:"kind"`
+		*TraceEnd
+	}{"end", end})
+}
+
+// Err returns the first error writing the trace.
+func (t *JSONTracer) Err() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.err
+}
+
+func (t *JSONTracer) write(record any) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.err == nil {
+		t.err = t.enc.Encode(record)
+	}
+}
+
+var invocationTracer atomic.Pointer[func(start *TraceStart) Tracer]
+
+// TraceInvocations traces every Ψ_M invocation (accumulate, refine and
+// is-authorized) into a tracer returned by newTracer, called once per
+// invocation with its start. Invocations may run concurrently. A nil
+// newTracer turns tracing off.
+func TraceInvocations(newTracer func(start *TraceStart) Tracer) {
+	if newTracer == nil {
+		invocationTracer.Store(nil)
+		return
+	}
+	invocationTracer.Store(&newTracer)
+}
+
+func newInvocationTracer(counter ProgramCounter, gas types.Gas, addition *HostCallArgs) Tracer {
+	newTracer := invocationTracer.Load()
+	if newTracer == nil {
+		return nil
+	}
+	// Only one of the two slots is set, by the invocation it belongs to.
+	return (*newTracer)(&TraceStart{
+		Service:    addition.GeneralArgs.ServiceID,
+		EntryPoint: counter,
+		Gas:        Gas(gas),
+		Slot:       max(addition.AccumulateArgs.Timeslot, addition.RefineArgs.TimeSlot),
+	})
+}
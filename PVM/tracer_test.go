@@ -0,0 +1,127 @@
+package PVM
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/New-JAMneration/JAM-Protocol/internal/types"
+)
+
+// storeBlob is
+//
+//	0: load_imm r0, 0x20000
+//	5: store_ind_u32 [r0], r1
+//	7: trap
+var storeBlob = []byte{0, 0, 8, 51, 0x00, 0x00, 0x00, 0x02, 122, 0x01, 0, 0xa1}
+
+func TestTracerEngines(t *testing.T) {
+	program, exitReason := DeBlobProgramCode(storeBlob)
+	if exitReason != ExitContinue {
+		t.Fatalf("DeBlobProgramCode: %v", exitReason)
+	}
+
+	trace := func(decoded bool) string {
+		var out bytes.Buffer
+		memory := &Memory{}
+		memory.SetPage(0x20, &Page{Value: make([]byte, ZP), Access: MemoryReadWrite})
+		interp := NewInterpreter(&program, Registers{1: 0xdeadbeef}, memory, 100)
+		interp.Tracer = NewJSONTracer(&out)
+		if decoded {
+			interp.SingleStepInvokeDecodedBlocks(0)
+		} else {
+			interp.SingleStepInvoke(0)
+		}
+		return out.String()
+	}
+
+	singleStep, decoded := trace(false), trace(true)
+	if singleStep != decoded {
+		t.Fatalf("engines trace differently\nsingle-step:\n%s\ndecoded:\n%s", singleStep, decoded)
+	}
+
+	want := []string{
+		`{"kind":"instr","pc":0,"opcode":51,"name":"load_imm","args":"0x00000002","gas_before":100,"gas_after":99,"regs":{"0":131072}}`,
+		`{"kind":"instr","pc":5,"opcode":122,"name":"store_ind_u32","args":"0x01","gas_before":99,"gas_after":98,"writes":[{"addr":131072,"data":"0xefbeadde"}]}`,
+		`{"kind":"instr","pc":7,"opcode":0,"name":"trap","args":"0x","gas_before":98,"gas_after":97,"exit":"panic"}`,
+	}
+	if got := strings.Split(strings.TrimSpace(decoded), "\n"); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("trace =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestTracerHostCall(t *testing.T) {
+	// 0: ecalli 0, 2: trap
+	program, exitReason := DeBlobProgramCode([]byte{0, 0, 3, 10, 0, 0, 0x05})
+	if exitReason != ExitContinue {
+		t.Fatalf("DeBlobProgramCode: %v", exitReason)
+	}
+
+	omegas := Omegas{func(input OmegaInput) OmegaOutput {
+		*input.VM.Gas -= 10
+		input.VM.Registers[7] = 42
+		return OmegaOutput{ExitReason: ExitContinue, Addition: input.Addition}
+	}}
+	var out bytes.Buffer
+	host := NewHost(&program, Registers{7: 1, 8: 2}, &Memory{}, 100, HostCallArgs{}, omegas)
+	host.Interpreter.Tracer = NewJSONTracer(&out)
+	host.HostCall(0, 0)
+
+	var records []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		var record map[string]any
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("invalid trace line %q: %v", line, err)
+		}
+		records = append(records, record)
+	}
+
+	var kinds []string
+	for _, record := range records {
+		kinds = append(kinds, record["kind"].(string))
+	}
+	if got := strings.Join(kinds, ","); got != "instr,host,instr,end" {
+		t.Fatalf("record kinds = %s, want instr,host,instr,end", got)
+	}
+
+	call := records[1]
+	if call["name"] != "gas" || call["gas_before"] != 99.0 || call["gas_after"] != 89.0 {
+		t.Errorf("host call record = %v", call)
+	}
+	if args := call["args"].([]any); args[0] != 1.0 || args[1] != 2.0 {
+		t.Errorf("host call args = %v, want ω7 = 1, ω8 = 2", args)
+	}
+	if regs := call["regs"].(map[string]any); len(regs) != 1 || regs["7"] != 42.0 {
+		t.Errorf("host call regs = %v, want 7: 42", regs)
+	}
+	if end := records[3]; end["exit"] != "panic" || end["gas"] != 88.0 {
+		t.Errorf("end record = %v", end)
+	}
+}
+
+func TestTraceInvocationsStart(t *testing.T) {
+	code := MustAssemble("trap\ntrap\ntrap\ntrap\ntrap\naccumulate:\ntrap\n").StandardProgram()
+
+	var out bytes.Buffer
+	TraceInvocations(func(start *TraceStart) Tracer {
+		tracer := NewJSONTracer(&out)
+		tracer.Start(start)
+		return tracer
+	})
+	defer TraceInvocations(nil)
+
+	serviceID := types.ServiceID(7)
+	Psi_M(code, 5, 100, nil, Omegas{}, HostCallArgs{
+		GeneralArgs:    GeneralArgs{ServiceID: &serviceID},
+		AccumulateArgs: AccumulateArgs{Timeslot: 3},
+	})
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if want := `{"kind":"start","service":7,"entry_point":5,"gas":100,"slot":3}`; lines[0] != want {
+		t.Errorf("first record = %s, want %s", lines[0], want)
+	}
+	if last := lines[len(lines)-1]; !strings.HasPrefix(last, `{"kind":"end"`) {
+		t.Errorf("last record = %s, want the end", last)
+	}
+}
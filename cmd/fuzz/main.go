@@ -13,6 +13,7 @@ import (
 	"strings"
 	"syscall"
 
+	"github.com/New-JAMneration/JAM-Protocol/PVM"
 	"github.com/New-JAMneration/JAM-Protocol/config"
 	"github.com/New-JAMneration/JAM-Protocol/internal/fuzz"
 	"github.com/New-JAMneration/JAM-Protocol/internal/fuzzenv"
@@ -171,6 +172,12 @@ func main() {
 
 	config.UpdateVersion(GP_VERSION, TARGET_VERSION)
 
+	gasModel, err := PVM.GasModelForVersion(GP_VERSION)
+	if err != nil {
+		logger.Fatalf("error selecting PVM gas model: %v", err)
+	}
+	PVM.SetGasModel(gasModel)
+
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
 	defer stop()
 
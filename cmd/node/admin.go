@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"github.com/New-JAMneration/JAM-Protocol/internal/database"
+	"github.com/New-JAMneration/JAM-Protocol/logger"
+)
+
+// adminSocket is the unix socket a running node serves admin requests on.
+var adminSocket string
+
+// startAdmin serves admin requests on the unix socket at path until stop is
+// called. Requests:
+//
+//	POST /checkpoint dir=<absolute path>: checkpoint the database into dir
+func startAdmin(path string, checkpointer database.Checkpointer) (stop func(), err error) {
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("admin socket: %w", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /checkpoint", func(w http.ResponseWriter, r *http.Request) {
+		dir := r.FormValue("dir")
+		if !filepath.IsAbs(dir) {
+			http.Error(w, "dir must be an absolute path", http.StatusBadRequest)
+			return
+		}
+		if err := checkpointer.Checkpoint(dir); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		logger.Infof("checkpoint written to %s", dir)
+		fmt.Fprintf(w, "checkpoint written to %s\n", dir)
+	})
+
+	server := &http.Server{Handler: mux}
+	go func() {
+		if err := server.Serve(listener); !errors.Is(err, http.ErrServerClosed) {
+			logger.Errorf("admin socket %s: %v", path, err)
+		}
+	}()
+	logger.Infof("Serving admin requests on %s", path)
+
+	return func() { server.Shutdown(context.Background()) }, nil
+}
+
+// adminCheckpoint asks the node serving the admin socket at path to
+// checkpoint its database into dir.
+func adminCheckpoint(path, dir string) error {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var dialer net.Dialer
+			return dialer.DialContext(ctx, "unix", path)
+		},
+	}}
+	resp, err := client.PostForm("http://admin/checkpoint", url.Values{"dir": {dir}})
+	if err != nil {
+		return fmt.Errorf("admin socket: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return errors.New(strings.TrimSpace(string(body)))
+	}
+	return nil
+}
@@ -0,0 +1,503 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/New-JAMneration/JAM-Protocol/config"
+	"github.com/New-JAMneration/JAM-Protocol/internal/archive"
+	"github.com/New-JAMneration/JAM-Protocol/internal/blockchain"
+	"github.com/New-JAMneration/JAM-Protocol/internal/database"
+	"github.com/New-JAMneration/JAM-Protocol/internal/fuzz"
+	"github.com/New-JAMneration/JAM-Protocol/internal/store"
+	"github.com/New-JAMneration/JAM-Protocol/internal/types"
+	"github.com/New-JAMneration/JAM-Protocol/internal/utilities/hash"
+	m "github.com/New-JAMneration/JAM-Protocol/internal/utilities/merklization"
+	jamtests "github.com/New-JAMneration/JAM-Protocol/jamtests/trace"
+	"github.com/New-JAMneration/JAM-Protocol/logger"
+	"github.com/urfave/cli/v3"
+)
+
+var (
+	archiveDataDir   string
+	archiveFromSlot  uint64
+	archiveToSlot    uint64
+	snapshotInterval int
+	importNoResume   bool
+)
+
+var exportCmd = &cli.Command{
+	Name:      "export",
+	Usage:     "Export the stored chain into a portable archive",
+	ArgsUsage: "<archive>",
+	Description: `Stream the canonical chain from the node database into a length-prefixed archive of
+codec-encoded blocks, reading one block at a time. Blocks of abandoned forks are not exported.
+The state of the first exported block is always included so the archive can be imported
+into an empty node; --snapshot-interval adds further state snapshots that import verifies
+and can resume from.
+For example:
+  go run ./cmd/node export chain.jam
+  go run ./cmd/node export --from 100 --to 200 --snapshot-interval 50 chain.jam`,
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:        "datadir",
+			Usage:       "Database directory (defaults to database.data_dir from the config)",
+			Destination: &archiveDataDir,
+		},
+		&cli.Uint64Flag{
+			Name:        "from",
+			Usage:       "First slot to export",
+			Destination: &archiveFromSlot,
+		},
+		&cli.Uint64Flag{
+			Name:        "to",
+			Usage:       "Last slot to export (0 = up to the head)",
+			Destination: &archiveToSlot,
+		},
+		&cli.IntFlag{
+			Name:        "snapshot-interval",
+			Usage:       "Write a state snapshot every N blocks (0 = first block only)",
+			Destination: &snapshotInterval,
+		},
+	},
+	Action: func(ctx context.Context, c *cli.Command) error {
+		config.InitConfig(configPath, mode)
+
+		if c.Args().Len() != 1 {
+			return fmt.Errorf("expected exactly one archive path")
+		}
+
+		db, err := openDatabase(archiveDataDir, true)
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+
+		repo := store.NewRepository(db)
+		blocks, err := repo.CanonicalBlocks(db, types.TimeSlot(archiveFromSlot), types.TimeSlot(archiveToSlot))
+		if err != nil {
+			return fmt.Errorf("failed to list canonical blocks: %w", err)
+		}
+		if blocks.Len() == 0 {
+			return fmt.Errorf("no blocks to export")
+		}
+
+		file, err := os.Create(c.Args().First())
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		w, err := archive.NewWriter(file)
+		if err != nil {
+			return err
+		}
+
+		export := &chainExporter{repo: repo, db: db, w: w}
+		for blocks.Next() {
+			if err := export.block(blocks.Block(), blocks.HeaderHash()); err != nil {
+				return err
+			}
+		}
+		if err := blocks.Error(); err != nil {
+			return err
+		}
+		if err := export.finish(); err != nil {
+			return err
+		}
+
+		if err := w.Flush(); err != nil {
+			return err
+		}
+
+		logger.Infof("Exported %d blocks (slot %d..%d) and %d state snapshots to %s",
+			export.blocks, export.first, export.last, export.snapshots, c.Args().First())
+		return nil
+	},
+}
+
+// chainExporter writes canonical blocks to an archive as they are read, with
+// a state snapshot after the first block and every snapshotInterval blocks.
+type chainExporter struct {
+	repo *store.Repository
+	db   database.Reader
+	w    *archive.Writer
+
+	blocks, snapshots int
+	first, last       types.TimeSlot
+
+	// pending is a block whose snapshot waits for the next block, because
+	// its state is only known through that block's ParentStateRoot.
+	pending      *types.Header
+	pendingFirst bool
+}
+
+func (e *chainExporter) block(block *types.Block, headerHash types.HeaderHash) error {
+	if e.pending != nil {
+		keyVals, loadErr := e.repo.GetStateData(e.db, block.Header.ParentStateRoot)
+		if err := e.snapshot(e.pending, e.pendingFirst, keyVals, loadErr); err != nil {
+			return err
+		}
+		e.pending = nil
+	}
+
+	if err := e.w.WriteBlock(block); err != nil {
+		return err
+	}
+	if e.blocks == 0 {
+		e.first = block.Header.Slot
+	}
+	e.last = block.Header.Slot
+	i := e.blocks
+	e.blocks++
+
+	if i != 0 && (snapshotInterval <= 0 || i%snapshotInterval != 0) {
+		return nil
+	}
+	keyVals, err := e.repo.GetStateDataByHeaderHash(e.db, headerHash)
+	if err != nil {
+		// Without an sr: mapping the next block commits to the state.
+		e.pending, e.pendingFirst = &block.Header, i == 0
+		return nil
+	}
+	return e.snapshot(&block.Header, i == 0, keyVals, nil)
+}
+
+// finish settles a snapshot still waiting for a block after the last one.
+func (e *chainExporter) finish() error {
+	if e.pending == nil {
+		return nil
+	}
+	err := fmt.Errorf("no state recorded for the last exported block")
+	return e.snapshot(e.pending, e.pendingFirst, nil, err)
+}
+
+// snapshot writes the state of header, or reports why it is missing: the
+// state of the first exported block is required, later ones are skipped.
+func (e *chainExporter) snapshot(header *types.Header, first bool, keyVals types.StateKeyVals, err error) error {
+	if err != nil {
+		if first {
+			return fmt.Errorf("state of the first exported block (slot %d) is required: %w", header.Slot, err)
+		}
+		logger.Warnf("skipping state snapshot at slot %d: %v", header.Slot, err)
+		return nil
+	}
+	if err := e.w.WriteState(&archive.StateSnapshot{Header: *header, KeyVals: keyVals}); err != nil {
+		return err
+	}
+	e.snapshots++
+	return nil
+}
+
+var importCmd = &cli.Command{
+	Name:      "import",
+	Usage:     "Import a chain archive or a directory of trace .bin files",
+	ArgsUsage: "<archive|trace-dir>",
+	Description: `Run every block of an archive (see "node export") or of a trace directory through the STF
+and store the result in the node database. Blocks already present in the database are
+skipped, so an interrupted import can simply be restarted.
+For example:
+  go run ./cmd/node import chain.jam
+  go run ./cmd/node import pkg/test_data/jam-test-vectors/traces/fallback`,
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:        "datadir",
+			Usage:       "Database directory (defaults to database.data_dir from the config)",
+			Destination: &archiveDataDir,
+		},
+		&cli.BoolFlag{
+			Name:        "no-resume",
+			Usage:       "Re-import blocks that are already stored",
+			Destination: &importNoResume,
+		},
+	},
+	Action: func(ctx context.Context, c *cli.Command) error {
+		config.InitConfig(configPath, mode)
+		if archiveDataDir != "" {
+			config.Config.Database.DataDir = archiveDataDir
+		}
+
+		if c.Args().Len() != 1 {
+			return fmt.Errorf("expected exactly one archive or trace directory")
+		}
+
+		source, err := openImportSource(c.Args().First())
+		if err != nil {
+			return err
+		}
+		defer source.Close()
+
+		if adminSocket != "" {
+			// Every ChainState shares the persistent database, so the
+			// instance at hand serves checkpoints for the whole import.
+			stopAdmin, err := startAdmin(adminSocket, blockchain.GetInstance())
+			if err != nil {
+				return err
+			}
+			defer stopAdmin()
+		}
+
+		importer := &chainImporter{
+			service: &fuzz.FuzzServiceStub{},
+			repo:    store.NewRepository(blockchain.GetInstance().PersistentDatabase()),
+			resume:  !importNoResume,
+			started: time.Now(),
+		}
+
+		return importer.run(source)
+	},
+}
+
+// stateImporter is the part of the fuzz service that chainImporter drives.
+type stateImporter interface {
+	SetState(header types.Header, stateKeyVals types.StateKeyVals, ancestry types.Ancestry) (types.StateRoot, error)
+	ImportBlock(block types.Block) (types.StateRoot, error)
+}
+
+// chainImporter feeds archive records into the global ChainState.
+type chainImporter struct {
+	service stateImporter
+	repo    *store.Repository
+	resume  bool
+
+	// live is set once a base state has been loaded into the ChainState.
+	live     bool
+	head     types.Header
+	headRoot types.StateRoot
+
+	// lastStored is the most recent block skipped because it was already stored.
+	lastStored *types.Header
+
+	imported, skipped int
+	started, printed  time.Time
+}
+
+// run applies every record of source.
+func (imp *chainImporter) run(source importSource) error {
+	for {
+		record, err := source.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if err := imp.apply(record); err != nil {
+			return err
+		}
+		imp.progress(source, false)
+	}
+	imp.progress(source, true)
+	return nil
+}
+
+func (imp *chainImporter) apply(record *archive.Record) error {
+	switch record.Kind {
+	case archive.RecordState:
+		return imp.applyState(record.State)
+	case archive.RecordBlock:
+		return imp.applyBlock(record.Block)
+	}
+	return fmt.Errorf("unexpected record kind %d", record.Kind)
+}
+
+func (imp *chainImporter) applyState(snapshot *archive.StateSnapshot) error {
+	root := m.MerklizationSerializedState(snapshot.KeyVals)
+	if imp.live {
+		// Mid-stream snapshots are checkpoints of the chain being imported.
+		if snapshot.Header.Slot == imp.head.Slot && root != imp.headRoot {
+			return fmt.Errorf("state root mismatch at slot %d: imported 0x%x, archive 0x%x", imp.head.Slot, imp.headRoot, root)
+		}
+		return nil
+	}
+	if imp.lastStored != nil && imp.lastStored.Slot >= snapshot.Header.Slot {
+		// The snapshot's block or a later one is already stored; resume
+		// from there instead. Export writes a block before its snapshot.
+		return nil
+	}
+
+	if _, err := imp.service.SetState(snapshot.Header, snapshot.KeyVals, nil); err != nil {
+		return fmt.Errorf("failed to load state at slot %d: %w", snapshot.Header.Slot, err)
+	}
+	imp.live, imp.head, imp.headRoot = true, snapshot.Header, root
+	imp.lastStored = nil
+	logger.Infof("Loaded base state at slot %d (root 0x%x)", snapshot.Header.Slot, root)
+	return nil
+}
+
+func (imp *chainImporter) applyBlock(block *types.Block) error {
+	headerHash, err := hash.ComputeBlockHeaderHash(block.Header)
+	if err != nil {
+		return err
+	}
+
+	if imp.resume {
+		if _, err := imp.repo.GetBlockByHash(imp.repo.Database(), types.OpaqueHash(headerHash)); err == nil {
+			// Also checked once live: a base state loaded from the source
+			// may precede blocks that are already stored. The ChainState
+			// head is then behind them, so resume from the last one.
+			imp.lastStored = &block.Header
+			imp.live = false
+			imp.skipped++
+			return nil
+		}
+	}
+	if !imp.live {
+		if imp.lastStored == nil {
+			// Blocks before the first state snapshot only describe the base block.
+			return nil
+		}
+		if err := imp.resumeFrom(imp.lastStored, block.Header.ParentStateRoot); err != nil {
+			return err
+		}
+	}
+
+	root, err := imp.service.ImportBlock(*block)
+	if err != nil {
+		return fmt.Errorf("failed to import block 0x%x at slot %d: %w", headerHash[:8], block.Header.Slot, err)
+	}
+	imp.head, imp.headRoot = block.Header, root
+	imp.imported++
+	return nil
+}
+
+// resumeFrom reloads the stored state of an already imported block.
+func (imp *chainImporter) resumeFrom(header *types.Header, stateRoot types.StateRoot) error {
+	keyVals, err := imp.repo.GetStateData(imp.repo.Database(), stateRoot)
+	if err != nil {
+		return fmt.Errorf("cannot resume after slot %d: %w", header.Slot, err)
+	}
+	if _, err := imp.service.SetState(*header, keyVals, nil); err != nil {
+		return fmt.Errorf("cannot resume after slot %d: %w", header.Slot, err)
+	}
+	imp.live, imp.head, imp.headRoot = true, *header, stateRoot
+	logger.Infof("Resuming after slot %d (%d stored blocks skipped)", header.Slot, imp.skipped)
+	return nil
+}
+
+func (imp *chainImporter) progress(source importSource, final bool) {
+	now := time.Now()
+	if !final && now.Sub(imp.printed) < 2*time.Second {
+		return
+	}
+	imp.printed = now
+
+	rate := float64(imp.imported) / now.Sub(imp.started).Seconds()
+	done, total := source.Progress()
+	logger.Infof("Imported %d blocks, skipped %d, head slot %d, %.1f blk/s, %d/%d (%.1f%%)",
+		imp.imported, imp.skipped, imp.head.Slot, rate, done, total, 100*float64(done)/float64(max(total, 1)))
+}
+
+// importSource yields archive records from an archive file or a trace directory.
+type importSource interface {
+	Next() (*archive.Record, error)
+	// Progress reports how much of the source has been consumed.
+	Progress() (done, total int64)
+	Close() error
+}
+
+func openImportSource(path string) (importSource, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return newTraceDirSource(path)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	reader, err := archive.NewReader(bufio.NewReader(file))
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &archiveSource{file: file, reader: reader, size: info.Size()}, nil
+}
+
+type archiveSource struct {
+	file   *os.File
+	reader *archive.Reader
+	size   int64
+}
+
+func (s *archiveSource) Next() (*archive.Record, error) { return s.reader.Next() }
+func (s *archiveSource) Progress() (int64, int64)       { return s.reader.Offset(), s.size }
+func (s *archiveSource) Close() error                   { return s.file.Close() }
+
+// traceDirSource turns genesis.bin into a state record and every other
+// trace step into a block record, in file name order.
+type traceDirSource struct {
+	files []string
+	next  int
+}
+
+func newTraceDirSource(dir string) (*traceDirSource, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var genesis string
+	var files []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".bin") {
+			continue
+		}
+		if name == "genesis.bin" {
+			genesis = filepath.Join(dir, name)
+			continue
+		}
+		files = append(files, filepath.Join(dir, name))
+	}
+	if genesis == "" {
+		return nil, fmt.Errorf("no genesis.bin in %s", dir)
+	}
+	sort.Strings(files)
+
+	return &traceDirSource{files: append([]string{genesis}, files...)}, nil
+}
+
+func (s *traceDirSource) Next() (*archive.Record, error) {
+	if s.next >= len(s.files) {
+		return nil, io.EOF
+	}
+	path := s.files[s.next]
+	s.next++
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	decoder := types.NewDecoder()
+
+	if s.next == 1 {
+		genesis := &jamtests.Genesis{}
+		if err := decoder.Decode(data, genesis); err != nil {
+			return nil, fmt.Errorf("failed to decode %s: %w", path, err)
+		}
+		return &archive.Record{
+			Kind:  archive.RecordState,
+			State: &archive.StateSnapshot{Header: genesis.Header, KeyVals: genesis.State.KeyVals},
+		}, nil
+	}
+
+	step := &jamtests.TraceTestCase{}
+	if err := decoder.Decode(data, step); err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", path, err)
+	}
+	return &archive.Record{Kind: archive.RecordBlock, Block: &step.Block}, nil
+}
+
+func (s *traceDirSource) Progress() (int64, int64) { return int64(s.next), int64(len(s.files)) }
+func (s *traceDirSource) Close() error             { return nil }
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/New-JAMneration/JAM-Protocol/internal/archive"
+	"github.com/New-JAMneration/JAM-Protocol/internal/database/provider/memory"
+	"github.com/New-JAMneration/JAM-Protocol/internal/store"
+	"github.com/New-JAMneration/JAM-Protocol/internal/types"
+	"github.com/New-JAMneration/JAM-Protocol/internal/utilities/hash"
+	m "github.com/New-JAMneration/JAM-Protocol/internal/utilities/merklization"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeStateImporter stores blocks and states the way the fuzz service does,
+// with the state after a block holding only its slot.
+type fakeStateImporter struct {
+	repo *store.Repository
+}
+
+func fakeState(slot types.TimeSlot) types.StateKeyVals {
+	return types.StateKeyVals{{Key: types.StateKey{byte(slot)}, Value: types.ByteSequence{byte(slot)}}}
+}
+
+func (s *fakeStateImporter) store(header types.Header, keyVals types.StateKeyVals) (types.StateRoot, error) {
+	db := s.repo.Database()
+	headerHash, err := hash.ComputeBlockHeaderHash(header)
+	if err != nil {
+		return types.StateRoot{}, err
+	}
+	root := m.MerklizationSerializedState(keyVals)
+	if err := s.repo.SaveBlockByHash(db, types.OpaqueHash(headerHash), &types.Block{Header: header}); err != nil {
+		return types.StateRoot{}, err
+	}
+	return root, s.repo.SaveStateData(db, root, keyVals)
+}
+
+func (s *fakeStateImporter) SetState(header types.Header, keyVals types.StateKeyVals, _ types.Ancestry) (types.StateRoot, error) {
+	return s.store(header, keyVals)
+}
+
+func (s *fakeStateImporter) ImportBlock(block types.Block) (types.StateRoot, error) {
+	return s.store(block.Header, fakeState(block.Header.Slot))
+}
+
+// recordSource yields records and fails after limit of them, like an
+// import that is interrupted.
+type recordSource struct {
+	records []*archive.Record
+	next    int
+	limit   int
+}
+
+var errInterrupted = errors.New("interrupted")
+
+func (s *recordSource) Next() (*archive.Record, error) {
+	if s.next == s.limit {
+		return nil, errInterrupted
+	}
+	if s.next == len(s.records) {
+		return nil, io.EOF
+	}
+	s.next++
+	return s.records[s.next-1], nil
+}
+
+func (s *recordSource) Progress() (int64, int64) { return int64(s.next), int64(len(s.records)) }
+func (s *recordSource) Close() error             { return nil }
+
+func TestImportResume(t *testing.T) {
+	const blocks = 6
+
+	// An export of slots 0..5 with a snapshot every 3 blocks: each block is
+	// followed by its state snapshot.
+	var records []*archive.Record
+	for slot := types.TimeSlot(0); slot < blocks; slot++ {
+		header := types.Header{Slot: slot}
+		if slot > 0 {
+			header.ParentStateRoot = m.MerklizationSerializedState(fakeState(slot - 1))
+		}
+		records = append(records, &archive.Record{Kind: archive.RecordBlock, Block: &types.Block{Header: header}})
+		if slot%3 == 0 {
+			records = append(records, &archive.Record{Kind: archive.RecordState, State: &archive.StateSnapshot{Header: header, KeyVals: fakeState(slot)}})
+		}
+	}
+
+	repo := store.NewRepository(memory.NewDatabase())
+	run := func(limit int) (*chainImporter, error) {
+		imp := &chainImporter{service: &fakeStateImporter{repo: repo}, repo: repo, resume: true}
+		return imp, imp.run(&recordSource{records: records, limit: limit})
+	}
+
+	// Interrupted after block 3 and its snapshot.
+	imp, err := run(6)
+	require.ErrorIs(t, err, errInterrupted)
+	require.Equal(t, 3, imp.imported)
+
+	imp, err = run(-1)
+	require.NoError(t, err)
+	require.Equal(t, 4, imp.skipped)
+	require.Equal(t, 2, imp.imported)
+
+	imp, err = run(-1)
+	require.NoError(t, err)
+	require.Equal(t, blocks, imp.skipped)
+	require.Equal(t, 0, imp.imported)
+}
@@ -0,0 +1,298 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/New-JAMneration/JAM-Protocol/config"
+	"github.com/New-JAMneration/JAM-Protocol/internal/database"
+	"github.com/New-JAMneration/JAM-Protocol/internal/database/provider/memory"
+	pebbledb "github.com/New-JAMneration/JAM-Protocol/internal/database/provider/pebble"
+	"github.com/New-JAMneration/JAM-Protocol/internal/store"
+	"github.com/New-JAMneration/JAM-Protocol/logger"
+	"github.com/urfave/cli/v3"
+)
+
+var dbDataDir string
+
+func dbDataDirFlag() cli.Flag {
+	return &cli.StringFlag{
+		Name:        "datadir",
+		Usage:       "Database directory (defaults to database.data_dir from the config)",
+		Destination: &dbDataDir,
+	}
+}
+
+var dbCmd = &cli.Command{
+	Name:  "db",
+	Usage: "Maintain the node database",
+	Commands: []*cli.Command{
+		checkpointCmd,
+		restoreCmd,
+		inspectCmd,
+		migrateCmd,
+	},
+}
+
+var checkpointCmd = &cli.Command{
+	Name:      "checkpoint",
+	Usage:     "Write a consistent copy of the node database",
+	ArgsUsage: "<dir>",
+	Description: `Create a checkpoint of the database in <dir>, which must not exist yet.
+Pebble databases use native checkpoints (hard-linked SSTables); redis databases are
+written as a key dump of the node's key prefixes.
+A running node holds the pebble lock; with --admin set to the socket it serves (see
+"node --admin"), the node takes the checkpoint itself and keeps importing. Otherwise
+the database is opened read-only.
+For example:
+  go run ./cmd/node db checkpoint ./backup/2024-01-01
+  go run ./cmd/node --admin /tmp/jam-admin.sock db checkpoint ./backup/2024-01-01`,
+	Flags: []cli.Flag{dbDataDirFlag()},
+	Action: func(ctx context.Context, c *cli.Command) error {
+		config.InitConfig(configPath, mode)
+
+		if c.Args().Len() != 1 {
+			return fmt.Errorf("expected exactly one checkpoint directory")
+		}
+		dir := c.Args().First()
+
+		if adminSocket != "" {
+			if err := adminCheckpoint(adminSocket, dir); err != nil {
+				return fmt.Errorf("checkpoint failed: %w", err)
+			}
+			logger.Infof("checkpoint written to %s", dir)
+			return nil
+		}
+
+		db, _, err := openDatabaseAnySchema(dbDataDir, true)
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+
+		if err := store.Checkpoint(db, dir); err != nil {
+			return fmt.Errorf("checkpoint failed: %w", err)
+		}
+		logger.Infof("checkpoint written to %s", dir)
+		return nil
+	},
+}
+
+var restoreCmd = &cli.Command{
+	Name:      "restore",
+	Usage:     "Replace the node database with a checkpoint",
+	ArgsUsage: "<dir>",
+	Description: `Verify that the head block of the checkpoint in <dir> is stored and that its state
+merklizes to the recorded state root, then swap the checkpoint in. The previous pebble
+directory is kept next to it as <datadir>.old-<unix time>; for redis the node's key
+prefixes are replaced by the dump.
+For example:
+  go run ./cmd/node db restore ./backup/2024-01-01`,
+	Flags: []cli.Flag{dbDataDirFlag()},
+	Action: func(ctx context.Context, c *cli.Command) error {
+		config.InitConfig(configPath, mode)
+
+		if c.Args().Len() != 1 {
+			return fmt.Errorf("expected exactly one checkpoint directory")
+		}
+		dir := c.Args().First()
+
+		switch config.Config.Database.Type {
+		case "pebble":
+			return restorePebble(dir, dbDataDir)
+		case "redis":
+			return restoreDump(dir)
+		default:
+			return fmt.Errorf("unsupported database type %q", config.Config.Database.Type)
+		}
+	},
+}
+
+// verifyCheckpoint checks the schema version and head of db and logs them.
+// The head of a checkpoint with an older schema is only readable after the
+// node migrates it on startup, so only its version is checked.
+func verifyCheckpoint(db database.Database) error {
+	repo := store.NewRepository(db)
+	version, err := repo.CheckSchema(db)
+	if err != nil {
+		return fmt.Errorf("checkpoint verification failed: %w", err)
+	}
+	if version != 0 && version < store.SchemaVersion {
+		logger.Warnf("checkpoint has schema version %d, head not verified; it is migrated to %d on startup", version, store.SchemaVersion)
+		return nil
+	}
+	head, err := repo.VerifyHead(db)
+	if err != nil {
+		return fmt.Errorf("checkpoint verification failed: %w", err)
+	}
+	logger.Infof("checkpoint head: slot %d, header 0x%x, state root 0x%x", head.Slot, head.HeaderHash, head.StateRoot)
+	return nil
+}
+
+func restorePebble(dir, dataDir string) error {
+	if dataDir == "" {
+		dataDir = config.Config.Database.DataDir
+	}
+
+	checkpoint, err := pebbledb.NewDatabase(dir, true)
+	if err != nil {
+		return fmt.Errorf("failed to open checkpoint: %w", err)
+	}
+	err = verifyCheckpoint(checkpoint)
+	checkpoint.Close()
+	if err != nil {
+		return err
+	}
+
+	// Opening the current database takes its lock, which fails while a node is running.
+	exists := false
+	if _, err := os.Stat(dataDir); err == nil {
+		exists = true
+		current, err := pebbledb.NewDatabase(dataDir, false)
+		if err != nil {
+			return fmt.Errorf("database at %s is in use or damaged: %w", dataDir, err)
+		}
+		current.Close()
+	}
+
+	staging := dataDir + ".restore"
+	if err := os.RemoveAll(staging); err != nil {
+		return err
+	}
+	if err := copyDir(dir, staging); err != nil {
+		return fmt.Errorf("failed to copy checkpoint: %w", err)
+	}
+
+	if exists {
+		old := fmt.Sprintf("%s.old-%d", dataDir, time.Now().Unix())
+		if err := os.Rename(dataDir, old); err != nil {
+			return err
+		}
+		logger.Infof("previous database moved to %s", old)
+	}
+	if err := os.Rename(staging, dataDir); err != nil {
+		return err
+	}
+
+	logger.Infof("restored %s into %s", dir, dataDir)
+	return nil
+}
+
+func restoreDump(dir string) error {
+	data, err := os.ReadFile(filepath.Join(dir, store.CheckpointDumpFile))
+	if err != nil {
+		return err
+	}
+
+	// Verify the dump in memory before touching the live database.
+	staged := memory.NewDatabase()
+	defer staged.Close()
+	if _, err := database.LoadDump(bytes.NewReader(data), staged); err != nil {
+		return err
+	}
+	if _, err := store.NewRepository(staged).MigrateSchema(staged); err != nil {
+		return fmt.Errorf("checkpoint verification failed: %w", err)
+	}
+	if err := verifyCheckpoint(staged); err != nil {
+		return err
+	}
+
+	db, _, err := openDatabaseAnySchema("", false)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	removed, err := deletePrefixes(db, store.SchemaPrefixes())
+	if err != nil {
+		return fmt.Errorf("failed to clear database: %w", err)
+	}
+	restored, err := database.LoadDump(bytes.NewReader(data), db)
+	if err != nil {
+		return fmt.Errorf("failed to load dump: %w", err)
+	}
+
+	logger.Infof("removed %d keys, restored %d keys from %s", removed, restored, dir)
+	return nil
+}
+
+func deletePrefixes(db database.Database, prefixes [][]byte) (int, error) {
+	removed := 0
+	for _, prefix := range prefixes {
+		var keys [][]byte
+		iter, err := db.NewIterator(prefix, nil)
+		if err != nil {
+			return removed, err
+		}
+		for iter.Next() {
+			keys = append(keys, append([]byte(nil), iter.Key()...))
+		}
+		err = iter.Error()
+		iter.Close()
+		if err != nil {
+			return removed, err
+		}
+
+		batch := db.NewBatch()
+		for _, key := range keys {
+			if err := batch.Delete(key); err != nil {
+				batch.Close()
+				return removed, err
+			}
+		}
+		err = batch.Commit()
+		batch.Close()
+		if err != nil {
+			return removed, err
+		}
+		removed += len(keys)
+	}
+	return removed, nil
+}
+
+// copyDir copies the regular files of src into the new directory dst.
+func copyDir(src, dst string) error {
+	entries, err := os.ReadDir(src)
+	if err != nil {
+		return err
+	}
+	if err := os.Mkdir(dst, 0o755); err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() {
+			continue
+		}
+		if err := copyFile(filepath.Join(src, entry.Name()), filepath.Join(dst, entry.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Sync(); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
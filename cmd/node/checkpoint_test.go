@@ -0,0 +1,103 @@
+package main
+
+import (
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/New-JAMneration/JAM-Protocol/internal/database"
+	pebbledb "github.com/New-JAMneration/JAM-Protocol/internal/database/provider/pebble"
+	"github.com/New-JAMneration/JAM-Protocol/internal/store"
+	"github.com/New-JAMneration/JAM-Protocol/internal/types"
+	"github.com/New-JAMneration/JAM-Protocol/internal/utilities/hash"
+	m "github.com/New-JAMneration/JAM-Protocol/internal/utilities/merklization"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCheckpointWhileImporting checkpoints a pebble database through the
+// admin socket while blocks keep being persisted, then restores it.
+func TestCheckpointWhileImporting(t *testing.T) {
+	tmp := t.TempDir()
+	db, err := pebbledb.NewDatabase(filepath.Join(tmp, "db"), false)
+	require.NoError(t, err)
+	repo := store.NewRepository(db)
+	_, err = repo.MigrateSchema(db)
+	require.NoError(t, err)
+
+	// Persist blocks the way the node does until stopped.
+	var persisted atomic.Int64
+	stop, done := make(chan struct{}), make(chan error)
+	go func() {
+		var parent types.HeaderHash
+		for slot := types.TimeSlot(1); ; slot++ {
+			select {
+			case <-stop:
+				done <- nil
+				return
+			default:
+			}
+			header := types.Header{Slot: slot, Parent: parent, ParentStateRoot: m.MerklizationSerializedState(fakeState(slot - 1))}
+			headerHash, err := hash.ComputeBlockHeaderHash(header)
+			if err != nil {
+				done <- err
+				return
+			}
+			keyVals := fakeState(slot)
+			root := m.MerklizationSerializedState(keyVals)
+			err = repo.WithSyncBatch(func(batch database.Batch) error {
+				if err := repo.SaveBlockByHash(batch, types.OpaqueHash(headerHash), &types.Block{Header: header}); err != nil {
+					return err
+				}
+				if err := repo.SaveCanonicalHash(batch, headerHash, slot); err != nil {
+					return err
+				}
+				if err := repo.SaveStateData(batch, root, keyVals); err != nil {
+					return err
+				}
+				return repo.SaveStateRootByHeaderHash(batch, headerHash, root)
+			})
+			if err != nil {
+				done <- err
+				return
+			}
+			parent = headerHash
+			persisted.Add(1)
+		}
+	}()
+
+	socket := filepath.Join(tmp, "admin.sock")
+	stopAdmin, err := startAdmin(socket, db.(database.Checkpointer))
+	require.NoError(t, err)
+	defer stopAdmin()
+
+	require.Eventually(t, func() bool { return persisted.Load() >= 20 }, 10*time.Second, time.Millisecond)
+	checkpoint := filepath.Join(tmp, "checkpoint")
+	require.NoError(t, adminCheckpoint(socket, checkpoint))
+	before := persisted.Load()
+	require.Eventually(t, func() bool { return persisted.Load() >= before+20 }, 10*time.Second, time.Millisecond)
+	close(stop)
+	require.NoError(t, <-done)
+	require.NoError(t, db.Close())
+
+	restored := filepath.Join(tmp, "restored")
+	require.NoError(t, restorePebble(checkpoint, restored))
+
+	db, err = pebbledb.NewDatabase(restored, true)
+	require.NoError(t, err)
+	defer db.Close()
+	head, err := store.NewRepository(db).VerifyHead(db)
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, int64(head.Slot), int64(20))
+	require.LessOrEqual(t, int64(head.Slot), before, "the checkpoint ends when it was taken")
+
+	// The offline command checkpoints a read-only database.
+	offline := filepath.Join(tmp, "offline")
+	require.NoError(t, store.Checkpoint(db, offline))
+	copied, err := pebbledb.NewDatabase(offline, true)
+	require.NoError(t, err)
+	defer copied.Close()
+	copiedHead, err := store.NewRepository(copied).VerifyHead(copied)
+	require.NoError(t, err)
+	require.Equal(t, head, copiedHead)
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/New-JAMneration/JAM-Protocol/config"
+	"github.com/New-JAMneration/JAM-Protocol/internal/database"
+	"github.com/New-JAMneration/JAM-Protocol/internal/database/provider/memory"
+	pebbledb "github.com/New-JAMneration/JAM-Protocol/internal/database/provider/pebble"
+	redisdb "github.com/New-JAMneration/JAM-Protocol/internal/database/provider/redis"
+	"github.com/New-JAMneration/JAM-Protocol/internal/store"
+	"github.com/New-JAMneration/JAM-Protocol/internal/types"
+	"github.com/New-JAMneration/JAM-Protocol/internal/utilities"
+	jamtests "github.com/New-JAMneration/JAM-Protocol/jamtests/trace"
+)
+
+// openDatabase opens the node database described by config.Config.Database.
+// An empty dataDir falls back to the configured data directory. The database
+// must use the key schema of this build; see openDatabaseAnySchema.
+func openDatabase(dataDir string, readOnly bool) (database.Database, error) {
+	db, version, err := openDatabaseAnySchema(dataDir, readOnly)
+	if err != nil {
+		return nil, err
+	}
+	if version != 0 && version != store.SchemaVersion {
+		db.Close()
+		return nil, fmt.Errorf("database has schema version %d, want %d: run \"node db migrate\" first", version, store.SchemaVersion)
+	}
+	return db, nil
+}
+
+// openDatabaseAnySchema is like openDatabase but also accepts older key
+// schemas, for commands that work on raw keys or migrate them. Databases
+// written with a newer key schema are refused.
+func openDatabaseAnySchema(dataDir string, readOnly bool) (database.Database, uint32, error) {
+	if dataDir == "" {
+		dataDir = config.Config.Database.DataDir
+	}
+
+	db, err := openProvider(dataDir, readOnly)
+	if err != nil {
+		return nil, 0, err
+	}
+	version, err := store.NewRepository(db).CheckSchema(db)
+	if err != nil {
+		db.Close()
+		return nil, 0, err
+	}
+	return db, version, nil
+}
+
+func openProvider(dataDir string, readOnly bool) (database.Database, error) {
+	dbConfig := config.Config.Database
+	switch dbConfig.Type {
+	case "pebble":
+		syncPolicy, err := pebbledb.ParseSyncPolicy(dbConfig.Sync)
+		if err != nil {
+			return nil, err
+		}
+		db, err := pebbledb.NewDatabaseWithOptions(dataDir, pebbledb.Options{ReadOnly: readOnly, Sync: syncPolicy})
+		if err != nil {
+			return nil, fmt.Errorf("failed to open pebble database at %s: %w", dataDir, err)
+		}
+		return db, nil
+	case "redis":
+		redisConfig := config.Config.Redis
+		return redisdb.NewDatabaseWithOptions(redisdb.Options{
+			Addr:     redisConfig.Address,
+			Password: redisConfig.Password,
+			DB:       redisConfig.Port,
+			Prefixes: store.SchemaPrefixes(),
+		}), nil
+	case "memory":
+		db, err := memory.NewDatabaseWithOptions(memory.Options{Path: dataDir, SaveOnClose: !readOnly && dataDir != ""})
+		if err != nil {
+			return nil, fmt.Errorf("failed to open memory database at %s: %w", dataDir, err)
+		}
+		return db, nil
+	default:
+		return nil, fmt.Errorf("unsupported database type %q", dbConfig.Type)
+	}
+}
+
+// loadStateAtHeader returns the posterior state of the block with headerHash.
+func loadStateAtHeader(repo *store.Repository, db database.Database, headerHash types.HeaderHash) (types.StateKeyVals, error) {
+	stateRoot, err := repo.StateRootAt(db, headerHash)
+	if err != nil {
+		return nil, err
+	}
+	return repo.GetStateData(db, stateRoot)
+}
+
+// loadTraceState reads the state stored in a trace .bin file: the genesis
+// state for genesis.bin, otherwise the post-state (or pre-state when pre is set).
+func loadTraceState(path string, pre bool) (types.StateKeyVals, error) {
+	if filepath.Base(path) == "genesis.bin" {
+		genesis := &jamtests.Genesis{}
+		if err := utilities.GetTestFromBin(path, genesis); err != nil {
+			return nil, err
+		}
+		return genesis.State.KeyVals, nil
+	}
+
+	step := &jamtests.TraceTestCase{}
+	if err := utilities.GetTestFromBin(path, step); err != nil {
+		return nil, err
+	}
+	if pre {
+		return step.PreState.KeyVals, nil
+	}
+	return step.PostState.KeyVals, nil
+}
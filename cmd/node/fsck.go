@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/New-JAMneration/JAM-Protocol/config"
+	"github.com/New-JAMneration/JAM-Protocol/internal/blockchain"
+	"github.com/New-JAMneration/JAM-Protocol/internal/stf"
+	"github.com/New-JAMneration/JAM-Protocol/internal/store"
+	"github.com/New-JAMneration/JAM-Protocol/internal/types"
+	m "github.com/New-JAMneration/JAM-Protocol/internal/utilities/merklization"
+	"github.com/New-JAMneration/JAM-Protocol/logger"
+	"github.com/urfave/cli/v3"
+)
+
+var (
+	fsckDataDir string
+	fsckRepair  bool
+	fsckReplay  bool
+)
+
+var fsckCmd = &cli.Command{
+	Name:  "fsck",
+	Usage: "Verify every stored state against its state root",
+	Description: `Check the node database for consistency:
+  - every stored state must merklize to the root it is keyed by
+  - every block's ParentStateRoot must match its parent's recorded root
+  - dangling index entries and unreferenced states are reported as orphans
+For example:
+  go run ./cmd/node fsck
+  go run ./cmd/node fsck --replay
+  go run ./cmd/node fsck --repair --datadir ./data/pebble`,
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:        "datadir",
+			Usage:       "Database directory (defaults to database.data_dir from the config)",
+			Destination: &fsckDataDir,
+		},
+		&cli.BoolFlag{
+			Name:        "repair",
+			Usage:       "Drop orphaned entries found during the check",
+			Destination: &fsckRepair,
+		},
+		&cli.BoolFlag{
+			Name:        "replay",
+			Usage:       "Re-run the STF from each parent state and compare the resulting root",
+			Destination: &fsckReplay,
+		},
+	},
+	Action: func(ctx context.Context, c *cli.Command) error {
+		config.InitConfig(configPath, mode)
+
+		db, err := openDatabase(fsckDataDir, !fsckRepair)
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+
+		opts := store.FsckOptions{Repair: fsckRepair}
+		if fsckReplay {
+			opts.Replay = replayBlock
+		}
+
+		report, err := store.NewRepository(db).Fsck(db, opts)
+		if err != nil {
+			return err
+		}
+
+		for _, issue := range report.Issues {
+			logger.Errorf("%s", issue)
+		}
+		logger.Info("----------------------------------------")
+		logger.Infof("Blocks: %d, States: %d, Replayed: %d, Issues: %d, Repaired: %d",
+			report.BlocksChecked, report.StatesChecked, report.Replayed, len(report.Issues), report.Repaired)
+
+		if !report.OK() && report.Repaired < len(report.Issues) {
+			return fmt.Errorf("fsck found %d issue(s)", len(report.Issues)-report.Repaired)
+		}
+		return nil
+	},
+}
+
+// replayBlock imports block on top of parentState with a fresh ChainState and
+// returns the posterior state root. The ChainState is kept in memory so it
+// never opens (or writes to) the database being checked.
+func replayBlock(parentState types.StateKeyVals, block *types.Block) (types.StateRoot, error) {
+	state, unmatchedKeyVals, err := m.StateKeyValsToState(parentState)
+	if err != nil {
+		return types.StateRoot{}, fmt.Errorf("failed to parse parent state: %w", err)
+	}
+
+	blockchain.ClearVerifierCache()
+	blockchain.ResetInstanceWithOptions(blockchain.Options{InMemory: true})
+	cs := blockchain.GetInstance()
+	cs.GetPriorStates().SetState(state)
+	cs.SetPriorStateUnmatchedKeyVals(unmatchedKeyVals)
+	cs.SetPostStateUnmatchedKeyVals(unmatchedKeyVals.DeepCopy())
+	cs.AddBlock(*block)
+
+	if _, err := stf.RunSTF(); err != nil {
+		return types.StateRoot{}, err
+	}
+
+	serializedState, err := m.StateEncoder(cs.GetPosteriorStates().GetState())
+	if err != nil {
+		return types.StateRoot{}, fmt.Errorf("failed to encode posterior state: %w", err)
+	}
+	postUnmatchedKeyVals := cs.GetPostStateUnmatchedKeyValsRef()
+	fullStateKeyVals := make(types.StateKeyVals, 0, len(postUnmatchedKeyVals)+len(serializedState))
+	fullStateKeyVals = append(fullStateKeyVals, postUnmatchedKeyVals...)
+	fullStateKeyVals = append(fullStateKeyVals, serializedState...)
+
+	return m.MerklizationSerializedState(fullStateKeyVals), nil
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/New-JAMneration/JAM-Protocol/config"
+	"github.com/New-JAMneration/JAM-Protocol/internal/database"
+	"github.com/New-JAMneration/JAM-Protocol/internal/stateview"
+	"github.com/New-JAMneration/JAM-Protocol/internal/store"
+	"github.com/urfave/cli/v3"
+)
+
+var (
+	inspectPrefix string
+	inspectStart  string
+	inspectLimit  int
+)
+
+var inspectCmd = &cli.Command{
+	Name:  "inspect",
+	Usage: "Inspect database keys and values (read-only)",
+	Description: `Inspect the node database without modifying it. The database is opened read-only,
+so a checkpoint of a live node can be inspected with --datadir.
+For example:
+  go run ./cmd/node db inspect stats
+  go run ./cmd/node db inspect keys --prefix h: --limit 10
+  go run ./cmd/node db inspect get sr:0x1234...`,
+	Commands: []*cli.Command{
+		inspectStatsCmd,
+		inspectKeysCmd,
+		inspectGetCmd,
+	},
+}
+
+var inspectStatsCmd = &cli.Command{
+	Name:  "stats",
+	Usage: "Print key counts and sizes per key prefix",
+	Flags: []cli.Flag{dbDataDirFlag()},
+	Action: func(ctx context.Context, c *cli.Command) error {
+		config.InitConfig(configPath, mode)
+
+		db, _, err := openDatabaseAnySchema(dbDataDir, true)
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+
+		stats, err := store.Stats(db)
+		if err != nil {
+			return err
+		}
+
+		tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', tabwriter.AlignRight)
+		fmt.Fprintln(tw, "PREFIX\tKIND\tKEYS\tKEY BYTES\tVALUE BYTES\t")
+		var total store.PrefixStats
+		for _, s := range stats {
+			fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%d\t\n", s.Prefix, s.Kind, s.Keys, s.KeyBytes, s.ValueBytes)
+			total.Keys += s.Keys
+			total.KeyBytes += s.KeyBytes
+			total.ValueBytes += s.ValueBytes
+		}
+		fmt.Fprintf(tw, "total\t\t%d\t%d\t%d\t\n", total.Keys, total.KeyBytes, total.ValueBytes)
+		return tw.Flush()
+	},
+}
+
+var inspectKeysCmd = &cli.Command{
+	Name:  "keys",
+	Usage: "List keys with their decoded slot and hash components",
+	Flags: []cli.Flag{
+		dbDataDirFlag(),
+		&cli.StringFlag{
+			Name:        "prefix",
+			Usage:       "Only list keys starting with this prefix (text, or 0x-prefixed hex)",
+			Destination: &inspectPrefix,
+		},
+		&cli.StringFlag{
+			Name:        "start",
+			Usage:       "Start listing at this key (same format as get)",
+			Destination: &inspectStart,
+		},
+		&cli.IntFlag{
+			Name:        "limit",
+			Usage:       "Maximum number of keys to list (0 = all)",
+			Value:       100,
+			Destination: &inspectLimit,
+		},
+	},
+	Action: func(ctx context.Context, c *cli.Command) error {
+		config.InitConfig(configPath, mode)
+
+		prefix, err := parseInspectKey(inspectPrefix)
+		if err != nil {
+			return err
+		}
+		start, err := parseInspectKey(inspectStart)
+		if err != nil {
+			return err
+		}
+		if len(start) > 0 && !strings.HasPrefix(string(start), string(prefix)) {
+			return fmt.Errorf("--start must begin with --prefix")
+		}
+
+		db, _, err := openDatabaseAnySchema(dbDataDir, true)
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+
+		opts := database.IteratorOptions{Prefix: prefix}
+		if len(start) > 0 {
+			opts.Start = start[len(prefix):]
+		}
+		iter, err := db.NewIteratorWithOptions(opts)
+		if err != nil {
+			return err
+		}
+		defer iter.Close()
+
+		tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "KEY\tKIND\tSLOT\tHASH\tSIZE")
+		listed := 0
+		for (inspectLimit == 0 || listed < inspectLimit) && iter.Next() {
+			info := store.DescribeKey(iter.Key())
+			slot, hash := "-", "-"
+			if info.Slot != nil {
+				slot = fmt.Sprint(*info.Slot)
+			}
+			if info.Hash != nil {
+				hash = "0x" + hex.EncodeToString(info.Hash[:])
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%d\n", info, info.Kind, slot, hash, len(iter.Value()))
+			listed++
+		}
+		if err := iter.Error(); err != nil {
+			return err
+		}
+		return tw.Flush()
+	},
+}
+
+var inspectGetCmd = &cli.Command{
+	Name:      "get",
+	Usage:     "Decode the value stored under a key as JSON",
+	ArgsUsage: "<key>",
+	Description: `Keys are written as a schema prefix followed by 0x-prefixed hex, as printed by
+"inspect keys" (e.g. sr:0x1234...), or entirely as 0x-prefixed hex.`,
+	Flags: []cli.Flag{dbDataDirFlag()},
+	Action: func(ctx context.Context, c *cli.Command) error {
+		config.InitConfig(configPath, mode)
+
+		if c.Args().Len() != 1 {
+			return fmt.Errorf("expected exactly one key")
+		}
+		key, err := parseInspectKey(c.Args().First())
+		if err != nil {
+			return err
+		}
+
+		db, _, err := openDatabaseAnySchema(dbDataDir, true)
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+
+		value, found, err := db.Get(key)
+		if err != nil {
+			return err
+		}
+		if !found {
+			return fmt.Errorf("key %s not found", store.DescribeKey(key))
+		}
+
+		decoded, err := store.NewRepository(db).DecodeValue(key, value)
+		if err != nil {
+			return err
+		}
+
+		info := store.DescribeKey(key)
+		out := map[string]any{
+			"key":   info.String(),
+			"kind":  info.Kind,
+			"size":  len(value),
+			"value": stateview.JSONValue(decoded),
+		}
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(out)
+	},
+}
+
+// parseInspectKey accepts "0x<hex>", "<text prefix>0x<hex>" or plain text.
+func parseInspectKey(s string) ([]byte, error) {
+	i := strings.Index(s, "0x")
+	if i < 0 {
+		return []byte(s), nil
+	}
+	decoded, err := hex.DecodeString(s[i+2:])
+	if err != nil {
+		return nil, fmt.Errorf("invalid key %q: %w", s, err)
+	}
+	return append([]byte(s[:i]), decoded...), nil
+}
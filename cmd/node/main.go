@@ -3,9 +3,13 @@ package main
 import (
 	"context"
 	"encoding/hex"
+	"fmt"
 	"log"
 	"os"
+	"os/signal"
+	"syscall"
 
+	"github.com/New-JAMneration/JAM-Protocol/PVM"
 	"github.com/New-JAMneration/JAM-Protocol/config"
 	"github.com/New-JAMneration/JAM-Protocol/internal/blockchain"
 	"github.com/New-JAMneration/JAM-Protocol/internal/telemetry"
@@ -53,10 +57,24 @@ var cmd = &cli.Command{
 			Value:       "",
 			Destination: &telemetryEndpoint,
 		},
+		&cli.StringFlag{
+			Name:        "admin",
+			Usage:       "Unix socket to serve admin requests (db checkpoint) on; empty = disabled",
+			Destination: &adminSocket,
+		},
 	},
 	Commands: []*cli.Command{
 		exampleCmd,
 		testCmd,
+		fsckCmd,
+		exportCmd,
+		importCmd,
+		stateCmd,
+		serviceCmd,
+		queryCmd,
+		dbCmd,
+		snapshotCmd,
+		pvmCmd,
 	},
 }
 
@@ -73,13 +91,22 @@ func init() {
 		// Ref: https://go.dev/blog/container-aware-gomaxprocs
 		// This is needed until upgrading Go version 1.25 or higher.
 		maxprocs.Set()
+
+		// Every command running the PVM charges gas as the configured
+		// protocol version does; set it before any program is deblobbed.
+		if err := config.InitConfig(configPath, mode); err != nil {
+			return ctx, err
+		}
+		gasModel, err := PVM.GasModelForVersion(config.Config.Info.JamVersion)
+		if err != nil {
+			return ctx, fmt.Errorf("gas model: %w", err)
+		}
+		PVM.SetGasModel(gasModel)
 		return ctx, nil
 	}
 }
 
 func node(ctx context.Context, cmd *cli.Command) error {
-	config.InitConfig(configPath, mode)
-
 	// JIP-3 telemetry. Empty --telemetry endpoint disables (no-op client).
 	// TODO(jip3-main-loop): once cmd/node has a main loop (Q1 in the #775
 	// planning comment), the client will live for the node's lifetime;
@@ -103,6 +130,19 @@ func node(ctx context.Context, cmd *cli.Command) error {
 	}
 
 	SetupJAMProtocol(chainPath)
+	if adminSocket == "" {
+		return nil
+	}
+
+	// Until the node has a main loop it only stays up to serve admin requests.
+	stopAdmin, err := startAdmin(adminSocket, blockchain.GetInstance())
+	if err != nil {
+		return err
+	}
+	defer stopAdmin()
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	<-ctx.Done()
 	return nil
 }
 
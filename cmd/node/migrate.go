@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+
+	"github.com/New-JAMneration/JAM-Protocol/config"
+	"github.com/New-JAMneration/JAM-Protocol/internal/store"
+	"github.com/New-JAMneration/JAM-Protocol/logger"
+	"github.com/urfave/cli/v3"
+)
+
+var migrateCmd = &cli.Command{
+	Name:  "migrate",
+	Usage: "Upgrade the database to the current key schema",
+	Description: `Upgrade the data dir in place to the key schema of this build. The node does the
+same on startup; running it explicitly lets operators migrate (and checkpoint first)
+while the node is stopped. Data dirs written by a newer build are refused.
+For example:
+  go run ./cmd/node db migrate`,
+	Flags: []cli.Flag{dbDataDirFlag()},
+	Action: func(ctx context.Context, c *cli.Command) error {
+		config.InitConfig(configPath, mode)
+
+		db, _, err := openDatabaseAnySchema(dbDataDir, false)
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+
+		from, err := store.NewRepository(db).MigrateSchema(db)
+		if err != nil {
+			return err
+		}
+		switch from {
+		case 0:
+			logger.Infof("empty database stamped with schema version %d", store.SchemaVersion)
+		case store.SchemaVersion:
+			logger.Infof("database already at schema version %d", store.SchemaVersion)
+		default:
+			logger.Infof("migrated database from schema version %d to %d", from, store.SchemaVersion)
+		}
+		return nil
+	},
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/New-JAMneration/JAM-Protocol/PVM"
+	"github.com/New-JAMneration/JAM-Protocol/internal/service_account"
+	"github.com/urfave/cli/v3"
+)
+
+var (
+	pvmDisasmInput      string
+	pvmDisasmJamVersion string
+)
+
+var pvmCmd = &cli.Command{
+	Name:  "pvm",
+	Usage: "Inspect PVM programs",
+	Commands: []*cli.Command{
+		pvmDisasmCmd,
+	},
+}
+
+var pvmDisasmCmd = &cli.Command{
+	Name:      "disasm",
+	Usage:     "Disassemble a PVM program blob",
+	ArgsUsage: "<file>",
+	Description: `Decode a program and print its memory layout, jump table, basic blocks and
+instructions, followed by the problems found: invalid opcodes, jumps to pcs that do
+not start a basic block and bitmask inconsistencies. <file> holds the blob either
+raw or as 0x-prefixed hex. --input selects what the blob is:
+  service   metadata and a standard program, as stored as a service's code (default)
+  standard  a standard program: RO/RW data, heap and stack sizes and code
+  program   the code blob only: jump table, code and bitmask
+For example:
+  go run ./cmd/node pvm disasm service.bin
+  go run ./cmd/node pvm disasm --input program --jam-version 0.8.0 code.hex`,
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:        "input",
+			Usage:       "Blob format: service, standard or program",
+			Value:       "service",
+			Destination: &pvmDisasmInput,
+		},
+		&cli.StringFlag{
+			Name:        "jam-version",
+			Usage:       "Protocol version whose gas model prices the basic blocks (default: 0.7)",
+			Destination: &pvmDisasmJamVersion,
+		},
+	},
+	Action: func(ctx context.Context, c *cli.Command) error {
+		if c.Args().Len() != 1 {
+			return fmt.Errorf("expected exactly one program file")
+		}
+		if pvmDisasmJamVersion != "" {
+			model, err := PVM.GasModelForVersion(pvmDisasmJamVersion)
+			if err != nil {
+				return err
+			}
+			PVM.SetGasModel(model)
+		}
+
+		blob, err := readBlobFile(c.Args().First())
+		if err != nil {
+			return err
+		}
+
+		out := os.Stdout
+		code := blob
+		switch pvmDisasmInput {
+		case "service":
+			metadata, standard, err := service_account.DecodeMetaCode(blob)
+			if err != nil {
+				return fmt.Errorf("decode metadata and code: %w", err)
+			}
+			fmt.Fprintf(out, "metadata: %d bytes %s\n", len(metadata), formatMetadata(metadata))
+			if code, err = printStandardProgram(out, standard); err != nil {
+				return err
+			}
+		case "standard":
+			if code, err = printStandardProgram(out, blob); err != nil {
+				return err
+			}
+		case "program":
+		default:
+			return fmt.Errorf("unknown --input %q, want service, standard or program", pvmDisasmInput)
+		}
+
+		d, err := PVM.Disassemble(code)
+		if err != nil {
+			return fmt.Errorf("decode program blob: %w", err)
+		}
+		printDisassembly(out, d)
+		return nil
+	},
+}
+
+// readBlobFile reads a file holding a blob raw or as 0x-prefixed hex.
+func readBlobFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if text := bytes.TrimSpace(data); bytes.HasPrefix(text, []byte("0x")) {
+		decoded, err := hex.DecodeString(string(text[2:]))
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid hex: %w", path, err)
+		}
+		return decoded, nil
+	}
+	return data, nil
+}
+
+// formatMetadata quotes metadata that is printable text and hex-encodes it
+// otherwise.
+func formatMetadata(metadata []byte) string {
+	for _, b := range metadata {
+		if b < 0x20 || b > 0x7e {
+			return "0x" + hex.EncodeToString(metadata)
+		}
+	}
+	return fmt.Sprintf("%q", metadata)
+}
+
+func printStandardProgram(out io.Writer, blob []byte) ([]byte, error) {
+	p, err := PVM.DecodeStandardProgram(blob)
+	if err != nil {
+		return nil, fmt.Errorf("decode standard program: %w", err)
+	}
+
+	layout := p.Layout
+	fmt.Fprintln(out, "memory layout:")
+	tw := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "  SEGMENT\tSTART\tEND\tPADDED\tSIZE")
+	segment := func(name string, s PVM.MemorySegment, size string) {
+		fmt.Fprintf(tw, "  %s\t0x%08x\t0x%08x\t0x%08x\t%s\n", name, s.Start, s.End, s.Padded, size)
+	}
+	segment("ro data", layout.ReadOnly, fmt.Sprintf("%d bytes", len(p.ReadOnlyData)))
+	segment("rw data", layout.ReadWrite, fmt.Sprintf("%d bytes + %d heap pages", len(p.ReadWriteData), p.HeapPages))
+	segment("heap", PVM.MemorySegment{Start: layout.HeapStart, End: layout.HeapStart, Padded: layout.Stack.Start}, "grows through sbrk")
+	segment("stack", layout.Stack, fmt.Sprintf("%d bytes", p.StackSize))
+	segment("arguments", layout.Arguments, "")
+	if err := tw.Flush(); err != nil {
+		return nil, err
+	}
+	return p.Code, nil
+}
+
+func printDisassembly(out io.Writer, d *PVM.Disassembly) {
+	fmt.Fprintf(out, "code: %d bytes, %d instructions, %d basic blocks\n", d.CodeSize, len(d.Instrs), len(d.Blocks))
+
+	fmt.Fprintf(out, "jump table: %d entries\n", len(d.JumpTable))
+	for i, dest := range d.JumpTable {
+		fmt.Fprintf(out, "  [%d] @%d\n", i, dest)
+	}
+
+	issuesAt := map[PVM.ProgramCounter][]string{}
+	for _, issue := range d.Issues {
+		issuesAt[issue.PC] = append(issuesAt[issue.PC], issue.Message)
+	}
+
+	blockAt := map[int]PVM.BlockMeta{}
+	for _, block := range d.Blocks {
+		blockAt[block.InstrStart] = block
+	}
+	for i := range d.Instrs {
+		if block, ok := blockAt[i]; ok {
+			fmt.Fprintf(out, "\nblock @%d: %d instructions, gas %d\n", block.StartPC, block.InstrCount(), block.GasCost)
+		}
+		instr := &d.Instrs[i]
+		name := PVM.OpcodeName(instr.Opcode)
+		if name == "" {
+			name = fmt.Sprintf("invalid(%d)", instr.Opcode)
+		}
+		line := fmt.Sprintf("  %6d: %-24s %s", instr.PC, name, PVM.FormatOperands(instr))
+		if issues := issuesAt[instr.PC]; len(issues) > 0 {
+			line += "  ; " + strings.Join(issues, "; ")
+		}
+		fmt.Fprintln(out, strings.TrimRight(line, " "))
+	}
+
+	fmt.Fprintf(out, "\n%d issues\n", len(d.Issues))
+	for _, issue := range d.Issues {
+		fmt.Fprintf(out, "  @%d: %s\n", issue.PC, issue.Message)
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/New-JAMneration/JAM-Protocol/PVM"
+	"github.com/New-JAMneration/JAM-Protocol/internal/types"
+)
+
+// startPVMTrace makes every PVM invocation write its trace as JSON Lines to
+// path, starting with a record of its service and entry point. The records
+// of an invocation are buffered, and the invocations of a slot are written
+// sorted by service id once an invocation of another slot starts, so the
+// file does not depend on the order concurrent accumulations run in. The
+// returned function writes what is left, stops tracing and closes the file.
+func startPVMTrace(path string) (func() error, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+
+	trace := &pvmTraceFile{file: file}
+	PVM.TraceInvocations(trace.start)
+
+	return func() error {
+		PVM.TraceInvocations(nil)
+		trace.mu.Lock()
+		defer trace.mu.Unlock()
+		trace.writePending()
+		return errors.Join(trace.err, file.Close())
+	}, nil
+}
+
+// pvmTraceFile collects the invocations of one slot before writing them.
+type pvmTraceFile struct {
+	mu      sync.Mutex
+	file    *os.File
+	err     error
+	slot    types.TimeSlot
+	pending []*invocationTrace
+}
+
+func (f *pvmTraceFile) start(start *PVM.TraceStart) PVM.Tracer {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	// Blocks are imported one at a time, so the invocations of the previous
+	// slot have all ended.
+	if start.Slot != f.slot {
+		f.writePending()
+		f.slot = start.Slot
+	}
+
+	trace := &invocationTrace{}
+	if start.Service != nil {
+		service := *start.Service
+		trace.service = &service
+	}
+	trace.JSONTracer = PVM.NewJSONTracer(&trace.records)
+	trace.Start(start)
+	f.pending = append(f.pending, trace)
+	return trace
+}
+
+// writePending writes the pending invocations ordered by service id, the
+// is-authorized ones without a service first. Invocations of the same
+// service run one after another and keep their order.
+func (f *pvmTraceFile) writePending() {
+	sort.SliceStable(f.pending, func(i, j int) bool {
+		a, b := f.pending[i].service, f.pending[j].service
+		return b != nil && (a == nil || *a < *b)
+	})
+	for _, trace := range f.pending {
+		if f.err == nil {
+			_, f.err = f.file.Write(trace.records.Bytes())
+		}
+	}
+	f.pending = nil
+}
+
+// invocationTrace buffers the trace of one invocation.
+type invocationTrace struct {
+	*PVM.JSONTracer
+	records bytes.Buffer
+	service *types.ServiceID
+}
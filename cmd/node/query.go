@@ -0,0 +1,240 @@
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/New-JAMneration/JAM-Protocol/config"
+	"github.com/New-JAMneration/JAM-Protocol/internal/database"
+	"github.com/New-JAMneration/JAM-Protocol/internal/stateview"
+	"github.com/New-JAMneration/JAM-Protocol/internal/store"
+	"github.com/New-JAMneration/JAM-Protocol/internal/types"
+	"github.com/New-JAMneration/JAM-Protocol/internal/utilities"
+	m "github.com/New-JAMneration/JAM-Protocol/internal/utilities/merklization"
+	"github.com/urfave/cli/v3"
+)
+
+var (
+	queryKey       string
+	queryComponent string
+	queryService   uint64
+	queryEnd       string
+	queryFrom      uint64
+	queryTo        uint64
+)
+
+// queryKeyFlags select the state key a query is about.
+func queryKeyFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:        "key",
+			Usage:       "Raw 31-byte state key (hex)",
+			Destination: &queryKey,
+		},
+		&cli.StringFlag{
+			Name:        "component",
+			Usage:       "State component C(i) by name (" + strings.Join(stateview.Components[:len(stateview.Components)-1], ", ") + ")",
+			Destination: &queryComponent,
+		},
+		&cli.Uint64Flag{
+			Name:        "service",
+			Usage:       "ServiceInfo key C(255, s) of this service",
+			Destination: &queryService,
+		},
+	}
+}
+
+var queryCmd = &cli.Command{
+	Name:  "query",
+	Usage: "Query historical states stored in the node database",
+	Description: `Read single keys, key ranges or the history of a key from the stored states without
+decoding whole states. Blocks are selected by --header or by --slot through the
+canonical slot index.
+For example:
+  go run ./cmd/node query get --slot 12 --component pi
+  go run ./cmd/node query range --slot 12 --key 0x00.. --end 0x10..
+  go run ./cmd/node query history --service 0 --from 1 --to 100`,
+	Commands: []*cli.Command{
+		{
+			Name:  "get",
+			Usage: "Print the value of one key at a block",
+			Flags: append([]cli.Flag{
+				&cli.StringFlag{Name: "datadir", Usage: "Database directory", Destination: &stateDataDir},
+				&cli.StringFlag{Name: "header", Usage: "Header hash of the block", Destination: &stateHeader},
+				&cli.Uint64Flag{Name: "slot", Usage: "Slot of the canonical block", Destination: &stateSlot},
+			}, queryKeyFlags()...),
+			Action: func(ctx context.Context, c *cli.Command) error {
+				return withArchive(func(repo *store.Repository, db database.Database) error {
+					key, err := queryStateKey(c)
+					if err != nil {
+						return err
+					}
+					stateRoot, err := queryStateRoot(c, repo, db)
+					if err != nil {
+						return err
+					}
+					value, found, err := repo.GetStateValue(db, stateRoot, key)
+					if err != nil {
+						return err
+					}
+					if !found {
+						return fmt.Errorf("key 0x%x not found in state 0x%x", key, stateRoot)
+					}
+					fmt.Printf("0x%x\n", value)
+					return nil
+				})
+			},
+		},
+		{
+			Name:  "range",
+			Usage: "Print the keys in [key, end) at a block",
+			Flags: []cli.Flag{
+				&cli.StringFlag{Name: "datadir", Usage: "Database directory", Destination: &stateDataDir},
+				&cli.StringFlag{Name: "header", Usage: "Header hash of the block", Destination: &stateHeader},
+				&cli.Uint64Flag{Name: "slot", Usage: "Slot of the canonical block", Destination: &stateSlot},
+				&cli.StringFlag{Name: "key", Usage: "First state key (hex, defaults to the lowest key)", Destination: &queryKey},
+				&cli.StringFlag{Name: "end", Usage: "State key bounding the range (hex, exclusive; defaults to open)", Destination: &queryEnd},
+			},
+			Action: func(ctx context.Context, c *cli.Command) error {
+				return withArchive(func(repo *store.Repository, db database.Database) error {
+					var start, end types.StateKey
+					var err error
+					if queryKey != "" {
+						if start, err = parseStateKey(queryKey); err != nil {
+							return err
+						}
+					}
+					if queryEnd != "" {
+						if end, err = parseStateKey(queryEnd); err != nil {
+							return err
+						}
+					}
+					stateRoot, err := queryStateRoot(c, repo, db)
+					if err != nil {
+						return err
+					}
+					keyVals, err := repo.GetStateRange(db, stateRoot, start, end)
+					if err != nil {
+						return err
+					}
+
+					tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+					fmt.Fprintln(tw, "KEY\tSIZE\tVALUE")
+					for _, kv := range keyVals {
+						fmt.Fprintf(tw, "0x%x\t%d\t%s\n", kv.Key, len(kv.Value), shortHex(kv.Value))
+					}
+					return tw.Flush()
+				})
+			},
+		},
+		{
+			Name:  "history",
+			Usage: "Print the value of one key at every canonical block in a slot range",
+			Flags: append([]cli.Flag{
+				&cli.StringFlag{Name: "datadir", Usage: "Database directory", Destination: &stateDataDir},
+				&cli.Uint64Flag{Name: "from", Usage: "First slot", Destination: &queryFrom},
+				&cli.Uint64Flag{Name: "to", Usage: "Last slot", Destination: &queryTo, Required: true},
+				&cli.BoolFlag{Name: "full", Usage: "Print full values instead of a prefix"},
+			}, queryKeyFlags()...),
+			Action: func(ctx context.Context, c *cli.Command) error {
+				return withArchive(func(repo *store.Repository, db database.Database) error {
+					key, err := queryStateKey(c)
+					if err != nil {
+						return err
+					}
+					history, err := repo.GetStateValueHistory(db, key, types.TimeSlot(queryFrom), types.TimeSlot(queryTo))
+					if err != nil {
+						return err
+					}
+
+					tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+					fmt.Fprintln(tw, "SLOT\tHEADER\tSTATE ROOT\tSIZE\tVALUE")
+					for _, entry := range history {
+						value := "-"
+						if entry.Found {
+							value = shortHex(entry.Value)
+							if c.Bool("full") {
+								value = "0x" + hex.EncodeToString(entry.Value)
+							}
+						}
+						fmt.Fprintf(tw, "%d\t0x%x\t0x%x\t%d\t%s\n",
+							entry.Slot, entry.HeaderHash[:8], entry.StateRoot[:8], len(entry.Value), value)
+					}
+					return tw.Flush()
+				})
+			},
+		},
+	},
+}
+
+func withArchive(fn func(repo *store.Repository, db database.Database) error) error {
+	config.InitConfig(configPath, mode)
+
+	db, err := openDatabase(stateDataDir, true)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	return fn(store.NewRepository(db), db)
+}
+
+// queryStateRoot returns the posterior state root of the block chosen by --header or --slot.
+func queryStateRoot(c *cli.Command, repo *store.Repository, db database.Database) (types.StateRoot, error) {
+	if c.IsSet("header") == c.IsSet("slot") {
+		return types.StateRoot{}, fmt.Errorf("exactly one of --header or --slot is required")
+	}
+
+	var headerHash types.HeaderHash
+	if c.IsSet("slot") {
+		var err error
+		if headerHash, err = repo.ResolveSlot(db, types.TimeSlot(stateSlot)); err != nil {
+			return types.StateRoot{}, err
+		}
+	} else {
+		hash, err := utilities.HexToOpaqueHash(stateHeader)
+		if err != nil {
+			return types.StateRoot{}, err
+		}
+		headerHash = types.HeaderHash(hash)
+	}
+	return repo.StateRootAt(db, headerHash)
+}
+
+// queryStateKey returns the state key chosen by --key, --component or --service.
+func queryStateKey(c *cli.Command) (types.StateKey, error) {
+	switch {
+	case c.IsSet("key"):
+		return parseStateKey(queryKey)
+	case c.IsSet("component"):
+		return stateview.ComponentKey(queryComponent)
+	case c.IsSet("service"):
+		wrapper := m.StateServiceWrapper{StateIndex: 255, ServiceIndex: types.ServiceID(queryService)}
+		return wrapper.StateKeyConstruct(), nil
+	}
+	return types.StateKey{}, fmt.Errorf("one of --key, --component or --service is required")
+}
+
+func parseStateKey(s string) (types.StateKey, error) {
+	data, err := hex.DecodeString(strings.TrimPrefix(s, "0x"))
+	if err != nil {
+		return types.StateKey{}, fmt.Errorf("invalid state key %q: %w", s, err)
+	}
+	var key types.StateKey
+	if len(data) != len(key) {
+		return types.StateKey{}, fmt.Errorf("state key must be %d bytes, got %d", len(key), len(data))
+	}
+	copy(key[:], data)
+	return key, nil
+}
+
+func shortHex(value []byte) string {
+	if len(value) > 32 {
+		return fmt.Sprintf("0x%x...", value[:32])
+	}
+	return fmt.Sprintf("0x%x", value)
+}
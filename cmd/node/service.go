@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/New-JAMneration/JAM-Protocol/config"
+	"github.com/New-JAMneration/JAM-Protocol/internal/stateview"
+	"github.com/New-JAMneration/JAM-Protocol/internal/types"
+	"github.com/urfave/cli/v3"
+)
+
+var serviceNow uint64
+
+var serviceCmd = &cli.Command{
+	Name:      "service",
+	Usage:     "Explore a service account: info, code, preimages and storage footprint",
+	ArgsUsage: "<service-id>",
+	Description: `Load a state like "node state" does and print everything known about one service:
+its ServiceInfo, code hash and metadata, each preimage with its lookup timeslots and
+status, the storage footprint against the minimum balance, and the keys that cannot be
+attributed because only their hash is part of the state key.
+For example:
+  go run ./cmd/node service --header 0x1234... 0
+  go run ./cmd/node service --trace traces/storage/00000010.bin --format json 0`,
+	Flags: append(stateSourceFlags(),
+		&cli.Uint64Flag{
+			Name:        "now",
+			Usage:       "Slot used to decide whether forgotten preimages can be expunged (defaults to tau)",
+			Destination: &serviceNow,
+		},
+	),
+	Action: func(ctx context.Context, c *cli.Command) error {
+		config.InitConfig(configPath, mode)
+
+		if c.Args().Len() != 1 {
+			return fmt.Errorf("expected exactly one service ID")
+		}
+		id, err := strconv.ParseUint(c.Args().First(), 10, 32)
+		if err != nil {
+			return fmt.Errorf("invalid service ID %q: %w", c.Args().First(), err)
+		}
+
+		keyVals, err := loadStateFromFlags(c)
+		if err != nil {
+			return err
+		}
+
+		now := types.TimeSlot(serviceNow)
+		if !c.IsSet("now") {
+			view, err := stateview.New(keyVals, stateview.Filter{Components: []string{stateview.Tau}})
+			if err != nil {
+				return err
+			}
+			now = view.Components[0].Value.(types.TimeSlot)
+		}
+
+		detail, err := stateview.NewServiceDetail(keyVals, types.ServiceID(id), now)
+		if err != nil {
+			return err
+		}
+
+		switch stateFormat {
+		case "json":
+			return detail.WriteJSON(os.Stdout)
+		case "table":
+			return detail.WriteTable(os.Stdout)
+		default:
+			return fmt.Errorf("unsupported format %q", stateFormat)
+		}
+	},
+}
@@ -0,0 +1,288 @@
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/New-JAMneration/JAM-Protocol/config"
+	"github.com/New-JAMneration/JAM-Protocol/internal/blockchain"
+	"github.com/New-JAMneration/JAM-Protocol/internal/database"
+	"github.com/New-JAMneration/JAM-Protocol/internal/snapshot"
+	"github.com/New-JAMneration/JAM-Protocol/internal/store"
+	"github.com/New-JAMneration/JAM-Protocol/internal/types"
+	"github.com/New-JAMneration/JAM-Protocol/internal/utilities/hash"
+	m "github.com/New-JAMneration/JAM-Protocol/internal/utilities/merklization"
+	"github.com/New-JAMneration/JAM-Protocol/logger"
+	"github.com/urfave/cli/v3"
+)
+
+var (
+	snapshotDataDir    string
+	snapshotSlot       uint64
+	snapshotChunkSize  int
+	snapshotHeaderHash string
+	snapshotTrustFile  bool
+)
+
+var snapshotCmd = &cli.Command{
+	Name:  "snapshot",
+	Usage: "Create and load state snapshot files",
+	Commands: []*cli.Command{
+		snapshotCreateCmd,
+		snapshotLoadCmd,
+	},
+}
+
+var snapshotCreateCmd = &cli.Command{
+	Name:      "create",
+	Usage:     "Write a block header, its ancestry and its state into a snapshot file",
+	ArgsUsage: "<file>",
+	Description: `Write the canonical block at --slot (default: the head), up to MaxLookupAge of its
+canonical ancestors and its full posterior state into <file>. The state is split into
+checksummed chunks listed in a manifest at the end of the file.
+For example:
+  go run ./cmd/node snapshot create head.snap
+  go run ./cmd/node snapshot create --slot 1200 slot-1200.snap`,
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:        "datadir",
+			Usage:       "Database directory (defaults to database.data_dir from the config)",
+			Destination: &snapshotDataDir,
+		},
+		&cli.Uint64Flag{
+			Name:        "slot",
+			Usage:       "Slot of the canonical block to snapshot (default: the head)",
+			Destination: &snapshotSlot,
+		},
+		&cli.IntFlag{
+			Name:        "chunk-size",
+			Usage:       "Size in bytes a state chunk is filled up to",
+			Value:       snapshot.DefaultChunkSize,
+			Destination: &snapshotChunkSize,
+		},
+	},
+	Action: func(ctx context.Context, c *cli.Command) error {
+		config.InitConfig(configPath, mode)
+
+		if c.Args().Len() != 1 {
+			return fmt.Errorf("expected exactly one snapshot file")
+		}
+		if snapshotChunkSize <= 0 {
+			return fmt.Errorf("--chunk-size must be positive")
+		}
+
+		db, err := openDatabase(snapshotDataDir, true)
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+
+		s, err := snapshotAt(store.NewRepository(db), db, c.IsSet("slot"), types.TimeSlot(snapshotSlot))
+		if err != nil {
+			return err
+		}
+
+		path := c.Args().First()
+		file, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+		manifest, err := snapshot.Write(file, s, snapshotChunkSize)
+		if err == nil {
+			err = file.Sync()
+		}
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			os.Remove(path)
+			return fmt.Errorf("failed to write snapshot: %w", err)
+		}
+
+		logger.Infof("Wrote snapshot of slot %d (header 0x%x, state root 0x%x): %d entries in %d chunks, %d ancestors, to %s",
+			s.Header.Slot, manifest.HeaderHash, s.StateRoot, manifest.Entries(), len(manifest.Chunks), len(s.Ancestry)-1, path)
+		return nil
+	},
+}
+
+// snapshotAt collects the snapshot of the canonical block at slot, or of the
+// head if hasSlot is false.
+func snapshotAt(repo *store.Repository, db database.IterableReader, hasSlot bool, slot types.TimeSlot) (*snapshot.Snapshot, error) {
+	var headerHash types.HeaderHash
+	if hasSlot {
+		var err error
+		if headerHash, err = repo.GetCanonicalHash(db, slot); err != nil {
+			return nil, err
+		}
+	} else {
+		head, err := repo.Head(db)
+		if err != nil {
+			return nil, err
+		}
+		headerHash = head.HeaderHash
+	}
+
+	block, err := repo.GetBlockByHash(db, types.OpaqueHash(headerHash))
+	if err != nil {
+		return nil, fmt.Errorf("block 0x%x: %w", headerHash, err)
+	}
+	stateRoot, err := repo.StateRootAt(db, headerHash)
+	if err != nil {
+		return nil, err
+	}
+	keyVals, err := repo.GetStateData(db, stateRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	return &snapshot.Snapshot{
+		Header:    block.Header,
+		Ancestry:  canonicalAncestry(repo, db, block.Header.Slot, headerHash),
+		StateRoot: stateRoot,
+		KeyVals:   keyVals,
+	}, nil
+}
+
+// canonicalAncestry returns the canonical blocks of the MaxLookupAge slots
+// ending at slot, oldest first, falling back to the ancestry of a seeded
+// snapshot below its base. Slots without a canonical block are skipped.
+func canonicalAncestry(repo *store.Repository, db database.Reader, slot types.TimeSlot, headerHash types.HeaderHash) types.Ancestry {
+	ancestry := types.Ancestry{{Slot: slot, HeaderHash: headerHash}}
+	for age := 1; age < types.MaxLookupAge && int(slot) >= age; age++ {
+		s := slot - types.TimeSlot(age)
+		if ancestor, err := repo.GetCanonicalHash(db, s); err == nil {
+			ancestry = append(ancestry, types.AncestryItem{Slot: s, HeaderHash: ancestor})
+		} else if ancestor, err := repo.GetAncestorHash(db, s); err == nil {
+			ancestry = append(ancestry, types.AncestryItem{Slot: s, HeaderHash: ancestor})
+		}
+	}
+	for i, j := 0, len(ancestry)-1; i < j; i, j = i+1, j-1 {
+		ancestry[i], ancestry[j] = ancestry[j], ancestry[i]
+	}
+	return ancestry
+}
+
+var snapshotLoadCmd = &cli.Command{
+	Name:      "load",
+	Usage:     "Verify a snapshot file and seed the node database with it",
+	ArgsUsage: "<file>",
+	Description: `Verify <file> and store its header, ancestry and state so the node continues from
+that block; a genesis header (slot 0) is seeded like the genesis of a chainspec.
+Verified: every chunk and the manifest against their checksums, the header against the
+header hash in the manifest, the state against the snapshot's state root, and that the
+header hashes to --header-hash, which must come from a source you trust.
+Not verified: that the state root is the posterior state of that header (a header only
+commits to its parent's state root) and that the ancestry hashes form a real chain; the
+file is trusted for both. --trust-file skips --header-hash and trusts the file entirely.
+For example:
+  go run ./cmd/node snapshot load --header-hash 0x1234... head.snap
+  go run ./cmd/node snapshot load --trust-file head.snap`,
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:        "datadir",
+			Usage:       "Database directory (defaults to database.data_dir from the config)",
+			Destination: &snapshotDataDir,
+		},
+		&cli.StringFlag{
+			Name:        "header-hash",
+			Usage:       "Trusted header hash the snapshot must be of (required unless --trust-file)",
+			Destination: &snapshotHeaderHash,
+		},
+		&cli.BoolFlag{
+			Name:        "trust-file",
+			Usage:       "Load the snapshot without checking its header against --header-hash",
+			Destination: &snapshotTrustFile,
+		},
+	},
+	Action: func(ctx context.Context, c *cli.Command) error {
+		config.InitConfig(configPath, mode)
+		if snapshotDataDir != "" {
+			config.Config.Database.DataDir = snapshotDataDir
+		}
+
+		if c.Args().Len() != 1 {
+			return fmt.Errorf("expected exactly one snapshot file")
+		}
+		if c.IsSet("header-hash") == snapshotTrustFile {
+			return fmt.Errorf("exactly one of --header-hash and --trust-file is required")
+		}
+
+		s, headerHash, err := readSnapshot(c.Args().First())
+		if err != nil {
+			return err
+		}
+		if snapshotTrustFile {
+			logger.Warnf("Loading snapshot of header 0x%x without checking it against a trusted header hash", headerHash)
+		} else {
+			trusted, err := parseHeaderHash(snapshotHeaderHash)
+			if err != nil {
+				return err
+			}
+			if trusted != headerHash {
+				return fmt.Errorf("snapshot is of header 0x%x, want 0x%x", headerHash, trusted)
+			}
+		}
+
+		cs := blockchain.GetInstance()
+		if s.Header.Slot == 0 {
+			if _, _, err := cs.SeedGenesisToBackend(ctx, s.Header, s.KeyVals); err != nil {
+				return fmt.Errorf("failed to seed genesis: %w", err)
+			}
+			cs.GenerateGenesisBlock(types.Block{Header: s.Header})
+		} else {
+			if _, err := cs.SeedSnapshotToBackend(ctx, s.Header, s.Ancestry, s.StateRoot, s.KeyVals); err != nil {
+				return fmt.Errorf("failed to seed snapshot: %w", err)
+			}
+		}
+
+		state, unmatchedKeyVals, err := m.StateKeyValsToState(s.KeyVals)
+		if err != nil {
+			return err
+		}
+		if err := cs.RestoreStateFromSnapshot(headerHash, state, unmatchedKeyVals); err != nil {
+			return err
+		}
+
+		logger.Infof("Loaded snapshot of slot %d (header 0x%x, state root 0x%x, %d entries)",
+			s.Header.Slot, headerHash, s.StateRoot, len(s.KeyVals))
+		return nil
+	},
+}
+
+func readSnapshot(path string) (*snapshot.Snapshot, types.HeaderHash, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, types.HeaderHash{}, err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return nil, types.HeaderHash{}, err
+	}
+
+	s, err := snapshot.Read(file, info.Size())
+	if err != nil {
+		return nil, types.HeaderHash{}, err
+	}
+	headerHash, err := hash.ComputeBlockHeaderHash(s.Header)
+	if err != nil {
+		return nil, types.HeaderHash{}, err
+	}
+	return s, headerHash, nil
+}
+
+func parseHeaderHash(s string) (types.HeaderHash, error) {
+	data, err := hex.DecodeString(strings.TrimPrefix(s, "0x"))
+	if err != nil {
+		return types.HeaderHash{}, fmt.Errorf("invalid header hash %q: %w", s, err)
+	}
+	var headerHash types.HeaderHash
+	if len(data) != len(headerHash) {
+		return types.HeaderHash{}, fmt.Errorf("header hash must be %d bytes, got %d", len(headerHash), len(data))
+	}
+	copy(headerHash[:], data)
+	return headerHash, nil
+}
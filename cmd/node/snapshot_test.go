@@ -0,0 +1,59 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/New-JAMneration/JAM-Protocol/internal/archive"
+	"github.com/New-JAMneration/JAM-Protocol/internal/blockchain"
+	"github.com/New-JAMneration/JAM-Protocol/internal/store"
+	"github.com/New-JAMneration/JAM-Protocol/internal/types"
+	"github.com/New-JAMneration/JAM-Protocol/internal/utilities/hash"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSeededSnapshot checks that a database seeded from a snapshot passes
+// fsck and exports from slot 0, although the ancestry blocks are missing.
+func TestSeededSnapshot(t *testing.T) {
+	blockchain.ResetInstanceWithOptions(blockchain.Options{InMemory: true})
+	defer blockchain.ResetInstance()
+	cs := blockchain.GetInstance()
+
+	header := types.Header{Slot: 20, Parent: types.HeaderHash{9}}
+	keyVals := types.StateKeyVals{{Key: types.StateKey{0xee, 1}, Value: []byte("storage")}}
+	_, stateRoot, err := cs.BuildStateRootInputKeyValsAndRoot(keyVals)
+	require.NoError(t, err)
+	headerHash, err := hash.ComputeBlockHeaderHash(header)
+	require.NoError(t, err)
+	ancestry := types.Ancestry{{Slot: 18, HeaderHash: types.HeaderHash{8}}, {Slot: 19, HeaderHash: header.Parent}, {Slot: 20, HeaderHash: headerHash}}
+
+	_, err = cs.SeedSnapshotToBackend(context.Background(), header, ancestry, stateRoot, keyVals)
+	require.NoError(t, err)
+
+	db := cs.PersistentDatabase()
+	repo := store.NewRepository(db)
+
+	report, err := repo.Fsck(db, store.FsckOptions{Repair: true})
+	require.NoError(t, err)
+	require.True(t, report.OK(), "unexpected issues: %v", report.Issues)
+	require.Equal(t, 1, report.BlocksChecked)
+	require.Zero(t, report.Repaired)
+
+	blocks, err := repo.CanonicalBlocks(db, 0, 0)
+	require.NoError(t, err)
+	var buf bytes.Buffer
+	w, err := archive.NewWriter(&buf)
+	require.NoError(t, err)
+	export := &chainExporter{repo: repo, db: db, w: w}
+	for blocks.Next() {
+		require.NoError(t, export.block(blocks.Block(), blocks.HeaderHash()))
+	}
+	require.NoError(t, blocks.Error())
+	require.NoError(t, export.finish())
+	require.Equal(t, 1, export.blocks)
+	require.Equal(t, 1, export.snapshots)
+
+	// A snapshot taken from the seeded database carries the ancestry on.
+	require.Equal(t, ancestry, canonicalAncestry(repo, db, header.Slot, headerHash))
+}
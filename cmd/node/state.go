@@ -0,0 +1,165 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/New-JAMneration/JAM-Protocol/config"
+	"github.com/New-JAMneration/JAM-Protocol/internal/stateview"
+	"github.com/New-JAMneration/JAM-Protocol/internal/store"
+	"github.com/New-JAMneration/JAM-Protocol/internal/types"
+	"github.com/New-JAMneration/JAM-Protocol/internal/utilities"
+	"github.com/urfave/cli/v3"
+)
+
+var (
+	stateDataDir    string
+	stateHeader     string
+	stateSlot       uint64
+	stateRoot       string
+	stateTrace      string
+	statePre        bool
+	stateComponents []string
+	stateServices   []uint
+	stateFormat     string
+)
+
+// stateSourceFlags are the flags shared by commands that inspect one state.
+func stateSourceFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:        "datadir",
+			Usage:       "Database directory (defaults to database.data_dir from the config)",
+			Destination: &stateDataDir,
+		},
+		&cli.StringFlag{
+			Name:        "header",
+			Usage:       "Header hash of the block whose posterior state is used",
+			Destination: &stateHeader,
+		},
+		&cli.Uint64Flag{
+			Name:        "slot",
+			Usage:       "Slot of the canonical block whose posterior state is used",
+			Destination: &stateSlot,
+		},
+		&cli.StringFlag{
+			Name:        "root",
+			Usage:       "State root of the state to use",
+			Destination: &stateRoot,
+		},
+		&cli.StringFlag{
+			Name:        "trace",
+			Usage:       "Trace .bin file to read the state from instead of the database",
+			Destination: &stateTrace,
+		},
+		&cli.BoolFlag{
+			Name:        "pre",
+			Usage:       "With --trace, use the pre-state instead of the post-state",
+			Destination: &statePre,
+		},
+		&cli.StringFlag{
+			Name:        "format",
+			Usage:       "Output format: table or json",
+			Value:       "table",
+			Destination: &stateFormat,
+		},
+	}
+}
+
+var stateCmd = &cli.Command{
+	Name:  "state",
+	Usage: "Decode and print a state as named Gray Paper components",
+	Description: `Load a state from the node database (by header hash, slot or state root) or from a trace
+.bin file and print its components and service accounts.
+For example:
+  go run ./cmd/node state --header 0x1234...
+  go run ./cmd/node state --trace traces/fallback/00000001.bin --component tau,eta
+  go run ./cmd/node state --root 0xabcd... --service 0 --format json`,
+	Flags: append(stateSourceFlags(),
+		&cli.StringSliceFlag{
+			Name:        "component",
+			Usage:       "Only print these components (" + strings.Join(stateview.Components, ", ") + ")",
+			Destination: &stateComponents,
+		},
+		&cli.UintSliceFlag{
+			Name:        "service",
+			Usage:       "Only print these service accounts",
+			Destination: &stateServices,
+		},
+	),
+	Action: func(ctx context.Context, c *cli.Command) error {
+		config.InitConfig(configPath, mode)
+
+		keyVals, err := loadStateFromFlags(c)
+		if err != nil {
+			return err
+		}
+
+		filter := stateview.Filter{Components: stateComponents}
+		for _, id := range stateServices {
+			filter.Services = append(filter.Services, types.ServiceID(id))
+		}
+
+		view, err := stateview.New(keyVals, filter)
+		if err != nil {
+			return err
+		}
+
+		switch stateFormat {
+		case "json":
+			return view.WriteJSON(os.Stdout)
+		case "table":
+			return view.WriteTable(os.Stdout)
+		default:
+			return fmt.Errorf("unsupported format %q", stateFormat)
+		}
+	},
+}
+
+// loadStateFromFlags resolves exactly one of --header, --slot, --root or
+// --trace to the StateKeyVals it refers to.
+func loadStateFromFlags(c *cli.Command) (types.StateKeyVals, error) {
+	sources := 0
+	for _, name := range []string{"header", "slot", "root", "trace"} {
+		if c.IsSet(name) {
+			sources++
+		}
+	}
+	if sources != 1 {
+		return nil, fmt.Errorf("exactly one of --header, --slot, --root or --trace is required")
+	}
+
+	if stateTrace != "" {
+		return loadTraceState(stateTrace, statePre)
+	}
+
+	db, err := openDatabase(stateDataDir, true)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+	repo := store.NewRepository(db)
+
+	switch {
+	case c.IsSet("root"):
+		root, err := utilities.HexToOpaqueHash(stateRoot)
+		if err != nil {
+			return nil, err
+		}
+		return repo.GetStateData(db, types.StateRoot(root))
+	case c.IsSet("slot"):
+		headerHash, err := repo.ResolveSlot(db, types.TimeSlot(stateSlot))
+		if err != nil {
+			return nil, err
+		}
+		return loadStateAtHeader(repo, db, headerHash)
+	}
+
+	headerHash, err := utilities.HexToOpaqueHash(stateHeader)
+	if err != nil {
+		return nil, err
+	}
+	return loadStateAtHeader(repo, db, types.HeaderHash(headerHash))
+}
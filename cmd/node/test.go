@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"fmt"
+	"sort"
 
 	"github.com/New-JAMneration/JAM-Protocol/config"
 	"github.com/New-JAMneration/JAM-Protocol/internal/blockchain"
@@ -10,10 +11,12 @@ import (
 	"github.com/New-JAMneration/JAM-Protocol/internal/types"
 	m "github.com/New-JAMneration/JAM-Protocol/internal/utilities/merklization"
 	"github.com/New-JAMneration/JAM-Protocol/internal/utilities/timing"
+	jamtestspvm "github.com/New-JAMneration/JAM-Protocol/jamtests/pvm"
 	"github.com/New-JAMneration/JAM-Protocol/logger"
 	"github.com/New-JAMneration/JAM-Protocol/testdata"
 	jamtestvector "github.com/New-JAMneration/JAM-Protocol/testdata/jam_test_vector"
 	jamtestnet "github.com/New-JAMneration/JAM-Protocol/testdata/jam_testnet"
+	pvmtestvector "github.com/New-JAMneration/JAM-Protocol/testdata/pvm_test_vector"
 	"github.com/New-JAMneration/JAM-Protocol/testdata/traces"
 	"github.com/urfave/cli/v3"
 )
@@ -26,21 +29,27 @@ var (
 	testRunSTF     bool
 	testGenesis    string
 	benchmarkRuns  int
+	testPVMTrace   string
 )
 
 var testCmd = &cli.Command{
 	Name:  "test",
 	Usage: "Run JAM Protocol tests",
 	Description: `Run tests for the JAM Protocol. 
-You can specify the test type (jam-test-vectors, jamtestnet, trace), mode (safrole, assurances, etc.), and size (tiny, full).
+You can specify the test type (jam-test-vectors, jamtestnet, trace, pvm), mode (safrole, assurances, etc.), and size (tiny, full).
+The pvm type runs every w3f pvm test vector and reports the results per opcode group; it ignores mode and size.
 For example:
   go run ./cmd/node test --type jam-test-vectors --mode safrole --size tiny
   go run ./cmd/node test --type jamtestnet --mode assurances
-  go run ./cmd/node test --type trace --mode safrole`,
+  go run ./cmd/node test --type trace --mode safrole
+  go run ./cmd/node test --type pvm --format json
+With --pvm-trace, every accumulate, refine and is-authorized PVM invocation writes its
+service, entry point, instructions and host calls as JSON Lines to the given file, ordered by
+slot and then service id, for diffing against other implementations.`,
 	Flags: []cli.Flag{
 		&cli.StringFlag{
 			Name:        "type",
-			Usage:       "Test data type (jam-test-vectors, jamtestnet, trace)",
+			Usage:       "Test data type (jam-test-vectors, jamtestnet, trace, pvm)",
 			Value:       "jam-test-vectors",
 			Destination: &testType,
 		},
@@ -58,7 +67,7 @@ For example:
 		},
 		&cli.StringFlag{
 			Name:        "format",
-			Usage:       "Test data format (json, binary) - only for jam-test-vectors",
+			Usage:       "Test data format (json, binary) - only for jam-test-vectors and pvm",
 			Value:       "binary",
 			Destination: &testFileFormat,
 		},
@@ -80,6 +89,11 @@ For example:
 			Value:       0,
 			Destination: &benchmarkRuns,
 		},
+		&cli.StringFlag{
+			Name:        "pvm-trace",
+			Usage:       "Write a JSON Lines trace of every PVM invocation to this file",
+			Destination: &testPVMTrace,
+		},
 	},
 	Action: func(ctx context.Context, c *cli.Command) error {
 		// Initialize config
@@ -100,6 +114,18 @@ For example:
 			logger.Fatal(err)
 		}
 
+		if testPVMTrace != "" {
+			stopTrace, err := startPVMTrace(testPVMTrace)
+			if err != nil {
+				logger.Fatalf("Error opening PVM trace: %v", err)
+			}
+			defer func() {
+				if err := stopTrace(); err != nil {
+					logger.Errorf("Error writing PVM trace: %v", err)
+				}
+			}()
+		}
+
 		// Enable timing if TIMING environment variable is set
 		if timing.Enabled {
 			timing.ResetGlobal()
@@ -119,6 +145,10 @@ For example:
 			logger.Fatalf("Error reading test data: %v", err)
 		}
 
+		if testType == "pvm" {
+			return runPVMTestVectors(reader, runner, testFiles)
+		}
+
 		// Validate benchmark mode
 		if benchmarkRuns > 0 {
 			if testType != "trace" {
@@ -384,7 +414,7 @@ For example:
 
 // Encapsulate validation logic into separate functions
 func validateTestType(testType string) error {
-	if testType != "jam-test-vectors" && testType != "jamtestnet" && testType != "trace" {
+	if testType != "jam-test-vectors" && testType != "jamtestnet" && testType != "trace" && testType != "pvm" {
 		return fmt.Errorf("invalid test type '%s'", testType)
 	}
 	return nil
@@ -452,7 +482,63 @@ func createReaderAndRunner(testType string, mode testdata.TestMode, size testdat
 		}
 		reader = testdata.NewTracesReader(mode, format)
 		runner = traces.NewTraceRunner()
+	case "pvm":
+		reader = testdata.NewPVMTestVectorsReader(format)
+		runner = pvmtestvector.NewPVMTestVectorsRunner()
 	}
 
 	return reader, runner, nil
 }
+
+// runPVMTestVectors runs every pvm test vector and reports how many passed
+// in each opcode group. Finding no vectors is an error, e.g. when --format
+// does not match the files present.
+func runPVMTestVectors(reader *testdata.TestDataReader, runner testdata.TestRunner, testFiles []testdata.TestData) error {
+	if len(testFiles) == 0 {
+		return fmt.Errorf("no pvm test vectors found in %s format", testFileFormat)
+	}
+
+	type groupResult struct{ passed, failed int }
+	groups := map[string]*groupResult{}
+	passed, failed := 0, 0
+
+	for _, testFile := range testFiles {
+		data, err := reader.ParseTestData(testFile.Data)
+		if err != nil {
+			logger.Errorf("Test %s failed: %v", testFile.Name, err)
+			failed++
+			continue
+		}
+		testCase := data.(*jamtestspvm.PVMTestCase)
+
+		group := groups[testCase.Group()]
+		if group == nil {
+			group = &groupResult{}
+			groups[testCase.Group()] = group
+		}
+		if err := runner.Run(testCase, false); err != nil {
+			logger.Errorf("Test %s failed: %v", testFile.Name, err)
+			group.failed++
+			failed++
+		} else {
+			logger.Debugf("Test %s passed", testFile.Name)
+			group.passed++
+			passed++
+		}
+	}
+
+	names := make([]string, 0, len(groups))
+	for name := range groups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	logger.Info("----------------------------------------")
+	for _, name := range names {
+		group := groups[name]
+		logger.Infof("%-20s passed %4d / %4d", name, group.passed, group.passed+group.failed)
+	}
+	logger.Info("----------------------------------------")
+	logger.Infof("Total: %d, Passed: %d, Failed: %d\n", len(testFiles), passed, failed)
+	return nil
+}
@@ -36,8 +36,12 @@ type config struct {
 		Password string `json:"password"`
 	} `json:"redis"`
 	Database struct {
-		Type    string `json:"type"`
+		Type string `json:"type"`
+		// DataDir is the pebble directory, or the file a memory database
+		// is loaded from and saved to.
 		DataDir string `json:"data_dir"`
+		// Sync is the pebble fsync policy: always, on-finalization or never.
+		Sync string `json:"sync"`
 	} `json:"database"`
 	Info struct {
 		FuzzVersion  uint8  `json:"fuzz_version"`
@@ -94,9 +98,11 @@ func DefaultConfig() config {
 		Database: struct {
 			Type    string `json:"type"`
 			DataDir string `json:"data_dir"`
+			Sync    string `json:"sync"`
 		}{
 			Type:    "pebble",
 			DataDir: "./data/pebble",
+			Sync:    "on-finalization",
 		},
 		Info: struct {
 			FuzzVersion  uint8  `json:"fuzz_version"`
@@ -382,15 +382,16 @@ type singleResult struct {
 	err error
 }
 
-// Deep copy single service accumulation input for goroutine parallelization
+// Copy single service accumulation input for goroutine parallelization. The
+// service accounts share their maps with in until the service changes them.
 func (in SingleServiceAccumulationInput) CloneForService(s types.ServiceID) SingleServiceAccumulationInput {
 	out := in
 	out.ServiceID = s
-	out.PartialStateSet = in.PartialStateSet.DeepCopy()
+	out.PartialStateSet = in.PartialStateSet.SharedCopy()
 	out.DeferredTransfers = slices.Clone(in.DeferredTransfers)
 	out.WorkReports = slices.Clone(in.WorkReports)
 	out.AlwaysAccumulateMap = maps.Clone(in.AlwaysAccumulateMap)
-	out.UnmatchedKeyVals = blockchain.GetInstance().GetPostStateUnmatchedKeyVals()
+	out.StateAccess = in.StateAccess.Child()
 	return out
 }
 
@@ -420,7 +421,12 @@ func ParallelizedAccumulation(input ParallelizedAccumulationInput) (output Paral
 	n := make(types.ServiceAccountState, len(d))
 	m := make(types.ServiceAccountState, len(d))
 
+	// Services read the unmatched key-value pairs through the block's overlay
+	// instead of each copying the whole pool.
+	access := blockchain.GetInstance().StateAccess()
+
 	singleInput := SingleServiceAccumulationInput{
+		StateAccess:         access,
 		PartialStateSet:     e,
 		DeferredTransfers:   t,
 		WorkReports:         r,
@@ -458,7 +464,7 @@ func ParallelizedAccumulation(input ParallelizedAccumulationInput) (output Paral
 		mu.Lock()
 		cache[s] = out
 		// Do not update global store here during parallel execution
-		// UnmatchedKeyVals will be merged after all services complete
+		// StateAccess will be committed after all services complete
 		mu.Unlock()
 
 		return out, nil
@@ -540,46 +546,16 @@ func ParallelizedAccumulation(input ParallelizedAccumulationInput) (output Paral
 		p = append(p, singleOutput.ServiceBlobs...)
 	}
 
-	// Merge UnmatchedKeyVals from all services using intersection
-	// Each service remove its own keys from UnmatchedKeyVals
-	// Keys removed by other services remain in each service's output
-	// Use intersection to merge UnmatchedKeyVals which not removed by any service
-	{
-		if len(s) > 0 {
-			keyCountMap := make(map[[31]byte]int, len(s))               // key -> count of how many services have this key
-			keyValueMap := make(map[[31]byte]types.StateKeyVal, len(s)) // key -> value of the key
-			serviceCount := 0
-
-			// Count occurrences of each key across all service outputs
-			for service_id := range s {
-				singleOutput, ok := cache[service_id]
-				if !ok {
-					continue
-				}
-				serviceCount++
-				// Deduplicate keys within each service output first
-				serviceKeySet := make(map[[31]byte]bool, len(singleOutput.UnmatchedKeyVals))
-				for _, kv := range singleOutput.UnmatchedKeyVals {
-					if !serviceKeySet[kv.Key] {
-						serviceKeySet[kv.Key] = true
-						keyCountMap[kv.Key]++
-						keyValueMap[kv.Key] = kv
-					}
-				}
+	// Commit the changes of every service to the unmatched key-value pairs.
+	// Each service removes its own keys, so the committed overlay holds the
+	// union of their removals.
+	if len(s) > 0 {
+		for service_id := range s {
+			if singleOutput, ok := cache[service_id]; ok {
+				singleOutput.StateAccess.Commit()
 			}
-
-			// Only keep keys that exist in ALL service outputs (intersection)
-			mergedUnmatchedKeyVals := make(types.StateKeyVals, 0, len(keyCountMap))
-			for key, count := range keyCountMap {
-				if count == serviceCount {
-					// This key exists in all service outputs, keep it
-					mergedUnmatchedKeyVals = append(mergedUnmatchedKeyVals, keyValueMap[key])
-				}
-			}
-
-			// Update the global store with merged result
-			blockchain.GetInstance().SetPostStateUnmatchedKeyVals(mergedUnmatchedKeyVals)
 		}
+		blockchain.GetInstance().ApplyStateAccess()
 	}
 
 	singleOutput, err := runSingleReplaceService(input.PartialStateSet.Bless, singleInput)
@@ -800,11 +776,10 @@ func SingleServiceAccumulation(input SingleServiceAccumulationInput) (output Sin
 	eta0 := blockchain.GetInstance().GetPosteriorStates().GetState().Eta[0]
 
 	// (e, w, f , s)↦ ΨA(e, τ′, s, g, iT ⌢ iU )
-	storageKeyVal := input.UnmatchedKeyVals
 	var pvmResult PVM.Psi_A_ReturnType
 	func() {
 		defer timing.Track("PVM.Psi_A")()
-		pvmResult = PVM.Psi_A(e, tauPrime, s, g, pvmItems, eta0, storageKeyVal)
+		pvmResult = PVM.Psi_A(e, tauPrime, s, g, pvmItems, eta0, input.StateAccess)
 	}()
 
 	// Collect PVM results as output
@@ -814,7 +789,8 @@ func SingleServiceAccumulation(input SingleServiceAccumulationInput) (output Sin
 		output.GasUsed = pvmResult.Gas
 		output.PartialStateSet = pvmResult.PartialStateSet
 		output.ServiceBlobs = pvmResult.ServiceBlobs
-		output.UnmatchedKeyVals = pvmResult.StorageKeyVal
+		pvmResult.StateAccess.Commit()
+		output.StateAccess = input.StateAccess
 	}
 	return output, nil
 }
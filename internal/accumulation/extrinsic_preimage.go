@@ -3,6 +3,7 @@ package accumulation
 import (
 	"bytes"
 	"errors"
+	"maps"
 
 	"github.com/New-JAMneration/JAM-Protocol/internal/blockchain"
 	"github.com/New-JAMneration/JAM-Protocol/internal/types"
@@ -157,6 +158,7 @@ func filterPreimageExtrinsics(eps types.PreimagesExtrinsic, d types.ServiceAccou
 	// stale len and corrupts E_P used later for π statistics (GP §13.5).
 	filtered := make(types.PreimagesExtrinsic, 0, len(eps))
 	copiedKeyVals := cs.GetPostStateUnmatchedKeyVals()
+	owned := ownedLookups{}
 	for _, ep := range eps {
 		// Calculate preimage hash and length
 		preimageHash := hash.Blake2bHash(ep.Blob)
@@ -170,7 +172,7 @@ func filterPreimageExtrinsics(eps types.PreimagesExtrinsic, d types.ServiceAccou
 				Hash:   preimageHash,
 				Length: preimageLength,
 			}
-			requestService := d[ep.Requester]
+			requestService := owned.account(d, ep.Requester)
 			// Pre-allocate with small initial capacity (lookup entries typically small)
 			requestService.LookupDict[lookupData] = make(types.TimeSlotSet, 0, 8)
 		}
@@ -184,6 +186,7 @@ func filterPreimageExtrinsics(eps types.PreimagesExtrinsic, d types.ServiceAccou
 // It integrates preimages into deltaDoubleDagger using the provided tauPrime time slot
 // v0.6.4 (12.39)
 func UpdateDeltaWithExtrinsicPreimage(eps types.PreimagesExtrinsic, deltaDoubleDagger types.ServiceAccountState, tauPrime types.TimeSlot) (types.ServiceAccountState, error) {
+	owned := ownedLookups{}
 	for _, ep := range eps {
 		preimageHash := hash.Blake2bHash(ep.Blob)
 		preimageLength := types.U32(len(ep.Blob))
@@ -207,6 +210,7 @@ func UpdateDeltaWithExtrinsicPreimage(eps types.PreimagesExtrinsic, deltaDoubleD
 		}
 
 		// Update map
+		serviceAccount = owned.account(deltaDoubleDagger, ep.Requester)
 		serviceAccount.LookupDict[lookupKey] = types.TimeSlotSet{tauPrime}
 		serviceAccount.PreimageLookup[preimageHash] = ep.Blob
 
@@ -247,6 +251,7 @@ func ProcessPreimageExtrinsics() error {
 // v0.6.5 (12.18)
 func Provide(d types.ServiceAccountState, eps types.ServiceBlobs) (types.ServiceAccountState, error) {
 	tauPrime := blockchain.GetInstance().GetPosteriorStates().GetTau()
+	owned := ownedLookups{}
 	for _, serviceblob := range eps {
 		serviceID := serviceblob.ServiceID
 		serviceAccount, found := d[serviceID]
@@ -262,6 +267,7 @@ func Provide(d types.ServiceAccountState, eps types.ServiceBlobs) (types.Service
 			continue
 		}
 
+		serviceAccount = owned.account(d, serviceID)
 		serviceAccount.LookupDict[lookupKey] = types.TimeSlotSet{tauPrime}
 		serviceAccount.PreimageLookup[lookupKey.Hash] = serviceblob.Blob
 		d[serviceID] = serviceAccount
@@ -269,3 +275,21 @@ func Provide(d types.ServiceAccountState, eps types.ServiceBlobs) (types.Service
 
 	return d, nil
 }
+
+// ownedLookups tracks the accounts whose lookup and preimage maps were copied
+// before integrating preimages. Accounts not changed by accumulation share
+// those maps with the prior state, which must not change.
+type ownedLookups map[types.ServiceID]struct{}
+
+// account returns service serviceID of d, copying its maps into d on first use.
+func (owned ownedLookups) account(d types.ServiceAccountState, serviceID types.ServiceID) types.ServiceAccount {
+	account := d[serviceID]
+	if _, ok := owned[serviceID]; ok {
+		return account
+	}
+	owned[serviceID] = struct{}{}
+	account.LookupDict = maps.Clone(account.LookupDict)
+	account.PreimageLookup = maps.Clone(account.PreimageLookup)
+	d[serviceID] = account
+	return account
+}
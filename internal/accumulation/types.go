@@ -1,6 +1,9 @@
 package accumulation
 
-import "github.com/New-JAMneration/JAM-Protocol/internal/types"
+import (
+	"github.com/New-JAMneration/JAM-Protocol/internal/stateaccess"
+	"github.com/New-JAMneration/JAM-Protocol/internal/types"
+)
 
 type OuterAccumulationInput struct {
 	GasLimit                     types.Gas                 // g    gas-limit
@@ -37,7 +40,7 @@ type SingleServiceAccumulationInput struct {
 	WorkReports         []types.WorkReport        // r   a sequence of work-reports
 	AlwaysAccumulateMap types.AlwaysAccumulateMap // f   a dictionary of privileged always-accumulate services
 	ServiceID           types.ServiceID           // s   a service index
-	UnmatchedKeyVals    types.StateKeyVals        // storage key-value pairs that were not matched yet
+	StateAccess         *stateaccess.Overlay      // storage key-value pairs that were not matched yet
 }
 
 type SingleServiceAccumulationOutput struct {
@@ -46,5 +49,5 @@ type SingleServiceAccumulationOutput struct {
 	AccumulationOutput *types.OpaqueHash        // a possible accumulation-output
 	GasUsed            types.Gas                // the actual PVM gas used
 	ServiceBlobs       types.ServiceBlobs       // a hash service pair of the accumulated service
-	UnmatchedKeyVals   types.StateKeyVals       // storage key-value pairs that were not matched yet
+	StateAccess        *stateaccess.Overlay     // changes to the unmatched key-value pairs, committed by the caller
 }
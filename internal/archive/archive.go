@@ -0,0 +1,203 @@
+// Package archive implements the portable chain archive used by
+// `node export` and `node import`.
+//
+// An archive is a fixed file header followed by length-prefixed records,
+// framed the same way as fuzz protocol messages:
+//
+//	file   := magic (8 bytes) | version (1 byte) | record*
+//	record := length (u32 LE) | kind (1 byte) | payload (length - 1 bytes)
+//
+// Payloads are codec-encoded: a types.Block for RecordBlock and a
+// StateSnapshot for RecordState.
+package archive
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/New-JAMneration/JAM-Protocol/internal/types"
+)
+
+const (
+	Magic   = "JAMCHAIN"
+	Version = uint8(1)
+
+	// maxRecordLength guards against reading a corrupted length prefix.
+	maxRecordLength = 1 << 30
+)
+
+var (
+	ErrInvalidMagic      = errors.New("archive: invalid magic")
+	ErrInvalidVersion    = errors.New("archive: unsupported version")
+	ErrInvalidRecordKind = errors.New("archive: invalid record kind")
+)
+
+type RecordKind uint8
+
+const (
+	RecordBlock RecordKind = 1
+	RecordState RecordKind = 2
+)
+
+// StateSnapshot is the full posterior state of the block with the given header.
+type StateSnapshot struct {
+	Header  types.Header
+	KeyVals types.StateKeyVals
+}
+
+func (s *StateSnapshot) Encode(e *types.Encoder) error {
+	if err := s.Header.Encode(e); err != nil {
+		return err
+	}
+
+	if err := s.KeyVals.Encode(e); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *StateSnapshot) Decode(d *types.Decoder) error {
+	if err := s.Header.Decode(d); err != nil {
+		return err
+	}
+
+	if err := s.KeyVals.Decode(d); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Record is a single decoded archive entry. Exactly one of Block and State is set.
+type Record struct {
+	Kind  RecordKind
+	Block *types.Block
+	State *StateSnapshot
+}
+
+// Writer appends records to an archive.
+type Writer struct {
+	w       *bufio.Writer
+	encoder *types.Encoder
+}
+
+// NewWriter writes the archive header to w and returns a Writer for the records.
+func NewWriter(w io.Writer) (*Writer, error) {
+	bw := bufio.NewWriter(w)
+	if _, err := bw.WriteString(Magic); err != nil {
+		return nil, err
+	}
+	if err := bw.WriteByte(Version); err != nil {
+		return nil, err
+	}
+	return &Writer{w: bw, encoder: types.NewEncoder()}, nil
+}
+
+func (w *Writer) WriteBlock(block *types.Block) error {
+	payload, err := w.encoder.Encode(block)
+	if err != nil {
+		return fmt.Errorf("failed to encode block: %w", err)
+	}
+	return w.writeRecord(RecordBlock, payload)
+}
+
+func (w *Writer) WriteState(snapshot *StateSnapshot) error {
+	payload, err := w.encoder.Encode(snapshot)
+	if err != nil {
+		return fmt.Errorf("failed to encode state snapshot: %w", err)
+	}
+	return w.writeRecord(RecordState, payload)
+}
+
+// Flush writes any buffered records to the underlying writer.
+func (w *Writer) Flush() error {
+	return w.w.Flush()
+}
+
+func (w *Writer) writeRecord(kind RecordKind, payload []byte) error {
+	var prefix [5]byte
+	binary.LittleEndian.PutUint32(prefix[:4], uint32(len(payload)+1))
+	prefix[4] = byte(kind)
+
+	if _, err := w.w.Write(prefix[:]); err != nil {
+		return err
+	}
+	_, err := w.w.Write(payload)
+	return err
+}
+
+// Reader reads records from an archive.
+type Reader struct {
+	r       *bufio.Reader
+	decoder *types.Decoder
+	offset  int64
+}
+
+// NewReader validates the archive header and returns a Reader positioned at the first record.
+func NewReader(r io.Reader) (*Reader, error) {
+	br := bufio.NewReader(r)
+
+	header := make([]byte, len(Magic)+1)
+	if _, err := io.ReadFull(br, header); err != nil {
+		return nil, fmt.Errorf("archive: failed to read header: %w", err)
+	}
+	if string(header[:len(Magic)]) != Magic {
+		return nil, ErrInvalidMagic
+	}
+	if header[len(Magic)] != Version {
+		return nil, fmt.Errorf("%w: %d", ErrInvalidVersion, header[len(Magic)])
+	}
+
+	return &Reader{r: br, decoder: types.NewDecoder(), offset: int64(len(header))}, nil
+}
+
+// Offset returns the number of bytes consumed so far, including the file header.
+func (r *Reader) Offset() int64 {
+	return r.offset
+}
+
+// Next returns the next record, or io.EOF once the archive is exhausted.
+func (r *Reader) Next() (*Record, error) {
+	var prefix [5]byte
+	n, err := io.ReadFull(r.r, prefix[:])
+	r.offset += int64(n)
+	if err == io.EOF {
+		return nil, io.EOF
+	}
+	if err != nil {
+		return nil, fmt.Errorf("archive: truncated record header at offset %d: %w", r.offset, err)
+	}
+
+	length := binary.LittleEndian.Uint32(prefix[:4])
+	if length == 0 || length > maxRecordLength {
+		return nil, fmt.Errorf("archive: invalid record length %d at offset %d", length, r.offset)
+	}
+
+	payload := make([]byte, length-1)
+	n, err = io.ReadFull(r.r, payload)
+	r.offset += int64(n)
+	if err != nil {
+		return nil, fmt.Errorf("archive: truncated record at offset %d: %w", r.offset, err)
+	}
+
+	record := &Record{Kind: RecordKind(prefix[4])}
+	switch record.Kind {
+	case RecordBlock:
+		record.Block = &types.Block{}
+		err = r.decoder.Decode(payload, record.Block)
+	case RecordState:
+		record.State = &StateSnapshot{}
+		err = r.decoder.Decode(payload, record.State)
+	default:
+		return nil, fmt.Errorf("%w: %d", ErrInvalidRecordKind, record.Kind)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("archive: failed to decode record: %w", err)
+	}
+
+	return record, nil
+}
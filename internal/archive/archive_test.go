@@ -0,0 +1,78 @@
+package archive
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/New-JAMneration/JAM-Protocol/internal/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestArchiveRoundTrip(t *testing.T) {
+	blocks := []types.Block{
+		{Header: types.Header{Slot: 1}},
+		{Header: types.Header{Slot: 2, Parent: types.HeaderHash{1}}},
+	}
+	snapshot := &StateSnapshot{
+		Header: blocks[0].Header,
+		KeyVals: types.StateKeyVals{
+			{Key: types.StateKey{1}, Value: []byte("value")},
+		},
+	}
+
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf)
+	require.NoError(t, err)
+	require.NoError(t, w.WriteBlock(&blocks[0]))
+	require.NoError(t, w.WriteState(snapshot))
+	require.NoError(t, w.WriteBlock(&blocks[1]))
+	require.NoError(t, w.Flush())
+
+	total := int64(buf.Len())
+	r, err := NewReader(&buf)
+	require.NoError(t, err)
+
+	record, err := r.Next()
+	require.NoError(t, err)
+	require.Equal(t, RecordBlock, record.Kind)
+	require.Equal(t, blocks[0].Header.Slot, record.Block.Header.Slot)
+
+	record, err = r.Next()
+	require.NoError(t, err)
+	require.Equal(t, RecordState, record.Kind)
+	require.Equal(t, snapshot.KeyVals, record.State.KeyVals)
+
+	record, err = r.Next()
+	require.NoError(t, err)
+	require.Equal(t, RecordBlock, record.Kind)
+	require.Equal(t, blocks[1].Header.Parent, record.Block.Header.Parent)
+
+	_, err = r.Next()
+	require.ErrorIs(t, err, io.EOF)
+	require.Equal(t, total, r.Offset())
+}
+
+func TestArchiveRejectsInvalidHeader(t *testing.T) {
+	_, err := NewReader(bytes.NewReader([]byte("NOTCHAIN\x01")))
+	require.ErrorIs(t, err, ErrInvalidMagic)
+
+	_, err = NewReader(bytes.NewReader([]byte(Magic + "\x09")))
+	require.ErrorIs(t, err, ErrInvalidVersion)
+}
+
+func TestArchiveTruncatedRecord(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf)
+	require.NoError(t, err)
+	require.NoError(t, w.WriteBlock(&types.Block{Header: types.Header{Slot: 1}}))
+	require.NoError(t, w.Flush())
+
+	data := buf.Bytes()
+	r, err := NewReader(bytes.NewReader(data[:len(data)-3]))
+	require.NoError(t, err)
+
+	_, err = r.Next()
+	require.Error(t, err)
+	require.NotErrorIs(t, err, io.EOF)
+}
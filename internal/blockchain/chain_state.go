@@ -14,6 +14,7 @@ import (
 	pebbledb "github.com/New-JAMneration/JAM-Protocol/internal/database/provider/pebble"
 	redisdb "github.com/New-JAMneration/JAM-Protocol/internal/database/provider/redis"
 	"github.com/New-JAMneration/JAM-Protocol/internal/fuzzenv"
+	"github.com/New-JAMneration/JAM-Protocol/internal/stateaccess"
 	"github.com/New-JAMneration/JAM-Protocol/internal/store"
 
 	"github.com/New-JAMneration/JAM-Protocol/internal/types"
@@ -35,6 +36,8 @@ type ChainState struct {
 	// Data access layer
 	repo           *store.Repository
 	persistentRepo *store.Repository
+	// inMemory is set when persistentRepo is repo and nothing reaches disk
+	inMemory bool
 
 	// State management
 	priorStates        *PriorStates
@@ -51,11 +54,20 @@ type ChainState struct {
 	preStateUnmatchedKeyVals   types.StateKeyVals
 	postStateUnmatchedKeyVals  types.StateKeyVals
 
+	// root of the prior state in repo, nil if it is not stored there
+	priorStateRoot *types.StateRoot
+	// changes of the block being processed to the unmatched key-vals
+	stateAccess *stateaccess.Overlay
+
 	// cache for leaf level merklization
 	keyLevelCache *KeyLevelCache
 
 	// tracks recent entries persisted to disk, used for fuzz-mode pruning
 	persistedEntries []persistedEntry
+
+	// highest slot in the persistent hh: index, loaded on first use
+	canonicalTop      types.TimeSlot
+	canonicalTopKnown bool
 }
 
 type persistedEntry struct {
@@ -74,7 +86,11 @@ func getPersistentDatabase() database.Database {
 		dbConfig := config.Config.Database
 		switch dbConfig.Type {
 		case "pebble":
-			db, err := pebbledb.NewDatabase(dbConfig.DataDir, false)
+			syncPolicy, err := pebbledb.ParseSyncPolicy(dbConfig.Sync)
+			if err != nil {
+				logger.Warnf("%v, using %s", err, pebbledb.SyncOnFinalization)
+			}
+			db, err := pebbledb.NewDatabaseWithOptions(dbConfig.DataDir, pebbledb.Options{Sync: syncPolicy})
 			if err != nil {
 				if strings.Contains(err.Error(), "lock") {
 					logger.Errorf("Failed to initialize Pebble database: %v. Database may be locked by another process or previous instance. Please ensure no other process is using the database at %s", err, dbConfig.DataDir)
@@ -87,30 +103,66 @@ func getPersistentDatabase() database.Database {
 			}
 		case "redis":
 			redisConfig := config.Config.Redis
-			globalPersistentDB = redisdb.NewDatabase(redisConfig.Address, redisConfig.Password, redisConfig.Port)
+			globalPersistentDB = redisdb.NewDatabaseWithOptions(redisdb.Options{
+				Addr:     redisConfig.Address,
+				Password: redisConfig.Password,
+				DB:       redisConfig.Port,
+				Prefixes: store.SchemaPrefixes(),
+			})
+		case "memory":
+			// DataDir names the file the database is loaded from and saved
+			// to on Close; empty keeps it volatile.
+			db, err := memory.NewDatabaseWithOptions(memory.Options{Path: dbConfig.DataDir, SaveOnClose: dbConfig.DataDir != ""})
+			if err != nil {
+				logger.Errorf("Failed to initialize memory database: %v", err)
+				db = memory.NewDatabase()
+			}
+			globalPersistentDB = db
 		default:
 			logger.Warnf("Unknown database type: %s, using memory database", dbConfig.Type)
 			globalPersistentDB = memory.NewDatabase()
 		}
+		migratePersistentDatabase(globalPersistentDB)
 	})
 	return globalPersistentDB
 }
 
+// migratePersistentDatabase upgrades the data dir to the current key schema.
+// A data dir written by a newer build is refused rather than misread.
+func migratePersistentDatabase(db database.Database) {
+	from, err := store.NewRepository(db).MigrateSchema(db)
+	if err != nil {
+		logger.Fatalf("Failed to open database at %s: %v", config.Config.Database.DataDir, err)
+	}
+	if from != 0 && from != store.SchemaVersion {
+		logger.Infof("Migrated database schema from version %d to %d", from, store.SchemaVersion)
+	}
+}
+
+// Options configures a ChainState.
+type Options struct {
+	// InMemory keeps blocks and states in memory only: the configured
+	// database is neither opened nor written. JAM_FUZZ implies it.
+	InMemory bool
+}
+
 // newChainStateRepositories returns the memory repo and persistent repo.
-// Under JAM_FUZZ both point at the same in-memory repository (no disk I/O).
-func newChainStateRepositories() (repo *store.Repository, persistentRepo *store.Repository) {
+// In memory both point at the same in-memory repository (no disk I/O).
+func newChainStateRepositories(inMemory bool) (repo *store.Repository, persistentRepo *store.Repository) {
 	repo = store.NewRepository(memory.NewDatabase())
-	if fuzzenv.Enabled() {
+	if inMemory {
 		return repo, repo
 	}
 	return repo, store.NewRepository(getPersistentDatabase())
 }
 
-func newChainState() *ChainState {
-	repo, persistentRepo := newChainStateRepositories()
+func newChainState(opts Options) *ChainState {
+	inMemory := opts.InMemory || fuzzenv.Enabled()
+	repo, persistentRepo := newChainStateRepositories(inMemory)
 	return &ChainState{
 		repo:           repo,
 		persistentRepo: persistentRepo,
+		inMemory:       inMemory,
 
 		priorStates:        NewPriorStates(),
 		intermediateStates: NewIntermediateStates(),
@@ -133,14 +185,21 @@ func newChainState() *ChainState {
 // If the instance doesn't exist, it creates one.
 func GetInstance() *ChainState {
 	initOnce.Do(func() {
-		globalChainState = newChainState()
+		globalChainState = newChainState(Options{})
 		logger.Debug("🚀 ChainState initialized")
 	})
 	return globalChainState
 }
 
 func ResetInstance() {
-	globalChainState = newChainState()
+	ResetInstanceWithOptions(Options{})
+}
+
+// ResetInstanceWithOptions replaces the singleton with a ChainState
+// configured by opts, which GetInstance returns from then on.
+func ResetInstanceWithOptions(opts Options) {
+	initOnce.Do(func() {})
+	globalChainState = newChainState(opts)
 	logger.Debug("🚀 ChainState reset")
 }
 
@@ -160,6 +219,16 @@ func (cs *ChainState) Database() database.Database {
 	return cs.persistentRepo.Database()
 }
 
+// Checkpoint writes a consistent copy of the persistent database to dir
+// while blocks keep being imported (see store.Checkpoint). It is the hook
+// behind the checkpoint admin request of a running node.
+func (cs *ChainState) Checkpoint(dir string) error {
+	if cs.inMemory {
+		return fmt.Errorf("checkpoint is not available for an in-memory chain state")
+	}
+	return store.Checkpoint(cs.persistentRepo.Database(), dir)
+}
+
 // PersistentDatabase returns the persistent database (same as used by persistentRepo).
 // Used by CE handlers via type assertion when bc is *ChainState.
 func (cs *ChainState) PersistentDatabase() database.Database {
@@ -291,6 +360,16 @@ func (cs *ChainState) GenerateGenesisBlock(block types.Block) error {
 // FinalizeBlock marks a block as finalized by its hash
 func (cs *ChainState) FinalizeBlock(blockHash types.HeaderHash) {
 	cs.finalizedIndex[blockHash] = true
+
+	if cs.inMemory {
+		return
+	}
+	err := cs.persistentRepo.WithSyncBatch(func(batch database.Batch) error {
+		return cs.persistentRepo.SaveFinalizedHash(batch, blockHash)
+	})
+	if err != nil {
+		logger.Errorf("FinalizeBlock: failed to persist finalized hash: %v", err)
+	}
 }
 
 // IsBlockFinalized checks if a block is finalized
@@ -399,6 +478,7 @@ func (cs *ChainState) GenerateGenesisState(state types.State) {
 // post-state update to pre-state
 func (cs *ChainState) StateCommit() {
 	latestBlock := cs.GetLatestBlock()
+	var committedRoot *types.StateRoot
 
 	blockHeaderHash, err := hash.ComputeBlockHeaderHash(latestBlock.Header)
 	if err != nil {
@@ -407,10 +487,11 @@ func (cs *ChainState) StateCommit() {
 		posteriorState := cs.GetPosteriorStates().GetState()
 
 		// Persist state for block
-		err = cs.PersistStateForBlock(blockHeaderHash, posteriorState)
+		stateRoot, err := cs.persistStateForBlock(blockHeaderHash, posteriorState)
 		if err != nil {
 			logger.Errorf("StateCommit: failed to persist state: %v", err)
 		} else {
+			committedRoot = &stateRoot
 			logger.Debugf("StateCommit: persisted state for block 0x%x", blockHeaderHash[:8])
 		}
 
@@ -439,6 +520,7 @@ func (cs *ChainState) StateCommit() {
 	cs.GetPriorStates().SetState(posterState)
 	postUnmatchedKeyVal := cs.GetPostStateUnmatchedKeyVals()
 	cs.SetPriorStateUnmatchedKeyVals(postUnmatchedKeyVal)
+	cs.priorStateRoot = committedRoot
 	cs.GetPosteriorStates().SetState(*NewPosteriorStates().state)
 }
 
@@ -459,15 +541,17 @@ func (cs *ChainState) StateCommitWithPreComputedState(
 	}
 
 	// Write state data to both memory (for fast reads) and disk (for persistence).
+	var committedRoot *types.StateRoot
 	err = cs.repo.SaveStateData(cs.repo.Database(), stateRoot, fullStateKeyVals)
 	if err != nil {
 		logger.Errorf("StateCommitWithPreComputedState: failed to store state data to memory: %v", err)
 	} else {
+		committedRoot = &stateRoot
 		logger.Debugf("StateCommitWithPreComputedState: persisted state for block 0x%x", blockHeaderHash[:8])
 	}
-	if !fuzzenv.Enabled() {
-		if err = cs.persistentRepo.SaveStateData(cs.persistentRepo.Database(), stateRoot, fullStateKeyVals); err != nil {
-			logger.Warnf("StateCommitWithPreComputedState: failed to store state data to disk: %v", err)
+	if !cs.inMemory {
+		if err = cs.persistBlockState(blockHeaderHash, stateRoot, fullStateKeyVals); err != nil {
+			logger.Warnf("StateCommitWithPreComputedState: failed to store state to disk: %v", err)
 		}
 	}
 
@@ -497,6 +581,7 @@ func (cs *ChainState) StateCommitWithPreComputedState(
 	cs.GetPriorStates().SetState(posterState)
 	postUnmatchedKeyVal := cs.GetPostStateUnmatchedKeyVals()
 	cs.SetPriorStateUnmatchedKeyVals(postUnmatchedKeyVal)
+	cs.priorStateRoot = committedRoot
 	cs.GetPosteriorStates().SetState(*NewPosteriorStates().state)
 }
 
@@ -525,19 +610,19 @@ func (cs *ChainState) AppendAncestry(ancestry types.Ancestry) {
 }
 
 // PruneOldData deletes old state and block data from in-memory storage (and from disk
-// when not in fuzz mode), keeping only the most recent FuzzPersistentRetainBlocks entries.
+// unless the ChainState is in memory), keeping only the most recent FuzzPersistentRetainBlocks entries.
 // Called after each successful ImportBlock in fuzz mode to prevent memory exhaustion.
+// Deleting a state also deletes the trie nodes no retained state shares.
 func (cs *ChainState) PruneOldData(stateRoot types.StateRoot, headerHash types.HeaderHash, slot types.TimeSlot) {
 	cs.persistedEntries = append(cs.persistedEntries, persistedEntry{stateRoot: stateRoot, headerHash: headerHash, slot: slot})
 	if len(cs.persistedEntries) <= fuzzenv.FuzzPersistentRetainBlocks {
 		return
 	}
 	cutoff := len(cs.persistedEntries) - fuzzenv.FuzzPersistentRetainBlocks
-	fuzzMemoryOnly := fuzzenv.Enabled()
 	for _, old := range cs.persistedEntries[:cutoff] {
 		cs.repo.DeleteStateData(cs.repo.Database(), old.stateRoot)
 		cs.repo.DeleteBlock(cs.repo.Database(), old.headerHash, old.slot)
-		if !fuzzMemoryOnly {
+		if !cs.inMemory {
 			cs.persistentRepo.DeleteStateData(cs.persistentRepo.Database(), old.stateRoot)
 			cs.persistentRepo.DeleteBlockByHash(cs.persistentRepo.Database(), types.OpaqueHash(old.headerHash))
 			cs.persistentRepo.DeleteHeaderTimeSlot(cs.persistentRepo.Database(), old.headerHash)
@@ -586,8 +671,13 @@ func (cs *ChainState) GetPriorStateUnmatchedKeyVals() types.StateKeyVals {
 	return cs.preStateUnmatchedKeyVals.DeepCopy()
 }
 
+// SetPriorStateUnmatchedKeyVals sets the prior unmatched key-vals. The prior
+// state is no longer known to be stored, so StateAccess reads the posterior
+// key-vals until the next commit.
 func (cs *ChainState) SetPriorStateUnmatchedKeyVals(unmatchedKeyVals types.StateKeyVals) {
 	cs.preStateUnmatchedKeyVals = unmatchedKeyVals
+	cs.priorStateRoot = nil
+	cs.stateAccess = nil
 }
 
 func (cs *ChainState) GetPostStateUnmatchedKeyVals() types.StateKeyVals {
@@ -606,8 +696,44 @@ func (cs *ChainState) GetPostStateUnmatchedKeyValsRef() types.StateKeyVals {
 	return cs.postStateUnmatchedKeyVals
 }
 
+// SetPostStateUnmatchedKeyVals sets the posterior unmatched key-vals and drops
+// the changes made through StateAccess.
 func (cs *ChainState) SetPostStateUnmatchedKeyVals(unmatchedKeyVals types.StateKeyVals) {
 	cs.postStateUnmatchedKeyVals = unmatchedKeyVals
+	cs.stateAccess = nil
+}
+
+// StateAccess returns the overlay the block being processed reads and
+// changes the unmatched key-vals through. It reads the prior state from repo
+// one key at a time when that state is stored, and the posterior key-vals
+// otherwise. Its changes are kept until ResetStateAccess.
+func (cs *ChainState) StateAccess() *stateaccess.Overlay {
+	if cs.stateAccess == nil {
+		cs.stateAccess = stateaccess.NewOverlay(cs.stateSource())
+	}
+	return cs.stateAccess
+}
+
+func (cs *ChainState) stateSource() stateaccess.Source {
+	if cs.priorStateRoot != nil {
+		stored, err := cs.repo.HasStateData(cs.repo.Database(), *cs.priorStateRoot)
+		if err == nil && stored {
+			return stateaccess.NewStoreSource(cs.repo, cs.repo.Database(), *cs.priorStateRoot)
+		}
+	}
+	return stateaccess.NewKeyValsSource(cs.postStateUnmatchedKeyVals)
+}
+
+// ApplyStateAccess applies the changes made through StateAccess to the
+// posterior unmatched key-vals.
+func (cs *ChainState) ApplyStateAccess() {
+	cs.postStateUnmatchedKeyVals = cs.stateAccess.Apply(cs.postStateUnmatchedKeyVals)
+}
+
+// ResetStateAccess drops the changes made through StateAccess, before a
+// block is processed.
+func (cs *ChainState) ResetStateAccess() {
+	cs.stateAccess = nil
 }
 
 /*
@@ -681,9 +807,15 @@ func (cs *ChainState) GetBlockByHash(headerHash types.HeaderHash) (types.Block,
 
 // PersistStateForBlock persists the state for a given block to Redis
 func (cs *ChainState) PersistStateForBlock(blockHeaderHash types.HeaderHash, state types.State) error {
+	_, err := cs.persistStateForBlock(blockHeaderHash, state)
+	return err
+}
+
+// persistStateForBlock is PersistStateForBlock returning the state root.
+func (cs *ChainState) persistStateForBlock(blockHeaderHash types.HeaderHash, state types.State) (types.StateRoot, error) {
 	serializedState, err := m.StateEncoder(state)
 	if err != nil {
-		return fmt.Errorf("failed to encode state: %w", err)
+		return types.StateRoot{}, fmt.Errorf("failed to encode state: %w", err)
 	}
 
 	unmatchedKeyVals := cs.GetPostStateUnmatchedKeyVals()
@@ -699,21 +831,73 @@ func (cs *ChainState) PersistStateForBlock(blockHeaderHash types.HeaderHash, sta
 
 	err = cs.repo.SaveStateRootByHeaderHash(cs.repo.Database(), blockHeaderHash, stateRoot)
 	if err != nil {
-		return fmt.Errorf("failed to store state root mapping: %w", err)
+		return types.StateRoot{}, fmt.Errorf("failed to store state root mapping: %w", err)
 	}
 
 	// Write state data to both memory (for fast reads) and disk (for persistence).
 	err = cs.repo.SaveStateData(cs.repo.Database(), stateRoot, fullStateKeyVals)
 	if err != nil {
-		return fmt.Errorf("failed to store state data to memory: %w", err)
+		return types.StateRoot{}, fmt.Errorf("failed to store state data to memory: %w", err)
 	}
-	if !fuzzenv.Enabled() {
-		if err = cs.persistentRepo.SaveStateData(cs.persistentRepo.Database(), stateRoot, fullStateKeyVals); err != nil {
-			logger.Warnf("PersistStateForBlock: failed to store state data to disk: %v", err)
+	if !cs.inMemory {
+		if err = cs.persistBlockState(blockHeaderHash, stateRoot, fullStateKeyVals); err != nil {
+			logger.Warnf("PersistStateForBlock: failed to store state to disk: %v", err)
 		}
 	}
 
-	return nil
+	return stateRoot, nil
+}
+
+// persistBlockState writes the state data and sr: mapping of blockHeaderHash
+// to disk in one durable batch. When it is the latest block, the block and
+// the canonical hh: head entry go in the same batch, so a crash never leaves
+// the head pointing at a block or state that was not stored; any other block
+// is not known to be canonical and keeps out of hh:.
+// sr: and hh: also let historical states be found by header hash or slot
+// (see store.Repository.ResolveSlot).
+func (cs *ChainState) persistBlockState(blockHeaderHash types.HeaderHash, stateRoot types.StateRoot, stateKeyVals types.StateKeyVals) error {
+	block := cs.GetLatestBlock()
+	latestHash, err := hash.ComputeBlockHeaderHash(block.Header)
+	if err != nil {
+		return fmt.Errorf("failed to compute block header hash: %w", err)
+	}
+
+	repo := cs.persistentRepo
+	canonical := latestHash == blockHeaderHash
+	if canonical && !cs.canonicalTopKnown {
+		top, _, _, err := repo.CanonicalTop(repo.Database())
+		if err != nil {
+			return fmt.Errorf("failed to find the canonical head: %w", err)
+		}
+		cs.canonicalTop, cs.canonicalTopKnown = top, true
+	}
+
+	err = repo.WithSyncBatch(func(batch database.Batch) error {
+		if canonical {
+			if err := repo.SaveBlockByHash(batch, types.OpaqueHash(blockHeaderHash), &block); err != nil {
+				return err
+			}
+			if err := repo.SaveHeaderTimeSlot(batch, blockHeaderHash, block.Header.Slot); err != nil {
+				return err
+			}
+			if err := repo.SaveCanonicalHash(batch, blockHeaderHash, block.Header.Slot); err != nil {
+				return err
+			}
+			// After a reorg to a shorter fork the old chain's entries
+			// above the new head are no longer canonical.
+			if err := repo.DeleteCanonicalHashes(batch, block.Header.Slot, cs.canonicalTop); err != nil {
+				return err
+			}
+		}
+		if err := repo.SaveStateData(batch, stateRoot, stateKeyVals); err != nil {
+			return err
+		}
+		return repo.SaveStateRootByHeaderHash(batch, blockHeaderHash, stateRoot)
+	})
+	if err == nil && canonical {
+		cs.canonicalTop = block.Header.Slot
+	}
+	return err
 }
 
 // merklizeWithKeyCache computes state root using key-level cache.
@@ -756,11 +940,23 @@ func (cs *ChainState) ClearKeyLevelCache() {
 
 // GetStateByBlockHash retrieves state data for a given block from persistent database
 func (cs *ChainState) GetStateByBlockHash(blockHeaderHash types.HeaderHash) (types.StateKeyVals, error) {
-	stateKeyVals, err := cs.repo.GetStateDataByHeaderHash(cs.repo.Database(), blockHeaderHash)
+	stateKeyVals, err := getStateDataFromSnapshot(cs.repo, blockHeaderHash)
 	if err == nil {
 		return stateKeyVals, nil
 	}
-	return cs.persistentRepo.GetStateDataByHeaderHash(cs.persistentRepo.Database(), blockHeaderHash)
+	return getStateDataFromSnapshot(cs.persistentRepo, blockHeaderHash)
+}
+
+// getStateDataFromSnapshot resolves the state root and reads the state data
+// from one snapshot, so a concurrent block import cannot be seen half applied.
+func getStateDataFromSnapshot(repo *store.Repository, blockHeaderHash types.HeaderHash) (types.StateKeyVals, error) {
+	snapshot, err := repo.Database().Snapshot()
+	if err != nil {
+		return nil, err
+	}
+	defer snapshot.Close()
+
+	return repo.GetStateDataByHeaderHash(snapshot, blockHeaderHash)
 }
 
 func (cs *ChainState) GetStateRootByBlockHash(blockHeaderHash types.HeaderHash) (types.StateRoot, error) {
@@ -802,7 +998,7 @@ func (cs *ChainState) persistBlockMapping(block types.Block) error {
 		return fmt.Errorf("failed to compute block header hash: %w", err)
 	}
 
-	if fuzzenv.Enabled() {
+	if cs.inMemory {
 		if err := cs.repo.SaveBlock(cs.repo.Database(), &block); err != nil {
 			return fmt.Errorf("failed to persist block to memory: %w", err)
 		}
@@ -849,7 +1045,13 @@ func (cs *ChainState) RestoreBlockAndState(blockHeaderHash types.HeaderHash) err
 		return err
 	}
 
-	return cs.restoreWithState(blockHeaderHash, block, state, unmatchedKeyVals)
+	if err := cs.restoreWithState(blockHeaderHash, block, state, unmatchedKeyVals); err != nil {
+		return err
+	}
+	if stateRoot, err := cs.repo.GetStateRootByHeaderHash(cs.repo.Database(), blockHeaderHash); err == nil {
+		cs.priorStateRoot = &stateRoot
+	}
+	return nil
 }
 
 // RestoreStateFromSnapshot restores block/ancestry management like RestoreBlockAndState,
@@ -959,5 +1161,68 @@ func (cs *ChainState) SeedGenesisToBackend(
 	return genesisBlockHash, genesisStateRoot, nil
 }
 
+// SeedSnapshotToBackend stores a trusted block header with its posterior
+// state and ancestry, as read from a snapshot file, so the node can continue
+// from it with RestoreStateFromSnapshot. The state must merklize to stateRoot.
+// The header is stored as a block without extrinsics and recorded as the
+// canonical and finalized head. The ancestry blocks are not stored, so their
+// hashes go to the ancestor index rather than the canonical one.
+func (cs *ChainState) SeedSnapshotToBackend(
+	ctx context.Context,
+	header types.Header,
+	ancestry types.Ancestry,
+	stateRoot types.StateRoot,
+	stateKeyVals types.StateKeyVals,
+) (types.HeaderHash, error) {
+	headerHash, err := hash.ComputeBlockHeaderHash(header)
+	if err != nil {
+		return types.HeaderHash{}, fmt.Errorf("compute header hash: %w", err)
+	}
+
+	merkleInputKeyVals, computedRoot, err := cs.BuildStateRootInputKeyValsAndRoot(stateKeyVals)
+	if err != nil {
+		return types.HeaderHash{}, fmt.Errorf("build merkle input + state root: %w", err)
+	}
+	if computedRoot != stateRoot {
+		return types.HeaderHash{}, fmt.Errorf("snapshot state root mismatch: computed=0x%x expected=0x%x", computedRoot, stateRoot)
+	}
+
+	block := types.Block{Header: header}
+	repo := cs.persistentRepo
+	err = repo.WithSyncBatch(func(batch database.Batch) error {
+		for _, item := range ancestry {
+			if item.HeaderHash == headerHash {
+				continue
+			}
+			if err := repo.SaveAncestorHash(batch, item.HeaderHash, item.Slot); err != nil {
+				return err
+			}
+		}
+		if err := repo.SaveBlockByHash(batch, types.OpaqueHash(headerHash), &block); err != nil {
+			return err
+		}
+		if err := repo.SaveHeaderTimeSlot(batch, headerHash, header.Slot); err != nil {
+			return err
+		}
+		if err := repo.SaveStateData(batch, stateRoot, merkleInputKeyVals); err != nil {
+			return err
+		}
+		if err := repo.SaveStateRootByHeaderHash(batch, headerHash, stateRoot); err != nil {
+			return err
+		}
+		if err := repo.SaveCanonicalHash(batch, headerHash, header.Slot); err != nil {
+			return err
+		}
+		return repo.SaveFinalizedHash(batch, headerHash)
+	})
+	if err != nil {
+		return types.HeaderHash{}, fmt.Errorf("store snapshot: %w", err)
+	}
+
+	cs.finalizedIndex[headerHash] = true
+	cs.AppendAncestry(ancestry)
+	return headerHash, nil
+}
+
 // Compile-time interface check
 var _ Blockchain = (*ChainState)(nil)
@@ -1,9 +1,12 @@
 package blockchain_test
 
 import (
+	"context"
+	"path/filepath"
 	"testing"
 
 	"github.com/New-JAMneration/JAM-Protocol/internal/blockchain"
+	"github.com/New-JAMneration/JAM-Protocol/internal/store"
 	"github.com/New-JAMneration/JAM-Protocol/internal/types"
 	"github.com/New-JAMneration/JAM-Protocol/internal/utilities/hash"
 	"github.com/stretchr/testify/assert"
@@ -139,3 +142,150 @@ func TestStorePersistsBlocksInPersistent(t *testing.T) {
 	require.Equal(t, block.Header.Parent, retrieved.Header.Parent, "retrieved block should match stored block")
 	require.Equal(t, block.Header.EpochMark, retrieved.Header.EpochMark, "retrieved block should match stored block")
 }
+
+func TestSeedSnapshotToBackend(t *testing.T) {
+	blockchain.ResetInstance()
+	cs := blockchain.GetInstance()
+
+	header := types.Header{Slot: 20, Parent: types.HeaderHash{9}}
+	keyVals := types.StateKeyVals{
+		{Key: types.StateKey{0xee, 1}, Value: []byte("storage")},
+	}
+	_, stateRoot, err := cs.BuildStateRootInputKeyValsAndRoot(keyVals)
+	require.NoError(t, err)
+	headerHash, err := hash.ComputeBlockHeaderHash(header)
+	require.NoError(t, err)
+	ancestry := types.Ancestry{{Slot: 19, HeaderHash: header.Parent}, {Slot: 20, HeaderHash: headerHash}}
+
+	_, err = cs.SeedSnapshotToBackend(context.Background(), header, ancestry, types.StateRoot{1}, keyVals)
+	require.Error(t, err, "a state that does not match the root is refused")
+
+	seeded, err := cs.SeedSnapshotToBackend(context.Background(), header, ancestry, stateRoot, keyVals)
+	require.NoError(t, err)
+	require.Equal(t, headerHash, seeded)
+	require.True(t, cs.IsBlockFinalized(headerHash))
+
+	_, state, err := cs.GetBlockAndState(headerHash)
+	require.NoError(t, err)
+	require.Equal(t, stateRoot, cs.ComputeStateRootWithCache(state))
+}
+
+func TestStateAccessReadsCommittedState(t *testing.T) {
+	defer tearDown()
+
+	blockchain.ResetInstance()
+	cs := blockchain.GetInstance()
+
+	key := types.StateKey{0xee, 1}
+	cs.AddBlock(types.Block{Header: types.Header{Slot: 7}})
+	cs.SetPostStateUnmatchedKeyVals(types.StateKeyVals{{Key: key, Value: []byte("stored")}})
+	cs.StateCommit()
+
+	// The committed state is read from the store, not the posterior key-vals.
+	cs.SetPostStateUnmatchedKeyVals(types.StateKeyVals{})
+	value, found, err := cs.StateAccess().Get(key)
+	require.NoError(t, err)
+	require.True(t, found)
+	require.Equal(t, []byte("stored"), value)
+
+	cs.StateAccess().Set(key, []byte("changed"))
+	cs.ApplyStateAccess()
+	require.Equal(t, types.StateKeyVals{{Key: key, Value: []byte("changed")}}, cs.GetPostStateUnmatchedKeyValsRef())
+
+	cs.ResetStateAccess()
+	value, _, err = cs.StateAccess().Get(key)
+	require.NoError(t, err)
+	require.Equal(t, []byte("stored"), value, "changes are dropped on reset")
+
+	// A prior state set directly is not known to be stored.
+	cs.SetPriorStateUnmatchedKeyVals(types.StateKeyVals{})
+	cs.SetPostStateUnmatchedKeyVals(types.StateKeyVals{})
+	_, found, err = cs.StateAccess().Get(key)
+	require.NoError(t, err)
+	require.False(t, found)
+}
+
+func TestPersistStateForBlockCanonicalHash(t *testing.T) {
+	defer tearDown()
+
+	blockchain.ResetInstance()
+	cs := blockchain.GetInstance()
+
+	latest := types.Block{Header: types.Header{Slot: 8}}
+	cs.AddBlock(latest)
+	latestHash, err := hash.ComputeBlockHeaderHash(latest.Header)
+	require.NoError(t, err)
+
+	// Only the latest block is recorded as canonical for its slot.
+	require.NoError(t, cs.PersistStateForBlock(types.HeaderHash{7}, types.State{}))
+	repo := store.NewRepository(cs.PersistentDatabase())
+	_, err = repo.GetCanonicalHash(repo.Database(), latest.Header.Slot)
+	require.Error(t, err)
+
+	require.NoError(t, cs.PersistStateForBlock(latestHash, types.State{}))
+	canonical, err := repo.GetCanonicalHash(repo.Database(), latest.Header.Slot)
+	require.NoError(t, err)
+	require.Equal(t, latestHash, canonical)
+}
+
+func TestPersistStateForBlockReorg(t *testing.T) {
+	defer tearDown()
+
+	blockchain.ResetInstance()
+	cs := blockchain.GetInstance()
+	repo := store.NewRepository(cs.PersistentDatabase())
+
+	persist := func(header types.Header) types.HeaderHash {
+		cs.AddBlock(types.Block{Header: header})
+		headerHash, err := hash.ComputeBlockHeaderHash(header)
+		require.NoError(t, err)
+		require.NoError(t, cs.PersistStateForBlock(headerHash, types.State{}))
+		return headerHash
+	}
+
+	base := persist(types.Header{Slot: 20})
+	persist(types.Header{Slot: 21, Parent: base})
+	persist(types.Header{Slot: 23, Parent: base})
+
+	// A reorg to a fork whose head is below the old one.
+	fork := persist(types.Header{Slot: 21, Parent: base, AuthorIndex: 1})
+
+	for slot, want := range map[types.TimeSlot]types.HeaderHash{20: base, 21: fork} {
+		canonical, err := repo.GetCanonicalHash(repo.Database(), slot)
+		require.NoError(t, err)
+		require.Equal(t, want, canonical, "slot %d", slot)
+	}
+	_, err := repo.GetCanonicalHash(repo.Database(), 23)
+	require.Error(t, err, "slot 23 is no longer canonical")
+
+	top, topHash, found, err := repo.CanonicalTop(repo.Database())
+	require.NoError(t, err)
+	require.True(t, found)
+	require.Equal(t, types.TimeSlot(21), top)
+	require.Equal(t, fork, topHash)
+}
+
+func TestInMemoryInstance(t *testing.T) {
+	defer tearDown()
+
+	blockchain.ResetInstanceWithOptions(blockchain.Options{InMemory: true})
+	cs := blockchain.GetInstance()
+
+	block := types.Block{Header: types.Header{Slot: 9}}
+	cs.AddBlock(block)
+	cs.SetPostStateUnmatchedKeyVals(types.StateKeyVals{{Key: types.StateKey{0xee, 2}, Value: []byte("kept")}})
+	cs.StateCommit()
+
+	headerHash, err := hash.ComputeBlockHeaderHash(block.Header)
+	require.NoError(t, err)
+	_, err = store.NewRepository(cs.PersistentDatabase()).GetStateRootByHeaderHash(cs.PersistentDatabase(), headerHash)
+	require.NoError(t, err)
+
+	require.Error(t, cs.Checkpoint(filepath.Join(t.TempDir(), "checkpoint")), "there is no database to checkpoint")
+
+	// Nothing reached the configured database.
+	blockchain.ResetInstance()
+	persistent := blockchain.GetInstance().PersistentDatabase()
+	_, err = store.NewRepository(persistent).GetStateRootByHeaderHash(persistent, headerHash)
+	require.Error(t, err)
+}
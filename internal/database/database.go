@@ -10,6 +10,7 @@ type Database interface {
 	Writer
 	Batcher
 	Iterable
+	Snapshotter
 	io.Closer
 }
 
@@ -19,6 +20,27 @@ type Reader interface {
 	Get(key []byte) ([]byte, bool, error)
 }
 
+// IterableReader is a Reader that can also iterate over key ranges.
+type IterableReader interface {
+	Reader
+	Iterable
+}
+
+// Snapshotter defines point-in-time snapshot creation for a key-value database.
+type Snapshotter interface {
+	// Snapshot returns a read-only view of the database as of the call.
+	// Writes committed afterwards, including batches, are not visible
+	// through it. The snapshot must be closed to release its resources.
+	Snapshot() (Snapshot, error)
+}
+
+// Snapshot is a consistent read-only view of a key-value database.
+type Snapshot interface {
+	Reader
+	Iterable
+	io.Closer
+}
+
 // Writer defines write-only operations for a key-value database.
 type Writer interface {
 	Put(key, value []byte) error
@@ -42,16 +64,68 @@ type Batch interface {
 	Close() error
 }
 
+// SyncBatch is a Batch whose commit can be made durable on demand. Providers
+// with a configurable fsync policy implement it so that head and finalized
+// pointer updates survive a crash even when ordinary writes are not synced.
+type SyncBatch interface {
+	Batch
+
+	// CommitSync commits the batch and waits until it is durable, as far as
+	// the provider's sync policy allows.
+	CommitSync() error
+}
+
+// CommitSync commits batch with CommitSync if it is a SyncBatch and with
+// Commit otherwise.
+func CommitSync(batch Batch) error {
+	if b, ok := batch.(SyncBatch); ok {
+		return b.CommitSync()
+	}
+	return batch.Commit()
+}
+
 // Iterable defines new iterator creation for a key-value database.
 type Iterable interface {
+	// NewIterator iterates forward over the keys with prefix, starting at
+	// prefix+start (inclusive).
 	NewIterator(prefix []byte, start []byte) (Iterator, error)
+
+	// NewIteratorWithOptions iterates over the range described by opts.
+	NewIteratorWithOptions(opts IteratorOptions) (Iterator, error)
+}
+
+// IteratorOptions describes the key range and direction of an iterator.
+// Start and End are relative to Prefix: the iterator covers the keys with
+// Prefix in [Prefix+Start, Prefix+End). An empty End leaves the range open
+// up to the end of the prefix.
+type IteratorOptions struct {
+	Prefix []byte
+	Start  []byte
+	End    []byte
+
+	// Reverse makes Next move from the largest key in the range towards
+	// the smallest.
+	Reverse bool
 }
 
 // Iterator defines the interface for iterating over key-value pairs in the database.
+//
+// A new iterator is positioned before its first entry, so Next must be called
+// before Key and Value. SeekGE and SeekLT position the iterator directly;
+// their key is a full key as returned by Key, and the iterator stays within
+// its range. After a seek, Next continues in the direction of the iterator.
 type Iterator interface {
 	Next() bool
 	Key() []byte
 	Value() []byte
 	Error() error
 	Close() error
+
+	// SeekGE moves to the smallest key in range that is >= key and reports
+	// whether such a key exists.
+	SeekGE(key []byte) bool
+
+	// SeekLT moves to the largest key in range that is < key and reports
+	// whether such a key exists.
+	SeekLT(key []byte) bool
 }
@@ -0,0 +1,194 @@
+package database
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+)
+
+// Key dumps are a provider-independent backup of selected key prefixes, used
+// where no native checkpoint exists (redis):
+//
+//	magic "JAMKVDMP" ++ (uvarint len ++ key ++ uvarint len ++ value)* ++
+//	0x00 end marker ++ uint64 count ++ uint32 CRC-32 (IEEE, little-endian)
+//
+// The checksum covers everything before it. Keys are never empty, so the
+// zero-length key marks the end of the entries.
+
+var dumpMagic = []byte("JAMKVDMP")
+
+// ErrCorruptDump is returned by LoadDump for truncated or damaged dumps.
+var ErrCorruptDump = errors.New("corrupt key dump")
+
+// Checkpointer is implemented by providers that can write a consistent copy
+// of the database to a directory while it stays in use.
+type Checkpointer interface {
+	Checkpoint(dir string) error
+}
+
+// Dump writes every key that starts with one of prefixes, read from a single
+// snapshot of db, and returns the number of entries written. No prefixes
+// selects all keys.
+func Dump(db Snapshotter, w io.Writer, prefixes [][]byte) (int, error) {
+	snapshot, err := db.Snapshot()
+	if err != nil {
+		return 0, err
+	}
+	defer snapshot.Close()
+
+	if len(prefixes) == 0 {
+		prefixes = [][]byte{nil}
+	}
+
+	checksum := crc32.NewIEEE()
+	out := bufio.NewWriter(io.MultiWriter(w, checksum))
+	if _, err := out.Write(dumpMagic); err != nil {
+		return 0, err
+	}
+
+	var lenBuf [binary.MaxVarintLen64]byte
+	writeBytes := func(b []byte) error {
+		n := binary.PutUvarint(lenBuf[:], uint64(len(b)))
+		if _, err := out.Write(lenBuf[:n]); err != nil {
+			return err
+		}
+		_, err := out.Write(b)
+		return err
+	}
+
+	count := 0
+	for _, prefix := range nonOverlapping(prefixes) {
+		iter, err := snapshot.NewIterator(prefix, nil)
+		if err != nil {
+			return count, err
+		}
+		for iter.Next() {
+			if err := writeBytes(iter.Key()); err != nil {
+				iter.Close()
+				return count, err
+			}
+			if err := writeBytes(iter.Value()); err != nil {
+				iter.Close()
+				return count, err
+			}
+			count++
+		}
+		err = iter.Error()
+		iter.Close()
+		if err != nil {
+			return count, err
+		}
+	}
+
+	var trailer [9]byte
+	binary.LittleEndian.PutUint64(trailer[1:], uint64(count))
+	if _, err := out.Write(trailer[:]); err != nil {
+		return count, err
+	}
+	if err := out.Flush(); err != nil {
+		return count, err
+	}
+
+	var sum [4]byte
+	binary.LittleEndian.PutUint32(sum[:], checksum.Sum32())
+	_, err = w.Write(sum[:])
+	return count, err
+}
+
+// LoadDump verifies a dump written by Dump and writes its entries to w in
+// batches. Nothing is written if the dump is corrupt.
+func LoadDump(r io.Reader, w Batcher) (int, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return 0, err
+	}
+	entries, err := parseDump(data)
+	if err != nil {
+		return 0, err
+	}
+
+	const batchSize = 1024
+	batch := w.NewBatch()
+	defer func() { batch.Close() }()
+	for i, entry := range entries {
+		if err := batch.Put(entry[0], entry[1]); err != nil {
+			return i, err
+		}
+		if (i+1)%batchSize == 0 {
+			if err := batch.Commit(); err != nil {
+				return i, err
+			}
+			batch.Close()
+			batch = w.NewBatch()
+		}
+	}
+	if err := batch.Commit(); err != nil {
+		return 0, err
+	}
+	return len(entries), nil
+}
+
+func parseDump(data []byte) ([][2][]byte, error) {
+	if len(data) < len(dumpMagic)+13 || !bytes.Equal(data[:len(dumpMagic)], dumpMagic) {
+		return nil, fmt.Errorf("%w: missing header", ErrCorruptDump)
+	}
+	body, sum := data[:len(data)-4], binary.LittleEndian.Uint32(data[len(data)-4:])
+	if crc32.ChecksumIEEE(body) != sum {
+		return nil, fmt.Errorf("%w: checksum mismatch", ErrCorruptDump)
+	}
+
+	rest := body[len(dumpMagic):]
+	readBytes := func() ([]byte, error) {
+		n, size := binary.Uvarint(rest)
+		if size <= 0 || n > uint64(len(rest)-size) {
+			return nil, fmt.Errorf("%w: truncated entry", ErrCorruptDump)
+		}
+		b := rest[size : size+int(n)]
+		rest = rest[size+int(n):]
+		return b, nil
+	}
+
+	var entries [][2][]byte
+	for {
+		key, err := readBytes()
+		if err != nil {
+			return nil, err
+		}
+		if len(key) == 0 {
+			break
+		}
+		value, err := readBytes()
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, [2][]byte{key, value})
+	}
+
+	if len(rest) != 8 || binary.LittleEndian.Uint64(rest) != uint64(len(entries)) {
+		return nil, fmt.Errorf("%w: entry count mismatch", ErrCorruptDump)
+	}
+	return entries, nil
+}
+
+// nonOverlapping drops prefixes covered by a shorter prefix in the list so
+// no key is dumped twice.
+func nonOverlapping(prefixes [][]byte) [][]byte {
+	var result [][]byte
+	for i, prefix := range prefixes {
+		covered := false
+		for j, other := range prefixes {
+			if i != j && bytes.HasPrefix(prefix, other) && (len(other) < len(prefix) || j < i) {
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			result = append(result, prefix)
+		}
+	}
+	return result
+}
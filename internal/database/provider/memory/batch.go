@@ -44,6 +44,8 @@ func (b *batch) Commit() error {
 		return errors.New("database is closed")
 	}
 
+	b.db.detach()
+
 	for _, op := range b.writeOps {
 		if op.isDelete {
 			delete(b.db.data, string(op.key))
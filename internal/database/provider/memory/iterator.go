@@ -9,6 +9,7 @@ import (
 
 type iterator struct {
 	index     int
+	reverse   bool
 	currKey   string
 	currValue []byte
 	keys      []string
@@ -17,24 +18,36 @@ type iterator struct {
 
 // NewIterator creates a new iterator for the given prefix. The start key is inclusive.
 func (db *memoryDB) NewIterator(prefix []byte, start []byte) (database.Iterator, error) {
+	return db.NewIteratorWithOptions(database.IteratorOptions{Prefix: prefix, Start: start})
+}
+
+// NewIteratorWithOptions creates a new iterator over the range described by opts.
+func (db *memoryDB) NewIteratorWithOptions(opts database.IteratorOptions) (database.Iterator, error) {
 	db.mu.RLock()
 	defer db.mu.RUnlock()
 
-	buf := make([]byte, 0, len(prefix)+len(start))
-	buf = append(buf, prefix...)
-	buf = append(buf, start...)
-	startString := string(buf)
+	return newIterator(db.data, opts), nil
+}
+
+func newIterator(data map[string][]byte, opts database.IteratorOptions) *iterator {
+	prefix := string(opts.Prefix)
+	lower := prefix + string(opts.Start)
+	upper := ""
+	if len(opts.End) > 0 {
+		upper = prefix + string(opts.End)
+	}
 
 	var keys []string
 
-	// Collect all keys in the range [start, end)
-	for key := range db.data {
-		if !strings.HasPrefix(key, startString) {
+	// Collect all keys in the range [lower, upper)
+	for key := range data {
+		if !strings.HasPrefix(key, prefix) || key < lower {
 			continue
 		}
-		if strings.Compare(key, startString) >= 0 {
-			keys = append(keys, key)
+		if upper != "" && key >= upper {
+			continue
 		}
+		keys = append(keys, key)
 	}
 
 	sort.Strings(keys)
@@ -43,23 +56,56 @@ func (db *memoryDB) NewIterator(prefix []byte, start []byte) (database.Iterator,
 	values := make([][]byte, len(keys))
 	for i, key := range keys {
 		// Make a copy of the value
-		v := db.data[key]
+		v := data[key]
 		valueCopy := make([]byte, len(v))
 		copy(valueCopy, v)
 		values[i] = valueCopy
 	}
 
-	return &iterator{
-		index:  -1, // Start at -1 so the first Next() call moves to index 0
-		keys:   keys,
-		values: values,
-	}, nil
+	iter := &iterator{
+		keys:    keys,
+		values:  values,
+		reverse: opts.Reverse,
+	}
+	iter.reset()
+	return iter
+}
+
+// reset moves the iterator before its first entry in iteration order.
+func (iter *iterator) reset() {
+	iter.index = -1 // Start at -1 so the first Next() call moves to index 0
+	if iter.reverse {
+		iter.index = len(iter.keys)
+	}
 }
 
 // Next advances the iterator to the next key/value pair.
 func (iter *iterator) Next() bool {
-	iter.index++
-	if iter.index >= len(iter.keys) {
+	if iter.reverse {
+		if iter.index >= 0 {
+			iter.index--
+		}
+	} else if iter.index < len(iter.keys) {
+		iter.index++
+	}
+	return iter.load()
+}
+
+// SeekGE moves to the smallest key that is greater than or equal to key.
+func (iter *iterator) SeekGE(key []byte) bool {
+	iter.index = sort.SearchStrings(iter.keys, string(key))
+	return iter.load()
+}
+
+// SeekLT moves to the largest key that is less than key.
+func (iter *iterator) SeekLT(key []byte) bool {
+	iter.index = sort.SearchStrings(iter.keys, string(key)) - 1
+	return iter.load()
+}
+
+// load updates the current entry and reports whether the iterator is valid.
+func (iter *iterator) load() bool {
+	if iter.index < 0 || iter.index >= len(iter.keys) {
 		return false
 	}
 	iter.currKey = iter.keys[iter.index]
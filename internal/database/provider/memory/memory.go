@@ -9,6 +9,14 @@ import (
 type memoryDB struct {
 	mu   sync.RWMutex
 	data map[string][]byte
+
+	// shared is set while snapshots reference data; the next write copies
+	// the map before modifying it.
+	shared bool
+
+	// path and saveOnClose are set by NewDatabaseWithOptions.
+	path        string
+	saveOnClose bool
 }
 
 func NewDatabase() database.Database {
@@ -46,6 +54,7 @@ func (db *memoryDB) Put(key, value []byte) error {
 
 	valueCopy := make([]byte, len(value))
 	copy(valueCopy, value)
+	db.detach()
 	db.data[string(key)] = valueCopy
 	return nil
 }
@@ -54,14 +63,48 @@ func (db *memoryDB) Delete(key []byte) error {
 	db.mu.Lock()
 	defer db.mu.Unlock()
 
+	db.detach()
 	delete(db.data, string(key))
 	return nil
 }
 
+// Close releases the data, saving it first if the database was opened with
+// SaveOnClose. The data is released even if saving fails.
 func (db *memoryDB) Close() error {
+	var err error
+	if db.saveOnClose {
+		err = db.save()
+	}
+
 	db.mu.Lock()
 	defer db.mu.Unlock()
 
 	db.data = nil
-	return nil
+	db.shared = false
+	return err
+}
+
+func (db *memoryDB) save() error {
+	db.mu.RLock()
+	closed := db.data == nil
+	db.mu.RUnlock()
+	if closed {
+		return nil
+	}
+	return Save(db, db.path)
+}
+
+// detach gives the database its own copy of data if snapshots share it.
+// Stored values are never modified in place, so copying the map suffices.
+// The caller must hold the write lock.
+func (db *memoryDB) detach() {
+	if !db.shared || db.data == nil {
+		return
+	}
+	data := make(map[string][]byte, len(db.data))
+	for key, value := range db.data {
+		data[key] = value
+	}
+	db.data = data
+	db.shared = false
 }
@@ -0,0 +1,86 @@
+package memory
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/New-JAMneration/JAM-Protocol/internal/database"
+)
+
+// Options configures a memory database.
+type Options struct {
+	// Path is the file the database is loaded from at construction. A
+	// missing file starts an empty database; empty keeps it purely in memory.
+	Path string
+	// SaveOnClose writes the database to Path on Close.
+	SaveOnClose bool
+}
+
+// NewDatabaseWithOptions creates a memory database, loading the key dump at
+// options.Path if it exists.
+func NewDatabaseWithOptions(options Options) (database.Database, error) {
+	if options.SaveOnClose && options.Path == "" {
+		return nil, errors.New("memory database: SaveOnClose requires a path")
+	}
+	db := &memoryDB{
+		data:        make(map[string][]byte),
+		path:        options.Path,
+		saveOnClose: options.SaveOnClose,
+	}
+	if options.Path == "" {
+		return db, nil
+	}
+
+	file, err := os.Open(options.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return db, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	if _, err := database.LoadDump(bufio.NewReader(file), db); err != nil {
+		return nil, fmt.Errorf("failed to load memory database from %s: %w", options.Path, err)
+	}
+	return db, nil
+}
+
+// Load returns a memory database with the contents of the key dump at path.
+// Unlike NewDatabaseWithOptions, the file must exist.
+func Load(path string) (database.Database, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, err
+	}
+	return NewDatabaseWithOptions(Options{Path: path})
+}
+
+// Save writes every key of db to path as a key dump. The file is replaced
+// atomically, so a failed save leaves the previous contents in place.
+func Save(db database.Snapshotter, path string) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	out := bufio.NewWriter(tmp)
+	_, err = database.Dump(db, out, nil)
+	if err == nil {
+		err = tmp.Chmod(0o644)
+	}
+	if err == nil {
+		err = out.Flush()
+	}
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to save memory database to %s: %w", path, err)
+	}
+	return os.Rename(tmp.Name(), path)
+}
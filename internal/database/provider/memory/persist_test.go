@@ -0,0 +1,46 @@
+package memory
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/New-JAMneration/JAM-Protocol/internal/database"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryDBPersistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "db.kvdump")
+
+	// A missing file starts an empty database.
+	db, err := NewDatabaseWithOptions(Options{Path: path, SaveOnClose: true})
+	require.NoError(t, err)
+	require.NoError(t, db.Put([]byte("a"), []byte("1")))
+	require.NoError(t, db.Put([]byte("b"), []byte("2")))
+	require.NoError(t, db.Close())
+
+	db, err = NewDatabaseWithOptions(Options{Path: path})
+	require.NoError(t, err)
+	value, found, err := db.Get([]byte("b"))
+	require.NoError(t, err)
+	require.True(t, found)
+	require.Equal(t, []byte("2"), value)
+
+	// Without SaveOnClose the file is left untouched.
+	require.NoError(t, db.Delete([]byte("a")))
+	require.NoError(t, db.Close())
+	db, err = Load(path)
+	require.NoError(t, err)
+	has, err := db.Has([]byte("a"))
+	require.NoError(t, err)
+	require.True(t, has)
+	require.NoError(t, db.Close())
+
+	// A damaged file is refused.
+	require.NoError(t, os.WriteFile(path, []byte("JAMKVDMP garbage"), 0o644))
+	_, err = NewDatabaseWithOptions(Options{Path: path})
+	require.ErrorIs(t, err, database.ErrCorruptDump)
+
+	_, err = Load(filepath.Join(t.TempDir(), "missing.kvdump"))
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
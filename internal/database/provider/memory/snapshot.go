@@ -0,0 +1,81 @@
+package memory
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/New-JAMneration/JAM-Protocol/internal/database"
+)
+
+type snapshot struct {
+	mu   sync.RWMutex
+	data map[string][]byte
+}
+
+// Snapshot shares the current map with the returned snapshot. The database
+// copies the map on its next write, so taking a snapshot is O(1).
+func (db *memoryDB) Snapshot() (database.Snapshot, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if db.data == nil {
+		return nil, errors.New("database is closed")
+	}
+
+	db.shared = true
+	return &snapshot{data: db.data}, nil
+}
+
+func (s *snapshot) Has(key []byte) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.data == nil {
+		return false, errors.New("snapshot is closed")
+	}
+
+	_, exists := s.data[string(key)]
+	return exists, nil
+}
+
+func (s *snapshot) Get(key []byte) ([]byte, bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.data == nil {
+		return nil, false, errors.New("snapshot is closed")
+	}
+
+	value, exists := s.data[string(key)]
+	if !exists {
+		return nil, false, nil
+	}
+
+	result := make([]byte, len(value))
+	copy(result, value)
+	return result, true, nil
+}
+
+func (s *snapshot) NewIterator(prefix []byte, start []byte) (database.Iterator, error) {
+	return s.NewIteratorWithOptions(database.IteratorOptions{Prefix: prefix, Start: start})
+}
+
+func (s *snapshot) NewIteratorWithOptions(opts database.IteratorOptions) (database.Iterator, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.data == nil {
+		return nil, errors.New("snapshot is closed")
+	}
+
+	return newIterator(s.data, opts), nil
+}
+
+// Close releases the snapshot. It is safe to call Close multiple times.
+func (s *snapshot) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.data = nil
+	return nil
+}
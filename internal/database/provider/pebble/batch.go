@@ -40,6 +40,12 @@ func (b *batch) Commit() error {
 	return b.pb.Commit(b.db.writeOpts)
 }
 
+// CommitSync commits the batch and, unless the policy is SyncNever, waits
+// until it is durable.
+func (b *batch) CommitSync() error {
+	return b.pb.Commit(b.db.syncOpts)
+}
+
 func (b *batch) Close() error {
 	return b.pb.Close()
 }
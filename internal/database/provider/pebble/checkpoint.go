@@ -0,0 +1,54 @@
+package pebble
+
+import (
+	"os"
+	"strings"
+
+	"github.com/cockroachdb/pebble"
+	"github.com/cockroachdb/pebble/vfs"
+)
+
+// Checkpoint writes a consistent copy of the database to dir, which must not
+// exist yet. SSTables are hard-linked where the filesystem allows it, so the
+// checkpoint is cheap and does not block concurrent writes.
+func (db *pebbleDB) Checkpoint(dir string) error {
+	if db.readOnly {
+		return db.copyFiles(dir)
+	}
+	return db.inner.Checkpoint(dir, pebble.WithFlushedWAL())
+}
+
+// copyFiles copies the files of a read-only database to dir. Pebble cannot
+// checkpoint a database it opened read-only, but the directory lock keeps
+// writers out, so the files as they are form a consistent copy.
+func (db *pebbleDB) copyFiles(dir string) error {
+	if _, err := db.fs.Stat(dir); err == nil {
+		return &os.PathError{Op: "checkpoint", Path: dir, Err: os.ErrExist}
+	}
+	names, err := db.fs.List(db.datadir)
+	if err != nil {
+		return err
+	}
+	if err := db.fs.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	for _, name := range names {
+		src, dst := db.fs.PathJoin(db.datadir, name), db.fs.PathJoin(dir, name)
+		info, err := db.fs.Stat(src)
+		if err != nil {
+			return err
+		}
+		if name == "LOCK" || info.IsDir() {
+			continue
+		}
+		if strings.HasSuffix(name, ".sst") {
+			err = vfs.LinkOrCopy(db.fs, src, dst)
+		} else {
+			err = vfs.Copy(db.fs, src, dst)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
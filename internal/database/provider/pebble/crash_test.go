@@ -0,0 +1,145 @@
+package pebble_test
+
+import (
+	"math"
+	"sync/atomic"
+	"testing"
+
+	"github.com/New-JAMneration/JAM-Protocol/internal/database"
+	pebbledb "github.com/New-JAMneration/JAM-Protocol/internal/database/provider/pebble"
+	"github.com/cockroachdb/pebble/vfs"
+	"github.com/cockroachdb/pebble/vfs/errorfs"
+	"github.com/test-go/testify/require"
+)
+
+// crash simulates a power loss: everything written to fs after the last
+// sync is dropped.
+func crash(t *testing.T, fs *vfs.MemFS, db database.Database) {
+	t.Helper()
+	fs.SetIgnoreSyncs(true)
+	require.NoError(t, db.Close())
+	fs.ResetToSyncedState()
+	fs.SetIgnoreSyncs(false)
+}
+
+func commit(t *testing.T, db database.Database, durable bool, kvs ...string) {
+	t.Helper()
+	batch := db.NewBatch()
+	defer batch.Close()
+	for i := 0; i < len(kvs); i += 2 {
+		require.NoError(t, batch.Put([]byte(kvs[i]), []byte(kvs[i+1])))
+	}
+	if durable {
+		require.NoError(t, database.CommitSync(batch))
+	} else {
+		require.NoError(t, batch.Commit())
+	}
+}
+
+func TestSyncPolicyCrash(t *testing.T) {
+	tests := []struct {
+		policy       pebbledb.SyncPolicy
+		keepsDurable bool
+		keepsPlain   bool
+	}{
+		{policy: pebbledb.SyncAlways, keepsDurable: true, keepsPlain: true},
+		{policy: pebbledb.SyncOnFinalization, keepsDurable: true, keepsPlain: false},
+		{policy: pebbledb.SyncNever, keepsDurable: false, keepsPlain: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.policy.String(), func(t *testing.T) {
+			fs := vfs.NewStrictMem()
+			options := pebbledb.Options{FS: fs, Sync: tt.policy}
+
+			db, err := pebbledb.NewDatabaseWithOptions("", options)
+			require.NoError(t, err)
+
+			// A head update followed by an ordinary write.
+			commit(t, db, true, "b:1", "block", "hh:1", "b:1")
+			commit(t, db, false, "b:2", "block")
+			require.NoError(t, db.Put([]byte("ht:2"), []byte("2")))
+
+			crash(t, fs, db)
+
+			db, err = pebbledb.NewDatabaseWithOptions("", options)
+			require.NoError(t, err)
+			defer db.Close()
+
+			for key, want := range map[string]bool{
+				"b:1": tt.keepsDurable, "hh:1": tt.keepsDurable,
+				"b:2": tt.keepsPlain, "ht:2": tt.keepsPlain,
+			} {
+				has, err := db.Has([]byte(key))
+				require.NoError(t, err)
+				require.Equal(t, want, has, "key %s", key)
+			}
+		})
+	}
+}
+
+// TestSyncBatchIsAtomic cuts the power at every write operation of a head
+// update and checks that the block and the head pointer are recovered
+// together or not at all.
+func TestSyncBatchIsAtomic(t *testing.T) {
+	var index atomic.Int32
+	triggered := func() bool { return index.Load() < 0 }
+
+	for k := int32(0); ; k++ {
+		mem := vfs.NewStrictMem()
+		fs := errorfs.Wrap(mem, errorfs.InjectorFunc(func(op errorfs.Op, _ string) error {
+			// Writes after the k-th one never reach stable storage.
+			if op.OpKind() == errorfs.OpKindWrite && index.Add(-1) == -1 {
+				mem.SetIgnoreSyncs(true)
+			}
+			return nil
+		}))
+		options := pebbledb.Options{FS: fs, Sync: pebbledb.SyncOnFinalization}
+
+		index.Store(math.MaxInt32)
+		db, err := pebbledb.NewDatabaseWithOptions("", options)
+		require.NoError(t, err)
+		commit(t, db, true, "b:1", "block", "hh:1", "b:1")
+
+		index.Store(k)
+		commit(t, db, true, "b:2", "block", "sd:2", "state", "hh:2", "b:2")
+		require.NoError(t, db.Close())
+		if !triggered() {
+			break
+		}
+
+		mem.ResetToSyncedState()
+		mem.SetIgnoreSyncs(false)
+		index.Store(math.MaxInt32)
+
+		db, err = pebbledb.NewDatabaseWithOptions("", options)
+		require.NoError(t, err)
+
+		has := func(key string) bool {
+			found, err := db.Has([]byte(key))
+			require.NoError(t, err)
+			return found
+		}
+		require.True(t, has("b:1"), "crash at write %d lost a durable block", k)
+		require.True(t, has("hh:1"), "crash at write %d lost a durable head", k)
+		head := has("hh:2")
+		require.Equal(t, head, has("b:2"), "crash at write %d split the batch", k)
+		require.Equal(t, head, has("sd:2"), "crash at write %d split the batch", k)
+		require.NoError(t, db.Close())
+	}
+}
+
+func TestParseSyncPolicy(t *testing.T) {
+	for _, policy := range []pebbledb.SyncPolicy{pebbledb.SyncAlways, pebbledb.SyncOnFinalization, pebbledb.SyncNever} {
+		parsed, err := pebbledb.ParseSyncPolicy(policy.String())
+		require.NoError(t, err)
+		require.Equal(t, policy, parsed)
+	}
+
+	parsed, err := pebbledb.ParseSyncPolicy("")
+	require.NoError(t, err)
+	require.Equal(t, pebbledb.SyncOnFinalization, parsed)
+
+	_, err = pebbledb.ParseSyncPolicy("sometimes")
+	require.Error(t, err)
+}
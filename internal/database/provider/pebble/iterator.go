@@ -1,18 +1,30 @@
 package pebble
 
 import (
+	"bytes"
+
 	"github.com/New-JAMneration/JAM-Protocol/internal/database"
 	"github.com/cockroachdb/pebble"
 )
 
 type iterator struct {
-	inner  *pebble.Iterator
-	isHead bool
-	closed bool
+	inner   *pebble.Iterator
+	isHead  bool
+	reverse bool
+	closed  bool
 }
 
 // NewIterator creates a new iterator for the given prefix. The start key is inclusive.
 func (db *pebbleDB) NewIterator(prefix []byte, start []byte) (database.Iterator, error) {
+	return newIterator(db.inner, database.IteratorOptions{Prefix: prefix, Start: start})
+}
+
+// NewIteratorWithOptions creates a new iterator over the range described by opts.
+func (db *pebbleDB) NewIteratorWithOptions(opts database.IteratorOptions) (database.Iterator, error) {
+	return newIterator(db.inner, opts)
+}
+
+func newIterator(r pebble.Reader, opts database.IteratorOptions) (database.Iterator, error) {
 	// prefix iterator upper bound calculation
 	// https://github.com/cockroachdb/pebble/blob/ffc306f908df470254d953bf865aca1c94e49271/iterator_example_test.go#L44
 	keyUpperBound := func(b []byte) []byte {
@@ -27,23 +39,39 @@ func (db *pebbleDB) NewIterator(prefix []byte, start []byte) (database.Iterator,
 		return nil // no upper-bound
 	}
 
-	lowerBound := make([]byte, 0, len(prefix)+len(start))
-	lowerBound = append(lowerBound, prefix...)
-	lowerBound = append(lowerBound, start...)
+	lowerBound := make([]byte, 0, len(opts.Prefix)+len(opts.Start))
+	lowerBound = append(lowerBound, opts.Prefix...)
+	lowerBound = append(lowerBound, opts.Start...)
+
+	upperBound := keyUpperBound(opts.Prefix)
+	if len(opts.End) > 0 {
+		end := make([]byte, 0, len(opts.Prefix)+len(opts.End))
+		end = append(end, opts.Prefix...)
+		end = append(end, opts.End...)
+		if upperBound == nil || bytes.Compare(end, upperBound) < 0 {
+			upperBound = end
+		}
+	}
 
-	iter, err := db.inner.NewIter(&pebble.IterOptions{
+	iter, err := r.NewIter(&pebble.IterOptions{
 		LowerBound: lowerBound,
-		UpperBound: keyUpperBound(prefix),
+		UpperBound: upperBound,
 	})
 	if err != nil {
 		return nil, err
 	}
 
-	iter.First()
+	if opts.Reverse {
+		iter.Last()
+	} else {
+		iter.First()
+	}
+
 	return &iterator{
-		inner:  iter,
-		isHead: true,
-		closed: false,
+		inner:   iter,
+		isHead:  true,
+		reverse: opts.Reverse,
+		closed:  false,
 	}, nil
 }
 
@@ -53,9 +81,24 @@ func (it *iterator) Next() bool {
 		it.isHead = false
 		return it.inner.Valid()
 	}
+	if it.reverse {
+		return it.inner.Prev()
+	}
 	return it.inner.Next()
 }
 
+// SeekGE moves to the smallest key that is greater than or equal to key.
+func (it *iterator) SeekGE(key []byte) bool {
+	it.isHead = false
+	return it.inner.SeekGE(key)
+}
+
+// SeekLT moves to the largest key that is less than key.
+func (it *iterator) SeekLT(key []byte) bool {
+	it.isHead = false
+	return it.inner.SeekLT(key)
+}
+
 // Key returns the current key.
 // The returned slice is only valid until the next call to `Next()`, and should not be modified.
 func (iter *iterator) Key() []byte {
@@ -12,14 +12,35 @@ type pebbleDB struct {
 	datadir   string
 	inner     *pebble.DB
 	writeOpts *pebble.WriteOptions
+	syncOpts  *pebble.WriteOptions
+	readOnly  bool
+	fs        vfs.FS
 }
 
+// Options configures a pebble database.
+type Options struct {
+	ReadOnly bool
+	Sync     SyncPolicy
+
+	// FS overrides the filesystem, e.g. for in-memory or fault-injecting
+	// tests. Nil uses the OS filesystem.
+	FS vfs.FS
+}
+
+// NewDatabase opens the pebble database in datadir with the default
+// SyncOnFinalization policy.
 func NewDatabase(datadir string, readOnly bool) (database.Database, error) {
+	return NewDatabaseWithOptions(datadir, Options{ReadOnly: readOnly, Sync: SyncOnFinalization})
+}
+
+// NewDatabaseWithOptions opens the pebble database in datadir.
+func NewDatabaseWithOptions(datadir string, options Options) (database.Database, error) {
 	opt := &pebble.Options{
 		// Default compaction concurrency is 1, use all available logical cores
 		// for speeding up compactions.
 		MaxConcurrentCompactions: runtime.NumCPU,
-		ReadOnly:                 readOnly,
+		ReadOnly:                 options.ReadOnly,
+		FS:                       options.FS,
 	}
 	db, err := pebble.Open(datadir, opt)
 	if err != nil {
@@ -28,26 +49,31 @@ func NewDatabase(datadir string, readOnly bool) (database.Database, error) {
 	engine := &pebbleDB{
 		datadir:   datadir,
 		inner:     db,
-		writeOpts: pebble.NoSync,
+		writeOpts: options.Sync.writeOptions(),
+		syncOpts:  options.Sync.syncOptions(),
+		readOnly:  options.ReadOnly,
+		fs:        options.FS,
+	}
+	if engine.fs == nil {
+		engine.fs = vfs.Default
 	}
 	return engine, nil
 }
 
 func NewTestDatabase() (database.Database, error) {
-	db, err := pebble.Open("", &pebble.Options{
-		FS: vfs.NewMem(),
-	})
-	if err != nil {
-		return nil, err
-	}
-	engine := &pebbleDB{
-		inner: db,
-	}
-	return engine, nil
+	return NewDatabaseWithOptions("", Options{FS: vfs.NewMem(), Sync: SyncNever})
 }
 
 func (db *pebbleDB) Has(key []byte) (bool, error) {
-	_, closer, err := db.inner.Get(key)
+	return has(db.inner, key)
+}
+
+func (db *pebbleDB) Get(key []byte) ([]byte, bool, error) {
+	return get(db.inner, key)
+}
+
+func has(r pebble.Reader, key []byte) (bool, error) {
+	_, closer, err := r.Get(key)
 	if err == pebble.ErrNotFound {
 		return false, nil
 	} else if err != nil {
@@ -59,8 +85,8 @@ func (db *pebbleDB) Has(key []byte) (bool, error) {
 	return true, nil
 }
 
-func (db *pebbleDB) Get(key []byte) ([]byte, bool, error) {
-	value, closer, err := db.inner.Get(key)
+func get(r pebble.Reader, key []byte) ([]byte, bool, error) {
+	value, closer, err := r.Get(key)
 	if err != nil {
 		if err == pebble.ErrNotFound {
 			return nil, false, nil
@@ -0,0 +1,43 @@
+package pebble
+
+import (
+	"github.com/New-JAMneration/JAM-Protocol/internal/database"
+	"github.com/cockroachdb/pebble"
+)
+
+type snapshot struct {
+	inner  *pebble.Snapshot
+	closed bool
+}
+
+// Snapshot returns a pebble snapshot, which pins the sequence number at the
+// time of the call until it is closed.
+func (db *pebbleDB) Snapshot() (database.Snapshot, error) {
+	return &snapshot{inner: db.inner.NewSnapshot()}, nil
+}
+
+func (s *snapshot) Has(key []byte) (bool, error) {
+	return has(s.inner, key)
+}
+
+func (s *snapshot) Get(key []byte) ([]byte, bool, error) {
+	return get(s.inner, key)
+}
+
+func (s *snapshot) NewIterator(prefix []byte, start []byte) (database.Iterator, error) {
+	return newIterator(s.inner, database.IteratorOptions{Prefix: prefix, Start: start})
+}
+
+func (s *snapshot) NewIteratorWithOptions(opts database.IteratorOptions) (database.Iterator, error) {
+	return newIterator(s.inner, opts)
+}
+
+// Close releases the snapshot.
+// It is safe to call Close multiple times.
+func (s *snapshot) Close() error {
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+	return s.inner.Close()
+}
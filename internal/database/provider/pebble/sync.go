@@ -0,0 +1,63 @@
+package pebble
+
+import (
+	"fmt"
+
+	"github.com/cockroachdb/pebble"
+)
+
+// SyncPolicy controls when pebble writes are fsynced to the write-ahead log.
+type SyncPolicy int
+
+const (
+	// SyncOnFinalization syncs only batches committed with CommitSync, which
+	// the node uses for head and finalized pointer updates. A crash loses at
+	// most the writes since the last such batch.
+	SyncOnFinalization SyncPolicy = iota
+	// SyncAlways syncs every write and batch commit.
+	SyncAlways
+	// SyncNever never syncs and leaves durability to the OS page cache.
+	SyncNever
+)
+
+// ParseSyncPolicy parses the database.sync config value. An empty string
+// selects SyncOnFinalization.
+func ParseSyncPolicy(s string) (SyncPolicy, error) {
+	switch s {
+	case "", "on-finalization":
+		return SyncOnFinalization, nil
+	case "always":
+		return SyncAlways, nil
+	case "never":
+		return SyncNever, nil
+	}
+	return 0, fmt.Errorf("unknown sync policy %q (want always, on-finalization or never)", s)
+}
+
+func (p SyncPolicy) String() string {
+	switch p {
+	case SyncOnFinalization:
+		return "on-finalization"
+	case SyncAlways:
+		return "always"
+	case SyncNever:
+		return "never"
+	}
+	return fmt.Sprintf("SyncPolicy(%d)", int(p))
+}
+
+// writeOptions are used for plain writes and Commit.
+func (p SyncPolicy) writeOptions() *pebble.WriteOptions {
+	if p == SyncAlways {
+		return pebble.Sync
+	}
+	return pebble.NoSync
+}
+
+// syncOptions are used for CommitSync.
+func (p SyncPolicy) syncOptions() *pebble.WriteOptions {
+	if p == SyncNever {
+		return pebble.NoSync
+	}
+	return pebble.Sync
+}
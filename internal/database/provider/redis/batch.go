@@ -11,7 +11,9 @@ type batch struct {
 
 func (db *redisDB) NewBatch() database.Batch {
 	return &batch{
-		pipeline: db.client.Pipeline(),
+		// MULTI/EXEC makes the batch atomic, so snapshots never observe it
+		// half applied.
+		pipeline: db.client.TxPipeline(),
 	}
 }
 
@@ -24,6 +26,7 @@ func (b *batch) Delete(key []byte) error {
 }
 
 func (b *batch) Commit() error {
+	b.pipeline.Incr(versionKey)
 	_, err := b.pipeline.Exec()
 	return err
 }
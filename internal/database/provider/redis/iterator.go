@@ -1,6 +1,8 @@
 package redis
 
 import (
+	"bytes"
+	"sort"
 	"strings"
 
 	"github.com/New-JAMneration/JAM-Protocol/internal/database"
@@ -9,6 +11,7 @@ import (
 
 type iterator struct {
 	index     int
+	reverse   bool
 	currKey   []byte
 	currValue []byte
 	keys      [][]byte
@@ -17,40 +20,29 @@ type iterator struct {
 
 // NewIterator creates a new iterator for the given prefix. The start key is inclusive.
 func (db *redisDB) NewIterator(prefix []byte, start []byte) (database.Iterator, error) {
-	buf := make([]byte, 0, len(prefix)+len(start))
-	buf = append(buf, prefix...)
-	buf = append(buf, start...)
-	startString := string(buf)
-
-	var nextCursor uint64
-	// Pre-allocate with heuristic: SCAN uses COUNT 100
-	allKeys := make([]string, 0, 100)
-	var err error
-
-	pattern := startString + "*"
-
-	for {
-		var keys []string
-		keys, nextCursor, err = db.client.Scan(nextCursor, pattern, 100).Result()
-		if err != nil {
-			return nil, err
-		}
-
-		// Filter keys that match the prefix
-		for _, key := range keys {
-			if strings.HasPrefix(key, startString) {
-				allKeys = append(allKeys, key)
-			}
-		}
+	return db.NewIteratorWithOptions(database.IteratorOptions{Prefix: prefix, Start: start})
+}
 
-		if nextCursor == 0 {
-			break
+// NewIteratorWithOptions creates a new iterator over the range described by opts.
+// SCAN returns keys in no particular order, so the matching keys are loaded
+// and sorted up front.
+func (db *redisDB) NewIteratorWithOptions(opts database.IteratorOptions) (database.Iterator, error) {
+	var allKeys []string
+	err := scanPrefix(db.client, opts.Prefix, func(key string) {
+		if inRange(key, opts) {
+			allKeys = append(allKeys, key)
 		}
+	})
+	if err != nil {
+		return nil, err
 	}
 
+	sort.Strings(allKeys)
+
 	// Pre-allocate capacity for keys and values
 	keys := make([][]byte, 0, len(allKeys))
 	values := make([][]byte, 0, len(allKeys))
+
 	for _, key := range allKeys {
 		value, err := db.client.Get(key).Bytes()
 		if err != nil && err != redis.Nil {
@@ -64,17 +56,110 @@ func (db *redisDB) NewIterator(prefix []byte, start []byte) (database.Iterator,
 		values = append(values, value)
 	}
 
-	return &iterator{
-		index:  -1, // Start at -1 so the first Next() call moves to index 0
-		keys:   keys,
-		values: values,
-	}, nil
+	return newIterator(keys, values, opts.Reverse), nil
+}
+
+// newIterator iterates over keys, which must be sorted, and their values.
+func newIterator(keys, values [][]byte, reverse bool) *iterator {
+	iter := &iterator{
+		index:   -1, // Start at -1 so the first Next() call moves to index 0
+		reverse: reverse,
+		keys:    keys,
+		values:  values,
+	}
+	if reverse {
+		iter.index = len(keys)
+	}
+	return iter
+}
+
+// scanner is implemented by redis.Client and by redis.Tx.
+type scanner interface {
+	Scan(cursor uint64, match string, count int64) *redis.ScanCmd
+}
+
+// scanPrefix calls fn with every key that starts with prefix, in no
+// particular order. SCAN walks the keyspace in steps instead of blocking the
+// server the way KEYS does.
+func scanPrefix(c scanner, prefix []byte, fn func(key string)) error {
+	pattern := escapePattern(string(prefix)) + "*"
+	var cursor uint64
+	for {
+		keys, next, err := c.Scan(cursor, pattern, 100).Result()
+		if err != nil {
+			return err
+		}
+		for _, key := range keys {
+			fn(key)
+		}
+		if next == 0 {
+			return nil
+		}
+		cursor = next
+	}
+}
+
+// inRange reports whether key lies in [Prefix+Start, Prefix+End) and has Prefix.
+func inRange(key string, opts database.IteratorOptions) bool {
+	if key == versionKey {
+		return false
+	}
+	prefix := string(opts.Prefix)
+	if !strings.HasPrefix(key, prefix) || key < prefix+string(opts.Start) {
+		return false
+	}
+	return len(opts.End) == 0 || key < prefix+string(opts.End)
+}
+
+// escapePattern escapes the glob characters of a SCAN MATCH pattern.
+func escapePattern(s string) string {
+	// Keys are binary, so walk bytes: ranging over runes would turn every
+	// byte that is not valid UTF-8 into U+FFFD.
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '*', '?', '[', ']', '\\':
+			b.WriteByte('\\')
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
 }
 
 // Next advances the iterator to the next key/value pair.
 func (iter *iterator) Next() bool {
-	iter.index++
-	if iter.index >= len(iter.keys) {
+	if iter.reverse {
+		if iter.index >= 0 {
+			iter.index--
+		}
+	} else if iter.index < len(iter.keys) {
+		iter.index++
+	}
+	return iter.load()
+}
+
+// SeekGE moves to the smallest key that is greater than or equal to key.
+func (iter *iterator) SeekGE(key []byte) bool {
+	iter.index = iter.search(key)
+	return iter.load()
+}
+
+// SeekLT moves to the largest key that is less than key.
+func (iter *iterator) SeekLT(key []byte) bool {
+	iter.index = iter.search(key) - 1
+	return iter.load()
+}
+
+// search returns the index of the first key that is not less than key.
+func (iter *iterator) search(key []byte) int {
+	return sort.Search(len(iter.keys), func(i int) bool {
+		return bytes.Compare(iter.keys[i], key) >= 0
+	})
+}
+
+// load updates the current entry and reports whether the iterator is valid.
+func (iter *iterator) load() bool {
+	if iter.index < 0 || iter.index >= len(iter.keys) {
 		return false
 	}
 	iter.currKey = iter.keys[iter.index]
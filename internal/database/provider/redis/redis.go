@@ -5,20 +5,46 @@ import (
 	"github.com/go-redis/redis"
 )
 
+// versionKey is incremented by every write in the same transaction, so a
+// snapshot watching it notices writes that land while it reads. It is kept
+// out of iterators and snapshots.
+const versionKey = "\xffredis:version"
+
 type redisDB struct {
 	client *redis.Client
+
+	// prefixes scope snapshots to the keys the caller owns; empty covers
+	// the whole database.
+	prefixes [][]byte
+}
+
+// Options configures a Redis-backed database.
+type Options struct {
+	Addr     string
+	Password string
+	DB       int
+
+	// Prefixes limits snapshots to keys with one of these prefixes; other
+	// keys read as missing through a snapshot. Empty snapshots every key.
+	Prefixes [][]byte
 }
 
 // NewDatabase creates a new Redis-backed database.
 func NewDatabase(addr string, password string, db int) database.Database {
+	return NewDatabaseWithOptions(Options{Addr: addr, Password: password, DB: db})
+}
+
+// NewDatabaseWithOptions creates a new Redis-backed database configured by options.
+func NewDatabaseWithOptions(options Options) database.Database {
 	client := redis.NewClient(&redis.Options{
-		Addr:     addr,
-		Password: password,
-		DB:       db,
+		Addr:     options.Addr,
+		Password: options.Password,
+		DB:       options.DB,
 	})
 
 	return &redisDB{
-		client: client,
+		client:   client,
+		prefixes: options.Prefixes,
 	}
 }
 
@@ -44,11 +70,21 @@ func (db *redisDB) Get(key []byte) ([]byte, bool, error) {
 }
 
 func (db *redisDB) Put(key, value []byte) error {
-	return db.client.Set(string(key), value, 0).Err()
+	_, err := db.client.TxPipelined(func(pipe redis.Pipeliner) error {
+		pipe.Set(string(key), value, 0)
+		pipe.Incr(versionKey)
+		return nil
+	})
+	return err
 }
 
 func (db *redisDB) Delete(key []byte) error {
-	return db.client.Del(string(key)).Err()
+	_, err := db.client.TxPipelined(func(pipe redis.Pipeliner) error {
+		pipe.Del(string(key))
+		pipe.Incr(versionKey)
+		return nil
+	})
+	return err
 }
 
 func (db *redisDB) Close() error {
@@ -1,11 +1,13 @@
 package redis
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/New-JAMneration/JAM-Protocol/internal/database"
 	database_test "github.com/New-JAMneration/JAM-Protocol/internal/database/test"
 	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis"
 )
 
 func TestRedisDatabase(t *testing.T) {
@@ -19,6 +21,86 @@ func TestRedisDatabase(t *testing.T) {
 			mr.Close()
 		})
 
-		return NewDatabase(mr.Addr(), "", 0)
+		db := NewDatabase(mr.Addr(), "", 0)
+		db.(*redisDB).client.WrapProcess(widenMatch)
+		return db
 	})
 }
+
+// widenMatch works around miniredis compiling SCAN MATCH patterns into a
+// UTF-8 regexp, which panics on the raw bytes Redis itself matches. Each
+// byte >= 0x80 becomes '*', so miniredis returns a superset of the keys and
+// inRange trims it to the prefix.
+func widenMatch(process func(redis.Cmder) error) func(redis.Cmder) error {
+	return func(cmd redis.Cmder) error {
+		args := cmd.Args()
+		for i := 0; cmd.Name() == "scan" && i+1 < len(args); i++ {
+			if args[i] != "match" {
+				continue
+			}
+			pattern := args[i+1].(string)
+			args[i+1] = strings.Map(func(r rune) rune {
+				if r >= 0x80 {
+					return '*'
+				}
+				return r
+			}, pattern)
+		}
+		return process(cmd)
+	}
+}
+
+func TestSnapshotPrefixes(t *testing.T) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("failed to start miniredis: %v", err)
+	}
+	defer mr.Close()
+
+	db := NewDatabaseWithOptions(Options{Addr: mr.Addr(), Prefixes: [][]byte{[]byte("a:"), []byte("b:")}})
+	defer db.Close()
+	for _, key := range []string{"a:1", "b:1", "c:1"} {
+		if err := db.Put([]byte(key), []byte(key)); err != nil {
+			t.Fatalf("Put(%q): %v", key, err)
+		}
+	}
+
+	snapshot, err := db.Snapshot()
+	if err != nil {
+		t.Fatalf("Snapshot: %v", err)
+	}
+	defer snapshot.Close()
+
+	for key, want := range map[string]bool{"a:1": true, "b:1": true, "c:1": false, versionKey: false} {
+		if _, found, err := snapshot.Get([]byte(key)); err != nil || found != want {
+			t.Errorf("snapshot Get(%q) found %v (err %v), want %v", key, found, err, want)
+		}
+	}
+
+	// The write counter stays out of iteration over the whole database.
+	iter, err := db.NewIterator(nil, nil)
+	if err != nil {
+		t.Fatalf("NewIterator: %v", err)
+	}
+	defer iter.Close()
+	var keys []string
+	for iter.Next() {
+		keys = append(keys, string(iter.Key()))
+	}
+	if len(keys) != 3 {
+		t.Errorf("iterated keys %q, want a:1, b:1 and c:1", keys)
+	}
+}
+
+func TestEscapePattern(t *testing.T) {
+	for in, want := range map[string]string{
+		"h:":           "h:",
+		"a*b?c[d]e\\f": "a\\*b\\?c\\[d\\]e\\\\f",
+		"hh:\x90\x01":  "hh:\x90\x01",
+		"\xff*":        "\xff\\*",
+	} {
+		if got := escapePattern(in); got != want {
+			t.Errorf("escapePattern(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package redis
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/New-JAMneration/JAM-Protocol/internal/database"
+	"github.com/go-redis/redis"
+)
+
+// snapshotAttempts bounds how often Snapshot starts over because a write
+// landed while it was reading.
+const snapshotAttempts = 10
+
+type snapshot struct {
+	mu   sync.RWMutex
+	data map[string][]byte
+}
+
+// Snapshot copies the keys under the configured prefixes into memory. Redis
+// has no native snapshots, so the cost is proportional to the size of those
+// keys; they are found with SCAN and read in one MULTI/EXEC, which fails and
+// is retried when a write commits in between.
+func (db *redisDB) Snapshot() (database.Snapshot, error) {
+	for attempt := 0; attempt < snapshotAttempts; attempt++ {
+		data, err := db.readSnapshot()
+		if err == redis.TxFailedErr {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return &snapshot{data: data}, nil
+	}
+	return nil, fmt.Errorf("snapshot interrupted by writes %d times", snapshotAttempts)
+}
+
+// readSnapshot reads every key under the prefixes while watching versionKey,
+// so it returns redis.TxFailedErr unless no write committed after the scan
+// started.
+func (db *redisDB) readSnapshot() (map[string][]byte, error) {
+	prefixes := db.prefixes
+	if len(prefixes) == 0 {
+		prefixes = [][]byte{nil}
+	}
+
+	var data map[string][]byte
+	err := db.client.Watch(func(tx *redis.Tx) error {
+		seen := make(map[string]bool)
+		var keys []string
+		for _, prefix := range prefixes {
+			err := scanPrefix(tx, prefix, func(key string) {
+				if key != versionKey && !seen[key] {
+					seen[key] = true
+					keys = append(keys, key)
+				}
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		cmds, err := tx.TxPipelined(func(pipe redis.Pipeliner) error {
+			// The version read keeps the transaction from being empty,
+			// so EXEC checks the watch even without keys.
+			pipe.Get(versionKey)
+			for _, key := range keys {
+				pipe.Get(key)
+			}
+			return nil
+		})
+		if err != nil && err != redis.Nil {
+			return err
+		}
+
+		data = make(map[string][]byte, len(keys))
+		for i, key := range keys {
+			value, err := cmds[i+1].(*redis.StringCmd).Bytes()
+			if err == redis.Nil {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			data[key] = value
+		}
+		return nil
+	}, versionKey)
+	return data, err
+}
+
+func (s *snapshot) Has(key []byte) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.data == nil {
+		return false, errors.New("snapshot is closed")
+	}
+
+	_, exists := s.data[string(key)]
+	return exists, nil
+}
+
+func (s *snapshot) Get(key []byte) ([]byte, bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.data == nil {
+		return nil, false, errors.New("snapshot is closed")
+	}
+
+	value, exists := s.data[string(key)]
+	if !exists {
+		return nil, false, nil
+	}
+
+	result := make([]byte, len(value))
+	copy(result, value)
+	return result, true, nil
+}
+
+// NewIterator creates a new iterator for the given prefix. The start key is inclusive.
+func (s *snapshot) NewIterator(prefix []byte, start []byte) (database.Iterator, error) {
+	return s.NewIteratorWithOptions(database.IteratorOptions{Prefix: prefix, Start: start})
+}
+
+// NewIteratorWithOptions creates a new iterator over the range described by opts.
+func (s *snapshot) NewIteratorWithOptions(opts database.IteratorOptions) (database.Iterator, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.data == nil {
+		return nil, errors.New("snapshot is closed")
+	}
+
+	var allKeys []string
+	for key := range s.data {
+		if inRange(key, opts) {
+			allKeys = append(allKeys, key)
+		}
+	}
+	sort.Strings(allKeys)
+
+	keys := make([][]byte, len(allKeys))
+	values := make([][]byte, len(allKeys))
+	for i, key := range allKeys {
+		keys[i] = []byte(key)
+		values[i] = append([]byte(nil), s.data[key]...)
+	}
+
+	return newIterator(keys, values, opts.Reverse), nil
+}
+
+// Close releases the snapshot. It is safe to call Close multiple times.
+func (s *snapshot) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.data = nil
+	return nil
+}
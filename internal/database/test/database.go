@@ -323,4 +323,307 @@ func TestDatabase(t *testing.T, New func() database.Database) {
 		assert.False(t, iter.Next(), "should not find keys outside range")
 		assert.NoError(t, iter.Error())
 	})
+
+	t.Run("IteratorBinaryPrefix", func(t *testing.T) {
+		store := New()
+		defer store.Close()
+		// e.g. an h: key of a slot >= 128, whose encoding is not valid UTF-8
+		putKeys(t, store, "h:\x90\x01:a", "h:\x90\x01:b", "h:\x91\x01:a", "h:\x7f\x01:a")
+
+		iter, err := store.NewIterator([]byte("h:\x90\x01:"), nil)
+		require.NoError(t, err)
+		defer iter.Close()
+
+		assert.Equal(t, []string{"h:\x90\x01:a", "h:\x90\x01:b"}, collectKeys(t, iter))
+	})
+
+	t.Run("IteratorWithEnd", func(t *testing.T) {
+		store := New()
+		defer store.Close()
+		putKeys(t, store, "range_a", "range_b", "range_c", "range_d", "rangf")
+
+		iter, err := store.NewIteratorWithOptions(database.IteratorOptions{
+			Prefix: []byte("range_"),
+			Start:  []byte("b"),
+			End:    []byte("d"),
+		})
+		require.NoError(t, err)
+		defer iter.Close()
+
+		assert.Equal(t, []string{"range_b", "range_c"}, collectKeys(t, iter))
+	})
+
+	t.Run("IteratorEndBeyondPrefix", func(t *testing.T) {
+		store := New()
+		defer store.Close()
+		putKeys(t, store, "range_a", "range_b", "rangf")
+
+		// The prefix still bounds the range when End lies past it
+		iter, err := store.NewIteratorWithOptions(database.IteratorOptions{
+			Prefix: []byte("range_"),
+			End:    []byte("\xff\xff"),
+		})
+		require.NoError(t, err)
+		defer iter.Close()
+
+		assert.Equal(t, []string{"range_a", "range_b"}, collectKeys(t, iter))
+	})
+
+	t.Run("IteratorReverse", func(t *testing.T) {
+		store := New()
+		defer store.Close()
+		putKeys(t, store, "other", "rev_1", "rev_2", "rev_3", "rev_4")
+
+		iter, err := store.NewIteratorWithOptions(database.IteratorOptions{
+			Prefix:  []byte("rev_"),
+			Reverse: true,
+		})
+		require.NoError(t, err)
+		defer iter.Close()
+
+		assert.Equal(t, []string{"rev_4", "rev_3", "rev_2", "rev_1"}, collectKeys(t, iter))
+
+		bounded, err := store.NewIteratorWithOptions(database.IteratorOptions{
+			Prefix:  []byte("rev_"),
+			Start:   []byte("2"),
+			End:     []byte("4"),
+			Reverse: true,
+		})
+		require.NoError(t, err)
+		defer bounded.Close()
+
+		assert.Equal(t, []string{"rev_3", "rev_2"}, collectKeys(t, bounded))
+	})
+
+	t.Run("IteratorSeek", func(t *testing.T) {
+		store := New()
+		defer store.Close()
+		putKeys(t, store, "seek_10", "seek_20", "seek_30", "seek_40", "seel")
+
+		iter, err := store.NewIteratorWithOptions(database.IteratorOptions{Prefix: []byte("seek_")})
+		require.NoError(t, err)
+		defer iter.Close()
+
+		require.True(t, iter.SeekGE([]byte("seek_20")))
+		assert.Equal(t, "seek_20", string(iter.Key()))
+		assert.Equal(t, "seek_20", string(iter.Value()))
+		require.True(t, iter.Next())
+		assert.Equal(t, "seek_30", string(iter.Key()))
+
+		require.True(t, iter.SeekGE([]byte("seek_25")))
+		assert.Equal(t, "seek_30", string(iter.Key()))
+
+		require.True(t, iter.SeekLT([]byte("seek_30")))
+		assert.Equal(t, "seek_20", string(iter.Key()))
+
+		// Seeks stay within the range of the iterator
+		require.True(t, iter.SeekGE([]byte("a")))
+		assert.Equal(t, "seek_10", string(iter.Key()))
+		require.True(t, iter.SeekLT([]byte("z")))
+		assert.Equal(t, "seek_40", string(iter.Key()))
+		assert.False(t, iter.SeekGE([]byte("seek_41")))
+		assert.False(t, iter.SeekLT([]byte("seek_10")))
+		assert.NoError(t, iter.Error())
+	})
+
+	t.Run("IteratorSeekReverse", func(t *testing.T) {
+		store := New()
+		defer store.Close()
+		putKeys(t, store, "seek_10", "seek_20", "seek_30", "seek_40")
+
+		iter, err := store.NewIteratorWithOptions(database.IteratorOptions{
+			Prefix:  []byte("seek_"),
+			Reverse: true,
+		})
+		require.NoError(t, err)
+		defer iter.Close()
+
+		// After a seek, Next keeps moving towards smaller keys
+		require.True(t, iter.SeekLT([]byte("seek_35")))
+		assert.Equal(t, "seek_30", string(iter.Key()))
+		require.True(t, iter.Next())
+		assert.Equal(t, "seek_20", string(iter.Key()))
+
+		require.True(t, iter.SeekGE([]byte("seek_20")))
+		assert.Equal(t, "seek_20", string(iter.Key()))
+		require.True(t, iter.Next())
+		assert.Equal(t, "seek_10", string(iter.Key()))
+		assert.False(t, iter.Next())
+		assert.NoError(t, iter.Error())
+	})
+
+	t.Run("SnapshotIteratorWithOptions", func(t *testing.T) {
+		store := New()
+		defer store.Close()
+		putKeys(t, store, "snap_1", "snap_2", "snap_3")
+
+		snapshot, err := store.Snapshot()
+		require.NoError(t, err)
+		defer snapshot.Close()
+
+		putKeys(t, store, "snap_4")
+
+		iter, err := snapshot.NewIteratorWithOptions(database.IteratorOptions{
+			Prefix:  []byte("snap_"),
+			End:     []byte("3"),
+			Reverse: true,
+		})
+		require.NoError(t, err)
+		defer iter.Close()
+
+		assert.Equal(t, []string{"snap_2", "snap_1"}, collectKeys(t, iter))
+	})
+
+	t.Run("SnapshotIsolation", func(t *testing.T) {
+		store := New()
+		defer store.Close()
+
+		require.NoError(t, store.Put([]byte("snap_a"), []byte("a1")))
+		require.NoError(t, store.Put([]byte("snap_b"), []byte("b1")))
+
+		snapshot, err := store.Snapshot()
+		require.NoError(t, err)
+		defer snapshot.Close()
+
+		// Modify the database after taking the snapshot
+		require.NoError(t, store.Put([]byte("snap_a"), []byte("a2")))
+		batch := store.NewBatch()
+		require.NoError(t, batch.Delete([]byte("snap_b")))
+		require.NoError(t, batch.Put([]byte("snap_c"), []byte("c2")))
+		require.NoError(t, batch.Commit())
+		require.NoError(t, batch.Close())
+
+		// The snapshot still sees the old state
+		value, found, err := snapshot.Get([]byte("snap_a"))
+		require.NoError(t, err)
+		assert.True(t, found)
+		assert.True(t, bytes.Equal(value, []byte("a1")))
+
+		has, err := snapshot.Has([]byte("snap_b"))
+		require.NoError(t, err)
+		assert.True(t, has)
+
+		has, err = snapshot.Has([]byte("snap_c"))
+		require.NoError(t, err)
+		assert.False(t, has)
+
+		// The database sees the new state
+		value, found, err = store.Get([]byte("snap_a"))
+		require.NoError(t, err)
+		assert.True(t, found)
+		assert.True(t, bytes.Equal(value, []byte("a2")))
+
+		has, err = store.Has([]byte("snap_b"))
+		require.NoError(t, err)
+		assert.False(t, has)
+	})
+
+	t.Run("SnapshotIterator", func(t *testing.T) {
+		store := New()
+		defer store.Close()
+
+		expectedKeys := [][]byte{[]byte("snap_1"), []byte("snap_2"), []byte("snap_3")}
+		expectedValues := [][]byte{[]byte("v1"), []byte("v2"), []byte("v3")}
+		for i, key := range expectedKeys {
+			require.NoError(t, store.Put(key, expectedValues[i]))
+		}
+		require.NoError(t, store.Put([]byte("other"), []byte("x")))
+
+		snapshot, err := store.Snapshot()
+		require.NoError(t, err)
+		defer snapshot.Close()
+
+		batch := store.NewBatch()
+		require.NoError(t, batch.Delete([]byte("snap_1")))
+		require.NoError(t, batch.Put([]byte("snap_2"), []byte("changed")))
+		require.NoError(t, batch.Put([]byte("snap_4"), []byte("v4")))
+		require.NoError(t, batch.Commit())
+		require.NoError(t, batch.Close())
+
+		iter, err := snapshot.NewIterator([]byte("snap_"), nil)
+		require.NoError(t, err)
+		defer iter.Close()
+
+		var iteratedKeys, iteratedValues [][]byte
+		for iter.Next() {
+			iteratedKeys = append(iteratedKeys, bytes.Clone(iter.Key()))
+			iteratedValues = append(iteratedValues, bytes.Clone(iter.Value()))
+		}
+		require.NoError(t, iter.Error())
+
+		require.Equal(t, len(expectedKeys), len(iteratedKeys), "should iterate the keys present at snapshot time")
+		for i := range iteratedKeys {
+			assert.True(t, bytes.Equal(iteratedKeys[i], expectedKeys[i]), "key at index %d should match", i)
+			assert.True(t, bytes.Equal(iteratedValues[i], expectedValues[i]), "value at index %d should match", i)
+		}
+
+		// Iterators created from the snapshot honour the start key as well
+		iter2, err := snapshot.NewIterator([]byte("snap_"), []byte("2"))
+		require.NoError(t, err)
+		defer iter2.Close()
+
+		require.True(t, iter2.Next())
+		assert.True(t, bytes.Equal(iter2.Key(), []byte("snap_2")))
+		assert.True(t, bytes.Equal(iter2.Value(), []byte("v2")))
+	})
+
+	t.Run("SnapshotMultiple", func(t *testing.T) {
+		store := New()
+		defer store.Close()
+
+		key := []byte("snap_key")
+		require.NoError(t, store.Put(key, []byte("v1")))
+
+		first, err := store.Snapshot()
+		require.NoError(t, err)
+		defer first.Close()
+
+		require.NoError(t, store.Put(key, []byte("v2")))
+
+		second, err := store.Snapshot()
+		require.NoError(t, err)
+		defer second.Close()
+
+		require.NoError(t, store.Delete(key))
+
+		value, found, err := first.Get(key)
+		require.NoError(t, err)
+		assert.True(t, found)
+		assert.True(t, bytes.Equal(value, []byte("v1")))
+
+		value, found, err = second.Get(key)
+		require.NoError(t, err)
+		assert.True(t, found)
+		assert.True(t, bytes.Equal(value, []byte("v2")))
+
+		// Closing one snapshot leaves the others usable
+		require.NoError(t, first.Close())
+		value, found, err = second.Get(key)
+		require.NoError(t, err)
+		assert.True(t, found)
+		assert.True(t, bytes.Equal(value, []byte("v2")))
+
+		_, found, err = store.Get(key)
+		require.NoError(t, err)
+		assert.False(t, found)
+	})
+}
+
+// putKeys stores each key with itself as the value.
+func putKeys(t *testing.T, store database.Writer, keys ...string) {
+	t.Helper()
+	for _, key := range keys {
+		require.NoError(t, store.Put([]byte(key), []byte(key)))
+	}
+}
+
+// collectKeys drains iter and returns the keys in iteration order.
+func collectKeys(t *testing.T, iter database.Iterator) []string {
+	t.Helper()
+	var keys []string
+	for iter.Next() {
+		keys = append(keys, string(iter.Key()))
+	}
+	require.NoError(t, iter.Error())
+	return keys
 }
@@ -0,0 +1,41 @@
+// Package fixtures loads the memory database key dumps in this directory
+// for tests.
+package fixtures
+
+import (
+	"flag"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/New-JAMneration/JAM-Protocol/internal/database"
+	"github.com/New-JAMneration/JAM-Protocol/internal/database/provider/memory"
+	"github.com/stretchr/testify/require"
+)
+
+// Update makes tests that build a fixture write it back with Save, e.g.
+// go test ./internal/store -run Fixture -update.
+var Update = flag.Bool("update", false, "rewrite database fixtures in internal/database/test/fixtures")
+
+// Path returns the file of the named fixture: a memory database key dump in
+// the directory of this file.
+func Path(name string) string {
+	_, file, _, _ := runtime.Caller(0)
+	return filepath.Join(filepath.Dir(file), name+".kvdump")
+}
+
+// Load returns a memory database holding the named fixture. Writes go to the
+// returned copy only, and it is closed when the test ends.
+func Load(t testing.TB, name string) database.Database {
+	t.Helper()
+	db, err := memory.Load(Path(name))
+	require.NoError(t, err, "fixture %s", name)
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// Save writes db as the named fixture.
+func Save(t testing.TB, name string, db database.Snapshotter) {
+	t.Helper()
+	require.NoError(t, memory.Save(db, Path(name)))
+}
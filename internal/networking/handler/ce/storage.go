@@ -42,6 +42,8 @@ func DB(bc blockchain.Blockchain) database.Database {
 }
 
 // --- Key prefixes (CE namespace, avoid collision with store)
+// Hashes and erasure roots are embedded as raw bytes (store schema version 2;
+// version 1 data dirs are migrated by store.MigrateSchema).
 var (
 	ceJustificationPrefix = []byte("ce/j/")
 	ceAssurancePrefix     = []byte("ce/a/")
@@ -55,9 +57,9 @@ var (
 )
 
 func ceJustificationKey(erasureRoot []byte, shardIndex uint32) []byte {
-	k := make([]byte, 0, len(ceJustificationPrefix)+hex.EncodedLen(len(erasureRoot))+1+4)
+	k := make([]byte, 0, len(ceJustificationPrefix)+len(erasureRoot)+1+4)
 	k = append(k, ceJustificationPrefix...)
-	k = append(k, hex.EncodeToString(erasureRoot)...)
+	k = append(k, erasureRoot...)
 	k = append(k, ':')
 	k = append(k, strconv.FormatUint(uint64(shardIndex), 10)...)
 	return k
@@ -75,16 +77,16 @@ func ceAssuranceSetKey(headerHash types.HeaderHash) string {
 }
 
 func cePreimageKey(h types.OpaqueHash) []byte {
-	k := make([]byte, 0, len(cePreimagePrefix)+hex.EncodedLen(len(h)))
+	k := make([]byte, 0, len(cePreimagePrefix)+len(h))
 	k = append(k, cePreimagePrefix...)
-	k = append(k, hex.EncodeToString(h[:])...)
+	k = append(k, h[:]...)
 	return k
 }
 
 func cePreimageAnnKey(h types.OpaqueHash) []byte {
-	k := make([]byte, 0, len(cePreimageAnnPrefix)+hex.EncodedLen(len(h)))
+	k := make([]byte, 0, len(cePreimageAnnPrefix)+len(h))
 	k = append(k, cePreimageAnnPrefix...)
-	k = append(k, hex.EncodeToString(h[:])...)
+	k = append(k, h[:]...)
 	return k
 }
 
@@ -93,9 +95,9 @@ func cePreimageAnnServiceSetKey(serviceID types.ServiceID) string {
 }
 
 func ceAuditAnnKey(headerHash types.OpaqueHash, tranche uint8) []byte {
-	k := make([]byte, 0, len(ceAuditAnnPrefix)+hex.EncodedLen(len(headerHash))+1+2)
+	k := make([]byte, 0, len(ceAuditAnnPrefix)+len(headerHash)+1+2)
 	k = append(k, ceAuditAnnPrefix...)
-	k = append(k, hex.EncodeToString(headerHash[:])...)
+	k = append(k, headerHash[:]...)
 	k = append(k, ':')
 	k = append(k, strconv.FormatUint(uint64(tranche), 10)...)
 	return k
@@ -106,9 +108,9 @@ func ceAuditAnnHeaderSetKey(headerHash types.OpaqueHash) string {
 }
 
 func ceJudgmentKey(workReportHash types.WorkReportHash, epochIndex types.U32, validatorIndex types.ValidatorIndex) []byte {
-	k := make([]byte, 0, len(ceJudgmentPrefix)+64+1+4+1+2)
+	k := make([]byte, 0, len(ceJudgmentPrefix)+len(workReportHash)+1+4+1+2)
 	k = append(k, ceJudgmentPrefix...)
-	k = append(k, hex.EncodeToString(workReportHash[:])...)
+	k = append(k, workReportHash[:]...)
 	k = append(k, ':')
 	k = append(k, strconv.FormatUint(uint64(epochIndex), 10)...)
 	k = append(k, ':')
@@ -129,9 +131,9 @@ func ceJudgmentValidatorSetKey(validatorIndex types.ValidatorIndex) string {
 }
 
 func wpBundleKey(erasureRoot []byte) []byte {
-	k := make([]byte, 0, len(ceWpBundlePrefix)+hex.EncodedLen(len(erasureRoot)))
+	k := make([]byte, 0, len(ceWpBundlePrefix)+len(erasureRoot))
 	k = append(k, ceWpBundlePrefix...)
-	k = append(k, hex.EncodeToString(erasureRoot)...)
+	k = append(k, erasureRoot...)
 	return k
 }
 
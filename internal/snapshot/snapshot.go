@@ -0,0 +1,356 @@
+// Package snapshot implements the state snapshot file used by
+// `node snapshot create` and `node snapshot load` to bootstrap a node at a
+// trusted block without replaying the chain.
+//
+// A snapshot holds a block header, its ancestry and the full posterior state
+// of the block, split into chunks:
+//
+//	file    := magic (8 bytes) | version (1 byte) | chunk* | manifest | trailer
+//	chunk   := codec-encoded StateKeyVals
+//	trailer := manifest offset (u64 LE) | manifest length (u32 LE) | blake2b(manifest) (32 bytes) | magic
+//
+// The codec-encoded Manifest lists every chunk with its offset, length, entry
+// count and blake2b hash, so each chunk is checked before it is decoded and
+// the manifest is found without scanning the file.
+package snapshot
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/New-JAMneration/JAM-Protocol/internal/types"
+	"github.com/New-JAMneration/JAM-Protocol/internal/utilities/hash"
+	m "github.com/New-JAMneration/JAM-Protocol/internal/utilities/merklization"
+)
+
+const (
+	Magic   = "JAMSNAP1"
+	Version = uint8(1)
+
+	// DefaultChunkSize is the encoded size a chunk is filled up to.
+	DefaultChunkSize = 16 << 20
+
+	trailerLength = 8 + 4 + 32 + 8 // offset, length, hash, magic
+
+	// maxManifestLength guards against reading a corrupted trailer.
+	maxManifestLength = 1 << 30
+)
+
+var (
+	ErrInvalidMagic   = errors.New("snapshot: invalid magic")
+	ErrInvalidVersion = errors.New("snapshot: unsupported version")
+	ErrChecksum       = errors.New("snapshot: checksum mismatch")
+)
+
+// Snapshot is the posterior state of the block with Header.
+type Snapshot struct {
+	Header types.Header
+	// Ancestry lists the canonical blocks up to and including Header, oldest
+	// first, as the fuzz protocol's Initialize message does.
+	Ancestry  types.Ancestry
+	StateRoot types.StateRoot
+	KeyVals   types.StateKeyVals
+}
+
+// Chunk describes one chunk of state entries.
+type Chunk struct {
+	Offset  types.U64
+	Length  types.U32
+	Entries types.U32
+	Hash    types.OpaqueHash
+}
+
+func (c *Chunk) Encode(e *types.Encoder) error {
+	if err := c.Offset.Encode(e); err != nil {
+		return err
+	}
+	if err := c.Length.Encode(e); err != nil {
+		return err
+	}
+	if err := c.Entries.Encode(e); err != nil {
+		return err
+	}
+	return c.Hash.Encode(e)
+}
+
+func (c *Chunk) Decode(d *types.Decoder) error {
+	if err := c.Offset.Decode(d); err != nil {
+		return err
+	}
+	if err := c.Length.Decode(d); err != nil {
+		return err
+	}
+	if err := c.Entries.Decode(d); err != nil {
+		return err
+	}
+	return c.Hash.Decode(d)
+}
+
+// Manifest describes a snapshot file.
+type Manifest struct {
+	Header     types.Header
+	HeaderHash types.HeaderHash
+	Ancestry   types.Ancestry
+	StateRoot  types.StateRoot
+	Chunks     []Chunk
+}
+
+func (mf *Manifest) Encode(e *types.Encoder) error {
+	if err := mf.Header.Encode(e); err != nil {
+		return err
+	}
+	if err := mf.HeaderHash.Encode(e); err != nil {
+		return err
+	}
+	if err := mf.Ancestry.Encode(e); err != nil {
+		return err
+	}
+	if err := mf.StateRoot.Encode(e); err != nil {
+		return err
+	}
+	if err := e.EncodeLength(uint64(len(mf.Chunks))); err != nil {
+		return err
+	}
+	for i := range mf.Chunks {
+		if err := mf.Chunks[i].Encode(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (mf *Manifest) Decode(d *types.Decoder) error {
+	if err := mf.Header.Decode(d); err != nil {
+		return err
+	}
+	if err := mf.HeaderHash.Decode(d); err != nil {
+		return err
+	}
+	if err := mf.Ancestry.Decode(d); err != nil {
+		return err
+	}
+	if err := mf.StateRoot.Decode(d); err != nil {
+		return err
+	}
+	length, err := d.DecodeLength()
+	if err != nil {
+		return err
+	}
+	mf.Chunks = make([]Chunk, length)
+	for i := range mf.Chunks {
+		if err := mf.Chunks[i].Decode(d); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Entries returns the number of state entries in the snapshot.
+func (mf *Manifest) Entries() int {
+	total := 0
+	for _, chunk := range mf.Chunks {
+		total += int(chunk.Entries)
+	}
+	return total
+}
+
+// Write writes s to w, filling each chunk up to chunkSize encoded bytes. The
+// key-values must be sorted by key.
+func Write(w io.Writer, s *Snapshot, chunkSize int) (*Manifest, error) {
+	headerHash, err := hash.ComputeBlockHeaderHash(s.Header)
+	if err != nil {
+		return nil, err
+	}
+	manifest := &Manifest{
+		Header:     s.Header,
+		HeaderHash: headerHash,
+		Ancestry:   s.Ancestry,
+		StateRoot:  s.StateRoot,
+	}
+
+	bw := bufio.NewWriter(w)
+	if _, err := bw.WriteString(Magic); err != nil {
+		return nil, err
+	}
+	if err := bw.WriteByte(Version); err != nil {
+		return nil, err
+	}
+	offset := uint64(len(Magic) + 1)
+
+	encoder := types.NewEncoder()
+	writeChunk := func(keyVals types.StateKeyVals) error {
+		payload, err := encoder.Encode(&keyVals)
+		if err != nil {
+			return fmt.Errorf("failed to encode chunk: %w", err)
+		}
+		if _, err := bw.Write(payload); err != nil {
+			return err
+		}
+		manifest.Chunks = append(manifest.Chunks, Chunk{
+			Offset:  types.U64(offset),
+			Length:  types.U32(len(payload)),
+			Entries: types.U32(len(keyVals)),
+			Hash:    hash.Blake2bHash(payload),
+		})
+		offset += uint64(len(payload))
+		return nil
+	}
+
+	start, size := 0, 0
+	for i, kv := range s.KeyVals {
+		entrySize := len(kv.Key) + len(kv.Value) + 8
+		if i > start && size+entrySize > chunkSize {
+			if err := writeChunk(s.KeyVals[start:i]); err != nil {
+				return nil, err
+			}
+			start, size = i, 0
+		}
+		size += entrySize
+	}
+	if start < len(s.KeyVals) {
+		if err := writeChunk(s.KeyVals[start:]); err != nil {
+			return nil, err
+		}
+	}
+
+	encodedManifest, err := encoder.Encode(manifest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode manifest: %w", err)
+	}
+	if _, err := bw.Write(encodedManifest); err != nil {
+		return nil, err
+	}
+
+	var trailer [trailerLength]byte
+	binary.LittleEndian.PutUint64(trailer[:8], offset)
+	binary.LittleEndian.PutUint32(trailer[8:12], uint32(len(encodedManifest)))
+	manifestHash := hash.Blake2bHash(encodedManifest)
+	copy(trailer[12:44], manifestHash[:])
+	copy(trailer[44:], Magic)
+	if _, err := bw.Write(trailer[:]); err != nil {
+		return nil, err
+	}
+	return manifest, bw.Flush()
+}
+
+// ReadManifest checks the file header and trailer of a snapshot of the given
+// size and returns its manifest.
+func ReadManifest(r io.ReaderAt, size int64) (*Manifest, error) {
+	header := make([]byte, len(Magic)+1)
+	if _, err := r.ReadAt(header, 0); err != nil {
+		return nil, fmt.Errorf("snapshot: failed to read header: %w", err)
+	}
+	if string(header[:len(Magic)]) != Magic {
+		return nil, ErrInvalidMagic
+	}
+	if header[len(Magic)] != Version {
+		return nil, fmt.Errorf("%w: %d", ErrInvalidVersion, header[len(Magic)])
+	}
+
+	if size < int64(len(header)+trailerLength) {
+		return nil, fmt.Errorf("snapshot: truncated file (%d bytes)", size)
+	}
+	trailer := make([]byte, trailerLength)
+	if _, err := r.ReadAt(trailer, size-trailerLength); err != nil {
+		return nil, fmt.Errorf("snapshot: failed to read trailer: %w", err)
+	}
+	if string(trailer[44:]) != Magic {
+		return nil, fmt.Errorf("snapshot: truncated file, trailer not found")
+	}
+
+	offset := binary.LittleEndian.Uint64(trailer[:8])
+	length := binary.LittleEndian.Uint32(trailer[8:12])
+	if length > maxManifestLength || offset+uint64(length) != uint64(size-trailerLength) {
+		return nil, fmt.Errorf("snapshot: invalid manifest position %d+%d", offset, length)
+	}
+	encoded := make([]byte, length)
+	if _, err := r.ReadAt(encoded, int64(offset)); err != nil {
+		return nil, fmt.Errorf("snapshot: failed to read manifest: %w", err)
+	}
+	if hash.Blake2bHash(encoded) != types.OpaqueHash(trailer[12:44]) {
+		return nil, fmt.Errorf("%w in manifest", ErrChecksum)
+	}
+
+	manifest := &Manifest{}
+	if err := types.NewDecoder().Decode(encoded, manifest); err != nil {
+		return nil, fmt.Errorf("snapshot: failed to decode manifest: %w", err)
+	}
+	return manifest, nil
+}
+
+// Read reads and verifies a snapshot: every chunk must match its checksum,
+// the ancestry must end at the header and the state entries must be sorted
+// and merklize to the state root of the manifest.
+func Read(r io.ReaderAt, size int64) (*Snapshot, error) {
+	manifest, err := ReadManifest(r, size)
+	if err != nil {
+		return nil, err
+	}
+
+	headerHash, err := hash.ComputeBlockHeaderHash(manifest.Header)
+	if err != nil {
+		return nil, err
+	}
+	if headerHash != manifest.HeaderHash {
+		return nil, fmt.Errorf("snapshot: header hashes to 0x%x, manifest has 0x%x", headerHash, manifest.HeaderHash)
+	}
+	if err := checkAncestry(manifest.Ancestry, manifest.Header.Slot, manifest.HeaderHash); err != nil {
+		return nil, err
+	}
+
+	keyVals := make(types.StateKeyVals, 0, manifest.Entries())
+	decoder := types.NewDecoder()
+	for i, chunk := range manifest.Chunks {
+		payload := make([]byte, chunk.Length)
+		if _, err := r.ReadAt(payload, int64(chunk.Offset)); err != nil {
+			return nil, fmt.Errorf("snapshot: failed to read chunk %d: %w", i, err)
+		}
+		if hash.Blake2bHash(payload) != chunk.Hash {
+			return nil, fmt.Errorf("%w in chunk %d", ErrChecksum, i)
+		}
+		var entries types.StateKeyVals
+		if err := decoder.Decode(payload, &entries); err != nil {
+			return nil, fmt.Errorf("snapshot: failed to decode chunk %d: %w", i, err)
+		}
+		if len(entries) != int(chunk.Entries) {
+			return nil, fmt.Errorf("snapshot: chunk %d has %d entries, manifest lists %d", i, len(entries), chunk.Entries)
+		}
+		keyVals = append(keyVals, entries...)
+	}
+
+	for i := 1; i < len(keyVals); i++ {
+		if bytes.Compare(keyVals[i-1].Key[:], keyVals[i].Key[:]) >= 0 {
+			return nil, fmt.Errorf("snapshot: state keys out of order at entry %d", i)
+		}
+	}
+	if root := m.MerklizationSerializedState(keyVals); root != manifest.StateRoot {
+		return nil, fmt.Errorf("snapshot: state merklizes to 0x%x, manifest has 0x%x", root, manifest.StateRoot)
+	}
+
+	return &Snapshot{
+		Header:    manifest.Header,
+		Ancestry:  manifest.Ancestry,
+		StateRoot: manifest.StateRoot,
+		KeyVals:   keyVals,
+	}, nil
+}
+
+func checkAncestry(ancestry types.Ancestry, slot types.TimeSlot, headerHash types.HeaderHash) error {
+	if len(ancestry) == 0 {
+		return fmt.Errorf("snapshot: empty ancestry")
+	}
+	last := ancestry[len(ancestry)-1]
+	if last.Slot != slot || last.HeaderHash != headerHash {
+		return fmt.Errorf("snapshot: ancestry ends at slot %d, header is at slot %d", last.Slot, slot)
+	}
+	for i := 1; i < len(ancestry); i++ {
+		if ancestry[i-1].Slot >= ancestry[i].Slot {
+			return fmt.Errorf("snapshot: ancestry slots out of order at item %d", i)
+		}
+	}
+	return nil
+}
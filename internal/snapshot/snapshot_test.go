@@ -0,0 +1,75 @@
+package snapshot
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/New-JAMneration/JAM-Protocol/internal/types"
+	"github.com/New-JAMneration/JAM-Protocol/internal/utilities/hash"
+	m "github.com/New-JAMneration/JAM-Protocol/internal/utilities/merklization"
+	"github.com/stretchr/testify/require"
+)
+
+func testSnapshot(t *testing.T) *Snapshot {
+	t.Helper()
+	header := types.Header{Slot: 12, Parent: types.HeaderHash{1}}
+	headerHash, err := hash.ComputeBlockHeaderHash(header)
+	require.NoError(t, err)
+
+	keyVals := make(types.StateKeyVals, 40)
+	for i := range keyVals {
+		keyVals[i] = types.StateKeyVal{Key: types.StateKey{byte(i), 7}, Value: bytes.Repeat([]byte{byte(i)}, i+1)}
+	}
+	return &Snapshot{
+		Header: header,
+		Ancestry: types.Ancestry{
+			{Slot: 11, HeaderHash: header.Parent},
+			{Slot: 12, HeaderHash: headerHash},
+		},
+		StateRoot: m.MerklizationSerializedState(keyVals),
+		KeyVals:   keyVals,
+	}
+}
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	s := testSnapshot(t)
+
+	var buf bytes.Buffer
+	manifest, err := Write(&buf, s, 256)
+	require.NoError(t, err)
+	require.Greater(t, len(manifest.Chunks), 1)
+	require.Equal(t, len(s.KeyVals), manifest.Entries())
+
+	data := buf.Bytes()
+	read, err := ReadManifest(bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+	require.Equal(t, manifest, read)
+
+	got, err := Read(bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+	require.Equal(t, s, got)
+}
+
+func TestSnapshotRejectsCorruption(t *testing.T) {
+	s := testSnapshot(t)
+	var buf bytes.Buffer
+	manifest, err := Write(&buf, s, 256)
+	require.NoError(t, err)
+	data := buf.Bytes()
+
+	corrupted := bytes.Clone(data)
+	corrupted[manifest.Chunks[1].Offset+3] ^= 0xff
+	_, err = Read(bytes.NewReader(corrupted), int64(len(corrupted)))
+	require.ErrorIs(t, err, ErrChecksum)
+
+	_, err = Read(bytes.NewReader(data[:len(data)-10]), int64(len(data)-10))
+	require.Error(t, err)
+
+	// A state that does not match the manifest's root is refused.
+	s.StateRoot = types.StateRoot{0xaa}
+	buf.Reset()
+	_, err = Write(&buf, s, DefaultChunkSize)
+	require.NoError(t, err)
+	_, err = Read(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.ErrorContains(t, err, "merklizes")
+}
@@ -0,0 +1,189 @@
+// Package stateaccess gives the STF keyed access to state entries that are
+// not decoded into types.State, such as service storage and lookup items
+// whose raw keys cannot be recovered from their state keys.
+//
+// Entries are read from a Source on demand, and writes and removals are
+// recorded in an Overlay instead of copying the entries up front. Overlays
+// stack: a child sees its parent's changes, is cheap to create per service or
+// per PVM context, and is merged into its parent with Commit once its result
+// is kept.
+package stateaccess
+
+import (
+	"bytes"
+	"sort"
+
+	"github.com/New-JAMneration/JAM-Protocol/internal/database"
+	"github.com/New-JAMneration/JAM-Protocol/internal/store"
+	"github.com/New-JAMneration/JAM-Protocol/internal/types"
+)
+
+// Source reads state entries by key.
+type Source interface {
+	Get(key types.StateKey) (value []byte, found bool, err error)
+}
+
+type keyValsSource map[types.StateKey][]byte
+
+// NewKeyValsSource indexes kvs by key. Values are shared with kvs, which must
+// not be modified while the source is in use.
+func NewKeyValsSource(kvs types.StateKeyVals) Source {
+	source := make(keyValsSource, len(kvs))
+	for _, kv := range kvs {
+		source[kv.Key] = kv.Value
+	}
+	return source
+}
+
+func (s keyValsSource) Get(key types.StateKey) ([]byte, bool, error) {
+	value, found := s[key]
+	return value, found, nil
+}
+
+type storeSource struct {
+	repo *store.Repository
+	r    database.Reader
+	root types.StateRoot
+}
+
+// NewStoreSource reads the entries of the state stored under root, walking
+// one trie path per key instead of loading the state.
+func NewStoreSource(repo *store.Repository, r database.Reader, root types.StateRoot) Source {
+	return &storeSource{repo: repo, r: r, root: root}
+}
+
+func (s *storeSource) Get(key types.StateKey) ([]byte, bool, error) {
+	return s.repo.GetStateValue(s.r, s.root, key)
+}
+
+type entry struct {
+	value   []byte
+	removed bool
+}
+
+// Overlay records writes and removals over a Source or a parent overlay.
+//
+// An overlay is not safe for concurrent use, but children may read a parent
+// concurrently as long as nothing writes to or commits into the parent. A nil
+// *Overlay is empty and ignores writes.
+type Overlay struct {
+	base    Source
+	parent  *Overlay
+	entries map[types.StateKey]entry
+}
+
+// NewOverlay returns an empty overlay over source.
+func NewOverlay(source Source) *Overlay {
+	return &Overlay{base: source, entries: make(map[types.StateKey]entry)}
+}
+
+// Get returns the value of key as seen through the overlay.
+func (o *Overlay) Get(key types.StateKey) ([]byte, bool, error) {
+	if o == nil {
+		return nil, false, nil
+	}
+	if e, ok := o.entries[key]; ok {
+		return e.value, !e.removed, nil
+	}
+	return o.base.Get(key)
+}
+
+// Set records value for key.
+func (o *Overlay) Set(key types.StateKey, value []byte) {
+	if o == nil {
+		return
+	}
+	o.entries[key] = entry{value: value}
+}
+
+// Remove records the removal of key.
+func (o *Overlay) Remove(key types.StateKey) {
+	if o == nil {
+		return
+	}
+	o.entries[key] = entry{removed: true}
+}
+
+// Take returns the value of key and removes it, for entries that move out of
+// the overlay into decoded state.
+func (o *Overlay) Take(key types.StateKey) ([]byte, bool, error) {
+	value, found, err := o.Get(key)
+	if err != nil || !found {
+		return nil, false, err
+	}
+	o.Remove(key)
+	return value, true, nil
+}
+
+// Child returns an empty overlay over o.
+func (o *Overlay) Child() *Overlay {
+	if o == nil {
+		return nil
+	}
+	return &Overlay{base: o, parent: o, entries: make(map[types.StateKey]entry)}
+}
+
+// Clone returns an overlay with the same parent and a copy of o's changes.
+func (o *Overlay) Clone() *Overlay {
+	if o == nil {
+		return nil
+	}
+	clone := &Overlay{base: o.base, parent: o.parent, entries: make(map[types.StateKey]entry, len(o.entries))}
+	for key, e := range o.entries {
+		clone.entries[key] = e
+	}
+	return clone
+}
+
+// Commit moves o's changes into its parent. It does nothing for an overlay
+// created with NewOverlay.
+func (o *Overlay) Commit() {
+	if o == nil || o.parent == nil {
+		return
+	}
+	for key, e := range o.entries {
+		o.parent.entries[key] = e
+	}
+	clear(o.entries)
+}
+
+// Apply applies the changes of o and its parents to kvs in place and returns
+// the result. Entries removed are dropped, and entries set but not in kvs are
+// appended in key order. kvs is returned as is when there are no changes.
+func (o *Overlay) Apply(kvs types.StateKeyVals) types.StateKeyVals {
+	changes := make(map[types.StateKey]entry)
+	for overlay := o; overlay != nil; overlay = overlay.parent {
+		for key, e := range overlay.entries {
+			if _, ok := changes[key]; !ok {
+				changes[key] = e
+			}
+		}
+	}
+	if len(changes) == 0 {
+		return kvs
+	}
+
+	kept := 0
+	for _, kv := range kvs {
+		if e, ok := changes[kv.Key]; ok {
+			delete(changes, kv.Key)
+			if e.removed {
+				continue
+			}
+			kv.Value = e.value
+		}
+		kvs[kept] = kv
+		kept++
+	}
+	clear(kvs[kept:])
+	kvs = kvs[:kept]
+
+	added := make(types.StateKeyVals, 0, len(changes))
+	for key, e := range changes {
+		if !e.removed {
+			added = append(added, types.StateKeyVal{Key: key, Value: e.value})
+		}
+	}
+	sort.Slice(added, func(i, j int) bool { return bytes.Compare(added[i].Key[:], added[j].Key[:]) < 0 })
+	return append(kvs, added...)
+}
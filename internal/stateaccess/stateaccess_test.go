@@ -0,0 +1,93 @@
+package stateaccess_test
+
+import (
+	"testing"
+
+	"github.com/New-JAMneration/JAM-Protocol/internal/database/provider/memory"
+	"github.com/New-JAMneration/JAM-Protocol/internal/stateaccess"
+	"github.com/New-JAMneration/JAM-Protocol/internal/store"
+	"github.com/New-JAMneration/JAM-Protocol/internal/types"
+	m "github.com/New-JAMneration/JAM-Protocol/internal/utilities/merklization"
+	"github.com/stretchr/testify/require"
+)
+
+var testKeyVals = types.StateKeyVals{
+	{Key: types.StateKey{1}, Value: []byte("one")},
+	{Key: types.StateKey{2}, Value: []byte("two")},
+	{Key: types.StateKey{3}, Value: []byte("three")},
+}
+
+func requireValue(t *testing.T, source stateaccess.Source, key types.StateKey, want []byte) {
+	t.Helper()
+	value, found, err := source.Get(key)
+	require.NoError(t, err)
+	if want == nil {
+		require.False(t, found, "key 0x%x", key)
+		return
+	}
+	require.True(t, found, "key 0x%x", key)
+	require.Equal(t, want, value)
+}
+
+func TestOverlay(t *testing.T) {
+	overlay := stateaccess.NewOverlay(stateaccess.NewKeyValsSource(testKeyVals))
+	requireValue(t, overlay, types.StateKey{1}, []byte("one"))
+	requireValue(t, overlay, types.StateKey{4}, nil)
+
+	value, found, err := overlay.Take(types.StateKey{1})
+	require.NoError(t, err)
+	require.True(t, found)
+	require.Equal(t, []byte("one"), value)
+	requireValue(t, overlay, types.StateKey{1}, nil)
+
+	overlay.Set(types.StateKey{4}, []byte("four"))
+	overlay.Set(types.StateKey{2}, []byte("TWO"))
+	require.Equal(t, types.StateKeyVals{
+		{Key: types.StateKey{2}, Value: []byte("TWO")},
+		{Key: types.StateKey{3}, Value: []byte("three")},
+		{Key: types.StateKey{4}, Value: []byte("four")},
+	}, overlay.Apply(testKeyVals.DeepCopy()))
+
+	unchanged := stateaccess.NewOverlay(stateaccess.NewKeyValsSource(testKeyVals))
+	kvs := testKeyVals.DeepCopy()
+	require.Same(t, &kvs[0], &unchanged.Apply(kvs)[0], "Apply returns kvs without changes")
+}
+
+func TestOverlayChildren(t *testing.T) {
+	parent := stateaccess.NewOverlay(stateaccess.NewKeyValsSource(testKeyVals))
+	first, second := parent.Child(), parent.Child()
+
+	first.Remove(types.StateKey{1})
+	second.Remove(types.StateKey{2})
+	requireValue(t, first, types.StateKey{2}, []byte("two"))
+	requireValue(t, parent, types.StateKey{1}, []byte("one"))
+
+	// A clone diverges from the overlay it was taken from.
+	checkpoint := first.Clone()
+	first.Remove(types.StateKey{3})
+	requireValue(t, checkpoint, types.StateKey{3}, []byte("three"))
+	requireValue(t, checkpoint, types.StateKey{1}, nil)
+
+	checkpoint.Commit()
+	second.Commit()
+	require.Equal(t, types.StateKeyVals{testKeyVals[2]}, parent.Apply(testKeyVals.DeepCopy()))
+
+	var empty *stateaccess.Overlay
+	empty.Remove(types.StateKey{1})
+	requireValue(t, empty, types.StateKey{1}, nil)
+	require.Nil(t, empty.Child())
+}
+
+func TestStoreSource(t *testing.T) {
+	db := memory.NewDatabase()
+	repo := store.NewRepository(db)
+	root := m.MerklizationSerializedState(testKeyVals)
+	require.NoError(t, repo.SaveStateData(db, root, testKeyVals))
+
+	overlay := stateaccess.NewOverlay(stateaccess.NewStoreSource(repo, db, root))
+	requireValue(t, overlay, types.StateKey{3}, []byte("three"))
+	requireValue(t, overlay, types.StateKey{5}, nil)
+
+	_, _, err := stateaccess.NewStoreSource(repo, db, types.StateRoot{0xee}).Get(types.StateKey{1})
+	require.Error(t, err)
+}
@@ -0,0 +1,272 @@
+package stateview
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// WriteJSON writes the view as indented JSON. Byte arrays and sequences are
+// rendered as 0x-prefixed hex and maps become objects with sorted keys.
+func (v *View) WriteJSON(w io.Writer) error {
+	components := make([]any, 0, len(v.Components))
+	for _, c := range v.Components {
+		components = append(components, map[string]any{
+			"name":  c.Name,
+			"key":   hexString(c.Key[:]),
+			"size":  c.Size,
+			"value": JSONValue(c.Value),
+		})
+	}
+
+	out := map[string]any{
+		"state_root": hexString(v.Root[:]),
+		"keys":       v.KeyCount,
+		"components": components,
+	}
+	if v.Services != nil {
+		out["services"] = JSONValue(v.Services)
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(out)
+}
+
+// WriteTable writes a one-line summary per component and per service.
+func (v *View) WriteTable(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+
+	fmt.Fprintf(tw, "state root\t0x%x\n", v.Root)
+	fmt.Fprintf(tw, "keys\t%d\n\n", v.KeyCount)
+
+	if len(v.Components) > 0 {
+		fmt.Fprintln(tw, "COMPONENT\tKEY\tSIZE\tSUMMARY")
+		for _, c := range v.Components {
+			fmt.Fprintf(tw, "%s\t0x%x\t%d\t%s\n", c.Name, c.Key[:1], c.Size, summary(reflect.ValueOf(c.Value)))
+		}
+		fmt.Fprintln(tw)
+	}
+
+	if v.Services != nil {
+		fmt.Fprintln(tw, "SERVICE\tCODE HASH\tBALANCE\tITEMS\tBYTES\tPREIMAGES\tLOOKUPS\tSTORAGE KEYS")
+		for _, s := range v.Services {
+			fmt.Fprintf(tw, "%d\t0x%x\t%d\t%d\t%d\t%d\t%d\t%d\n",
+				s.ID, s.Info.CodeHash[:8], s.Info.Balance, s.Info.Items, s.Info.Bytes,
+				s.Preimages, s.Lookups, s.Storage)
+		}
+	}
+
+	return tw.Flush()
+}
+
+// summary describes a component in a few words.
+func summary(v reflect.Value) string {
+	switch v.Kind() {
+	case reflect.Slice, reflect.Map:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return fmt.Sprintf("%d bytes", v.Len())
+		}
+		return fmt.Sprintf("%d entries", v.Len())
+	case reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return hexString(bytesOf(v))
+		}
+		return fmt.Sprintf("%d entries", v.Len())
+	case reflect.Struct:
+		parts := make([]string, 0, v.NumField())
+		for i := 0; i < v.NumField(); i++ {
+			if !v.Type().Field(i).IsExported() {
+				continue
+			}
+			field := v.Field(i)
+			switch field.Kind() {
+			case reflect.Slice, reflect.Map, reflect.Array, reflect.Struct:
+				parts = append(parts, fmt.Sprintf("%s: %s", v.Type().Field(i).Name, summary(field)))
+			default:
+				parts = append(parts, fmt.Sprintf("%s: %v", v.Type().Field(i).Name, field.Interface()))
+			}
+		}
+		return strings.Join(parts, ", ")
+	case reflect.Invalid:
+		return "-"
+	}
+	return fmt.Sprintf("%v", v.Interface())
+}
+
+// JSONValue converts v into values encoding/json renders readably: byte
+// arrays and slices become hex strings and maps with non-string keys become
+// objects keyed by the rendered key.
+func JSONValue(v any) any {
+	return jsonValue(reflect.ValueOf(v))
+}
+
+func jsonValue(v reflect.Value) any {
+	switch v.Kind() {
+	case reflect.Invalid:
+		return nil
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return jsonValue(v.Elem())
+	case reflect.Array, reflect.Slice:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return []any{}
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return hexString(bytesOf(v))
+		}
+		out := make([]any, v.Len())
+		for i := range out {
+			out[i] = jsonValue(v.Index(i))
+		}
+		return out
+	case reflect.Map:
+		out := make(map[string]any, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			out[mapKey(iter.Key())] = jsonValue(iter.Value())
+		}
+		return out
+	case reflect.Struct:
+		out := make(map[string]any, v.NumField())
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			out[fieldName(field)] = jsonValue(v.Field(i))
+		}
+		return out
+	}
+	return v.Interface()
+}
+
+func mapKey(v reflect.Value) string {
+	switch value := jsonValue(v).(type) {
+	case string:
+		return value
+	case map[string]any:
+		// Composite keys such as LookupMetaMapkey: join the fields in name order.
+		names := make([]string, 0, len(value))
+		for name := range value {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		parts := make([]string, len(names))
+		for i, name := range names {
+			parts[i] = fmt.Sprintf("%v", value[name])
+		}
+		return strings.Join(parts, ":")
+	case nil:
+		return ""
+	default:
+		return fmt.Sprintf("%v", value)
+	}
+}
+
+func fieldName(field reflect.StructField) string {
+	if tag, ok := field.Tag.Lookup("json"); ok {
+		if name, _, _ := strings.Cut(tag, ","); name != "" && name != "-" {
+			return name
+		}
+	}
+	return toSnakeCase(field.Name)
+}
+
+func toSnakeCase(name string) string {
+	var b strings.Builder
+	var prev rune
+	for i, r := range name {
+		if r >= 'A' && r <= 'Z' {
+			if i > 0 && !(prev >= 'A' && prev <= 'Z') {
+				b.WriteByte('_')
+			}
+			prev = r
+			r += 'a' - 'A'
+		} else {
+			prev = r
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func bytesOf(v reflect.Value) []byte {
+	if v.Kind() == reflect.Slice {
+		return v.Bytes()
+	}
+	out := make([]byte, v.Len())
+	for i := range out {
+		out[i] = byte(v.Index(i).Uint())
+	}
+	return out
+}
+
+func hexString(b []byte) string {
+	return "0x" + hex.EncodeToString(b)
+}
+
+// WriteJSON writes the service detail as indented JSON.
+func (d *ServiceDetail) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(JSONValue(d))
+}
+
+// WriteTable writes the service detail as aligned sections.
+func (d *ServiceDetail) WriteTable(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+
+	fmt.Fprintf(tw, "service\t%d\n", d.ID)
+	fmt.Fprintf(tw, "code hash\t0x%x\n", d.CodeHash)
+	if d.CodeFound {
+		fmt.Fprintf(tw, "code\t%d bytes\n", d.CodeSize)
+		fmt.Fprintf(tw, "metadata\t0x%x\n", d.Metadata)
+	} else {
+		fmt.Fprintln(tw, "code\tnot stored")
+	}
+	fmt.Fprintf(tw, "min item gas\t%d\n", d.Info.MinItemGas)
+	fmt.Fprintf(tw, "min memo gas\t%d\n", d.Info.MinMemoGas)
+	fmt.Fprintf(tw, "created\t%d\n", d.Info.CreationSlot)
+	fmt.Fprintf(tw, "last accumulation\t%d\n", d.Info.LastAccumulationSlot)
+	fmt.Fprintf(tw, "parent service\t%d\n\n", d.Info.ParentService)
+
+	f := d.Footprint
+	fmt.Fprintf(tw, "items\t%d\n", f.Items)
+	fmt.Fprintf(tw, "bytes\t%d\n", f.Bytes)
+	fmt.Fprintf(tw, "deposit offset\t%d\n", f.DepositOffset)
+	fmt.Fprintf(tw, "balance\t%d\n", f.Balance)
+	fmt.Fprintf(tw, "min balance\t%d", f.MinBalance)
+	if f.BelowMinimum {
+		fmt.Fprint(tw, "\t(below minimum)")
+	}
+	fmt.Fprint(tw, "\n\n")
+
+	fmt.Fprintln(tw, "PREIMAGE\tLENGTH\tSTORED\tTIMESLOTS\tSTATUS")
+	for _, p := range d.Preimages {
+		status := string(p.Status)
+		if p.Expungeable {
+			status += " (expungeable)"
+		}
+		fmt.Fprintf(tw, "0x%x\t%d\t%t\t%v\t%s\n", p.Hash, p.Length, p.Stored, p.Timeslots, status)
+	}
+	fmt.Fprintln(tw)
+
+	fmt.Fprintln(tw, "UNATTRIBUTED KEY\tSIZE\tNOTE")
+	for _, k := range d.Unattributed {
+		note := "storage"
+		if k.MaybeLookup {
+			note = "storage or lookup"
+		}
+		fmt.Fprintf(tw, "0x%x\t%d\t%s\n", k.Key, k.Size, note)
+	}
+
+	return tw.Flush()
+}
@@ -0,0 +1,201 @@
+package stateview
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/New-JAMneration/JAM-Protocol/internal/service_account"
+	"github.com/New-JAMneration/JAM-Protocol/internal/types"
+	m "github.com/New-JAMneration/JAM-Protocol/internal/utilities/merklization"
+)
+
+// PreimageStatus is the lifecycle stage of a preimage given by its lookup
+// timeslot set (GP 9.2).
+type PreimageStatus string
+
+const (
+	// PreimageSolicited: [] – requested but not yet provided.
+	PreimageSolicited PreimageStatus = "solicited"
+	// PreimageAvailable: [x] – available since x.
+	PreimageAvailable PreimageStatus = "available"
+	// PreimageForgotten: [x, y] – available from x until y.
+	PreimageForgotten PreimageStatus = "forgotten"
+	// PreimageReavailable: [x, y, z] – forgotten at y and available again since z.
+	PreimageReavailable PreimageStatus = "reavailable"
+	// PreimageUnreferenced: the blob is stored without a lookup entry,
+	// which breaks invariant (9.6).
+	PreimageUnreferenced PreimageStatus = "unreferenced"
+	// PreimageInvalid: the lookup entry holds more than three timeslots.
+	PreimageInvalid PreimageStatus = "invalid"
+)
+
+func preimageStatus(timeslots types.TimeSlotSet) PreimageStatus {
+	switch len(timeslots) {
+	case 0:
+		return PreimageSolicited
+	case 1:
+		return PreimageAvailable
+	case 2:
+		return PreimageForgotten
+	case 3:
+		return PreimageReavailable
+	}
+	return PreimageInvalid
+}
+
+// Preimage is one preimage of a service with its lookup metadata.
+type Preimage struct {
+	Hash      types.OpaqueHash  `json:"hash"`
+	Length    types.U32         `json:"length"`
+	Stored    bool              `json:"stored"`
+	Timeslots types.TimeSlotSet `json:"timeslots"`
+	Status    PreimageStatus    `json:"status"`
+	// Expungeable is set for forgotten preimages whose retention period D
+	// has elapsed at the inspected slot.
+	Expungeable bool `json:"expungeable"`
+}
+
+// Footprint compares the storage footprint of a service against its balance.
+type Footprint struct {
+	Items         types.U32 `json:"items"`
+	Bytes         types.U64 `json:"bytes"`
+	DepositOffset types.U64 `json:"deposit_offset"`
+	MinBalance    types.U64 `json:"min_balance"`
+	Balance       types.U64 `json:"balance"`
+	BelowMinimum  bool      `json:"below_minimum"`
+}
+
+// UnattributedKey is a service key that could not be classified because its
+// state key only holds a hash of the original storage key or lookup hash.
+type UnattributedKey struct {
+	Key  types.StateKey `json:"key"`
+	Size int            `json:"size"`
+	// MaybeLookup is set when the value decodes as a lookup timeslot set,
+	// typically a solicited preimage whose blob was never provided.
+	MaybeLookup bool `json:"maybe_lookup"`
+}
+
+// ServiceDetail is the explorer view of a single service account.
+type ServiceDetail struct {
+	ID           types.ServiceID    `json:"id"`
+	Info         types.ServiceInfo  `json:"info"`
+	CodeHash     types.OpaqueHash   `json:"code_hash"`
+	CodeFound    bool               `json:"code_found"`
+	CodeSize     int                `json:"code_size"`
+	Metadata     types.ByteSequence `json:"metadata"`
+	Preimages    []Preimage         `json:"preimages"`
+	Footprint    Footprint          `json:"footprint"`
+	Unattributed []UnattributedKey  `json:"unattributed"`
+}
+
+// NewServiceDetail decodes keyVals and explores the service id as seen at slot now.
+func NewServiceDetail(keyVals types.StateKeyVals, id types.ServiceID, now types.TimeSlot) (*ServiceDetail, error) {
+	state, unmatched, err := m.StateKeyValsToState(keyVals)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode state: %w", err)
+	}
+
+	account, ok := state.Delta[id]
+	if !ok {
+		return nil, fmt.Errorf("service %d not found", id)
+	}
+
+	info := account.ServiceInfo
+	detail := &ServiceDetail{
+		ID:       id,
+		Info:     info,
+		CodeHash: info.CodeHash,
+		Footprint: Footprint{
+			Items:         info.Items,
+			Bytes:         info.Bytes,
+			DepositOffset: info.DepositOffset,
+			MinBalance:    service_account.CalcThresholdBalance(info.Items, info.Bytes, info.DepositOffset),
+			Balance:       info.Balance,
+		},
+	}
+	detail.Footprint.BelowMinimum = detail.Footprint.Balance < detail.Footprint.MinBalance
+
+	if blob, exists := account.PreimageLookup[info.CodeHash]; exists {
+		metadata, code, err := service_account.DecodeMetaCode(blob)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode code of service %d: %w", id, err)
+		}
+		detail.CodeFound = true
+		detail.CodeSize = len(code)
+		detail.Metadata = metadata
+	}
+
+	detail.Preimages = preimages(account, now)
+	detail.Unattributed = unattributedKeys(unmatched, id)
+
+	return detail, nil
+}
+
+func preimages(account types.ServiceAccount, now types.TimeSlot) []Preimage {
+	result := make([]Preimage, 0, len(account.PreimageLookup))
+	for preimageHash, blob := range account.PreimageLookup {
+		key := types.LookupMetaMapkey{Hash: preimageHash, Length: types.U32(len(blob))}
+		timeslots, referenced := account.LookupDict[key]
+
+		preimage := Preimage{
+			Hash:      preimageHash,
+			Length:    key.Length,
+			Stored:    true,
+			Timeslots: timeslots,
+			Status:    PreimageUnreferenced,
+		}
+		if referenced {
+			preimage.Status = preimageStatus(timeslots)
+			preimage.Expungeable = preimage.Status == PreimageForgotten &&
+				timeslots[1]+types.TimeSlot(types.UnreferencedPreimageTimeslots) < now
+		}
+		result = append(result, preimage)
+	}
+
+	// StateKeyValsToState only pairs lookups with a stored blob, but a
+	// decoded LookupDict may also carry solicited entries.
+	for key, timeslots := range account.LookupDict {
+		if _, stored := account.PreimageLookup[key.Hash]; stored {
+			continue
+		}
+		result = append(result, Preimage{
+			Hash:      key.Hash,
+			Length:    key.Length,
+			Timeslots: timeslots,
+			Status:    preimageStatus(timeslots),
+		})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return string(result[i].Hash[:]) < string(result[j].Hash[:])
+	})
+	return result
+}
+
+func unattributedKeys(unmatched types.StateKeyVals, id types.ServiceID) []UnattributedKey {
+	var result []UnattributedKey
+	for _, kv := range unmatched {
+		owner, err := m.DecodeServiceIDFromType3(kv.Key)
+		if err != nil || owner != id {
+			continue
+		}
+		result = append(result, UnattributedKey{
+			Key:         kv.Key,
+			Size:        len(kv.Value),
+			MaybeLookup: isTimeSlotSet(kv.Value),
+		})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return string(result[i].Key[:]) < string(result[j].Key[:])
+	})
+	return result
+}
+
+// isTimeSlotSet reports whether value is exactly an encoded TimeSlotSet of at most three slots.
+func isTimeSlotSet(value types.ByteSequence) bool {
+	if len(value) == 0 || value[0] > 3 {
+		return false
+	}
+	return len(value) == 1+4*int(value[0])
+}
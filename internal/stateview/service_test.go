@@ -0,0 +1,90 @@
+package stateview_test
+
+import (
+	"testing"
+
+	"github.com/New-JAMneration/JAM-Protocol/internal/stateview"
+	"github.com/New-JAMneration/JAM-Protocol/internal/types"
+	"github.com/New-JAMneration/JAM-Protocol/internal/utilities/hash"
+	m "github.com/New-JAMneration/JAM-Protocol/internal/utilities/merklization"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewServiceDetail(t *testing.T) {
+	code, err := types.NewEncoder().Encode(&types.MetaCode{
+		Metadata: types.ByteSequence("meta"),
+		Code:     types.ByteSequence{0, 1, 2, 3},
+	})
+	require.NoError(t, err)
+	codeHash := hash.Blake2bHash(code)
+
+	forgotten := types.ByteSequence("forgotten")
+	forgottenHash := hash.Blake2bHash(forgotten)
+	solicitedHash := types.OpaqueHash{0x55}
+
+	account := types.ServiceAccount{
+		ServiceInfo: types.ServiceInfo{CodeHash: codeHash, Balance: 10, Items: 5, Bytes: 300},
+		PreimageLookup: types.PreimagesMapEntry{
+			codeHash:      code,
+			forgottenHash: forgotten,
+		},
+		LookupDict: types.LookupMetaMapEntry{
+			{Hash: codeHash, Length: types.U32(len(code))}:           {1},
+			{Hash: forgottenHash, Length: types.U32(len(forgotten))}: {1, 2},
+			{Hash: solicitedHash, Length: 10}:                        {},
+		},
+		StorageDict: types.Storage{"key": types.ByteSequence("value")},
+	}
+
+	keyVals, err := m.StateEncoder(types.State{Delta: types.ServiceAccountState{3: account}})
+	require.NoError(t, err)
+	keyVals = serviceKeyVals(keyVals)
+
+	detail, err := stateview.NewServiceDetail(keyVals, 3, 2+types.TimeSlot(types.UnreferencedPreimageTimeslots)+1)
+	require.NoError(t, err)
+
+	require.True(t, detail.CodeFound)
+	require.Equal(t, 4, detail.CodeSize)
+	require.Equal(t, types.ByteSequence("meta"), detail.Metadata)
+
+	require.True(t, detail.Footprint.BelowMinimum)
+	require.Equal(t, types.U64(10), detail.Footprint.Balance)
+
+	statuses := make(map[types.OpaqueHash]stateview.Preimage)
+	for _, p := range detail.Preimages {
+		statuses[p.Hash] = p
+	}
+	require.Len(t, statuses, 2)
+	require.Equal(t, stateview.PreimageAvailable, statuses[codeHash].Status)
+	require.Equal(t, stateview.PreimageForgotten, statuses[forgottenHash].Status)
+	require.True(t, statuses[forgottenHash].Expungeable)
+
+	// The solicited lookup and the storage item are hashed into their keys.
+	require.Len(t, detail.Unattributed, 2)
+	lookups := 0
+	for _, key := range detail.Unattributed {
+		if key.MaybeLookup {
+			lookups++
+		}
+	}
+	require.Equal(t, 1, lookups)
+
+	_, err = stateview.NewServiceDetail(keyVals, 4, 0)
+	require.Error(t, err)
+}
+
+// serviceKeyVals drops the fixed-size components, which do not round-trip
+// from their zero value.
+func serviceKeyVals(keyVals types.StateKeyVals) types.StateKeyVals {
+	omit := make(map[types.StateKey]bool)
+	for i := types.U8(1); i <= 16; i++ {
+		omit[m.C(i)] = true
+	}
+	result := make(types.StateKeyVals, 0, len(keyVals))
+	for _, kv := range keyVals {
+		if !omit[kv.Key] {
+			result = append(result, kv)
+		}
+	}
+	return result
+}
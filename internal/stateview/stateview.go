@@ -0,0 +1,230 @@
+// Package stateview decodes serialized state (StateKeyVals) into the named
+// Gray Paper components for inspection tools such as `node state`.
+package stateview
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+
+	"github.com/New-JAMneration/JAM-Protocol/internal/types"
+	m "github.com/New-JAMneration/JAM-Protocol/internal/utilities/merklization"
+)
+
+// Component names, in state key order C(1)..C(16).
+const (
+	Alpha    = "alpha"
+	Varphi   = "varphi"
+	Beta     = "beta"
+	Gamma    = "gamma"
+	Psi      = "psi"
+	Eta      = "eta"
+	Iota     = "iota"
+	Kappa    = "kappa"
+	Lambda   = "lambda"
+	Rho      = "rho"
+	Tau      = "tau"
+	Chi      = "chi"
+	Pi       = "pi"
+	Vartheta = "vartheta"
+	Xi       = "xi"
+	Theta    = "theta"
+
+	// Delta selects the per-service accounts.
+	Delta = "delta"
+)
+
+// Components lists every component name accepted by Filter, in output order.
+var Components = []string{
+	Alpha, Varphi, Beta, Gamma, Psi, Eta, Iota, Kappa, Lambda,
+	Rho, Tau, Chi, Pi, Vartheta, Xi, Theta, Delta,
+}
+
+var componentIndex = map[string]types.U8{
+	Alpha: 1, Varphi: 2, Beta: 3, Gamma: 4, Psi: 5, Eta: 6, Iota: 7, Kappa: 8,
+	Lambda: 9, Rho: 10, Tau: 11, Chi: 12, Pi: 13, Vartheta: 14, Xi: 15, Theta: 16,
+}
+
+// ComponentKey returns the state key C(i) of the named component.
+func ComponentKey(name string) (types.StateKey, error) {
+	index, ok := componentIndex[name]
+	if !ok {
+		return types.StateKey{}, fmt.Errorf("unknown state component %q", name)
+	}
+	return m.C(index), nil
+}
+
+func componentValue(state *types.State, name string) any {
+	switch name {
+	case Alpha:
+		return state.Alpha
+	case Varphi:
+		return state.Varphi
+	case Beta:
+		return state.Beta
+	case Gamma:
+		return state.Gamma
+	case Psi:
+		return state.Psi
+	case Eta:
+		return state.Eta
+	case Iota:
+		return state.Iota
+	case Kappa:
+		return state.Kappa
+	case Lambda:
+		return state.Lambda
+	case Rho:
+		return state.Rho
+	case Tau:
+		return state.Tau
+	case Chi:
+		return state.Chi
+	case Pi:
+		return state.Pi
+	case Vartheta:
+		return state.Vartheta
+	case Xi:
+		return state.Xi
+	case Theta:
+		return state.Theta
+	}
+	return nil
+}
+
+// Filter narrows a View. An empty filter selects everything.
+type Filter struct {
+	Components []string
+	Services   []types.ServiceID
+}
+
+// Validate reports unknown component names.
+func (f Filter) Validate() error {
+	for _, name := range f.Components {
+		if !slices.Contains(Components, name) {
+			return fmt.Errorf("unknown state component %q", name)
+		}
+	}
+	return nil
+}
+
+func (f Filter) component(name string) bool {
+	if len(f.Components) == 0 {
+		// Selecting only services narrows the output to delta.
+		return len(f.Services) == 0 || name == Delta
+	}
+	// Selecting services implies delta.
+	if name == Delta && len(f.Services) > 0 {
+		return true
+	}
+	return slices.Contains(f.Components, name)
+}
+
+func (f Filter) service(id types.ServiceID) bool {
+	return len(f.Services) == 0 || slices.Contains(f.Services, id)
+}
+
+// Component is a single decoded C(1)..C(16) entry.
+type Component struct {
+	Name  string
+	Key   types.StateKey
+	Size  int
+	Value any
+}
+
+// Service summarizes one service account of delta.
+type Service struct {
+	ID        types.ServiceID   `json:"id"`
+	Info      types.ServiceInfo `json:"info"`
+	Preimages int               `json:"preimages"`
+	Lookups   int               `json:"lookups"`
+	// Storage counts the remaining keys of the service. They are storage
+	// items or lookups whose preimage is absent; the hashed key does not
+	// allow telling them apart.
+	Storage      int `json:"storage"`
+	StorageBytes int `json:"storage_bytes"`
+}
+
+// View is the decoded state.
+type View struct {
+	Root       types.StateRoot
+	KeyCount   int
+	Components []Component
+	Services   []Service
+}
+
+// New decodes keyVals and keeps the parts selected by filter.
+func New(keyVals types.StateKeyVals, filter Filter) (*View, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+
+	state, unmatched, err := m.StateKeyValsToState(keyVals)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode state: %w", err)
+	}
+
+	sizes := make(map[types.StateKey]int, len(keyVals))
+	for _, kv := range keyVals {
+		sizes[kv.Key] = len(kv.Value)
+	}
+
+	view := &View{
+		Root:     m.MerklizationSerializedState(keyVals),
+		KeyCount: len(keyVals),
+	}
+
+	for _, name := range Components {
+		if name == Delta || !filter.component(name) {
+			continue
+		}
+		key := m.C(componentIndex[name])
+		view.Components = append(view.Components, Component{
+			Name:  name,
+			Key:   key,
+			Size:  sizes[key],
+			Value: componentValue(&state, name),
+		})
+	}
+
+	if filter.component(Delta) {
+		view.Services = services(&state, unmatched, filter)
+	}
+
+	return view, nil
+}
+
+func services(state *types.State, unmatched types.StateKeyVals, filter Filter) []Service {
+	byID := make(map[types.ServiceID]*Service, len(state.Delta))
+	for id, account := range state.Delta {
+		if !filter.service(id) {
+			continue
+		}
+		byID[id] = &Service{
+			ID:        id,
+			Info:      account.ServiceInfo,
+			Preimages: len(account.PreimageLookup),
+			Lookups:   len(account.LookupDict),
+		}
+	}
+
+	for _, kv := range unmatched {
+		id, err := m.DecodeServiceIDFromType3(kv.Key)
+		if err != nil {
+			continue
+		}
+		service, ok := byID[id]
+		if !ok {
+			continue
+		}
+		service.Storage++
+		service.StorageBytes += len(kv.Value)
+	}
+
+	result := make([]Service, 0, len(byID))
+	for _, service := range byID {
+		result = append(result, *service)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ID < result[j].ID })
+	return result
+}
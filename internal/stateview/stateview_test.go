@@ -0,0 +1,127 @@
+package stateview_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/New-JAMneration/JAM-Protocol/internal/stateview"
+	"github.com/New-JAMneration/JAM-Protocol/internal/types"
+	"github.com/New-JAMneration/JAM-Protocol/internal/utilities/hash"
+	m "github.com/New-JAMneration/JAM-Protocol/internal/utilities/merklization"
+	"github.com/stretchr/testify/require"
+)
+
+func testStateKeyVals(t *testing.T) types.StateKeyVals {
+	preimage := types.ByteSequence("preimage")
+	preimageHash := hash.Blake2bHash(preimage)
+
+	state := types.State{
+		Tau: 42,
+		Eta: types.EntropyBuffer{{1}, {2}, {3}, {4}},
+		Delta: types.ServiceAccountState{
+			7: {
+				ServiceInfo:    types.ServiceInfo{CodeHash: types.OpaqueHash{0xaa}, Balance: 1000, Items: 3},
+				PreimageLookup: types.PreimagesMapEntry{preimageHash: preimage},
+				LookupDict: types.LookupMetaMapEntry{
+					{Hash: preimageHash, Length: types.U32(len(preimage))}: {5},
+				},
+				StorageDict: types.Storage{"key": types.ByteSequence("value")},
+			},
+			9: {
+				ServiceInfo:    types.ServiceInfo{Balance: 5},
+				PreimageLookup: types.PreimagesMapEntry{},
+				LookupDict:     types.LookupMetaMapEntry{},
+				StorageDict:    types.Storage{},
+			},
+		},
+	}
+
+	encoded, err := m.StateEncoder(state)
+	require.NoError(t, err)
+
+	// Most components are fixed-size per chain spec and do not round-trip from
+	// their zero value; keep eta and tau only.
+	omit := make(map[types.StateKey]bool)
+	for i := types.U8(1); i <= 16; i++ {
+		if i != 6 && i != 11 {
+			omit[m.C(i)] = true
+		}
+	}
+	keyVals := make(types.StateKeyVals, 0, len(encoded))
+	for _, kv := range encoded {
+		if !omit[kv.Key] {
+			keyVals = append(keyVals, kv)
+		}
+	}
+	return keyVals
+}
+
+func TestNew(t *testing.T) {
+	keyVals := testStateKeyVals(t)
+
+	view, err := stateview.New(keyVals, stateview.Filter{})
+	require.NoError(t, err)
+	require.Equal(t, m.MerklizationSerializedState(keyVals), view.Root)
+	require.Len(t, view.Components, 16)
+
+	require.Len(t, view.Services, 2)
+	service := view.Services[0]
+	require.Equal(t, types.ServiceID(7), service.ID)
+	require.Equal(t, types.U64(1000), service.Info.Balance)
+	require.Equal(t, 1, service.Preimages)
+	require.Equal(t, 1, service.Lookups)
+	require.Equal(t, 1, service.Storage)
+}
+
+func TestNewFilter(t *testing.T) {
+	keyVals := testStateKeyVals(t)
+
+	view, err := stateview.New(keyVals, stateview.Filter{Components: []string{stateview.Tau, stateview.Eta}})
+	require.NoError(t, err)
+	require.Len(t, view.Components, 2)
+	require.Equal(t, stateview.Eta, view.Components[0].Name)
+	require.Equal(t, stateview.Tau, view.Components[1].Name)
+	require.Equal(t, types.TimeSlot(42), view.Components[1].Value)
+	require.Nil(t, view.Services)
+
+	view, err = stateview.New(keyVals, stateview.Filter{Services: []types.ServiceID{9}})
+	require.NoError(t, err)
+	require.Empty(t, view.Components)
+	require.Len(t, view.Services, 1)
+	require.Equal(t, types.ServiceID(9), view.Services[0].ID)
+
+	_, err = stateview.New(keyVals, stateview.Filter{Components: []string{"omega"}})
+	require.Error(t, err)
+}
+
+func TestWriteJSON(t *testing.T) {
+	view, err := stateview.New(testStateKeyVals(t), stateview.Filter{Components: []string{stateview.Eta, stateview.Delta}})
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, view.WriteJSON(&buf))
+
+	var out struct {
+		Components []struct {
+			Name  string
+			Value []string
+		}
+		Services []struct {
+			ID   uint32 `json:"id"`
+			Info struct {
+				CodeHash string `json:"code_hash"`
+			}
+		}
+	}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &out))
+	require.Len(t, out.Components, 1)
+	require.Len(t, out.Components[0].Value, 4)
+	require.Equal(t, "0x02"+string(bytes.Repeat([]byte("00"), 31)), out.Components[0].Value[1])
+	require.Equal(t, uint32(7), out.Services[0].ID)
+	require.Equal(t, "0xaa"+string(bytes.Repeat([]byte("00"), 31)), out.Services[0].Info.CodeHash)
+
+	buf.Reset()
+	require.NoError(t, view.WriteTable(&buf))
+	require.Contains(t, buf.String(), "eta")
+}
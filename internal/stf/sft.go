@@ -46,6 +46,9 @@ func RunSTF() (bool, error) {
 		unmatchedKeyVals = cs.GetPriorStateUnmatchedKeyVals()
 	)
 
+	// Drop the unmatched key-val changes of a block that was not committed
+	cs.ResetStateAccess()
+
 	// Update timeslot
 	cs.GetPosteriorStates().SetTau(header.Slot)
 
@@ -86,6 +86,9 @@ func RunSTFWithTiming() (bool, error, STFTiming) {
 		unmatchedKeyVals = cs.GetPriorStateUnmatchedKeyVals()
 	)
 
+	// Drop the unmatched key-val changes of a block that was not committed
+	cs.ResetStateAccess()
+
 	// Update timeslot
 	cs.GetPosteriorStates().SetTau(header.Slot)
 
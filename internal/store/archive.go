@@ -0,0 +1,234 @@
+package store
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+
+	"github.com/New-JAMneration/JAM-Protocol/internal/database"
+	"github.com/New-JAMneration/JAM-Protocol/internal/types"
+)
+
+// Archive queries over historical states. Every state stays in its state
+// trie, so single keys and key ranges are answered by walking only the paths
+// that lead to them.
+
+// StateValueAt is the value of a state key in the posterior state of one block.
+type StateValueAt struct {
+	Slot       types.TimeSlot
+	HeaderHash types.HeaderHash
+	StateRoot  types.StateRoot
+	Value      []byte
+	Found      bool
+}
+
+// ResolveSlot returns the canonical header hash for slot from the hh: index.
+// Databases written before hh: was persisted fall back to the ht: index; if
+// several blocks share the slot, the one with a recorded state root wins.
+func (repo *Repository) ResolveSlot(r database.IterableReader, slot types.TimeSlot) (types.HeaderHash, error) {
+	return newArchiveResolver(repo, r).resolveSlot(slot)
+}
+
+// StateRootAt returns the posterior state root of the block with headerHash.
+// Without an sr: entry the root is taken from a child block's ParentStateRoot.
+func (repo *Repository) StateRootAt(r database.IterableReader, headerHash types.HeaderHash) (types.StateRoot, error) {
+	return newArchiveResolver(repo, r).stateRoot(headerHash)
+}
+
+// archiveResolver answers slot and state root lookups, building the fallback
+// indexes for databases without hh:/sr: entries at most once.
+type archiveResolver struct {
+	repo *Repository
+	r    database.IterableReader
+
+	slots      map[types.TimeSlot][]types.HeaderHash
+	childRoots map[types.HeaderHash]types.StateRoot
+}
+
+func newArchiveResolver(repo *Repository, r database.IterableReader) *archiveResolver {
+	return &archiveResolver{repo: repo, r: r}
+}
+
+func (a *archiveResolver) resolveSlot(slot types.TimeSlot) (types.HeaderHash, error) {
+	if headerHash, err := a.repo.GetCanonicalHash(a.r, slot); err == nil {
+		return headerHash, nil
+	}
+
+	if err := a.loadSlots(); err != nil {
+		return types.HeaderHash{}, err
+	}
+
+	candidates := a.slots[slot]
+	if len(candidates) == 0 {
+		return types.HeaderHash{}, fmt.Errorf("no block found for slot %d", slot)
+	}
+	for _, headerHash := range candidates {
+		if has, _ := a.r.Has(stateRootKey(headerHash)); has {
+			return headerHash, nil
+		}
+	}
+	return candidates[0], nil
+}
+
+// loadSlots builds the ht: fallback index of the header hashes per slot.
+func (a *archiveResolver) loadSlots() error {
+	if a.slots != nil {
+		return nil
+	}
+	a.slots = make(map[types.TimeSlot][]types.HeaderHash)
+	return forEach(a.r, headerTimeSlotPrefix, func(key, value []byte) {
+		if len(key) != len(headerTimeSlotPrefix)+len(types.HeaderHash{}) {
+			return
+		}
+		var s types.TimeSlot
+		if err := a.repo.decoder.Decode(value, &s); err != nil {
+			return
+		}
+		a.slots[s] = append(a.slots[s], types.HeaderHash(key[len(headerTimeSlotPrefix):]))
+	})
+}
+
+// canonicalSlots returns the canonical header hash of every slot in
+// [from, to] that has one, ordered by slot; to = 0 leaves the range open.
+// Databases without hh: entries are resolved slot by slot through ht:.
+func (a *archiveResolver) canonicalSlots(from, to types.TimeSlot) ([]canonicalSlot, error) {
+	inRange := func(slot types.TimeSlot) bool {
+		return slot >= from && (to == 0 || slot <= to)
+	}
+
+	var slots []canonicalSlot
+	recorded := false
+	err := forEach(a.r, headerHashPrefix, func(key, value []byte) {
+		var slot types.TimeSlot
+		if len(value) != len(types.HeaderHash{}) || a.repo.decoder.Decode(key[len(headerHashPrefix):], &slot) != nil {
+			return
+		}
+		recorded = true
+		if inRange(slot) {
+			slots = append(slots, canonicalSlot{Slot: slot, HeaderHash: types.HeaderHash(value)})
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if !recorded {
+		if err := a.loadSlots(); err != nil {
+			return nil, err
+		}
+		for slot := range a.slots {
+			if !inRange(slot) {
+				continue
+			}
+			headerHash, err := a.resolveSlot(slot)
+			if err != nil {
+				return nil, err
+			}
+			slots = append(slots, canonicalSlot{Slot: slot, HeaderHash: headerHash})
+		}
+	}
+
+	sort.Slice(slots, func(i, j int) bool { return slots[i].Slot < slots[j].Slot })
+	return slots, nil
+}
+
+func (a *archiveResolver) stateRoot(headerHash types.HeaderHash) (types.StateRoot, error) {
+	if stateRoot, err := a.repo.GetStateRootByHeaderHash(a.r, headerHash); err == nil {
+		return stateRoot, nil
+	}
+
+	if a.childRoots == nil {
+		childRoots := make(map[types.HeaderHash]types.StateRoot)
+		err := a.repo.forEachHeader(a.r, func(header *types.Header) {
+			childRoots[header.Parent] = header.ParentStateRoot
+		})
+		if err != nil {
+			return types.StateRoot{}, err
+		}
+		a.childRoots = childRoots
+	}
+
+	if stateRoot, ok := a.childRoots[headerHash]; ok {
+		return stateRoot, nil
+	}
+	return types.StateRoot{}, fmt.Errorf("state root not found for header hash %x", headerHash)
+}
+
+// GetStateValue returns the value of key in the state with stateRoot.
+func (repo *Repository) GetStateValue(r database.Reader, stateRoot types.StateRoot, key types.StateKey) ([]byte, bool, error) {
+	trieRoot, err := stateTrieRoot(r, stateRoot)
+	if err != nil {
+		return nil, false, err
+	}
+	return lookupStateTrie(r, trieRoot, key)
+}
+
+// GetStateRange returns the entries of the state with stateRoot whose keys
+// lie in [start, end), ordered by key. A zero end leaves the range open.
+func (repo *Repository) GetStateRange(r database.Reader, stateRoot types.StateRoot, start, end types.StateKey) (types.StateKeyVals, error) {
+	trieRoot, err := stateTrieRoot(r, stateRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	var result types.StateKeyVals
+	walk := &stateTrieWalk{r: r, start: &start, fn: func(key types.StateKey, node *stateNode) error {
+		value, err := leafValue(r, node)
+		if err != nil {
+			return err
+		}
+		result = append(result, types.StateKeyVal{Key: key, Value: value})
+		return nil
+	}}
+	if end != (types.StateKey{}) {
+		walk.end = &end
+	}
+	if err := walk.walk(trieRoot, 0, types.StateKey{}); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// GetStateValueHistory returns the value of key at every canonical block in
+// slots [from, to]. Slots without a block are skipped, and blocks sharing a
+// state root reuse the previous lookup.
+func (repo *Repository) GetStateValueHistory(r database.IterableReader, key types.StateKey, from, to types.TimeSlot) ([]StateValueAt, error) {
+	if from > to {
+		return nil, fmt.Errorf("invalid slot range %d..%d", from, to)
+	}
+
+	resolver := newArchiveResolver(repo, r)
+	cache := make(map[types.StateRoot]StateValueAt)
+
+	var history []StateValueAt
+	for slot := from; ; slot++ {
+		if headerHash, err := resolver.resolveSlot(slot); err == nil {
+			stateRoot, err := resolver.stateRoot(headerHash)
+			if err != nil {
+				return nil, fmt.Errorf("slot %d: %w", slot, err)
+			}
+
+			entry, ok := cache[stateRoot]
+			if !ok {
+				value, found, err := repo.GetStateValue(r, stateRoot, key)
+				if err != nil {
+					return nil, fmt.Errorf("slot %d: %w", slot, err)
+				}
+				entry = StateValueAt{StateRoot: stateRoot, Value: value, Found: found}
+				cache[stateRoot] = entry
+			}
+			entry.Slot, entry.HeaderHash = slot, headerHash
+			history = append(history, entry)
+		}
+		if slot == to {
+			break
+		}
+	}
+	return history, nil
+}
+
+func sortStateKeyVals(keyVals types.StateKeyVals) {
+	sort.Slice(keyVals, func(i, j int) bool {
+		return bytes.Compare(keyVals[i].Key[:], keyVals[j].Key[:]) < 0
+	})
+}
@@ -0,0 +1,102 @@
+package store_test
+
+import (
+	"testing"
+
+	"github.com/New-JAMneration/JAM-Protocol/internal/database/provider/memory"
+	"github.com/New-JAMneration/JAM-Protocol/internal/store"
+	"github.com/New-JAMneration/JAM-Protocol/internal/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveSlot(t *testing.T) {
+	db := memory.NewDatabase()
+	repo := store.NewRepository(db)
+	hashes, _ := seedFsckChain(t, db, repo)
+
+	// Without hh: entries the slot is resolved through ht:.
+	headerHash, err := repo.ResolveSlot(db, 1)
+	require.NoError(t, err)
+	require.Equal(t, hashes[1], headerHash)
+
+	require.NoError(t, repo.SaveCanonicalHash(db, hashes[2], 1))
+	headerHash, err = repo.ResolveSlot(db, 1)
+	require.NoError(t, err)
+	require.Equal(t, hashes[2], headerHash)
+
+	_, err = repo.ResolveSlot(db, 7)
+	require.Error(t, err)
+}
+
+func TestStateRootAtFallsBackToChild(t *testing.T) {
+	db := memory.NewDatabase()
+	repo := store.NewRepository(db)
+	hashes, roots := seedFsckChain(t, db, repo)
+
+	require.NoError(t, db.Delete(append([]byte("sr:"), hashes[1][:]...)))
+
+	root, err := repo.StateRootAt(db, hashes[1])
+	require.NoError(t, err)
+	require.Equal(t, roots[1], root)
+
+	// The head has no child to take the root from.
+	require.NoError(t, db.Delete(append([]byte("sr:"), hashes[2][:]...)))
+	_, err = repo.StateRootAt(db, hashes[2])
+	require.Error(t, err)
+}
+
+func TestGetStateValueAndRange(t *testing.T) {
+	db := memory.NewDatabase()
+	repo := store.NewRepository(db)
+
+	state := types.StateKeyVals{
+		{Key: types.StateKey{3}, Value: []byte("three")},
+		{Key: types.StateKey{1}, Value: []byte("one")},
+		{Key: types.StateKey{2}, Value: make([]byte, 300)},
+	}
+	root := types.StateRoot{0xaa}
+	require.NoError(t, repo.SaveStateData(db, root, state))
+
+	value, found, err := repo.GetStateValue(db, root, types.StateKey{2})
+	require.NoError(t, err)
+	require.True(t, found)
+	require.Len(t, value, 300)
+
+	_, found, err = repo.GetStateValue(db, root, types.StateKey{4})
+	require.NoError(t, err)
+	require.False(t, found)
+
+	keyVals, err := repo.GetStateRange(db, root, types.StateKey{1}, types.StateKey{3})
+	require.NoError(t, err)
+	require.Len(t, keyVals, 2)
+	require.Equal(t, types.StateKey{1}, keyVals[0].Key)
+	require.Equal(t, types.StateKey{2}, keyVals[1].Key)
+
+	keyVals, err = repo.GetStateRange(db, root, types.StateKey{2}, types.StateKey{})
+	require.NoError(t, err)
+	require.Len(t, keyVals, 2)
+	require.Equal(t, types.ByteSequence("three"), keyVals[1].Value)
+
+	_, _, err = repo.GetStateValue(db, types.StateRoot{0xbb}, types.StateKey{1})
+	require.Error(t, err)
+}
+
+func TestGetStateValueHistory(t *testing.T) {
+	db := memory.NewDatabase()
+	repo := store.NewRepository(db)
+	hashes, roots := seedFsckChain(t, db, repo)
+
+	history, err := repo.GetStateValueHistory(db, types.StateKey{1}, 0, 5)
+	require.NoError(t, err)
+	require.Len(t, history, 3)
+	for i, entry := range history {
+		require.Equal(t, types.TimeSlot(i), entry.Slot)
+		require.Equal(t, hashes[i], entry.HeaderHash)
+		require.Equal(t, roots[i], entry.StateRoot)
+		require.True(t, entry.Found)
+		require.Equal(t, []byte{byte(i)}, entry.Value)
+	}
+
+	_, err = repo.GetStateValueHistory(db, types.StateKey{1}, 3, 2)
+	require.Error(t, err)
+}
@@ -106,3 +106,112 @@ func (repo *Repository) GetBlockByHash(r database.Reader, hash types.OpaqueHash)
 
 	return block, nil
 }
+
+// forEachHeader calls fn with the header of every stored block, from both the
+// hash-indexed (b:) and the slot-indexed (h:) layouts; a block stored in both
+// is seen twice.
+func (repo *Repository) forEachHeader(r database.Iterable, fn func(header *types.Header)) error {
+	iter, err := r.NewIterator(blockByHashPrefix, nil)
+	if err != nil {
+		return err
+	}
+	for iter.Next() {
+		block := &types.Block{}
+		if err := repo.decoder.Decode(iter.Value(), block); err != nil {
+			iter.Close()
+			return fmt.Errorf("failed to decode block %x: %w", iter.Key(), err)
+		}
+		fn(&block.Header)
+	}
+	if err := iter.Error(); err != nil {
+		iter.Close()
+		return err
+	}
+	iter.Close()
+
+	iter, err = r.NewIterator(headerPrefix, nil)
+	if err != nil {
+		return err
+	}
+	defer iter.Close()
+	for iter.Next() {
+		header := &types.Header{}
+		if err := repo.decoder.Decode(iter.Value(), header); err != nil {
+			return fmt.Errorf("failed to decode header %x: %w", iter.Key(), err)
+		}
+		fn(header)
+	}
+	return iter.Error()
+}
+
+// canonicalSlot is one entry of the canonical chain.
+type canonicalSlot struct {
+	Slot       types.TimeSlot
+	HeaderHash types.HeaderHash
+}
+
+// CanonicalBlockIterator walks the canonical chain in slot order. Only the
+// slot index is held in memory; each block is read when Next reaches it.
+type CanonicalBlockIterator struct {
+	repo  *Repository
+	r     database.Reader
+	slots []canonicalSlot
+	next  int
+
+	block *types.Block
+	err   error
+}
+
+// CanonicalBlocks returns an iterator over the canonical blocks with slots in
+// [from, to]; to = 0 leaves the range open. The chain is taken from the hh:
+// index, or for databases written before it from ht: as ResolveSlot does, so
+// blocks of abandoned forks are left out.
+func (repo *Repository) CanonicalBlocks(r database.IterableReader, from, to types.TimeSlot) (*CanonicalBlockIterator, error) {
+	slots, err := newArchiveResolver(repo, r).canonicalSlots(from, to)
+	if err != nil {
+		return nil, err
+	}
+	return &CanonicalBlockIterator{repo: repo, r: r, slots: slots}, nil
+}
+
+// Len returns the number of blocks the iterator walks.
+func (it *CanonicalBlockIterator) Len() int {
+	return len(it.slots)
+}
+
+// Next reads the next block, reporting false at the end of the chain or on
+// an error.
+func (it *CanonicalBlockIterator) Next() bool {
+	if it.err != nil || it.next >= len(it.slots) {
+		it.block = nil
+		return false
+	}
+	entry := it.slots[it.next]
+	it.next++
+
+	block, err := it.repo.GetBlockByHash(it.r, types.OpaqueHash(entry.HeaderHash))
+	if err != nil {
+		block, err = it.repo.GetBlock(it.r, entry.HeaderHash, entry.Slot)
+	}
+	if err != nil {
+		it.block, it.err = nil, fmt.Errorf("canonical block 0x%x at slot %d: %w", entry.HeaderHash, entry.Slot, err)
+		return false
+	}
+	it.block = block
+	return true
+}
+
+// Block returns the block read by the last call to Next.
+func (it *CanonicalBlockIterator) Block() *types.Block {
+	return it.block
+}
+
+// HeaderHash returns the header hash of the block read by the last call to Next.
+func (it *CanonicalBlockIterator) HeaderHash() types.HeaderHash {
+	return it.slots[it.next-1].HeaderHash
+}
+
+// Error returns the error that stopped the iterator, if any.
+func (it *CanonicalBlockIterator) Error() error {
+	return it.err
+}
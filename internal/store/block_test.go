@@ -111,3 +111,54 @@ func TestDeleteNonExistentBlock(t *testing.T) {
 	headerHash := types.HeaderHash{}
 	require.NoError(t, repo.DeleteBlock(db, headerHash, 1))
 }
+
+func TestCanonicalBlocks(t *testing.T) {
+	db := memory.NewDatabase()
+	repo := store.NewRepository(db)
+	hashes, _ := seedFsckChain(t, db, repo)
+
+	// A fork block at slot 1 without a state is not canonical.
+	fork := &types.Block{Header: types.Header{Slot: 1, Parent: hashes[0], AuthorIndex: 1}}
+	forkHash, err := hash.ComputeBlockHeaderHash(fork.Header)
+	require.NoError(t, err)
+	require.NoError(t, repo.SaveBlockByHash(db, types.OpaqueHash(forkHash), fork))
+	require.NoError(t, repo.SaveHeaderTimeSlot(db, forkHash, 1))
+
+	canonical := func(from, to types.TimeSlot) []types.HeaderHash {
+		it, err := repo.CanonicalBlocks(db, from, to)
+		require.NoError(t, err)
+		var headerHashes []types.HeaderHash
+		for it.Next() {
+			headerHash, err := hash.ComputeBlockHeaderHash(it.Block().Header)
+			require.NoError(t, err)
+			require.Equal(t, it.HeaderHash(), headerHash)
+			headerHashes = append(headerHashes, headerHash)
+		}
+		require.NoError(t, it.Error())
+		require.Len(t, headerHashes, it.Len())
+		return headerHashes
+	}
+
+	// Without hh: entries the chain is resolved through ht:.
+	require.Equal(t, hashes, canonical(0, 0))
+	require.Equal(t, hashes[1:2], canonical(1, 1))
+
+	// Blocks stored only in the slot-indexed layout are read as well.
+	bySlot := &types.Block{Header: types.Header{Slot: 3, Parent: hashes[2]}}
+	bySlotHash, err := hash.ComputeBlockHeaderHash(bySlot.Header)
+	require.NoError(t, err)
+	require.NoError(t, repo.SaveBlock(db, bySlot))
+	for slot, headerHash := range hashes {
+		require.NoError(t, repo.SaveCanonicalHash(db, headerHash, types.TimeSlot(slot)))
+	}
+	require.NoError(t, repo.SaveCanonicalHash(db, bySlotHash, 3))
+	require.Equal(t, append(hashes, bySlotHash), canonical(0, 0))
+	require.Equal(t, []types.HeaderHash{hashes[2], bySlotHash}, canonical(2, 0))
+
+	// A canonical entry without a stored block stops the iteration.
+	require.NoError(t, repo.SaveCanonicalHash(db, types.HeaderHash{9}, 4))
+	it, err := repo.CanonicalBlocks(db, 4, 0)
+	require.NoError(t, err)
+	require.False(t, it.Next())
+	require.Error(t, it.Error())
+}
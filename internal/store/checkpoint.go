@@ -0,0 +1,116 @@
+package store
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/New-JAMneration/JAM-Protocol/internal/database"
+	"github.com/New-JAMneration/JAM-Protocol/internal/types"
+	m "github.com/New-JAMneration/JAM-Protocol/internal/utilities/merklization"
+)
+
+// CheckpointDumpFile is the key dump written into a checkpoint directory for
+// providers without native checkpoints.
+const CheckpointDumpFile = "keys.dump"
+
+// Checkpoint writes a consistent copy of db to dir, which must not exist yet.
+// Providers implementing database.Checkpointer use their native mechanism;
+// the others get a key dump of the SchemaPrefixes in dir/CheckpointDumpFile.
+func Checkpoint(db database.Database, dir string) error {
+	if checkpointer, ok := db.(database.Checkpointer); ok {
+		return checkpointer.Checkpoint(dir)
+	}
+
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		return err
+	}
+	f, err := os.Create(filepath.Join(dir, CheckpointDumpFile))
+	if err != nil {
+		return err
+	}
+	if _, err := database.Dump(db, f, SchemaPrefixes()); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to dump database: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Head is the newest canonical block recorded in the hh: index.
+type Head struct {
+	Slot       types.TimeSlot
+	HeaderHash types.HeaderHash
+	StateRoot  types.StateRoot
+}
+
+// CanonicalTop returns the highest slot in the hh: index and its header
+// hash. found is false when the index is empty.
+func (repo *Repository) CanonicalTop(r database.Iterable) (slot types.TimeSlot, headerHash types.HeaderHash, found bool, err error) {
+	err = forEach(r, headerHashPrefix, func(key, value []byte) {
+		var keySlot types.TimeSlot
+		if len(value) != len(types.HeaderHash{}) || repo.decoder.Decode(key[len(headerHashPrefix):], &keySlot) != nil {
+			return
+		}
+		if !found || keySlot > slot {
+			slot, headerHash, found = keySlot, types.HeaderHash(value), true
+		}
+	})
+	return slot, headerHash, found, err
+}
+
+// Head returns the canonical block with the highest slot.
+func (repo *Repository) Head(r database.IterableReader) (Head, error) {
+	slot, headerHash, found, err := repo.CanonicalTop(r)
+	if err != nil {
+		return Head{}, err
+	}
+	if !found {
+		return Head{}, fmt.Errorf("no canonical head recorded")
+	}
+
+	head := Head{Slot: slot, HeaderHash: headerHash}
+	head.StateRoot, err = repo.StateRootAt(r, head.HeaderHash)
+	if err != nil {
+		return Head{}, err
+	}
+	return head, nil
+}
+
+// VerifyHead checks that the head block is stored, that its state merklizes
+// to the recorded state root and that a recorded finalized block is stored.
+func (repo *Repository) VerifyHead(r database.IterableReader) (Head, error) {
+	head, err := repo.Head(r)
+	if err != nil {
+		return Head{}, err
+	}
+
+	if _, err := repo.GetBlockByHash(r, types.OpaqueHash(head.HeaderHash)); err != nil {
+		return head, fmt.Errorf("head block 0x%x: %w", head.HeaderHash, err)
+	}
+
+	keyVals, err := repo.GetStateData(r, head.StateRoot)
+	if err != nil {
+		return head, fmt.Errorf("head state: %w", err)
+	}
+	if root := m.MerklizationSerializedState(keyVals); root != head.StateRoot {
+		return head, fmt.Errorf("head state merklizes to 0x%x, want 0x%x", root, head.StateRoot)
+	}
+
+	if has, err := r.Has(finalizedHeaderHashPrefix); err != nil {
+		return head, err
+	} else if has {
+		finalized, err := repo.GetFinalizedHash(r)
+		if err != nil {
+			return head, err
+		}
+		if _, err := repo.GetBlockByHash(r, types.OpaqueHash(finalized)); err != nil {
+			return head, fmt.Errorf("finalized block 0x%x: %w", finalized, err)
+		}
+	}
+
+	return head, nil
+}
@@ -0,0 +1,105 @@
+package store_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/New-JAMneration/JAM-Protocol/internal/database"
+	"github.com/New-JAMneration/JAM-Protocol/internal/database/provider/memory"
+	pebbledb "github.com/New-JAMneration/JAM-Protocol/internal/database/provider/pebble"
+	"github.com/New-JAMneration/JAM-Protocol/internal/store"
+	"github.com/New-JAMneration/JAM-Protocol/internal/types"
+	"github.com/stretchr/testify/require"
+)
+
+func seedCanonicalChain(t *testing.T, db database.Database, repo *store.Repository) ([]types.HeaderHash, []types.StateRoot) {
+	t.Helper()
+	hashes, roots := seedFsckChain(t, db, repo)
+	for slot, headerHash := range hashes {
+		require.NoError(t, repo.SaveCanonicalHash(db, headerHash, types.TimeSlot(slot)))
+	}
+	return hashes, roots
+}
+
+func TestVerifyHead(t *testing.T) {
+	db := memory.NewDatabase()
+	repo := store.NewRepository(db)
+	hashes, roots := seedCanonicalChain(t, db, repo)
+	require.NoError(t, repo.SaveFinalizedHash(db, hashes[1]))
+
+	head, err := repo.VerifyHead(db)
+	require.NoError(t, err)
+	require.Equal(t, store.Head{Slot: 2, HeaderHash: hashes[2], StateRoot: roots[2]}, head)
+
+	// A head state that no longer matches its root is rejected.
+	require.NoError(t, repo.SaveStateData(db, roots[2], types.StateKeyVals{{Key: types.StateKey{1}, Value: []byte{9}}}))
+	_, err = repo.VerifyHead(db)
+	require.ErrorContains(t, err, "merklizes")
+}
+
+func TestVerifyHeadWithoutHead(t *testing.T) {
+	db := memory.NewDatabase()
+	_, err := store.NewRepository(db).VerifyHead(db)
+	require.Error(t, err)
+}
+
+func TestCheckpointDump(t *testing.T) {
+	db := memory.NewDatabase()
+	repo := store.NewRepository(db)
+	hashes, _ := seedCanonicalChain(t, db, repo)
+	require.NoError(t, db.Put([]byte("unrelated"), []byte("x")))
+
+	// The memory provider has no native checkpoint, so a key dump is written.
+	dir := filepath.Join(t.TempDir(), "checkpoint")
+	require.NoError(t, store.Checkpoint(db, dir))
+
+	data, err := os.ReadFile(filepath.Join(dir, store.CheckpointDumpFile))
+	require.NoError(t, err)
+
+	restored := memory.NewDatabase()
+	_, err = database.LoadDump(bytes.NewReader(data), restored)
+	require.NoError(t, err)
+
+	head, err := store.NewRepository(restored).VerifyHead(restored)
+	require.NoError(t, err)
+	require.Equal(t, hashes[2], head.HeaderHash)
+
+	has, err := restored.Has([]byte("unrelated"))
+	require.NoError(t, err)
+	require.False(t, has, "keys outside the schema prefixes are not dumped")
+
+	// A damaged dump is rejected before anything is written.
+	data[len(data)/2] ^= 0xff
+	target := memory.NewDatabase()
+	_, err = database.LoadDump(bytes.NewReader(data), target)
+	require.ErrorIs(t, err, database.ErrCorruptDump)
+	iter, err := target.NewIterator(nil, nil)
+	require.NoError(t, err)
+	defer iter.Close()
+	require.False(t, iter.Next())
+}
+
+func TestCheckpointPebble(t *testing.T) {
+	db, err := pebbledb.NewDatabase(filepath.Join(t.TempDir(), "db"), false)
+	require.NoError(t, err)
+	defer db.Close()
+	repo := store.NewRepository(db)
+	hashes, _ := seedCanonicalChain(t, db, repo)
+
+	dir := filepath.Join(t.TempDir(), "checkpoint")
+	require.NoError(t, store.Checkpoint(db, dir))
+
+	// Writes after the checkpoint do not reach it.
+	require.NoError(t, repo.SaveCanonicalHash(db, hashes[0], 3))
+
+	checkpoint, err := pebbledb.NewDatabase(dir, true)
+	require.NoError(t, err)
+	defer checkpoint.Close()
+
+	head, err := store.NewRepository(checkpoint).VerifyHead(checkpoint)
+	require.NoError(t, err)
+	require.Equal(t, types.TimeSlot(2), head.Slot)
+	require.Equal(t, hashes[2], head.HeaderHash)
+}
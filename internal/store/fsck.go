@@ -0,0 +1,323 @@
+package store
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/New-JAMneration/JAM-Protocol/internal/database"
+	"github.com/New-JAMneration/JAM-Protocol/internal/types"
+	"github.com/New-JAMneration/JAM-Protocol/internal/utilities/hash"
+	m "github.com/New-JAMneration/JAM-Protocol/internal/utilities/merklization"
+)
+
+// FsckIssueKind classifies a problem found by Repository.Fsck.
+type FsckIssueKind string
+
+const (
+	// FsckCorruptState: an sd: entry cannot be read or does not merklize to its key.
+	FsckCorruptState FsckIssueKind = "corrupt_state"
+	// FsckMissingState: an sr: entry points at a state root without sd: data.
+	FsckMissingState FsckIssueKind = "missing_state"
+	// FsckMissingBlock: a header hash is referenced (sr:, ht:, hh: or as a parent) but the block is not stored.
+	FsckMissingBlock FsckIssueKind = "missing_block"
+	// FsckBrokenLink: Header.ParentStateRoot differs from the state root recorded for the parent.
+	FsckBrokenLink FsckIssueKind = "broken_link"
+	// FsckReplayMismatch: re-running the STF from the parent state yields a different root.
+	FsckReplayMismatch FsckIssueKind = "replay_mismatch"
+	// FsckOrphan: an entry nothing else refers to. These are the only issues Repair deletes.
+	FsckOrphan FsckIssueKind = "orphan"
+)
+
+// FsckIssue describes a single inconsistency.
+type FsckIssue struct {
+	Kind       FsckIssueKind
+	Key        []byte
+	HeaderHash types.HeaderHash
+	Detail     string
+}
+
+func (i FsckIssue) String() string {
+	return fmt.Sprintf("[%s] key=0x%x header=0x%x: %s", i.Kind, i.Key, i.HeaderHash[:8], i.Detail)
+}
+
+// FsckReport is the result of Repository.Fsck.
+type FsckReport struct {
+	BlocksChecked int
+	StatesChecked int
+	Replayed      int
+	Repaired      int
+	Issues        []FsckIssue
+}
+
+// OK reports whether the check found no issues.
+func (r *FsckReport) OK() bool {
+	return len(r.Issues) == 0
+}
+
+func (r *FsckReport) add(kind FsckIssueKind, key []byte, headerHash types.HeaderHash, format string, args ...any) {
+	r.Issues = append(r.Issues, FsckIssue{
+		Kind:       kind,
+		Key:        bytes.Clone(key),
+		HeaderHash: headerHash,
+		Detail:     fmt.Sprintf(format, args...),
+	})
+}
+
+// ReplayFunc re-runs the STF for block on top of parentState and returns the posterior state root.
+type ReplayFunc func(parentState types.StateKeyVals, block *types.Block) (types.StateRoot, error)
+
+// FsckOptions configures Repository.Fsck.
+type FsckOptions struct {
+	// Repair deletes orphaned entries after the check. Nothing else is modified.
+	Repair bool
+
+	// Replay, when set, is called for every block whose parent state is stored,
+	// and the result is compared against the block's recorded state root.
+	Replay ReplayFunc
+}
+
+// Fsck verifies that every stored state matches its state root and that the
+// header/state-root chain is linked consistently.
+//
+// A state (sd:) counts as referenced when an sr: entry points at it or when a
+// stored header commits to it through ParentStateRoot, so the states of
+// blocks whose sr: mapping was never persisted are not treated as orphans.
+func (repo *Repository) Fsck(db database.Database, opts FsckOptions) (*FsckReport, error) {
+	report := &FsckReport{}
+
+	blocks, err := repo.collectBlocks(db, report)
+	if err != nil {
+		return nil, err
+	}
+
+	stateRoots := make(map[types.HeaderHash]types.StateRoot)
+	if err := forEach(db, stateRootPrefix, func(key, value []byte) {
+		var headerHash types.HeaderHash
+		if len(key) != len(stateRootPrefix)+len(headerHash) || len(value) != len(types.StateRoot{}) {
+			report.add(FsckCorruptState, key, headerHash, "malformed state root entry (value %d bytes)", len(value))
+			return
+		}
+		copy(headerHash[:], key[len(stateRootPrefix):])
+		stateRoots[headerHash] = types.StateRoot(value)
+	}); err != nil {
+		return nil, err
+	}
+
+	// Verify every stored state against the root it is keyed by.
+	storedStates := make(map[types.StateRoot]bool)
+	if err := forEach(db, stateDataPrefix, func(key, value []byte) {
+		if len(key) != len(stateDataPrefix)+len(types.StateRoot{}) {
+			report.add(FsckCorruptState, key, types.HeaderHash{}, "malformed state data key")
+			return
+		}
+		root := types.StateRoot(key[len(stateDataPrefix):])
+		storedStates[root] = true
+		report.StatesChecked++
+
+		stateKeyVals, err := repo.GetStateData(db, root)
+		if err != nil {
+			report.add(FsckCorruptState, key, types.HeaderHash{}, "%v", err)
+			return
+		}
+		if got := m.MerklizationSerializedState(stateKeyVals); got != root {
+			report.add(FsckCorruptState, key, types.HeaderHash{}, "state merklizes to 0x%x", got)
+		}
+	}); err != nil {
+		return nil, err
+	}
+
+	referenced := make(map[types.StateRoot]bool, len(stateRoots))
+	for headerHash, root := range stateRoots {
+		referenced[root] = true
+		key := stateRootKey(headerHash)
+		if !storedStates[root] {
+			report.add(FsckMissingState, key, headerHash, "state data for root 0x%x not found", root)
+		}
+		if _, ok := blocks[headerHash]; !ok {
+			report.add(FsckOrphan, key, headerHash, "state root recorded for unknown block")
+		}
+	}
+
+	// Walk the chain: parent presence, ParentStateRoot linkage and optional replay.
+	rootSlot, hasRoot := lowestSlot(blocks)
+	for headerHash, block := range blocks {
+		report.BlocksChecked++
+		header := &block.Header
+		referenced[header.ParentStateRoot] = true
+
+		parent, parentFound := blocks[header.Parent]
+		if !parentFound {
+			if hasRoot && header.Slot != rootSlot {
+				report.add(FsckMissingBlock, nil, headerHash, "parent 0x%x not found", header.Parent[:8])
+			}
+			continue
+		}
+
+		parentRoot, ok := stateRoots[header.Parent]
+		if !ok {
+			continue
+		}
+		if header.ParentStateRoot != parentRoot {
+			report.add(FsckBrokenLink, nil, headerHash,
+				"ParentStateRoot 0x%x, parent (slot %d) has root 0x%x", header.ParentStateRoot, parent.Header.Slot, parentRoot)
+		}
+
+		if opts.Replay == nil || !storedStates[parentRoot] {
+			continue
+		}
+		wantRoot, ok := stateRoots[headerHash]
+		if !ok {
+			continue
+		}
+		parentState, err := repo.GetStateData(db, parentRoot)
+		if err != nil {
+			// Already reported as a corrupt state above.
+			continue
+		}
+		report.Replayed++
+		gotRoot, err := opts.Replay(parentState, block)
+		if err != nil {
+			report.add(FsckReplayMismatch, nil, headerHash, "replay failed: %v", err)
+		} else if gotRoot != wantRoot {
+			report.add(FsckReplayMismatch, nil, headerHash, "replay produced 0x%x, recorded 0x%x", gotRoot, wantRoot)
+		}
+	}
+
+	var orphans [][]byte
+	for root := range storedStates {
+		if !referenced[root] {
+			key := stateDataKey(root)
+			report.add(FsckOrphan, key, types.HeaderHash{}, "state data not referenced by any block")
+		}
+	}
+	for _, issue := range report.Issues {
+		if issue.Kind == FsckOrphan && issue.Key != nil {
+			orphans = append(orphans, issue.Key)
+		}
+	}
+
+	if opts.Repair && len(orphans) > 0 {
+		// Dropping a state also frees the trie nodes only it used.
+		err := repo.WithBatch(func(batch database.Batch) error {
+			for _, key := range orphans {
+				var err error
+				if bytes.HasPrefix(key, stateDataPrefix) {
+					err = repo.DeleteStateData(batch, types.StateRoot(key[len(stateDataPrefix):]))
+				} else {
+					err = batch.Delete(key)
+				}
+				if err != nil {
+					return fmt.Errorf("failed to drop orphaned entry 0x%x: %w", key, err)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return report, fmt.Errorf("failed to drop orphaned entries: %w", err)
+		}
+		report.Repaired = len(orphans)
+	}
+
+	return report, nil
+}
+
+// collectBlocks gathers every block known to the store, from both the
+// slot-indexed header layout (h:/e:) and the hash-indexed block layout (b:).
+// Dangling ht: and hh: index entries are recorded on the report.
+func (repo *Repository) collectBlocks(db database.Database, report *FsckReport) (map[types.HeaderHash]*types.Block, error) {
+	blocks := make(map[types.HeaderHash]*types.Block)
+
+	if err := forEach(db, blockByHashPrefix, func(key, value []byte) {
+		block := &types.Block{}
+		if err := repo.decoder.Decode(value, block); err != nil {
+			report.add(FsckCorruptState, key, types.HeaderHash{}, "failed to decode block: %v", err)
+			return
+		}
+		headerHash, err := hash.ComputeBlockHeaderHash(block.Header)
+		if err != nil {
+			report.add(FsckCorruptState, key, types.HeaderHash{}, "failed to hash header: %v", err)
+			return
+		}
+		if !bytes.Equal(key[len(blockByHashPrefix):], headerHash[:]) {
+			report.add(FsckCorruptState, key, headerHash, "block stored under a different hash")
+		}
+		blocks[headerHash] = block
+	}); err != nil {
+		return nil, err
+	}
+
+	if err := forEach(db, headerPrefix, func(key, value []byte) {
+		headerHash := types.HeaderHash(hash.Blake2bHash(value))
+		if _, ok := blocks[headerHash]; ok {
+			return
+		}
+		header := types.Header{}
+		if err := repo.decoder.Decode(value, &header); err != nil {
+			report.add(FsckCorruptState, key, headerHash, "failed to decode header: %v", err)
+			return
+		}
+		block := &types.Block{Header: header}
+		if extrinsic, err := repo.GetExtrinsic(db, headerHash, header.Slot); err == nil {
+			block.Extrinsic = *extrinsic
+		}
+		blocks[headerHash] = block
+	}); err != nil {
+		return nil, err
+	}
+
+	if err := forEach(db, headerTimeSlotPrefix, func(key, _ []byte) {
+		if len(key) != len(headerTimeSlotPrefix)+len(types.HeaderHash{}) {
+			report.add(FsckCorruptState, key, types.HeaderHash{}, "malformed time slot index key")
+			return
+		}
+		headerHash := types.HeaderHash(key[len(headerTimeSlotPrefix):])
+		if _, ok := blocks[headerHash]; !ok {
+			report.add(FsckOrphan, key, headerHash, "time slot index for unknown block")
+		}
+	}); err != nil {
+		return nil, err
+	}
+
+	if err := forEach(db, headerHashPrefix, func(key, value []byte) {
+		if len(value) != len(types.HeaderHash{}) {
+			report.add(FsckCorruptState, key, types.HeaderHash{}, "malformed canonical hash entry")
+			return
+		}
+		headerHash := types.HeaderHash(value)
+		if _, ok := blocks[headerHash]; !ok {
+			report.add(FsckMissingBlock, key, headerHash, "canonical hash points at unknown block")
+		}
+	}); err != nil {
+		return nil, err
+	}
+
+	return blocks, nil
+}
+
+// forEach copies out every key/value under prefix and hands it to fn.
+func forEach(db database.Iterable, prefix []byte, fn func(key, value []byte)) error {
+	iter, err := db.NewIterator(prefix, nil)
+	if err != nil {
+		return err
+	}
+	defer iter.Close()
+
+	for iter.Next() {
+		fn(bytes.Clone(iter.Key()), bytes.Clone(iter.Value()))
+	}
+	return iter.Error()
+}
+
+func lowestSlot(blocks map[types.HeaderHash]*types.Block) (types.TimeSlot, bool) {
+	var (
+		lowest types.TimeSlot
+		found  bool
+	)
+	for _, block := range blocks {
+		if !found || block.Header.Slot < lowest {
+			lowest = block.Header.Slot
+			found = true
+		}
+	}
+	return lowest, found
+}
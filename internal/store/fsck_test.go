@@ -0,0 +1,176 @@
+package store_test
+
+import (
+	"testing"
+
+	"github.com/New-JAMneration/JAM-Protocol/internal/database"
+	"github.com/New-JAMneration/JAM-Protocol/internal/database/provider/memory"
+	"github.com/New-JAMneration/JAM-Protocol/internal/database/test/fixtures"
+	"github.com/New-JAMneration/JAM-Protocol/internal/store"
+	"github.com/New-JAMneration/JAM-Protocol/internal/types"
+	"github.com/New-JAMneration/JAM-Protocol/internal/utilities/hash"
+	m "github.com/New-JAMneration/JAM-Protocol/internal/utilities/merklization"
+	"github.com/stretchr/testify/require"
+)
+
+// seedFsckChain stores a three block chain with a state per block and
+// returns the header hashes in order.
+func seedFsckChain(t *testing.T, db database.Database, repo *store.Repository) ([]types.HeaderHash, []types.StateRoot) {
+	t.Helper()
+
+	var (
+		hashes []types.HeaderHash
+		roots  []types.StateRoot
+		parent types.HeaderHash
+		prev   types.StateRoot
+	)
+	for slot := types.TimeSlot(0); slot < 3; slot++ {
+		header := types.Header{Slot: slot, Parent: parent, ParentStateRoot: prev}
+		headerHash, err := hash.ComputeBlockHeaderHash(header)
+		require.NoError(t, err)
+
+		state := types.StateKeyVals{{Key: types.StateKey{1}, Value: []byte{byte(slot)}}}
+		root := m.MerklizationSerializedState(state)
+
+		block := &types.Block{Header: header}
+		require.NoError(t, repo.SaveBlockByHash(db, types.OpaqueHash(headerHash), block))
+		require.NoError(t, repo.SaveHeaderTimeSlot(db, headerHash, slot))
+		require.NoError(t, repo.SaveStateRootByHeaderHash(db, headerHash, root))
+		require.NoError(t, repo.SaveStateData(db, root, state))
+
+		hashes = append(hashes, headerHash)
+		roots = append(roots, root)
+		parent, prev = headerHash, root
+	}
+	return hashes, roots
+}
+
+func TestFsckHealthyChain(t *testing.T) {
+	db := memory.NewDatabase()
+	repo := store.NewRepository(db)
+	seedFsckChain(t, db, repo)
+
+	report, err := repo.Fsck(db, store.FsckOptions{})
+	require.NoError(t, err)
+	require.True(t, report.OK(), "unexpected issues: %v", report.Issues)
+	require.Equal(t, 3, report.BlocksChecked)
+	require.Equal(t, 3, report.StatesChecked)
+}
+
+func TestFsckDetectsCorruptAndMissingState(t *testing.T) {
+	db := memory.NewDatabase()
+	repo := store.NewRepository(db)
+	_, roots := seedFsckChain(t, db, repo)
+
+	// Overwrite the second state with data that does not match its root,
+	// and drop the third one entirely.
+	require.NoError(t, repo.SaveStateData(db, roots[1], types.StateKeyVals{{Key: types.StateKey{2}, Value: []byte("x")}}))
+	require.NoError(t, repo.DeleteStateData(db, roots[2]))
+
+	report, err := repo.Fsck(db, store.FsckOptions{})
+	require.NoError(t, err)
+
+	kinds := map[store.FsckIssueKind]int{}
+	for _, issue := range report.Issues {
+		kinds[issue.Kind]++
+	}
+	require.Equal(t, 1, kinds[store.FsckCorruptState])
+	require.Equal(t, 1, kinds[store.FsckMissingState])
+}
+
+func TestFsckDetectsBrokenLink(t *testing.T) {
+	db := memory.NewDatabase()
+	repo := store.NewRepository(db)
+	hashes, _ := seedFsckChain(t, db, repo)
+
+	require.NoError(t, repo.SaveStateRootByHeaderHash(db, hashes[0], types.StateRoot{0xff}))
+
+	report, err := repo.Fsck(db, store.FsckOptions{})
+	require.NoError(t, err)
+
+	var broken []store.FsckIssue
+	for _, issue := range report.Issues {
+		if issue.Kind == store.FsckBrokenLink {
+			broken = append(broken, issue)
+		}
+	}
+	require.Len(t, broken, 1)
+	require.Equal(t, hashes[1], broken[0].HeaderHash)
+}
+
+func TestFsckReplay(t *testing.T) {
+	db := memory.NewDatabase()
+	repo := store.NewRepository(db)
+	_, roots := seedFsckChain(t, db, repo)
+
+	calls := 0
+	report, err := repo.Fsck(db, store.FsckOptions{
+		Replay: func(_ types.StateKeyVals, block *types.Block) (types.StateRoot, error) {
+			calls++
+			if block.Header.Slot == 2 {
+				return types.StateRoot{}, nil
+			}
+			return roots[block.Header.Slot], nil
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, 2, calls)
+	require.Equal(t, 2, report.Replayed)
+	require.Len(t, report.Issues, 1)
+	require.Equal(t, store.FsckReplayMismatch, report.Issues[0].Kind)
+}
+
+func TestFsckRepairDropsOrphans(t *testing.T) {
+	db := memory.NewDatabase()
+	repo := store.NewRepository(db)
+	seedFsckChain(t, db, repo)
+
+	orphanState := types.StateKeyVals{{Key: types.StateKey{9}, Value: []byte("orphan")}}
+	orphanRoot := m.MerklizationSerializedState(orphanState)
+	require.NoError(t, repo.SaveStateData(db, orphanRoot, orphanState))
+	require.NoError(t, repo.SaveHeaderTimeSlot(db, types.HeaderHash{0xaa}, 7))
+
+	report, err := repo.Fsck(db, store.FsckOptions{Repair: true})
+	require.NoError(t, err)
+	require.Len(t, report.Issues, 2)
+	require.Equal(t, 2, report.Repaired)
+
+	_, err = repo.GetStateData(db, orphanRoot)
+	require.Error(t, err)
+	_, err = repo.GetHeaderTimeSlot(db, types.HeaderHash{0xaa})
+	require.Error(t, err)
+
+	report, err = repo.Fsck(db, store.FsckOptions{})
+	require.NoError(t, err)
+	require.True(t, report.OK(), "unexpected issues: %v", report.Issues)
+}
+
+// TestChainFixture checks the checked-in "chain-3" fixture: the chain
+// seedFsckChain builds, with canonical and finalized hashes. Regenerate it
+// with -update.
+func TestChainFixture(t *testing.T) {
+	if *fixtures.Update {
+		built := memory.NewDatabase()
+		repo := store.NewRepository(built)
+		_, err := repo.MigrateSchema(built)
+		require.NoError(t, err)
+		hashes, _ := seedFsckChain(t, built, repo)
+		for slot, headerHash := range hashes {
+			require.NoError(t, repo.SaveCanonicalHash(built, headerHash, types.TimeSlot(slot)))
+		}
+		require.NoError(t, repo.SaveFinalizedHash(built, hashes[1]))
+		fixtures.Save(t, "chain-3", built)
+	}
+
+	db := fixtures.Load(t, "chain-3")
+	repo := store.NewRepository(db)
+
+	head, err := repo.VerifyHead(db)
+	require.NoError(t, err)
+	require.Equal(t, types.TimeSlot(2), head.Slot)
+
+	report, err := repo.Fsck(db, store.FsckOptions{})
+	require.NoError(t, err)
+	require.True(t, report.OK(), "unexpected issues: %v", report.Issues)
+	require.Equal(t, 3, report.BlocksChecked)
+}
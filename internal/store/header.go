@@ -23,6 +23,36 @@ func (repo *Repository) SaveCanonicalHash(w database.Writer, hash types.HeaderHa
 	return w.Put(canocicalHeaderHashKey(repo.encoder, slot), hash[:])
 }
 
+// DeleteCanonicalHashes deletes the canonical hashes of the slots after
+// slot up to and including to.
+func (repo *Repository) DeleteCanonicalHashes(w database.Writer, slot, to types.TimeSlot) error {
+	for s := slot + 1; s > slot && s <= to; s++ {
+		if err := w.Delete(canocicalHeaderHashKey(repo.encoder, s)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetAncestorHash returns the header hash recorded for slot by
+// SaveAncestorHash.
+func (repo *Repository) GetAncestorHash(r database.Reader, slot types.TimeSlot) (types.HeaderHash, error) {
+	data, found, err := r.Get(ancestorHashKey(repo.encoder, slot))
+	if err != nil {
+		return types.HeaderHash{}, err
+	}
+	if !found {
+		return types.HeaderHash{}, fmt.Errorf("ancestor hash not found for slot %d", slot)
+	}
+	return types.HeaderHash(data), nil
+}
+
+// SaveAncestorHash records hash as the canonical block of slot for a block
+// that is not stored, such as the ancestry of a seeded snapshot.
+func (repo *Repository) SaveAncestorHash(w database.Writer, hash types.HeaderHash, slot types.TimeSlot) error {
+	return w.Put(ancestorHashKey(repo.encoder, slot), hash[:])
+}
+
 func (repo *Repository) GetFinalizedHash(r database.Reader) (types.HeaderHash, error) {
 	data, _, err := r.Get(finalizedHeaderHashPrefix)
 	if err != nil {
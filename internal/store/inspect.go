@@ -0,0 +1,213 @@
+package store
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/New-JAMneration/JAM-Protocol/internal/database"
+	"github.com/New-JAMneration/JAM-Protocol/internal/types"
+)
+
+// Key inspection for operators (`node db inspect`): every key written by the
+// node is matched against the schema in prefix.go and split into its slot and
+// hash components.
+
+// KeyInfo describes a key in terms of the key schema.
+type KeyInfo struct {
+	// Prefix is the schema prefix the key starts with, or empty if unknown.
+	// CE keys report their sub-namespace, e.g. "ce/j/".
+	Prefix string
+	Kind   string
+	Slot   *types.TimeSlot
+	Hash   *types.OpaqueHash
+	// Rest holds the bytes after the prefix that are not slot or hash.
+	Rest []byte
+}
+
+// String formats the key as its prefix followed by the remaining bytes in hex.
+func (k KeyInfo) String() string {
+	return k.Prefix + "0x" + hex.EncodeToString(k.keyRest())
+}
+
+func (k KeyInfo) keyRest() []byte {
+	var rest []byte
+	if k.Slot != nil {
+		rest = binary.LittleEndian.AppendUint32(rest, uint32(*k.Slot))
+	}
+	if k.Slot != nil && k.Hash != nil {
+		rest = append(rest, separator...)
+	}
+	if k.Hash != nil {
+		rest = append(rest, k.Hash[:]...)
+	}
+	return append(rest, k.Rest...)
+}
+
+type keyKind struct {
+	prefix []byte
+	kind   string
+	// layout of the bytes after the prefix
+	slot, hash bool
+	decode     func(repo *Repository, value []byte) (any, error)
+}
+
+func decodeAs[T any](repo *Repository, value []byte) (any, error) {
+	var v T
+	if err := repo.decoder.Decode(value, &v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+func rawHash(_ *Repository, value []byte) (any, error) {
+	if len(value) != len(types.OpaqueHash{}) {
+		return nil, fmt.Errorf("want a 32-byte hash, got %d bytes", len(value))
+	}
+	return types.OpaqueHash(value), nil
+}
+
+var keyKinds = []keyKind{
+	{prefix: headerPrefix, kind: "header", slot: true, hash: true, decode: decodeAs[types.Header]},
+	{prefix: headerHashPrefix, kind: "canonical_hash", slot: true, decode: rawHash},
+	{prefix: headerTimeSlotPrefix, kind: "header_slot", hash: true, decode: decodeAs[types.TimeSlot]},
+	{prefix: finalizedHeaderHashPrefix, kind: "finalized_hash", decode: rawHash},
+	{prefix: extrinsicPrefix, kind: "extrinsic", slot: true, hash: true, decode: decodeAs[types.Extrinsic]},
+	{prefix: blockByHashPrefix, kind: "block", hash: true, decode: decodeAs[types.Block]},
+	{prefix: hashSegmentMapPrefix, kind: "hash_segment_map", decode: func(_ *Repository, value []byte) (any, error) {
+		var v map[string]string
+		err := json.Unmarshal(value, &v)
+		return v, err
+	}},
+	{prefix: segmentErasurePrefix, kind: "segment_erasure", hash: true, decode: func(_ *Repository, value []byte) (any, error) {
+		if len(value) < 40 {
+			return nil, fmt.Errorf("want at least 40 bytes, got %d", len(value))
+		}
+		return segmentErasureValue{
+			ErasureRoot: types.OpaqueHash(value[:32]),
+			ExpiresAt:   int64(binary.BigEndian.Uint64(value[32:40])),
+		}, nil
+	}},
+	{prefix: stateRootPrefix, kind: "state_root", hash: true, decode: rawHash},
+	{prefix: stateDataPrefix, kind: "state_data", hash: true, decode: rawHash},
+	{prefix: stateNodePrefix, kind: "state_node", hash: true},
+	{prefix: stateValuePrefix, kind: "state_value", hash: true},
+	{prefix: stateNodeRefsPrefix, kind: "state_node_refs", hash: true, decode: decodeAs[types.U32]},
+	{prefix: stateValueRefsPrefix, kind: "state_value_refs", hash: true, decode: decodeAs[types.U32]},
+	{prefix: ceNamespacePrefix, kind: "ce"},
+	{prefix: schemaVersionKey, kind: "schema_version", decode: decodeAs[types.U32]},
+}
+
+func matchKeyKind(key []byte) (keyKind, bool) {
+	for _, kind := range keyKinds {
+		if bytes.HasPrefix(key, kind.prefix) {
+			return kind, true
+		}
+	}
+	return keyKind{}, false
+}
+
+// DescribeKey splits key into its schema components. Keys whose layout does
+// not match their prefix keep everything after the prefix in Rest.
+func DescribeKey(key []byte) KeyInfo {
+	kind, ok := matchKeyKind(key)
+	if !ok {
+		return KeyInfo{Kind: "unknown", Rest: bytes.Clone(key)}
+	}
+
+	info := KeyInfo{Prefix: string(kind.prefix), Kind: kind.kind}
+	rest := key[len(kind.prefix):]
+
+	if bytes.Equal(kind.prefix, ceNamespacePrefix) {
+		// ce/<namespace>/...
+		if i := bytes.IndexByte(rest, '/'); i >= 0 {
+			info.Prefix += string(rest[:i+1])
+			info.Kind = "ce/" + string(rest[:i])
+			rest = rest[i+1:]
+		}
+		info.Rest = bytes.Clone(rest)
+		return info
+	}
+
+	want := 0
+	if kind.slot {
+		want += 4
+	}
+	if kind.slot && kind.hash {
+		want += len(separator)
+	}
+	if kind.hash {
+		want += len(types.OpaqueHash{})
+	}
+	if len(rest) != want {
+		info.Rest = bytes.Clone(rest)
+		return info
+	}
+
+	if kind.slot {
+		slot := types.TimeSlot(binary.LittleEndian.Uint32(rest))
+		info.Slot = &slot
+		rest = rest[4:]
+		if kind.hash {
+			rest = rest[len(separator):]
+		}
+	}
+	if kind.hash {
+		hash := types.OpaqueHash(rest)
+		info.Hash = &hash
+	}
+	return info
+}
+
+// DecodeValue decodes the value stored under key with the codec type the
+// schema uses for it. Values without a known type are returned as bytes.
+func (repo *Repository) DecodeValue(key, value []byte) (any, error) {
+	kind, ok := matchKeyKind(key)
+	if !ok || kind.decode == nil {
+		return types.ByteSequence(value), nil
+	}
+	decoded, err := kind.decode(repo, value)
+	if err != nil {
+		return nil, fmt.Errorf("decode %s value: %w", kind.kind, err)
+	}
+	return decoded, nil
+}
+
+// PrefixStats counts the keys under one schema prefix.
+type PrefixStats struct {
+	Prefix     string
+	Kind       string
+	Keys       int
+	KeyBytes   int64
+	ValueBytes int64
+}
+
+// Stats scans the whole database and groups the keys by schema prefix, in
+// prefix order. Keys outside the schema are counted under kind "unknown".
+func Stats(db database.Iterable) ([]PrefixStats, error) {
+	byPrefix := make(map[string]*PrefixStats)
+	err := forEach(db, nil, func(key, value []byte) {
+		info := DescribeKey(key)
+		stats, ok := byPrefix[info.Prefix]
+		if !ok {
+			stats = &PrefixStats{Prefix: info.Prefix, Kind: info.Kind}
+			byPrefix[info.Prefix] = stats
+		}
+		stats.Keys++
+		stats.KeyBytes += int64(len(key))
+		stats.ValueBytes += int64(len(value))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]PrefixStats, 0, len(byPrefix))
+	for _, stats := range byPrefix {
+		result = append(result, *stats)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Prefix < result[j].Prefix })
+	return result, nil
+}
@@ -0,0 +1,85 @@
+package store_test
+
+import (
+	"testing"
+
+	"github.com/New-JAMneration/JAM-Protocol/internal/database"
+	"github.com/New-JAMneration/JAM-Protocol/internal/database/provider/memory"
+	"github.com/New-JAMneration/JAM-Protocol/internal/store"
+	"github.com/New-JAMneration/JAM-Protocol/internal/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDescribeKey(t *testing.T) {
+	headerHash := types.OpaqueHash{0xab, 0xcd}
+
+	key := append([]byte("h:"), 5, 0, 0, 0, ':')
+	key = append(key, headerHash[:]...)
+	info := store.DescribeKey(key)
+	require.Equal(t, "h:", info.Prefix)
+	require.Equal(t, "header", info.Kind)
+	require.Equal(t, types.TimeSlot(5), *info.Slot)
+	require.Equal(t, headerHash, *info.Hash)
+	require.Empty(t, info.Rest)
+
+	info = store.DescribeKey(append([]byte("hh:"), 1, 2, 0, 0))
+	require.Equal(t, "canonical_hash", info.Kind)
+	require.Equal(t, types.TimeSlot(0x201), *info.Slot)
+	require.Nil(t, info.Hash)
+	require.Equal(t, "hh:0x01020000", info.String())
+
+	info = store.DescribeKey([]byte("ce/j/abcd:3"))
+	require.Equal(t, "ce/j/", info.Prefix)
+	require.Equal(t, "ce/j", info.Kind)
+	require.Equal(t, []byte("abcd:3"), info.Rest)
+
+	// A key with the wrong layout keeps its bytes in Rest.
+	info = store.DescribeKey([]byte("sr:short"))
+	require.Equal(t, "state_root", info.Kind)
+	require.Nil(t, info.Hash)
+	require.Equal(t, []byte("short"), info.Rest)
+
+	info = store.DescribeKey([]byte("other"))
+	require.Equal(t, "", info.Prefix)
+	require.Equal(t, "unknown", info.Kind)
+	require.Equal(t, "0x6f74686572", info.String())
+}
+
+func TestDecodeValueAndStats(t *testing.T) {
+	db := memory.NewDatabase()
+	repo := store.NewRepository(db)
+	hashes, roots := seedFsckChain(t, db, repo)
+	require.NoError(t, db.Put([]byte("ce/a/x"), []byte{1, 2}))
+
+	slot, err := repo.DecodeValue(append([]byte("ht:"), hashes[2][:]...), mustGet(t, db, append([]byte("ht:"), hashes[2][:]...)))
+	require.NoError(t, err)
+	require.Equal(t, types.TimeSlot(2), slot)
+
+	stateKey := append([]byte("sd:"), roots[1][:]...)
+	trieRoot, err := repo.DecodeValue(stateKey, mustGet(t, db, stateKey))
+	require.NoError(t, err)
+	require.Equal(t, types.OpaqueHash(roots[1]), trieRoot)
+
+	raw, err := repo.DecodeValue([]byte("ce/a/x"), []byte{1, 2})
+	require.NoError(t, err)
+	require.Equal(t, types.ByteSequence{1, 2}, raw)
+
+	_, err = repo.DecodeValue(append([]byte("sr:"), hashes[0][:]...), []byte{1})
+	require.Error(t, err)
+
+	stats, err := store.Stats(db)
+	require.NoError(t, err)
+	counts := make(map[string]int)
+	for _, s := range stats {
+		counts[s.Prefix] = s.Keys
+	}
+	require.Equal(t, map[string]int{"b:": 3, "ht:": 3, "sr:": 3, "sd:": 3, "sn:": 3, "snr:": 3, "ce/a/": 1}, counts)
+}
+
+func mustGet(t *testing.T, db database.Reader, key []byte) []byte {
+	t.Helper()
+	value, found, err := db.Get(key)
+	require.NoError(t, err)
+	require.True(t, found)
+	return value
+}
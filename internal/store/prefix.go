@@ -11,6 +11,9 @@ var (
 	headerHashPrefix          = []byte("hh:")
 	headerTimeSlotPrefix      = []byte("ht:")
 	finalizedHeaderHashPrefix = []byte("fh:")
+	// ancestorHashPrefix maps slots to the header hashes of a seeded
+	// snapshot's ancestry. Unlike hh: entries, these blocks are not stored.
+	ancestorHashPrefix = []byte("an:")
 
 	extrinsicPrefix = []byte("e:")
 
@@ -20,10 +23,34 @@ var (
 
 	segmentErasurePrefix = []byte("segment_erasure:")
 
-	stateRootPrefix = []byte("sr:")
-	stateDataPrefix = []byte("sd:")
+	stateRootPrefix  = []byte("sr:")
+	stateDataPrefix  = []byte("sd:")
+	stateNodePrefix  = []byte("sn:")
+	stateValuePrefix = []byte("sv:")
+	// stateNodeRefsPrefix and stateValueRefsPrefix count the references to
+	// the entries under sn: and sv: (see state_refs.go).
+	stateNodeRefsPrefix  = []byte("snr:")
+	stateValueRefsPrefix = []byte("svr:")
+
+	// ceNamespacePrefix holds the CE protocol data written by
+	// handler/ce/storage.go (ce/j/, ce/a/, ce/p/, ...).
+	ceNamespacePrefix = []byte("ce/")
+
+	// schemaVersionKey records the key layout of the data dir (see schema.go).
+	schemaVersionKey = []byte("schema_version")
 )
 
+// SchemaPrefixes returns the key prefixes written by the node: the store
+// schema and the CE namespace.
+func SchemaPrefixes() [][]byte {
+	return [][]byte{
+		headerPrefix, headerHashPrefix, headerTimeSlotPrefix, finalizedHeaderHashPrefix, ancestorHashPrefix,
+		extrinsicPrefix, blockByHashPrefix, hashSegmentMapPrefix, segmentErasurePrefix,
+		stateRootPrefix, stateDataPrefix, stateNodePrefix, stateValuePrefix,
+		stateNodeRefsPrefix, stateValueRefsPrefix, ceNamespacePrefix, schemaVersionKey,
+	}
+}
+
 func headerKeyPrefix(encoder *types.Encoder, slot types.TimeSlot) []byte {
 	timeSlotEncoded, _ := encoder.Encode(&slot)
 	return append(append(headerPrefix, timeSlotEncoded...), separator...)
@@ -38,6 +65,11 @@ func canocicalHeaderHashKey(encoder *types.Encoder, slot types.TimeSlot) []byte
 	return append(headerHashPrefix, encoded...)
 }
 
+func ancestorHashKey(encoder *types.Encoder, slot types.TimeSlot) []byte {
+	encoded, _ := encoder.Encode(&slot)
+	return append(ancestorHashPrefix, encoded...)
+}
+
 func headerTimeSlotKey(hash types.HeaderHash) []byte {
 	return append(headerTimeSlotPrefix, hash[:]...)
 }
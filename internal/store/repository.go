@@ -1,6 +1,8 @@
 package store
 
 import (
+	"sync"
+
 	"github.com/New-JAMneration/JAM-Protocol/internal/database"
 	"github.com/New-JAMneration/JAM-Protocol/internal/types"
 )
@@ -9,6 +11,8 @@ type Repository struct {
 	db      database.Database
 	encoder *types.Encoder
 	decoder *types.Decoder
+	// stateMu serializes state writes (see state_refs.go).
+	stateMu sync.Mutex
 }
 
 func NewRepository(db database.Database) *Repository {
@@ -25,15 +29,17 @@ func (repo *Repository) Database() database.Database {
 
 // NewBatch creates a new batch for batched writes.
 // User of this method is responsible for committing and closing the batch.
+// State data cannot be written to it; use WithBatch or WithSyncBatch.
 func (repo *Repository) NewBatch() database.Batch {
 	return repo.db.NewBatch()
 }
 
 // WithBatch executes the given function within a batch.
 // It creates a new batch, passes it to the function, and commits the batch if the function returns no error.
-// The batch is closed after the function execution.
+// The batch is closed after the function execution. State data written to it
+// is committed under the repository's state lock.
 func (repo *Repository) WithBatch(fn func(batch database.Batch) error) error {
-	batch := repo.db.NewBatch()
+	batch := repo.newStateBatch()
 	defer batch.Close()
 
 	if err := fn(batch); err != nil {
@@ -41,3 +47,16 @@ func (repo *Repository) WithBatch(fn func(batch database.Batch) error) error {
 	}
 	return batch.Commit()
 }
+
+// WithSyncBatch is like WithBatch but commits durably (see database.SyncBatch).
+// Use it for writes that move the head or finalized pointer together with the
+// blocks and states they point to.
+func (repo *Repository) WithSyncBatch(fn func(batch database.Batch) error) error {
+	batch := repo.newStateBatch()
+	defer batch.Close()
+
+	if err := fn(batch); err != nil {
+		return err
+	}
+	return database.CommitSync(batch.Batch)
+}
@@ -0,0 +1,224 @@
+package store
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/New-JAMneration/JAM-Protocol/internal/database"
+	"github.com/New-JAMneration/JAM-Protocol/internal/types"
+)
+
+// Key schema versioning. The layout of a data dir is recorded under
+// schemaVersionKey; data dirs written before the record existed are version 1.
+// MigrateSchema upgrades a data dir in place, one version at a time, and
+// refuses versions newer than SchemaVersion.
+
+// SchemaVersion is the key layout written by this build.
+//
+//	1: CE keys embed hashes and erasure roots as hex strings.
+//	2: CE keys embed them as raw bytes.
+//	3: states are stored as trie nodes (sn:, sv:); sd: holds the trie root
+//	   instead of an encoded StateKeyVals.
+//	4: trie nodes and values are reference counted (snr:, svr:).
+const SchemaVersion uint32 = 4
+
+// legacySchemaVersion is assumed for data dirs without a version record.
+const legacySchemaVersion uint32 = 1
+
+// ErrUnknownSchemaVersion is returned for data dirs written by a newer build.
+var ErrUnknownSchemaVersion = errors.New("unknown schema version")
+
+// Migration upgrades a data dir from version From to From+1. Migrate reads db
+// and stages its changes in batch, which also receives the new version record,
+// so a step is applied completely or not at all. Steps too large for one batch
+// may commit intermediate batches themselves if running them again after an
+// interruption is safe.
+type Migration struct {
+	From        uint32
+	Description string
+	Migrate     func(db database.Database, batch database.Batch) error
+}
+
+// migrations lists one step per version, in order.
+var migrations = []Migration{
+	{From: 1, Description: "store CE keys with raw bytes instead of hex", Migrate: migrateCEKeysToRaw},
+	{From: 2, Description: "store states as trie nodes", Migrate: migrateStateBlobsToTrie},
+	{From: 3, Description: "count references to state trie nodes", Migrate: migrateStateRefs},
+}
+
+// SchemaVersion returns the recorded schema version of r. Data dirs without a
+// record are version 1, or 0 if they hold no node keys at all.
+func (repo *Repository) SchemaVersion(r database.IterableReader) (uint32, error) {
+	value, found, err := r.Get(schemaVersionKey)
+	if err != nil {
+		return 0, err
+	}
+	if found {
+		var version types.U32
+		if err := repo.decoder.Decode(value, &version); err != nil {
+			return 0, fmt.Errorf("decode schema version: %w", err)
+		}
+		return uint32(version), nil
+	}
+
+	for _, prefix := range SchemaPrefixes() {
+		iter, err := r.NewIterator(prefix, nil)
+		if err != nil {
+			return 0, err
+		}
+		hasKeys := iter.Next()
+		err = iter.Error()
+		iter.Close()
+		if err != nil {
+			return 0, err
+		}
+		if hasKeys {
+			return legacySchemaVersion, nil
+		}
+	}
+	return 0, nil
+}
+
+// CheckSchema fails with ErrUnknownSchemaVersion if r was written by a newer
+// build. Older versions pass; they are upgraded by MigrateSchema.
+func (repo *Repository) CheckSchema(r database.IterableReader) (uint32, error) {
+	version, err := repo.SchemaVersion(r)
+	if err != nil {
+		return 0, err
+	}
+	if version > SchemaVersion {
+		return version, fmt.Errorf("%w %d, this build supports up to %d", ErrUnknownSchemaVersion, version, SchemaVersion)
+	}
+	return version, nil
+}
+
+// MigrateSchema upgrades db to SchemaVersion and returns the version it had
+// before. An empty database is stamped with SchemaVersion directly.
+func (repo *Repository) MigrateSchema(db database.Database) (uint32, error) {
+	from, err := repo.CheckSchema(db)
+	if err != nil {
+		return 0, err
+	}
+	if from == 0 {
+		return 0, repo.saveSchemaVersion(db, SchemaVersion)
+	}
+
+	for version := from; version < SchemaVersion; version++ {
+		migration := migrations[version-1]
+		batch := db.NewBatch()
+		err := migration.Migrate(db, batch)
+		if err == nil {
+			err = repo.saveSchemaVersion(batch, version+1)
+		}
+		if err == nil {
+			err = database.CommitSync(batch)
+		}
+		batch.Close()
+		if err != nil {
+			return from, fmt.Errorf("migrate schema %d to %d (%s): %w", version, version+1, migration.Description, err)
+		}
+	}
+	return from, nil
+}
+
+func (repo *Repository) saveSchemaVersion(w database.Writer, version uint32) error {
+	v := types.U32(version)
+	encoded, err := repo.encoder.Encode(&v)
+	if err != nil {
+		return err
+	}
+	return w.Put(schemaVersionKey, encoded)
+}
+
+// ceHexKeyPrefixes are the CE namespaces whose keys start with a hex-encoded
+// hash in version 1. The set namespace (ce/s/) keys on set names and is kept.
+var ceHexKeyPrefixes = [][]byte{
+	[]byte("ce/j/"),
+	[]byte("ce/p/"),
+	[]byte("ce/pa/"),
+	[]byte("ce/aa/"),
+	[]byte("ce/jg/"),
+	[]byte("ce/wp_bundle/"),
+}
+
+// migrateCEKeysToRaw rewrites ce/<ns>/<hex>[:<suffix>] as ce/<ns>/<raw>[:<suffix>].
+func migrateCEKeysToRaw(db database.Database, batch database.Batch) error {
+	for _, prefix := range ceHexKeyPrefixes {
+		var rewriteErr error
+		err := forEach(db, prefix, func(key, value []byte) {
+			if rewriteErr != nil {
+				return
+			}
+			rest := key[len(prefix):]
+			encoded, suffix := rest, []byte(nil)
+			if i := bytes.IndexByte(rest, ':'); i >= 0 {
+				encoded, suffix = rest[:i], rest[i:]
+			}
+			raw, err := hex.DecodeString(string(encoded))
+			if err != nil {
+				rewriteErr = fmt.Errorf("key %q: %w", key, err)
+				return
+			}
+
+			newKey := append(append(bytes.Clone(prefix), raw...), suffix...)
+			if rewriteErr = batch.Delete(key); rewriteErr != nil {
+				return
+			}
+			rewriteErr = batch.Put(newKey, value)
+		})
+		if err != nil {
+			return err
+		}
+		if rewriteErr != nil {
+			return rewriteErr
+		}
+	}
+	return nil
+}
+
+// migrateStateBlobsToTrie replaces every encoded StateKeyVals under sd: with
+// a state trie, committing one state at a time. Converted entries hold a
+// 32-byte trie root, which no encoded StateKeyVals can be, so an interrupted
+// run resumes where it stopped.
+func migrateStateBlobsToTrie(db database.Database, _ database.Batch) error {
+	var blobKeys [][]byte
+	if err := forEach(db, stateDataPrefix, func(key, value []byte) {
+		if len(value) != len(types.OpaqueHash{}) {
+			blobKeys = append(blobKeys, key)
+		}
+	}); err != nil {
+		return err
+	}
+
+	repo := NewRepository(db)
+	for _, key := range blobKeys {
+		if len(key) != len(stateDataPrefix)+len(types.StateRoot{}) {
+			return fmt.Errorf("malformed state data key %q", key)
+		}
+		data, _, err := db.Get(key)
+		if err != nil {
+			return err
+		}
+		var stateKeyVals types.StateKeyVals
+		if err := repo.decoder.Decode(data, &stateKeyVals); err != nil {
+			return fmt.Errorf("decode state data %q: %w", key, err)
+		}
+
+		err = repo.WithBatch(func(batch database.Batch) error {
+			return repo.SaveStateData(batch, types.StateRoot(key[len(stateDataPrefix):]), stateKeyVals)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// migrateStateRefs counts the references to the stored trie nodes and values,
+// dropping those no state refers to.
+func migrateStateRefs(db database.Database, batch database.Batch) error {
+	_, err := recountStateRefs(db, batch)
+	return err
+}
@@ -0,0 +1,93 @@
+package store_test
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/New-JAMneration/JAM-Protocol/internal/database/provider/memory"
+	"github.com/New-JAMneration/JAM-Protocol/internal/store"
+	"github.com/New-JAMneration/JAM-Protocol/internal/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrateSchemaEmpty(t *testing.T) {
+	db := memory.NewDatabase()
+	repo := store.NewRepository(db)
+
+	version, err := repo.SchemaVersion(db)
+	require.NoError(t, err)
+	require.Zero(t, version)
+
+	from, err := repo.MigrateSchema(db)
+	require.NoError(t, err)
+	require.Zero(t, from)
+
+	version, err = repo.SchemaVersion(db)
+	require.NoError(t, err)
+	require.Equal(t, store.SchemaVersion, version)
+}
+
+func TestMigrateSchemaCEKeys(t *testing.T) {
+	db := memory.NewDatabase()
+	repo := store.NewRepository(db)
+	seedFsckChain(t, db, repo)
+
+	root := types.OpaqueHash{0xaa, 0xbb}
+	hexRoot := hex.EncodeToString(root[:])
+	require.NoError(t, db.Put([]byte("ce/j/"+hexRoot+":3"), []byte("justification")))
+	require.NoError(t, db.Put([]byte("ce/wp_bundle/"+hexRoot), []byte("bundle")))
+	require.NoError(t, db.Put([]byte("ce/s/set:"+hexRoot+"\x00m"), []byte("member")))
+
+	// A data dir without a version record but with node keys is version 1.
+	version, err := repo.SchemaVersion(db)
+	require.NoError(t, err)
+	require.Equal(t, uint32(1), version)
+
+	from, err := repo.MigrateSchema(db)
+	require.NoError(t, err)
+	require.Equal(t, uint32(1), from)
+
+	justificationKey := append(append([]byte("ce/j/"), root[:]...), ":3"...)
+	require.Equal(t, []byte("justification"), mustGet(t, db, justificationKey))
+	require.Equal(t, []byte("bundle"), mustGet(t, db, append([]byte("ce/wp_bundle/"), root[:]...)))
+	require.Equal(t, []byte("member"), mustGet(t, db, []byte("ce/s/set:"+hexRoot+"\x00m")), "set keys are unchanged")
+
+	has, err := db.Has([]byte("ce/j/" + hexRoot + ":3"))
+	require.NoError(t, err)
+	require.False(t, has)
+
+	// Running again is a no-op.
+	from, err = repo.MigrateSchema(db)
+	require.NoError(t, err)
+	require.Equal(t, store.SchemaVersion, from)
+	require.Equal(t, []byte("justification"), mustGet(t, db, justificationKey))
+}
+
+func TestMigrateSchemaRefusesUnknownVersion(t *testing.T) {
+	db := memory.NewDatabase()
+	repo := store.NewRepository(db)
+	require.NoError(t, db.Put([]byte("schema_version"), []byte{99, 0, 0, 0}))
+	require.NoError(t, db.Put([]byte("ce/p/zz"), []byte("x")))
+
+	_, err := repo.CheckSchema(db)
+	require.ErrorIs(t, err, store.ErrUnknownSchemaVersion)
+
+	_, err = repo.MigrateSchema(db)
+	require.ErrorIs(t, err, store.ErrUnknownSchemaVersion)
+	require.Equal(t, []byte("x"), mustGet(t, db, []byte("ce/p/zz")), "data is left untouched")
+}
+
+func TestMigrateSchemaFailureIsAtomic(t *testing.T) {
+	db := memory.NewDatabase()
+	repo := store.NewRepository(db)
+	require.NoError(t, db.Put([]byte("ce/p/aabb"), []byte("ok")))
+	require.NoError(t, db.Put([]byte("ce/p/not-hex"), []byte("bad")))
+
+	_, err := repo.MigrateSchema(db)
+	require.Error(t, err)
+
+	version, err := repo.SchemaVersion(db)
+	require.NoError(t, err)
+	require.Equal(t, uint32(1), version)
+	require.Equal(t, []byte("ok"), mustGet(t, db, []byte("ce/p/aabb")))
+}
@@ -1,6 +1,7 @@
 package store
 
 import (
+	"bytes"
 	"fmt"
 
 	"github.com/New-JAMneration/JAM-Protocol/internal/database"
@@ -25,33 +26,87 @@ func (repo *Repository) GetStateRootByHeaderHash(r database.Reader, headerHash t
 	return stateRoot, nil
 }
 
+// SaveStateData stores stateKeyVals as a state trie (see state_trie.go) and
+// points stateRoot at it. Nodes already stored are shared, not written again.
+// w is the repository database or a batch from WithBatch or WithSyncBatch.
 func (repo *Repository) SaveStateData(w database.Writer, stateRoot types.StateRoot, stateKeyVals types.StateKeyVals) error {
-	data, err := repo.encoder.Encode(&stateKeyVals)
+	entries := make(types.StateKeyVals, len(stateKeyVals))
+	copy(entries, stateKeyVals)
+	sortStateKeyVals(entries)
+
+	trie, err := buildStateTrie(entries, 0)
 	if err != nil {
-		return fmt.Errorf("failed to encode state data: %w", err)
+		return fmt.Errorf("failed to build state trie: %w", err)
 	}
-	return w.Put(stateDataKey(stateRoot), data)
+	trieRoot := trie.nodeHash()
+
+	return repo.withStateBatch(w, func(batch *stateBatch) error {
+		old, found, err := batch.Get(stateDataKey(stateRoot))
+		if err != nil {
+			return err
+		}
+		if found && bytes.Equal(old, trieRoot[:]) {
+			return nil
+		}
+		if err := writeStateTrie(batch, trie); err != nil {
+			return fmt.Errorf("failed to store state trie: %w", err)
+		}
+		if err := batch.retainNode(trieRoot); err != nil {
+			return err
+		}
+		if err := batch.Put(stateDataKey(stateRoot), trieRoot[:]); err != nil {
+			return err
+		}
+		if found && len(old) == len(types.OpaqueHash{}) {
+			return batch.releaseNode(types.OpaqueHash(old))
+		}
+		return nil
+	})
 }
 
+// DeleteStateData removes the entry for stateRoot, and the trie nodes and
+// values no other state uses. w is as for SaveStateData.
 func (repo *Repository) DeleteStateData(w database.Writer, stateRoot types.StateRoot) error {
-	return w.Delete(stateDataKey(stateRoot))
+	return repo.withStateBatch(w, func(batch *stateBatch) error {
+		old, found, err := batch.Get(stateDataKey(stateRoot))
+		if err != nil || !found {
+			return err
+		}
+		if err := batch.Delete(stateDataKey(stateRoot)); err != nil {
+			return err
+		}
+		if len(old) != len(types.OpaqueHash{}) {
+			return nil
+		}
+		return batch.releaseNode(types.OpaqueHash(old))
+	})
+}
+
+// HasStateData reports whether the state with stateRoot is stored.
+func (repo *Repository) HasStateData(r database.Reader, stateRoot types.StateRoot) (bool, error) {
+	_, found, err := r.Get(stateDataKey(stateRoot))
+	return found, err
 }
 
+// GetStateData returns every entry of the state with stateRoot, ordered by key.
 func (repo *Repository) GetStateData(r database.Reader, stateRoot types.StateRoot) (types.StateKeyVals, error) {
-	data, found, err := r.Get(stateDataKey(stateRoot))
+	trieRoot, err := stateTrieRoot(r, stateRoot)
 	if err != nil {
 		return nil, err
 	}
-	if !found {
-		return nil, fmt.Errorf("state data not found for state root %x", stateRoot)
-	}
 
-	var stateKeyVals types.StateKeyVals
-	err = repo.decoder.Decode(data, &stateKeyVals)
-	if err != nil {
-		return nil, fmt.Errorf("failed to decode state data: %w", err)
+	stateKeyVals := types.StateKeyVals{}
+	walk := &stateTrieWalk{r: r, fn: func(key types.StateKey, node *stateNode) error {
+		value, err := leafValue(r, node)
+		if err != nil {
+			return err
+		}
+		stateKeyVals = append(stateKeyVals, types.StateKeyVal{Key: key, Value: value})
+		return nil
+	}}
+	if err := walk.walk(trieRoot, 0, types.StateKey{}); err != nil {
+		return nil, fmt.Errorf("failed to read state data: %w", err)
 	}
-
 	return stateKeyVals, nil
 }
 
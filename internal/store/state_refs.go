@@ -0,0 +1,203 @@
+package store
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/New-JAMneration/JAM-Protocol/internal/database"
+	"github.com/New-JAMneration/JAM-Protocol/internal/types"
+)
+
+// State reference counts. Every stored trie node counts the sd: entries and
+// stored parent nodes pointing at it under snr:<node hash>, and every value
+// under sv: counts the leaves pointing at it under svr:<value hash>. Deleting
+// a state releases its trie root, and a node or value whose count drops to
+// zero is deleted and releases what it points at in turn, so a state's
+// unshared nodes go with it and no sweep over the whole trie store is needed.
+//
+// Counts are read, changed and committed under the repository's state lock,
+// which a stateBatch takes with its first state write and holds until it is
+// closed. A save can therefore never rely on a node that a concurrent delete
+// is about to remove.
+
+func stateNodeRefsKey(h types.OpaqueHash) []byte {
+	ref := trieNodeRef(h)
+	return append(bytes.Clone(stateNodeRefsPrefix), ref[:]...)
+}
+
+func stateValueRefsKey(valueHash types.OpaqueHash) []byte {
+	return append(bytes.Clone(stateValueRefsPrefix), valueHash[:]...)
+}
+
+func encodeRefs(n uint32) []byte {
+	return binary.LittleEndian.AppendUint32(nil, n)
+}
+
+// errForeignBatch is returned for state writes to a batch that was not
+// opened by the repository, whose commit the state lock cannot cover.
+var errForeignBatch = errors.New("state data must be written to the repository database or a batch from WithBatch or WithSyncBatch")
+
+// stateBatch is the batch WithBatch and WithSyncBatch hand out. It remembers
+// its writes so that state writes can read what the batch has staged, and
+// holds the state lock from the first state write until it is closed.
+type stateBatch struct {
+	database.Batch
+	repo   *Repository
+	staged map[string][]byte // nil for deleted keys
+	locked bool
+}
+
+func (repo *Repository) newStateBatch() *stateBatch {
+	return &stateBatch{Batch: repo.db.NewBatch(), repo: repo, staged: make(map[string][]byte)}
+}
+
+func (b *stateBatch) Put(key, value []byte) error {
+	if err := b.Batch.Put(key, value); err != nil {
+		return err
+	}
+	b.staged[string(key)] = bytes.Clone(value)
+	return nil
+}
+
+func (b *stateBatch) Delete(key []byte) error {
+	if err := b.Batch.Delete(key); err != nil {
+		return err
+	}
+	b.staged[string(key)] = nil
+	return nil
+}
+
+func (b *stateBatch) Get(key []byte) ([]byte, bool, error) {
+	if value, ok := b.staged[string(key)]; ok {
+		return value, value != nil, nil
+	}
+	return b.repo.db.Get(key)
+}
+
+func (b *stateBatch) Has(key []byte) (bool, error) {
+	_, found, err := b.Get(key)
+	return found, err
+}
+
+func (b *stateBatch) Close() error {
+	if b.locked {
+		b.locked = false
+		b.repo.stateMu.Unlock()
+	}
+	return b.Batch.Close()
+}
+
+// lockState takes the state lock for the rest of the batch.
+func (b *stateBatch) lockState() {
+	if !b.locked {
+		b.repo.stateMu.Lock()
+		b.locked = true
+	}
+}
+
+// withStateBatch runs fn on the stateBatch w, or on a new one committed
+// afterwards if w is the repository database, with the state lock held.
+func (repo *Repository) withStateBatch(w database.Writer, fn func(batch *stateBatch) error) error {
+	if batch, ok := w.(*stateBatch); ok && batch.repo == repo {
+		batch.lockState()
+		return fn(batch)
+	}
+	if db, ok := w.(database.Database); !ok || db != repo.db {
+		return errForeignBatch
+	}
+	return repo.WithBatch(func(batch database.Batch) error {
+		b := batch.(*stateBatch)
+		b.lockState()
+		return fn(b)
+	})
+}
+
+func (b *stateBatch) refs(key []byte) (uint32, error) {
+	data, found, err := b.Get(key)
+	if err != nil || !found {
+		return 0, err
+	}
+	if len(data) != 4 {
+		return 0, fmt.Errorf("malformed reference count %q", key)
+	}
+	return binary.LittleEndian.Uint32(data), nil
+}
+
+// retainNode adds a reference to the stored node h.
+func (b *stateBatch) retainNode(h types.OpaqueHash) error {
+	if trieNodeRef(h) == (types.OpaqueHash{}) {
+		return nil
+	}
+	key := stateNodeRefsKey(h)
+	n, err := b.refs(key)
+	if err != nil {
+		return err
+	}
+	return b.Put(key, encodeRefs(n+1))
+}
+
+// retainValue adds a reference to the value stored under sv:valueHash.
+func (b *stateBatch) retainValue(valueHash types.OpaqueHash) error {
+	key := stateValueRefsKey(valueHash)
+	n, err := b.refs(key)
+	if err != nil {
+		return err
+	}
+	return b.Put(key, encodeRefs(n+1))
+}
+
+// releaseNode drops a reference to the stored node h, deleting it and
+// releasing its children or value once nothing refers to it.
+func (b *stateBatch) releaseNode(h types.OpaqueHash) error {
+	if trieNodeRef(h) == (types.OpaqueHash{}) {
+		return nil
+	}
+	key := stateNodeRefsKey(h)
+	n, err := b.refs(key)
+	if err != nil {
+		return err
+	}
+	if n > 1 {
+		return b.Put(key, encodeRefs(n-1))
+	}
+
+	node, err := getStateNode(b, h)
+	if err != nil {
+		return err
+	}
+	if err := b.Delete(key); err != nil {
+		return err
+	}
+	if err := b.Delete(stateNodeKey(h)); err != nil {
+		return err
+	}
+
+	if !node.isBranch() {
+		if valueHash, ok := node.valueHash(); ok {
+			return b.releaseValue(valueHash)
+		}
+		return nil
+	}
+	left, right := node.children()
+	if err := b.releaseNode(left); err != nil {
+		return err
+	}
+	return b.releaseNode(right)
+}
+
+func (b *stateBatch) releaseValue(valueHash types.OpaqueHash) error {
+	key := stateValueRefsKey(valueHash)
+	n, err := b.refs(key)
+	if err != nil {
+		return err
+	}
+	if n > 1 {
+		return b.Put(key, encodeRefs(n-1))
+	}
+	if err := b.Delete(key); err != nil {
+		return err
+	}
+	return b.Delete(stateValueKey(valueHash))
+}
@@ -0,0 +1,264 @@
+package store_test
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"sync"
+	"testing"
+
+	"github.com/New-JAMneration/JAM-Protocol/internal/database"
+	"github.com/New-JAMneration/JAM-Protocol/internal/database/provider/memory"
+	"github.com/New-JAMneration/JAM-Protocol/internal/store"
+	"github.com/New-JAMneration/JAM-Protocol/internal/types"
+	m "github.com/New-JAMneration/JAM-Protocol/internal/utilities/merklization"
+	"github.com/stretchr/testify/require"
+)
+
+var stateTriePrefixes = []string{"sd:", "sn:", "sv:", "snr:", "svr:"}
+
+// dumpStateTries returns the state entries of db by key.
+func dumpStateTries(t testing.TB, db database.Iterable) map[string]string {
+	t.Helper()
+	dump := make(map[string]string)
+	for _, prefix := range stateTriePrefixes {
+		iter, err := db.NewIterator([]byte(prefix), nil)
+		require.NoError(t, err)
+		for iter.Next() {
+			dump[string(iter.Key())] = string(iter.Value())
+		}
+		require.NoError(t, iter.Error())
+		iter.Close()
+	}
+	return dump
+}
+
+// changedState returns state with n values replaced, one of them by a value
+// long enough to be stored under sv:.
+func changedState(rng *rand.Rand, state types.StateKeyVals, n int) types.StateKeyVals {
+	next := append(types.StateKeyVals{}, state...)
+	for i := range n {
+		value := make([]byte, 8+40*(i%2))
+		rng.Read(value)
+		next[rng.Intn(len(next))].Value = value
+	}
+	return next
+}
+
+// savedAlone returns the state entries of a database holding only states.
+func savedAlone(t *testing.T, states ...types.StateKeyVals) map[string]string {
+	db := memory.NewDatabase()
+	repo := store.NewRepository(db)
+	for _, state := range states {
+		require.NoError(t, repo.SaveStateData(db, m.MerklizationSerializedState(state), state))
+	}
+	return dumpStateTries(t, db)
+}
+
+func TestDeleteStateData(t *testing.T) {
+	db := memory.NewDatabase()
+	repo := store.NewRepository(db)
+	rng := rand.New(rand.NewSource(3))
+
+	first := randomState(rng, 50)
+	second := changedState(rng, first, 3)
+	second[0].Value = bytes.Repeat([]byte{7}, 64)
+	firstRoot := m.MerklizationSerializedState(first)
+	secondRoot := m.MerklizationSerializedState(second)
+	require.NoError(t, repo.SaveStateData(db, firstRoot, first))
+	require.NoError(t, repo.SaveStateData(db, secondRoot, second))
+	// Saving a stored state again changes nothing.
+	require.NoError(t, repo.SaveStateData(db, secondRoot, second))
+	require.Equal(t, savedAlone(t, first, second), dumpStateTries(t, db))
+
+	// The nodes only the first state used go with it.
+	require.NoError(t, repo.DeleteStateData(db, firstRoot))
+	require.Equal(t, savedAlone(t, second), dumpStateTries(t, db))
+	got, err := repo.GetStateData(db, secondRoot)
+	require.NoError(t, err)
+	require.Equal(t, second, got)
+
+	require.NoError(t, repo.DeleteStateData(db, secondRoot))
+	require.NoError(t, repo.DeleteStateData(db, secondRoot))
+	require.Empty(t, dumpStateTries(t, db))
+}
+
+// States written to one batch share the nodes the batch has staged.
+func TestStateDataInOneBatch(t *testing.T) {
+	db := memory.NewDatabase()
+	repo := store.NewRepository(db)
+	rng := rand.New(rand.NewSource(5))
+
+	first := randomState(rng, 50)
+	second := changedState(rng, first, 3)
+	err := repo.WithBatch(func(batch database.Batch) error {
+		for _, state := range []types.StateKeyVals{first, second} {
+			if err := repo.SaveStateData(batch, m.MerklizationSerializedState(state), state); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, savedAlone(t, first, second), dumpStateTries(t, db))
+
+	err = repo.WithSyncBatch(func(batch database.Batch) error {
+		for _, state := range []types.StateKeyVals{first, second} {
+			if err := repo.DeleteStateData(batch, m.MerklizationSerializedState(state)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	require.Empty(t, dumpStateTries(t, db))
+
+	// A batch the repository did not open cannot be read or locked.
+	batch := db.NewBatch()
+	defer batch.Close()
+	require.Error(t, repo.SaveStateData(batch, m.MerklizationSerializedState(first), first))
+}
+
+// PruneStateNodes rebuilds the reference counts, as the upgrade from schema
+// version 3 does, and drops the nodes nothing refers to.
+func TestPruneStateNodes(t *testing.T) {
+	db := memory.NewDatabase()
+	repo := store.NewRepository(db)
+	rng := rand.New(rand.NewSource(6))
+
+	first := randomState(rng, 50)
+	second := changedState(rng, first, 3)
+	for _, state := range []types.StateKeyVals{first, second} {
+		require.NoError(t, repo.SaveStateData(db, m.MerklizationSerializedState(state), state))
+	}
+	want := dumpStateTries(t, db)
+
+	for key := range want {
+		if bytes.HasPrefix([]byte(key), []byte("snr:")) || bytes.HasPrefix([]byte(key), []byte("svr:")) {
+			require.NoError(t, db.Delete([]byte(key)))
+		}
+	}
+	leftover := types.StateKeyVals{{Key: types.StateKey{1}, Value: bytes.Repeat([]byte{1}, 40)}}
+	leftoverRoot := m.MerklizationSerializedState(leftover)
+	require.NoError(t, repo.SaveStateData(db, leftoverRoot, leftover))
+	require.NoError(t, db.Delete(append([]byte("sd:"), leftoverRoot[:]...)))
+
+	removed, err := repo.PruneStateNodes(db)
+	require.NoError(t, err)
+	require.Equal(t, 2, removed, "the leftover leaf and its value")
+	require.Equal(t, want, dumpStateTries(t, db))
+
+	removed, err = repo.PruneStateNodes(db)
+	require.NoError(t, err)
+	require.Zero(t, removed)
+}
+
+// TestConcurrentStateWrites saves and deletes states from several goroutines
+// at once, then checks that the retained states are intact and that their
+// reference counts are exact.
+func TestConcurrentStateWrites(t *testing.T) {
+	db := memory.NewDatabase()
+	repo := store.NewRepository(db)
+	base := randomState(rand.New(rand.NewSource(7)), 300)
+
+	const writers, rounds, retain = 4, 30, 3
+	kept := make([][]types.StateKeyVals, writers)
+	var wg sync.WaitGroup
+	errs := make(chan error, writers)
+	for w := range writers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rng := rand.New(rand.NewSource(int64(w)))
+			var saved []types.StateKeyVals
+			for range rounds {
+				state := changedState(rng, base, 5)
+				if err := repo.SaveStateData(db, m.MerklizationSerializedState(state), state); err != nil {
+					errs <- err
+					return
+				}
+				saved = append(saved, state)
+				if len(saved) > retain {
+					if err := repo.DeleteStateData(db, m.MerklizationSerializedState(saved[0])); err != nil {
+						errs <- err
+						return
+					}
+					saved = saved[1:]
+				}
+			}
+			kept[w] = saved
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		require.NoError(t, err)
+	}
+
+	var all []types.StateKeyVals
+	for _, saved := range kept {
+		for _, state := range saved {
+			got, err := repo.GetStateData(db, m.MerklizationSerializedState(state))
+			require.NoError(t, err)
+			require.Equal(t, state, got)
+			all = append(all, state)
+		}
+	}
+	want := dumpStateTries(t, db)
+	removed, err := repo.PruneStateNodes(db)
+	require.NoError(t, err)
+	require.Zero(t, removed)
+	require.Equal(t, want, dumpStateTries(t, db))
+	require.Equal(t, savedAlone(t, all...), want)
+}
+
+// BenchmarkPruneOldStates saves a state with a few changed values and drops
+// the state saved retain blocks before, the way fuzz mode keeps a window of
+// recent states. "delete" frees the dropped state's nodes as it deletes it;
+// "sweep" adds the full mark-and-sweep of PruneStateNodes once per retention
+// window, as pruning did before states were reference counted.
+func BenchmarkPruneOldStates(b *testing.B) {
+	const retain, changed = 8, 20
+	for _, keys := range []int{1_000, 10_000, 100_000} {
+		for _, sweep := range []bool{false, true} {
+			name := "delete"
+			if sweep {
+				name = "sweep"
+			}
+			b.Run(fmt.Sprintf("keys=%d/%s", keys, name), func(b *testing.B) {
+				db := memory.NewDatabase()
+				repo := store.NewRepository(db)
+				rng := rand.New(rand.NewSource(8))
+				state := randomState(rng, keys)
+				var roots []types.StateRoot
+				save := func() {
+					state = changedState(rng, state, changed)
+					root := m.MerklizationSerializedState(state)
+					if err := repo.SaveStateData(db, root, state); err != nil {
+						b.Fatal(err)
+					}
+					roots = append(roots, root)
+				}
+				for range retain {
+					save()
+				}
+
+				b.ResetTimer()
+				for i := range b.N {
+					b.StopTimer()
+					save()
+					b.StartTimer()
+					if err := repo.DeleteStateData(db, roots[0]); err != nil {
+						b.Fatal(err)
+					}
+					roots = roots[1:]
+					if sweep && i%retain == retain-1 {
+						if _, err := repo.PruneStateNodes(db); err != nil {
+							b.Fatal(err)
+						}
+					}
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,393 @@
+package store
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+
+	"github.com/New-JAMneration/JAM-Protocol/internal/database"
+	"github.com/New-JAMneration/JAM-Protocol/internal/types"
+	"github.com/New-JAMneration/JAM-Protocol/internal/utilities/hash"
+	m "github.com/New-JAMneration/JAM-Protocol/internal/utilities/merklization"
+)
+
+// State storage. A state is stored as the nodes of its binary Merkle trie
+// (GP appendix D), each under sn:<node hash>; values longer than 32 bytes are
+// stored once under sv:<blake2b(value)>, and sd:<state root> points at the
+// trie root. States share every unchanged subtree, saving a state writes only
+// the nodes the database does not have yet, and a single key is found by
+// walking one path from the root. Nodes and values are reference counted
+// (see state_refs.go) and deleted with the last state using them.
+
+const maxTrieDepth = len(types.StateKey{}) * 8
+
+// trieNodeRef is the hash a node is stored under. Branch nodes keep only 255
+// bits of their left child's hash, so every node is addressed with the first
+// bit cleared.
+func trieNodeRef(h types.OpaqueHash) types.OpaqueHash {
+	h[0] &= 0x7f
+	return h
+}
+
+func stateNodeKey(h types.OpaqueHash) []byte {
+	ref := trieNodeRef(h)
+	return append(bytes.Clone(stateNodePrefix), ref[:]...)
+}
+
+func stateValueKey(valueHash types.OpaqueHash) []byte {
+	return append(bytes.Clone(stateValuePrefix), valueHash[:]...)
+}
+
+func keyBit(key types.StateKey, depth int) bool {
+	return key[depth/8]&(1<<(7-depth%8)) != 0
+}
+
+// trieNode is a node of a trie built in memory before it is written.
+type trieNode struct {
+	hash        types.OpaqueHash
+	encoded     [64]byte
+	left, right *trieNode
+	// value is set for leaves whose value does not fit in the node.
+	value []byte
+}
+
+func (n *trieNode) nodeHash() types.OpaqueHash {
+	if n == nil {
+		return types.OpaqueHash{}
+	}
+	return n.hash
+}
+
+// buildStateTrie builds the trie of entries, which must be sorted by key.
+func buildStateTrie(entries []types.StateKeyVal, depth int) (*trieNode, error) {
+	switch {
+	case len(entries) == 0:
+		return nil, nil
+	case len(entries) == 1:
+		node := &trieNode{encoded: m.EncodeLeafNode(entries[0].Key, entries[0].Value)}
+		if len(entries[0].Value) > 32 {
+			node.value = entries[0].Value
+		}
+		node.hash = hash.Blake2bHash(node.encoded[:])
+		return node, nil
+	case depth == maxTrieDepth:
+		return nil, fmt.Errorf("duplicate state key 0x%x", entries[0].Key)
+	}
+
+	pivot := sort.Search(len(entries), func(i int) bool { return keyBit(entries[i].Key, depth) })
+	left, err := buildStateTrie(entries[:pivot], depth+1)
+	if err != nil {
+		return nil, err
+	}
+	right, err := buildStateTrie(entries[pivot:], depth+1)
+	if err != nil {
+		return nil, err
+	}
+
+	node := &trieNode{left: left, right: right, encoded: m.EncodeBranchNode(left.nodeHash(), right.nodeHash())}
+	node.hash = hash.Blake2bHash(node.encoded[:])
+	return node, nil
+}
+
+// writeStateTrie writes the nodes of node that b does not have. Children are
+// written before their parent, so a stored node always has its subtree, and
+// a new node retains its children and value (see state_refs.go).
+func writeStateTrie(b *stateBatch, node *trieNode) error {
+	if node == nil {
+		return nil
+	}
+	key := stateNodeKey(node.hash)
+	if has, err := b.Has(key); err != nil || has {
+		return err
+	}
+
+	for _, child := range []*trieNode{node.left, node.right} {
+		if err := writeStateTrie(b, child); err != nil {
+			return err
+		}
+		if err := b.retainNode(child.nodeHash()); err != nil {
+			return err
+		}
+	}
+	if node.value != nil {
+		valueHash := types.OpaqueHash(node.encoded[32:])
+		if err := b.Put(stateValueKey(valueHash), node.value); err != nil {
+			return err
+		}
+		if err := b.retainValue(valueHash); err != nil {
+			return err
+		}
+	}
+	return b.Put(key, node.encoded[:])
+}
+
+// stateNode is a stored trie node.
+type stateNode [64]byte
+
+func (n *stateNode) isBranch() bool {
+	return n[0]&0x80 == 0
+}
+
+func (n *stateNode) children() (left, right types.OpaqueHash) {
+	return types.OpaqueHash(n[:32]), types.OpaqueHash(n[32:])
+}
+
+func (n *stateNode) leafKey() types.StateKey {
+	return types.StateKey(n[1:32])
+}
+
+// valueHash returns the hash of a leaf value stored under sv:.
+func (n *stateNode) valueHash() (types.OpaqueHash, bool) {
+	if n[0]&0x40 == 0 {
+		return types.OpaqueHash{}, false
+	}
+	return types.OpaqueHash(n[32:]), true
+}
+
+func getStateNode(r database.Reader, h types.OpaqueHash) (*stateNode, error) {
+	data, found, err := r.Get(stateNodeKey(h))
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("state trie node 0x%x not found", h)
+	}
+	if len(data) != len(stateNode{}) {
+		return nil, fmt.Errorf("state trie node 0x%x has %d bytes", h, len(data))
+	}
+	return (*stateNode)(data), nil
+}
+
+func leafValue(r database.Reader, node *stateNode) ([]byte, error) {
+	valueHash, ok := node.valueHash()
+	if !ok {
+		return bytes.Clone(node[32 : 32+node[0]&0x3f]), nil
+	}
+	value, found, err := r.Get(stateValueKey(valueHash))
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("state value 0x%x not found", valueHash)
+	}
+	return value, nil
+}
+
+// stateTrieRoot returns the trie root stored for stateRoot.
+func stateTrieRoot(r database.Reader, stateRoot types.StateRoot) (types.OpaqueHash, error) {
+	data, found, err := r.Get(stateDataKey(stateRoot))
+	if err != nil {
+		return types.OpaqueHash{}, err
+	}
+	if !found {
+		return types.OpaqueHash{}, fmt.Errorf("state data not found for state root %x", stateRoot)
+	}
+	if len(data) != len(types.OpaqueHash{}) {
+		return types.OpaqueHash{}, fmt.Errorf("malformed state data entry for state root %x (%d bytes)", stateRoot, len(data))
+	}
+	return types.OpaqueHash(data), nil
+}
+
+// lookupStateTrie follows the path of key from root.
+func lookupStateTrie(r database.Reader, root types.OpaqueHash, key types.StateKey) ([]byte, bool, error) {
+	ref := root
+	for depth := 0; ; depth++ {
+		if trieNodeRef(ref) == (types.OpaqueHash{}) {
+			return nil, false, nil
+		}
+		node, err := getStateNode(r, ref)
+		if err != nil {
+			return nil, false, err
+		}
+		if !node.isBranch() {
+			if node.leafKey() != key {
+				return nil, false, nil
+			}
+			value, err := leafValue(r, node)
+			return value, err == nil, err
+		}
+		if depth == maxTrieDepth {
+			return nil, false, fmt.Errorf("state trie deeper than %d bits", maxTrieDepth)
+		}
+
+		left, right := node.children()
+		if keyBit(key, depth) {
+			ref = right
+		} else {
+			ref = left
+		}
+	}
+}
+
+// stateTrieWalk visits the leaves of a trie in key order.
+type stateTrieWalk struct {
+	r database.Reader
+	// start and end bound the visited keys to [start, end); a nil end leaves
+	// the range open.
+	start, end *types.StateKey
+	fn         func(key types.StateKey, node *stateNode) error
+}
+
+// walk visits the subtree ref, whose keys all start with the first depth bits
+// of path.
+func (w *stateTrieWalk) walk(ref types.OpaqueHash, depth int, path types.StateKey) error {
+	if trieNodeRef(ref) == (types.OpaqueHash{}) || !w.overlaps(depth, path) {
+		return nil
+	}
+	node, err := getStateNode(w.r, ref)
+	if err != nil {
+		return err
+	}
+	if !node.isBranch() {
+		key := node.leafKey()
+		if w.start != nil && bytes.Compare(key[:], w.start[:]) < 0 {
+			return nil
+		}
+		if w.end != nil && bytes.Compare(key[:], w.end[:]) >= 0 {
+			return nil
+		}
+		return w.fn(key, node)
+	}
+	if depth == maxTrieDepth {
+		return fmt.Errorf("state trie deeper than %d bits", maxTrieDepth)
+	}
+
+	left, right := node.children()
+	if err := w.walk(left, depth+1, path); err != nil {
+		return err
+	}
+	path[depth/8] |= 1 << (7 - depth%8)
+	return w.walk(right, depth+1, path)
+}
+
+// overlaps reports whether the keys starting with the first depth bits of
+// path can fall in [start, end).
+func (w *stateTrieWalk) overlaps(depth int, path types.StateKey) bool {
+	if w.end != nil && bytes.Compare(path[:], w.end[:]) >= 0 {
+		// path with the remaining bits cleared is the smallest key below it.
+		return false
+	}
+	if w.start == nil {
+		return true
+	}
+	highest := path
+	for i := depth; i < maxTrieDepth; i++ {
+		if i%8 == 0 {
+			for j := i / 8; j < len(highest); j++ {
+				highest[j] = 0xff
+			}
+			break
+		}
+		highest[i/8] |= 1 << (7 - i%8)
+	}
+	return bytes.Compare(highest[:], w.start[:]) >= 0
+}
+
+// PruneStateNodes recounts the references to every trie node and value from
+// the sd: entries, deletes the nodes and values nothing refers to and returns
+// how many it deleted. Deleting a state already frees what only it used; this
+// full pass over the trie store is for repairs and schema upgrades, and holds
+// the state lock while it runs.
+func (repo *Repository) PruneStateNodes(db database.Database) (int, error) {
+	repo.stateMu.Lock()
+	defer repo.stateMu.Unlock()
+
+	batch := db.NewBatch()
+	defer batch.Close()
+	removed, err := recountStateRefs(db, batch)
+	if err != nil {
+		return 0, err
+	}
+	if err := batch.Commit(); err != nil {
+		return 0, err
+	}
+	return removed, nil
+}
+
+// recountStateRefs stages in w the reference counts of the nodes and values
+// reachable from the sd: entries of r, and the deletion of the others and of
+// stale counts. It returns how many nodes and values it deletes.
+func recountStateRefs(r database.IterableReader, w database.Writer) (int, error) {
+	nodeRefs := make(map[types.OpaqueHash]uint32)
+	valueRefs := make(map[types.OpaqueHash]uint32)
+
+	var mark func(ref types.OpaqueHash) error
+	mark = func(ref types.OpaqueHash) error {
+		ref = trieNodeRef(ref)
+		if ref == (types.OpaqueHash{}) {
+			return nil
+		}
+		nodeRefs[ref]++
+		if nodeRefs[ref] > 1 {
+			return nil
+		}
+		node, err := getStateNode(r, ref)
+		if err != nil {
+			return err
+		}
+		if !node.isBranch() {
+			if valueHash, ok := node.valueHash(); ok {
+				valueRefs[valueHash]++
+			}
+			return nil
+		}
+		left, right := node.children()
+		if err := mark(left); err != nil {
+			return err
+		}
+		return mark(right)
+	}
+
+	var roots []types.OpaqueHash
+	if err := forEach(r, stateDataPrefix, func(key, value []byte) {
+		if len(value) == len(types.OpaqueHash{}) {
+			roots = append(roots, types.OpaqueHash(value))
+		}
+	}); err != nil {
+		return 0, err
+	}
+	for _, root := range roots {
+		if err := mark(root); err != nil {
+			return 0, fmt.Errorf("mark state trie 0x%x: %w", root, err)
+		}
+	}
+
+	var dead [][]byte
+	collect := func(prefix []byte, live map[types.OpaqueHash]uint32) error {
+		return forEach(r, prefix, func(key, _ []byte) {
+			if len(key) != len(prefix)+len(types.OpaqueHash{}) || live[types.OpaqueHash(key[len(prefix):])] == 0 {
+				dead = append(dead, key)
+			}
+		})
+	}
+	if err := collect(stateNodePrefix, nodeRefs); err != nil {
+		return 0, err
+	}
+	if err := collect(stateValuePrefix, valueRefs); err != nil {
+		return 0, err
+	}
+	removed := len(dead)
+	if err := collect(stateNodeRefsPrefix, nodeRefs); err != nil {
+		return 0, err
+	}
+	if err := collect(stateValueRefsPrefix, valueRefs); err != nil {
+		return 0, err
+	}
+
+	for _, key := range dead {
+		if err := w.Delete(key); err != nil {
+			return 0, err
+		}
+	}
+	for ref, n := range nodeRefs {
+		if err := w.Put(stateNodeRefsKey(ref), encodeRefs(n)); err != nil {
+			return 0, err
+		}
+	}
+	for valueHash, n := range valueRefs {
+		if err := w.Put(stateValueRefsKey(valueHash), encodeRefs(n)); err != nil {
+			return 0, err
+		}
+	}
+	return removed, nil
+}
@@ -0,0 +1,131 @@
+package store_test
+
+import (
+	"bytes"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/New-JAMneration/JAM-Protocol/internal/database"
+	"github.com/New-JAMneration/JAM-Protocol/internal/database/provider/memory"
+	"github.com/New-JAMneration/JAM-Protocol/internal/store"
+	"github.com/New-JAMneration/JAM-Protocol/internal/types"
+	m "github.com/New-JAMneration/JAM-Protocol/internal/utilities/merklization"
+	"github.com/stretchr/testify/require"
+)
+
+func randomState(rng *rand.Rand, n int) types.StateKeyVals {
+	state := make(types.StateKeyVals, n)
+	for i := range state {
+		rng.Read(state[i].Key[:])
+		state[i].Value = make([]byte, rng.Intn(80))
+		rng.Read(state[i].Value)
+	}
+	sort.Slice(state, func(i, j int) bool { return bytes.Compare(state[i].Key[:], state[j].Key[:]) < 0 })
+	return state
+}
+
+func countKeys(t *testing.T, db database.Iterable, prefix string) int {
+	t.Helper()
+	iter, err := db.NewIterator([]byte(prefix), nil)
+	require.NoError(t, err)
+	defer iter.Close()
+	count := 0
+	for iter.Next() {
+		count++
+	}
+	require.NoError(t, iter.Error())
+	return count
+}
+
+func TestStateTrieSharesUnchangedEntries(t *testing.T) {
+	db := memory.NewDatabase()
+	repo := store.NewRepository(db)
+	rng := rand.New(rand.NewSource(1))
+
+	state := randomState(rng, 500)
+	root := m.MerklizationSerializedState(state)
+	require.NoError(t, repo.SaveStateData(db, root, state))
+	nodes := countKeys(t, db, "sn:")
+	require.GreaterOrEqual(t, nodes, 2*len(state)-1)
+
+	// Changing one value adds one leaf and the branches on its path.
+	next := append(types.StateKeyVals{}, state...)
+	next[42] = types.StateKeyVal{Key: state[42].Key, Value: []byte("changed")}
+	nextRoot := m.MerklizationSerializedState(next)
+	require.NoError(t, repo.SaveStateData(db, nextRoot, next))
+	added := countKeys(t, db, "sn:") - nodes
+	require.Greater(t, added, 1)
+	require.Less(t, added, 32)
+
+	got, err := repo.GetStateData(db, nextRoot)
+	require.NoError(t, err)
+	require.Equal(t, next, got)
+	require.Equal(t, nextRoot, m.MerklizationSerializedState(got))
+
+	value, found, err := repo.GetStateValue(db, root, state[42].Key)
+	require.NoError(t, err)
+	require.True(t, found)
+	require.Equal(t, []byte(state[42].Value), value)
+
+	_, found, err = repo.GetStateValue(db, root, types.StateKey{0xff, 0xff})
+	require.NoError(t, err)
+	require.False(t, found)
+}
+
+func TestStateTrieRange(t *testing.T) {
+	db := memory.NewDatabase()
+	repo := store.NewRepository(db)
+	rng := rand.New(rand.NewSource(2))
+
+	state := randomState(rng, 200)
+	root := m.MerklizationSerializedState(state)
+	require.NoError(t, repo.SaveStateData(db, root, state))
+
+	for i := 0; i < 20; i++ {
+		a, b := rng.Intn(len(state)), rng.Intn(len(state))
+		if a > b {
+			a, b = b, a
+		}
+		got, err := repo.GetStateRange(db, root, state[a].Key, state[b].Key)
+		require.NoError(t, err)
+		require.Equal(t, state[a:b], append(types.StateKeyVals{}, got...))
+	}
+
+	got, err := repo.GetStateRange(db, root, state[150].Key, types.StateKey{})
+	require.NoError(t, err)
+	require.Equal(t, state[150:], got)
+}
+
+func TestMigrateStateBlobs(t *testing.T) {
+	db := memory.NewDatabase()
+	repo := store.NewRepository(db)
+	rng := rand.New(rand.NewSource(4))
+	encoder := types.NewEncoder()
+
+	// A version 2 data dir stores every state as one encoded StateKeyVals.
+	var roots []types.StateRoot
+	var states []types.StateKeyVals
+	for i := 0; i < 3; i++ {
+		state := randomState(rng, 20+i)
+		root := m.MerklizationSerializedState(state)
+		blob, err := encoder.Encode(&state)
+		require.NoError(t, err)
+		require.NoError(t, db.Put(append([]byte("sd:"), root[:]...), blob))
+		roots, states = append(roots, root), append(states, state)
+	}
+	require.NoError(t, db.Put([]byte("schema_version"), []byte{2, 0, 0, 0}))
+
+	from, err := repo.MigrateSchema(db)
+	require.NoError(t, err)
+	require.Equal(t, uint32(2), from)
+
+	for i, root := range roots {
+		got, err := repo.GetStateData(db, root)
+		require.NoError(t, err)
+		require.Equal(t, states[i], got)
+	}
+	version, err := repo.SchemaVersion(db)
+	require.NoError(t, err)
+	require.Equal(t, store.SchemaVersion, version)
+}
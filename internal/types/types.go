@@ -11,6 +11,7 @@ import (
 	"errors"
 	"fmt"
 	"maps"
+	"slices"
 	"sort"
 	"strings"
 	"sync"
@@ -1287,6 +1288,8 @@ type PartialStateSet struct {
 	Designate   ServiceID           // $v$: Designate
 	CreateAcct  ServiceID           // $r$: Create account
 	AlwaysAccum AlwaysAccumulateMap // $\mathbf{z}$: Always accumulate
+
+	shared map[ServiceID]struct{} // accounts whose maps CopyOnWrite shares with another set
 }
 
 func (origin *PartialStateSet) DeepCopy() PartialStateSet {
@@ -1313,6 +1316,67 @@ func (origin *PartialStateSet) DeepCopy() PartialStateSet {
 		copiedServiceAccounts[serviceID] = copiedAccount
 	}
 
+	copied := origin.copyWithoutServiceAccounts()
+	copied.ServiceAccounts = copiedServiceAccounts
+	return copied
+}
+
+// CopyOnWrite returns a copy of the set whose service accounts share their
+// storage, lookup and preimage maps with it, so copying costs O(accounts)
+// instead of O(state), as a checkpoint does after every few host calls.
+// The maps of an account must not be changed until Own is called for it on
+// the set changing them.
+func (origin *PartialStateSet) CopyOnWrite() PartialStateSet {
+	if origin.shared == nil {
+		origin.shared = make(map[ServiceID]struct{}, len(origin.ServiceAccounts))
+	}
+	for serviceID := range origin.ServiceAccounts {
+		origin.shared[serviceID] = struct{}{}
+	}
+	return origin.SharedCopy()
+}
+
+// SharedCopy is CopyOnWrite for a set whose maps are not changed while the
+// copy is in use. Only the copy calls Own before changing them, so origin is
+// only read and may be copied concurrently.
+func (origin *PartialStateSet) SharedCopy() PartialStateSet {
+	shared := make(map[ServiceID]struct{}, len(origin.ServiceAccounts))
+	for serviceID := range origin.ServiceAccounts {
+		shared[serviceID] = struct{}{}
+	}
+
+	copied := origin.copyWithoutServiceAccounts()
+	copied.ServiceAccounts = maps.Clone(origin.ServiceAccounts)
+	copied.shared = shared
+	return copied
+}
+
+// Own gives service account serviceID copies of the maps it shares with
+// another set since CopyOnWrite, and reports whether it had to.
+func (origin *PartialStateSet) Own(serviceID ServiceID) bool {
+	if _, shared := origin.shared[serviceID]; !shared {
+		return false
+	}
+	delete(origin.shared, serviceID)
+	account, exists := origin.ServiceAccounts[serviceID]
+	if !exists {
+		return false
+	}
+	// host calls replace storage values and preimages but change the time
+	// slot sets of the lookup dict in place
+	account.StorageDict = maps.Clone(account.StorageDict)
+	account.PreimageLookup = maps.Clone(account.PreimageLookup)
+	lookupDict := make(LookupMetaMapEntry, len(account.LookupDict))
+	for k, v := range account.LookupDict {
+		lookupDict[k] = slices.Clone(v)
+	}
+	account.LookupDict = lookupDict
+	origin.ServiceAccounts[serviceID] = account
+	return true
+}
+
+// copyWithoutServiceAccounts copies everything in the set but the service accounts.
+func (origin *PartialStateSet) copyWithoutServiceAccounts() PartialStateSet {
 	// ValidatorsData
 	copiedValidators := make(ValidatorsData, len(origin.ValidatorKeys))
 	copy(copiedValidators, origin.ValidatorKeys)
@@ -1346,14 +1410,13 @@ func (origin *PartialStateSet) DeepCopy() PartialStateSet {
 	copiedAlwaysAccum := maps.Clone(origin.AlwaysAccum)
 
 	return PartialStateSet{
-		ServiceAccounts: copiedServiceAccounts,
-		ValidatorKeys:   copiedValidators,
-		Authorizers:     copiedAuthorizers,
-		Bless:           origin.Bless,
-		Assign:          copiedAssign,
-		Designate:       origin.Designate,
-		CreateAcct:      origin.CreateAcct,
-		AlwaysAccum:     copiedAlwaysAccum,
+		ValidatorKeys: copiedValidators,
+		Authorizers:   copiedAuthorizers,
+		Bless:         origin.Bless,
+		Assign:        copiedAssign,
+		Designate:     origin.Designate,
+		CreateAcct:    origin.CreateAcct,
+		AlwaysAccum:   copiedAlwaysAccum,
 	}
 }
 
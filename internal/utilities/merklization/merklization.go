@@ -5,9 +5,9 @@ import (
 	"github.com/New-JAMneration/JAM-Protocol/internal/utilities/hash"
 )
 
-// encodeBranchNode encodes a branch node as [64]byte with zero heap allocation.
+// EncodeBranchNode encodes a branch node as [64]byte with zero heap allocation.
 // Layout: {left[0] & 0x7F, left[1:32], right[0:32]}
-func encodeBranchNode(left, right types.OpaqueHash) [64]byte {
+func EncodeBranchNode(left, right types.OpaqueHash) [64]byte {
 	var node [64]byte
 	node[0] = left[0] & 0x7F
 	copy(node[1:32], left[1:])
@@ -15,10 +15,10 @@ func encodeBranchNode(left, right types.OpaqueHash) [64]byte {
 	return node
 }
 
-// encodeLeafNode encodes a leaf node as [64]byte with zero heap allocation.
+// EncodeLeafNode encodes a leaf node as [64]byte with zero heap allocation.
 // Embedded leaf (value <= 32 bytes): {0x80 | len(value), key[:31], value, zero-padding}
 // Regular leaf (value > 32 bytes):   {0xC0, key[:31], blake2b(value)}
-func encodeLeafNode(key types.StateKey, value []byte) [64]byte {
+func EncodeLeafNode(key types.StateKey, value []byte) [64]byte {
 	var node [64]byte
 	if len(value) <= 32 {
 		node[0] = 0x80 | byte(len(value))
@@ -35,7 +35,7 @@ func encodeLeafNode(key types.StateKey, value []byte) [64]byte {
 
 // EncodeLeafNodeHash computes the leaf hash for (key, value) using [64]byte encoding.
 func EncodeLeafNodeHash(key types.StateKey, value []byte) types.OpaqueHash {
-	node := encodeLeafNode(key, value)
+	node := EncodeLeafNode(key, value)
 	return hash.Blake2bHash(node[:])
 }
 
@@ -60,7 +60,7 @@ func merklize(entries []types.StateKeyVal, depth int) types.OpaqueHash {
 		return types.OpaqueHash{}
 	}
 	if len(entries) == 1 {
-		node := encodeLeafNode(entries[0].Key, entries[0].Value)
+		node := EncodeLeafNode(entries[0].Key, entries[0].Value)
 		return hash.Blake2bHash(node[:])
 	}
 
@@ -68,7 +68,7 @@ func merklize(entries []types.StateKeyVal, depth int) types.OpaqueHash {
 	leftHash := merklize(entries[:pivot], depth+1)
 	rightHash := merklize(entries[pivot:], depth+1)
 
-	node := encodeBranchNode(leftHash, rightHash)
+	node := EncodeBranchNode(leftHash, rightHash)
 	return hash.Blake2bHash(node[:])
 }
 
@@ -81,7 +81,7 @@ func merklizeWithCache(entries []types.StateKeyVal, depth int, cache LeafHashCac
 		if cache != nil {
 			return cache(entries[0].Key, entries[0].Value)
 		}
-		node := encodeLeafNode(entries[0].Key, entries[0].Value)
+		node := EncodeLeafNode(entries[0].Key, entries[0].Value)
 		return hash.Blake2bHash(node[:])
 	}
 
@@ -89,7 +89,7 @@ func merklizeWithCache(entries []types.StateKeyVal, depth int, cache LeafHashCac
 	leftHash := merklizeWithCache(entries[:pivot], depth+1, cache)
 	rightHash := merklizeWithCache(entries[pivot:], depth+1, cache)
 
-	node := encodeBranchNode(leftHash, rightHash)
+	node := EncodeBranchNode(leftHash, rightHash)
 	return hash.Blake2bHash(node[:])
 }
 
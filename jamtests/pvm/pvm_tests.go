@@ -0,0 +1,330 @@
+package jamtests
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/New-JAMneration/JAM-Protocol/PVM"
+	"github.com/New-JAMneration/JAM-Protocol/internal/types"
+)
+
+// Exit statuses used by the "expected-status" field of the pvm test vectors.
+const (
+	StatusHalt      = "halt"
+	StatusPanic     = "panic"
+	StatusPageFault = "page-fault"
+	StatusOutOfGas  = "out-of-gas"
+	StatusHostCall  = "host-call"
+)
+
+var statusCodes = []string{StatusHalt, StatusPanic, StatusPageFault, StatusOutOfGas, StatusHostCall}
+
+// PVMTestCase is a w3f pvm test vector: a program blob run from the initial
+// registers, page map, memory and gas, and the state it must stop in.
+type PVMTestCase struct {
+	PVM.InstructionTestCase
+
+	// Result is set by Run.
+	Result *PVMResult `json:"-"`
+}
+
+// PVMResult is the state the interpreter stopped in.
+type PVMResult struct {
+	Status           string
+	ProgramCounter   PVM.ProgramCounter
+	Registers        PVM.Registers
+	Gas              PVM.Gas
+	PageFaultAddress uint32
+	Memory           *PVM.Memory
+}
+
+// Run executes the program with the single-step interpreter until it stops
+// and stores the final state in Result.
+func (t *PVMTestCase) Run() *PVMResult {
+	result := &PVMResult{
+		Status:         StatusPanic,
+		ProgramCounter: t.InitialProgramCounter,
+		Registers:      t.InitialRegisters,
+		Gas:            t.InitialGas,
+		Memory:         newMemory(t.InitialPageMap, t.InitialMemory),
+	}
+	t.Result = result
+
+	program, exitReason := PVM.DeBlobProgramCode(t.ProgramBlob)
+	if exitReason.GetReasonType() != PVM.CONTINUE {
+		return result
+	}
+
+	interp := PVM.NewInterpreter(&program, t.InitialRegisters, result.Memory, t.InitialGas)
+	pc := t.InitialProgramCounter
+	for {
+		exitReason, pcPrime := interp.SingleStepStateTransition(pc)
+		if exitReason.GetReasonType() == PVM.CONTINUE {
+			pc = pcPrime
+			continue
+		}
+
+		// The vectors expect the PC of the instruction that stopped the
+		// program, which SingleStepStateTransition does not report for
+		// halt and panic.
+		result.ProgramCounter = pc
+		switch exitReason.GetReasonType() {
+		case PVM.HALT:
+			result.Status = StatusHalt
+		case PVM.PANIC:
+			result.Status = StatusPanic
+		case PVM.PAGE_FAULT:
+			result.Status = StatusPageFault
+			result.PageFaultAddress = exitReason.GetPageFaultAddress()
+		case PVM.OUT_OF_GAS:
+			result.Status = StatusOutOfGas
+		case PVM.HOST_CALL:
+			result.Status = StatusHostCall
+			result.ProgramCounter = pcPrime
+		}
+		result.Registers = interp.Registers
+		result.Gas = interp.Gas
+		return result
+	}
+}
+
+// newMemory maps every page of pageMaps and writes chunks into them.
+func newMemory(pageMaps PVM.PageMaps, chunks PVM.MemoryChunks) *PVM.Memory {
+	memory := &PVM.Memory{}
+	for _, pageMap := range pageMaps {
+		access := PVM.MemoryReadOnly
+		if pageMap.IsWritable {
+			access = PVM.MemoryReadWrite
+		}
+		first := pageMap.Address / PVM.ZP
+		last := (uint64(pageMap.Address) + uint64(pageMap.Length) + PVM.ZP - 1) / PVM.ZP
+		for page := uint64(first); page < last; page++ {
+			memory.SetPage(uint32(page), &PVM.Page{Value: make([]byte, PVM.ZP), Access: access})
+		}
+	}
+	for _, chunk := range chunks {
+		memory.Write(uint64(chunk.Address), chunk.Contents)
+	}
+	return memory
+}
+
+// Group returns the opcode group reported for the test: the operand
+// category of the instruction named by an "inst_<opcode>" test, or the name
+// prefix (e.g. "gas", "riscv") for other tests.
+func (t *PVMTestCase) Group() string {
+	rest, found := strings.CutPrefix(t.Name, "inst_")
+	if !found {
+		prefix, _, _ := strings.Cut(t.Name, "_")
+		return prefix
+	}
+
+	// Opcode names share prefixes (load_i8, load_imm), so the longest name
+	// followed by "_" or the end of the test name wins.
+	best := ""
+	for op := 0; op < 256; op++ {
+		name := PVM.OpcodeName(byte(op))
+		if name != "" && len(name) > len(best) && (rest == name || strings.HasPrefix(rest, name+"_")) {
+			best = name
+		}
+	}
+	if best == "" {
+		return "inst"
+	}
+	op, _ := PVM.OpcodeByName(best)
+	return PVM.GetOpcodeInfo(op).Category.String()
+}
+
+// Dump is a no-op: pvm vectors do not touch the chain state.
+func (t *PVMTestCase) Dump() error {
+	return nil
+}
+
+func (t *PVMTestCase) GetPostState() interface{} {
+	return t.Result
+}
+
+func (t *PVMTestCase) GetOutput() interface{} {
+	return t.Result
+}
+
+func (t *PVMTestCase) ExpectError() error {
+	return nil
+}
+
+// Validate compares the result of Run with the expected exit status, PC,
+// registers, gas and memory chunks.
+func (t *PVMTestCase) Validate() error {
+	result := t.Result
+	if result == nil {
+		return fmt.Errorf("%s: test case has not been run", t.Name)
+	}
+
+	var mismatches []string
+	if result.Status != t.ExpectedStatus {
+		mismatches = append(mismatches, fmt.Sprintf("status %s, want %s", result.Status, t.ExpectedStatus))
+	}
+	if result.ProgramCounter != t.ExpectedProgramCounter {
+		mismatches = append(mismatches, fmt.Sprintf("pc %d, want %d", result.ProgramCounter, t.ExpectedProgramCounter))
+	}
+	for i, value := range result.Registers {
+		if value != t.ExpectedRegisters[i] {
+			mismatches = append(mismatches, fmt.Sprintf("%s = 0x%x, want 0x%x", PVM.RegName[i], value, t.ExpectedRegisters[i]))
+		}
+	}
+	if result.Gas != t.ExpectedGas {
+		mismatches = append(mismatches, fmt.Sprintf("gas %d, want %d", result.Gas, t.ExpectedGas))
+	}
+	if t.ExpectedStatus == StatusPageFault && result.PageFaultAddress != t.ExpectedPageFaultAddress {
+		mismatches = append(mismatches, fmt.Sprintf("page fault at 0x%x, want 0x%x", result.PageFaultAddress, t.ExpectedPageFaultAddress))
+	}
+	for _, chunk := range t.ExpectedMemory {
+		if got, ok := readMemory(result.Memory, chunk.Address, len(chunk.Contents)); !ok {
+			mismatches = append(mismatches, fmt.Sprintf("memory at 0x%x is not mapped", chunk.Address))
+		} else if !bytes.Equal(got, chunk.Contents) {
+			mismatches = append(mismatches, fmt.Sprintf("memory at 0x%x = %x, want %x", chunk.Address, got, chunk.Contents))
+		}
+	}
+
+	if len(mismatches) > 0 {
+		return fmt.Errorf("%s: %s", t.Name, strings.Join(mismatches, "; "))
+	}
+	return nil
+}
+
+func readMemory(memory *PVM.Memory, address uint32, length int) ([]byte, bool) {
+	if length == 0 {
+		return nil, true
+	}
+	end := uint64(address) + uint64(length) - 1
+	for page := uint64(address) / PVM.ZP; page <= end/PVM.ZP; page++ {
+		if memory.Page(uint32(page)) == nil {
+			return nil, false
+		}
+	}
+	return memory.Read(uint64(address), uint64(length)), true
+}
+
+// Decode reads the binary form of a test vector: the JSON fields in order,
+// with registers, gas and addresses as fixed-width little-endian integers,
+// byte strings and lists length-prefixed and the status as an index into
+// halt, panic, page-fault, out-of-gas, host-call. testdata/inst_add_32.bin
+// is testdata/inst_add_32.json in this form.
+func (t *PVMTestCase) Decode(d *types.Decoder) error {
+	var name types.ByteSequence
+	if err := name.Decode(d); err != nil {
+		return err
+	}
+	t.Name = string(name)
+
+	if err := decodeRegisters(d, &t.InitialRegisters); err != nil {
+		return err
+	}
+	if err := decodeU32(d, (*uint32)(&t.InitialProgramCounter)); err != nil {
+		return err
+	}
+
+	length, err := d.DecodeLength()
+	if err != nil {
+		return err
+	}
+	t.InitialPageMap = make(PVM.PageMaps, length)
+	for i := range t.InitialPageMap {
+		pageMap := &t.InitialPageMap[i]
+		if err := decodeU32(d, &pageMap.Address); err != nil {
+			return err
+		}
+		if err := decodeU32(d, &pageMap.Length); err != nil {
+			return err
+		}
+		var writable types.U8
+		if err := writable.Decode(d); err != nil {
+			return err
+		}
+		pageMap.IsWritable = writable != 0
+	}
+
+	if t.InitialMemory, err = decodeMemoryChunks(d); err != nil {
+		return err
+	}
+	if err := decodeGas(d, &t.InitialGas); err != nil {
+		return err
+	}
+
+	var program types.ByteSequence
+	if err := program.Decode(d); err != nil {
+		return err
+	}
+	t.ProgramBlob = program
+
+	var status types.U8
+	if err := status.Decode(d); err != nil {
+		return err
+	}
+	if int(status) >= len(statusCodes) {
+		return fmt.Errorf("invalid pvm test status %d", status)
+	}
+	t.ExpectedStatus = statusCodes[status]
+
+	if err := decodeRegisters(d, &t.ExpectedRegisters); err != nil {
+		return err
+	}
+	if err := decodeU32(d, (*uint32)(&t.ExpectedProgramCounter)); err != nil {
+		return err
+	}
+	if t.ExpectedMemory, err = decodeMemoryChunks(d); err != nil {
+		return err
+	}
+	if err := decodeGas(d, &t.ExpectedGas); err != nil {
+		return err
+	}
+	return decodeU32(d, &t.ExpectedPageFaultAddress)
+}
+
+func decodeU32(d *types.Decoder, v *uint32) error {
+	var value types.U32
+	if err := value.Decode(d); err != nil {
+		return err
+	}
+	*v = uint32(value)
+	return nil
+}
+
+func decodeGas(d *types.Decoder, gas *PVM.Gas) error {
+	var value types.U64
+	if err := value.Decode(d); err != nil {
+		return err
+	}
+	*gas = PVM.Gas(value)
+	return nil
+}
+
+func decodeRegisters(d *types.Decoder, registers *PVM.Registers) error {
+	for i := range registers {
+		var value types.U64
+		if err := value.Decode(d); err != nil {
+			return err
+		}
+		registers[i] = uint64(value)
+	}
+	return nil
+}
+
+func decodeMemoryChunks(d *types.Decoder) (PVM.MemoryChunks, error) {
+	length, err := d.DecodeLength()
+	if err != nil {
+		return nil, err
+	}
+	chunks := make(PVM.MemoryChunks, length)
+	for i := range chunks {
+		if err := decodeU32(d, &chunks[i].Address); err != nil {
+			return nil, err
+		}
+		var contents types.ByteSequence
+		if err := contents.Decode(d); err != nil {
+			return nil, err
+		}
+		chunks[i].Contents = contents
+	}
+	return chunks, nil
+}
@@ -0,0 +1,29 @@
+package jamtests
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/New-JAMneration/JAM-Protocol/internal/types"
+	"github.com/stretchr/testify/require"
+)
+
+// TestDecodeFormats checks that the binary form of a vector decodes to the
+// same test case as its JSON form, and that the vector passes.
+func TestDecodeFormats(t *testing.T) {
+	data, err := os.ReadFile("testdata/inst_add_32.json")
+	require.NoError(t, err)
+	var fromJSON PVMTestCase
+	require.NoError(t, json.Unmarshal(data, &fromJSON))
+
+	data, err = os.ReadFile("testdata/inst_add_32.bin")
+	require.NoError(t, err)
+	var fromBinary PVMTestCase
+	require.NoError(t, types.NewDecoder().Decode(data, &fromBinary))
+
+	require.Equal(t, fromJSON.InstructionTestCase, fromBinary.InstructionTestCase)
+
+	fromBinary.Run()
+	require.NoError(t, fromBinary.Validate())
+}
@@ -0,0 +1,29 @@
+package pvmtestvector
+
+import (
+	"fmt"
+
+	jamtestspvm "github.com/New-JAMneration/JAM-Protocol/jamtests/pvm"
+	"github.com/New-JAMneration/JAM-Protocol/testdata"
+)
+
+type PVMTestVectorsRunner struct{}
+
+func NewPVMTestVectorsRunner() *PVMTestVectorsRunner {
+	return &PVMTestVectorsRunner{}
+}
+
+// Run executes the program of a pvm test vector and compares the state the
+// interpreter stopped in with the expected one. runSTF is ignored.
+func (r *PVMTestVectorsRunner) Run(data interface{}, runSTF bool) error {
+	testCase, ok := data.(*jamtestspvm.PVMTestCase)
+	if !ok {
+		return fmt.Errorf("unexpected pvm test data %T", data)
+	}
+	testCase.Run()
+	return r.Verify(testCase)
+}
+
+func (r *PVMTestVectorsRunner) Verify(data testdata.Testable) error {
+	return data.Validate()
+}
@@ -14,6 +14,7 @@ import (
 	jamtestsdisputes "github.com/New-JAMneration/JAM-Protocol/jamtests/disputes"
 	jamtestshistory "github.com/New-JAMneration/JAM-Protocol/jamtests/history"
 	jamtestspreimages "github.com/New-JAMneration/JAM-Protocol/jamtests/preimages"
+	jamtestspvm "github.com/New-JAMneration/JAM-Protocol/jamtests/pvm"
 	jamtestsreports "github.com/New-JAMneration/JAM-Protocol/jamtests/reports"
 	jamtestssafrole "github.com/New-JAMneration/JAM-Protocol/jamtests/safrole"
 	jamtestsstatistics "github.com/New-JAMneration/JAM-Protocol/jamtests/statistics"
@@ -119,6 +120,16 @@ func NewTracesReader(mode TestMode, format DataFormat) *TestDataReader {
 	return reader
 }
 
+// NewPVMTestVectorsReader creates a TestDataReader for the w3f pvm test
+// vectors, which do not depend on a mode or size.
+func NewPVMTestVectorsReader(format DataFormat) *TestDataReader {
+	return &TestDataReader{
+		dataType: "pvm",
+		format:   format,
+		basePath: filepath.Join("pkg", "test_data", "jam-test-vectors", "pvm", "programs"),
+	}
+}
+
 // ReadTestData reads all test files from the configured directory
 func (r *TestDataReader) ReadTestData() ([]TestData, error) {
 	var testFiles []TestData
@@ -228,6 +239,12 @@ func (r *TestDataReader) ParseTestData(data []byte) (result Testable, err error)
 		default:
 			return nil, fmt.Errorf("unsupported test mode: %s", r.mode)
 		}
+	case "pvm":
+		var pvmTestCase jamtestspvm.PVMTestCase
+		if err := r.ReadFile(data, &pvmTestCase); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal/decode pvm test data: %v", err)
+		}
+		result = &pvmTestCase
 	case "jamtestnet":
 		// For jamtestnet, we need to handle state transitions
 		return nil, fmt.Errorf("work in progress: %s", r.dataType)